@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"electron-go-app/backend/internal/app"
+	"electron-go-app/backend/internal/infra/logger"
+	"electron-go-app/backend/internal/repository"
+	modelsvc "electron-go-app/backend/internal/service/model"
+)
+
+var (
+	dryRun    = flag.Bool("dry-run", false, "只统计需要轮换的凭据，不写回数据库")
+	batchSize = flag.Int("batch-size", modelsvc.DefaultKeyRotationBatchSize, "每批读取的凭据数量")
+)
+
+// 使用 MODEL_CREDENTIAL_MASTER_KEY 作为新主密钥，并把旧主密钥写入 MODEL_CREDENTIAL_LEGACY_KEYS 后执行。
+func main() {
+	flag.Parse()
+
+	zapLogger, err := logger.Init()
+	if err != nil {
+		panic(fmt.Sprintf("init logger failed: %v", err))
+	}
+	defer logger.Sync()
+	sugar := zapLogger.Sugar().With("component", "rotate-credential-key")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	resources, err := app.InitResources(ctx)
+	if err != nil {
+		sugar.Fatalw("init resources failed", "error", err)
+	}
+	defer func() {
+		if cerr := resources.Close(); cerr != nil {
+			sugar.Warnw("close resources failed", "error", cerr)
+		}
+	}()
+
+	db := resources.DBConn()
+	service := modelsvc.NewService(repository.NewModelCredentialRepository(db), repository.NewUserRepository(db))
+	report, err := service.RotateMasterKey(ctx, modelsvc.KeyRotationOptions{BatchSize: *batchSize, DryRun: *dryRun})
+	if err != nil {
+		sugar.Fatalw("rotate master key failed", "error", err, "scanned", report.Scanned, "rotated", report.Rotated)
+	}
+
+	sugar.Infow("rotate master key completed",
+		"dry_run", *dryRun,
+		"primary_key_id", report.PrimaryKeyID,
+		"scanned", report.Scanned,
+		"rotated", report.Rotated,
+		"up_to_date", report.UpToDate,
+		"conflicts", report.Conflicts,
+		"failed", report.Failed,
+	)
+	if len(report.Failed) > 0 {
+		os.Exit(1)
+	}
+}
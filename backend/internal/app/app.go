@@ -20,6 +20,8 @@ import (
 	"electron-go-app/backend/internal/config"
 	adminmetricsdomain "electron-go-app/backend/internal/domain/adminmetrics"
 	changelog "electron-go-app/backend/internal/domain/changelog"
+	"electron-go-app/backend/internal/domain/modelcache"
+	"electron-go-app/backend/internal/domain/modelusage"
 	promptdomain "electron-go-app/backend/internal/domain/prompt"
 	domain "electron-go-app/backend/internal/domain/user"
 	infra "electron-go-app/backend/internal/infra/client"
@@ -81,8 +83,10 @@ func InitResources(ctx context.Context) (*Resources, error) {
 		&promptdomain.PublicPrompt{},
 		&promptdomain.PromptComment{},
 		&promptdomain.PromptCommentLike{},
+		&promptdomain.PromptRun{},
 		&adminmetricsdomain.DailyRecord{},
 		&adminmetricsdomain.EventRecord{},
+		&modelusage.Record{},
 	); err != nil {
 		return nil, fmt.Errorf("auto migrate: %w", err)
 	}
@@ -162,8 +166,16 @@ func initLocalResources(ctx context.Context, flags config.RuntimeFlags) (*Resour
 		&promptdomain.PublicPrompt{},
 		&promptdomain.PromptComment{},
 		&promptdomain.PromptCommentLike{},
+		&promptdomain.PromptRun{},
 		&adminmetricsdomain.DailyRecord{},
 		&adminmetricsdomain.EventRecord{},
+		&modelusage.Record{},
+		&modelcache.Entry{},
+		&promptdomain.WorkspaceRecord{},
+		&promptdomain.WorkspaceKeywordRecord{},
+		&promptdomain.WorkspaceHistoryRecord{},
+		&promptdomain.PersistenceTaskRecord{},
+		&promptdomain.PersistenceTaskStatusRecord{},
 	); err != nil {
 		return nil, fmt.Errorf("auto migrate sqlite: %w", err)
 	}
@@ -18,10 +18,13 @@ import (
 
 	"electron-go-app/backend/internal/app"
 	"electron-go-app/backend/internal/config"
+	"electron-go-app/backend/internal/domain/llm"
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
 	"electron-go-app/backend/internal/handler"
 	"electron-go-app/backend/internal/infra/captcha"
 	"electron-go-app/backend/internal/infra/email"
 	"electron-go-app/backend/internal/infra/metrics"
+	"electron-go-app/backend/internal/infra/model/replay"
 	promptinfra "electron-go-app/backend/internal/infra/prompt"
 	"electron-go-app/backend/internal/infra/ratelimit"
 	"electron-go-app/backend/internal/infra/token"
@@ -72,6 +75,7 @@ func BuildApplication(ctx context.Context, logger *zap.SugaredLogger, resources
 	verificationRepo := repository.NewEmailVerificationRepository(resources.DBConn())
 	tokens := token.NewJWTManager(cfg.JWTSecret, cfg.AccessTTL, cfg.RefreshTTL)
 	modelRepo := repository.NewModelCredentialRepository(resources.DBConn())
+	modelUsageRepo := repository.NewModelUsageRepository(resources.DBConn())
 	changelogRepo := repository.NewChangelogRepository(resources.DBConn())
 	promptRepo := repository.NewPromptRepository(resources.DBConn())
 	keywordRepo := repository.NewKeywordRepository(resources.DBConn())
@@ -85,15 +89,27 @@ func BuildApplication(ctx context.Context, logger *zap.SugaredLogger, resources
 	var (
 		workspaceStore   promptsvc.WorkspaceStore
 		persistenceQueue promptsvc.PersistenceQueue
+		taskStatusStore  promptsvc.TaskStatusStore
 	)
 	adminMetricsCfg := loadAdminMetricsConfig(logger)
 	adminMetricsSvc := adminmetricssvc.NewService(adminMetricsCfg, logger.With("component", "service.adminmetrics"), adminMetricsRepo)
 	adminMetricsSvc.Start(ctx)
 	adminUserCfg := loadAdminUserConfig(logger)
 	adminUserSvc := adminusersvc.NewService(adminUserCfg, userRepo, promptRepo, logger.With("component", "service.adminuser"))
-	if resources.Redis != nil {
+	persistenceRetry, persistenceClaimIdle := loadPersistenceQueueConfig(logger)
+	switch {
+	case resources.Redis != nil:
 		workspaceStore = promptinfra.NewWorkspaceStore(resources.Redis)
-		persistenceQueue = promptinfra.NewPersistenceQueue(resources.Redis, "")
+		persistenceQueue = promptinfra.NewPersistenceQueue(resources.Redis, "",
+			promptinfra.WithQueueRetryPolicy(persistenceRetry),
+			promptinfra.WithQueueClaimIdle(persistenceClaimIdle),
+		)
+		taskStatusStore = promptinfra.NewTaskStatusStore(resources.Redis)
+	case isLocalMode:
+		// 离线模式没有 Redis，工作区与落库队列改用 SQLite，保持与在线模式相同的工作台体验。
+		workspaceStore = repository.NewPromptWorkspaceRepository(resources.DBConn(), 0)
+		persistenceQueue = repository.NewPersistenceTaskRepository(resources.DBConn(), persistenceRetry)
+		taskStatusStore = repository.NewPersistenceTaskStatusRepository(resources.DBConn(), 0)
 	}
 
 	// 刷新令牌优先落在 Redis，便于服务重启后继续验证。
@@ -176,8 +192,21 @@ func BuildApplication(ctx context.Context, logger *zap.SugaredLogger, resources
 
 	// Prompt 服务与 Handler 较为复杂，涉及关键词管理、工作空间、持久化队列等。
 	promptCfg := loadPromptConfig(logger, isLocalMode)
+	promptCfg.TaskStatus = taskStatusStore
+	promptCfg.Runs = repository.NewPromptRunRepository(resources.DBConn())
+	// 响应缓存在线模式存放在 Redis，本地模式落在 SQLite。
+	if promptCfg.ResponseCache.Enabled {
+		switch {
+		case resources.Redis != nil:
+			promptCfg.ResponseCache.Store = promptinfra.NewResponseCache(resources.Redis, "")
+		case isLocalMode:
+			promptCfg.ResponseCache.Store = repository.NewModelResponseCacheRepository(resources.DBConn())
+		default:
+			logger.Warnw("prompt response cache enabled but redis unavailable, feature disabled")
+		}
+	}
 	// 模型服务与 Handler 负责模型凭据的管理与测试连接。
-	modelService := modelsvc.NewService(modelRepo, userRepo)
+	modelService := modelsvc.NewServiceWithConfig(modelRepo, userRepo, modelUsageRepo, loadModelConfig(logger))
 	// 构建 Prompt 工作台服务，并注入关键词上限、限流配置等依赖。
 	promptService, err := promptsvc.NewServiceWithConfig(promptRepo, keywordRepo, modelService, workspaceStore, persistenceQueue, logger, freeTierLimiter, adminMetricsSvc, promptCfg)
 	if err != nil {
@@ -247,7 +276,7 @@ func BuildApplication(ctx context.Context, logger *zap.SugaredLogger, resources
 		ipGuardHandler = handler.NewIPGuardHandler(ipGuard)
 	}
 
-	// 启动后台持久化任务（若 Redis 可用）。
+	// 启动后台持久化任务（Redis 或本地 SQLite 队列可用时）。
 	if workspaceStore != nil && persistenceQueue != nil {
 		promptService.StartPersistenceWorker(ctx, 0)
 	}
@@ -255,6 +284,7 @@ func BuildApplication(ctx context.Context, logger *zap.SugaredLogger, resources
 		publicPromptService.StartVisitFlushWorker(ctx)
 		publicPromptService.StartScoreRefreshWorker(ctx)
 	}
+	modelService.StartHealthChecker(ctx)
 	// 更新日志服务与 Handler 相对简单，主要负责变更条目的查询。
 	changelogService := changelogsrv.NewService(changelogRepo)
 	changelogHandler := handler.NewChangelogHandler(changelogService, modelService)
@@ -446,6 +476,97 @@ func loadPublicPromptRateLimit(logger *zap.SugaredLogger) handler.PublicPromptRa
 	}
 }
 
+// loadModelConfig 读取模型客户端的重试、退避、熔断与价格表配置。
+func loadModelConfig(logger *zap.SugaredLogger) modelsvc.Config {
+	defaults := llm.DefaultRetryPolicy()
+	circuitDefaults := modelsvc.DefaultCircuitConfig()
+	var maxRetries int
+	// parseIntEnv 仅接受正整数，这里单独处理 0 以支持关闭重试。
+	if strings.TrimSpace(os.Getenv("MODEL_RETRY_MAX_RETRIES")) == "0" {
+		maxRetries = 0
+	} else {
+		maxRetries = parseIntEnv("MODEL_RETRY_MAX_RETRIES", defaults.MaxRetries, logger)
+	}
+	return modelsvc.Config{
+		Retry: llm.RetryPolicy{
+			MaxRetries: maxRetries,
+			BaseDelay:  parseDurationEnv("MODEL_RETRY_BASE_DELAY", defaults.BaseDelay, logger),
+			MaxDelay:   parseDurationEnv("MODEL_RETRY_MAX_DELAY", defaults.MaxDelay, logger),
+		},
+		Circuit: modelsvc.CircuitConfig{
+			FailureThreshold: parseIntEnv("MODEL_CIRCUIT_FAILURE_THRESHOLD", circuitDefaults.FailureThreshold, logger),
+			OpenTimeout:      parseDurationEnv("MODEL_CIRCUIT_OPEN_TIMEOUT", circuitDefaults.OpenTimeout, logger),
+		},
+		Prices:     loadPriceCatalog(logger),
+		Replay:     loadReplayConfig(logger),
+		CatalogTTL: parseDurationEnv("MODEL_CATALOG_TTL", modelsvc.DefaultCatalogTTL, logger),
+		Health:     loadModelHealthConfig(logger),
+	}
+}
+
+// loadPersistenceQueueConfig 读取落库队列的重试策略与崩溃回收等待时长。
+func loadPersistenceQueueConfig(logger *zap.SugaredLogger) (promptdomain.PersistenceRetryPolicy, time.Duration) {
+	defaults := promptdomain.DefaultPersistenceRetryPolicy()
+	policy := promptdomain.PersistenceRetryPolicy{
+		MaxAttempts: parseIntEnv("PROMPT_PERSIST_MAX_ATTEMPTS", defaults.MaxAttempts, logger),
+		BaseDelay:   parseDurationEnv("PROMPT_PERSIST_RETRY_BASE_DELAY", defaults.BaseDelay, logger),
+		MaxDelay:    parseDurationEnv("PROMPT_PERSIST_RETRY_MAX_DELAY", defaults.MaxDelay, logger),
+	}
+	return policy, parseDurationEnv("PROMPT_PERSIST_CLAIM_IDLE", 5*time.Minute, logger)
+}
+
+// loadModelHealthConfig 读取后台凭据健康检查配置，MODEL_HEALTH_CHECK_INTERVAL=0 时关闭。
+func loadModelHealthConfig(logger *zap.SugaredLogger) modelsvc.HealthConfig {
+	var interval time.Duration
+	// parseDurationEnv 仅接受正数，这里单独处理 0 以支持关闭健康检查。
+	if strings.TrimSpace(os.Getenv("MODEL_HEALTH_CHECK_INTERVAL")) != "0" {
+		interval = parseDurationEnv("MODEL_HEALTH_CHECK_INTERVAL", modelsvc.DefaultHealthCheckInterval, logger)
+	}
+	return modelsvc.HealthConfig{
+		Interval:  interval,
+		Timeout:   parseDurationEnv("MODEL_HEALTH_CHECK_TIMEOUT", modelsvc.DefaultHealthCheckTimeout, logger),
+		BatchSize: parseIntEnv("MODEL_HEALTH_CHECK_BATCH_SIZE", modelsvc.DefaultHealthCheckBatchSize, logger),
+	}
+}
+
+// loadReplayConfig 读取模型录制/回放配置，MODEL_REPLAY_DIR 为空时关闭 replay 提供方与全局模式。
+func loadReplayConfig(logger *zap.SugaredLogger) modelsvc.ReplayConfig {
+	dir := strings.TrimSpace(os.Getenv("MODEL_REPLAY_DIR"))
+	if dir == "" {
+		return modelsvc.ReplayConfig{}
+	}
+	cfg := modelsvc.ReplayConfig{
+		Dir:      dir,
+		Cassette: strings.TrimSpace(os.Getenv("MODEL_REPLAY_CASSETTE")),
+	}
+	rawMode := strings.TrimSpace(os.Getenv("MODEL_REPLAY_MODE"))
+	if rawMode == "" || strings.EqualFold(rawMode, "off") {
+		return cfg
+	}
+	mode, err := replay.ParseMode(rawMode)
+	if err != nil {
+		logger.Warnw("invalid MODEL_REPLAY_MODE, global replay disabled", "value", rawMode, "error", err)
+		return cfg
+	}
+	cfg.Mode = mode
+	logger.Infow("model replay enabled for all credentials", "mode", mode, "dir", dir)
+	return cfg
+}
+
+// loadPriceCatalog 读取 MODEL_PRICE_CATALOG_FILE 指定的价格表，未配置或解析失败时使用内置价格。
+func loadPriceCatalog(logger *zap.SugaredLogger) modelsvc.PriceCatalog {
+	path := strings.TrimSpace(os.Getenv("MODEL_PRICE_CATALOG_FILE"))
+	if path == "" {
+		return modelsvc.DefaultPriceCatalog()
+	}
+	catalog, err := modelsvc.LoadPriceCatalogFile(path)
+	if err != nil {
+		logger.Warnw("load model price catalog failed, fallback to defaults", "path", path, "error", err)
+		return modelsvc.DefaultPriceCatalog()
+	}
+	return catalog
+}
+
 // loadPublicPromptListConfig 读取公共 Prompt 列表分页配置。
 func loadPublicPromptListConfig(logger *zap.SugaredLogger) publicpromptsvc.Config {
 	cfg := publicpromptsvc.Config{
@@ -625,6 +746,12 @@ func loadPromptConfig(logger *zap.SugaredLogger, isLocal bool) promptsvc.Config
 			Prefix:          strings.TrimSpace(os.Getenv("PROMPT_SHARE_PREFIX")),
 			MaxEncodedBytes: parseIntEnv("PROMPT_SHARE_MAX_BYTES", promptsvc.DefaultShareMaxEncodedLength, logger),
 		},
+		ResponseCache: promptsvc.ResponseCacheConfig{
+			Enabled:      parseBoolEnv("PROMPT_RESPONSE_CACHE_ENABLED", false),
+			InterpretTTL: parseDurationEnv("PROMPT_RESPONSE_CACHE_TTL_INTERPRET", promptsvc.DefaultResponseCacheInterpretTTL, logger),
+			AugmentTTL:   parseDurationEnv("PROMPT_RESPONSE_CACHE_TTL_AUGMENT", promptsvc.DefaultResponseCacheAugmentTTL, logger),
+			IngestTTL:    parseDurationEnv("PROMPT_RESPONSE_CACHE_TTL_INGEST", promptsvc.DefaultResponseCacheIngestTTL, logger),
+		},
 	}
 }
 
@@ -12,6 +12,7 @@ type DailyRecord struct {
 	GenerateSuccessRate  float64   `gorm:"column:generate_success_rate"`
 	AverageLatencyMillis float64   `gorm:"column:average_latency_ms"`
 	SaveTotal            int       `gorm:"column:save_total"`
+	GenerateCost         float64   `gorm:"column:generate_cost"`
 	CreatedAt            time.Time `gorm:"column:created_at"`
 	UpdatedAt            time.Time `gorm:"column:updated_at"`
 }
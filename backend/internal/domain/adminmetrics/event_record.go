@@ -9,6 +9,7 @@ type EventRecord struct {
 	Kind           string     `gorm:"column:kind"`
 	Success        bool       `gorm:"column:success"`
 	DurationMillis *int64     `gorm:"column:duration_ms"`
+	Cost           *float64   `gorm:"column:cost"`
 	OccurredAt     time.Time  `gorm:"column:occurred_at"`
 	CreatedAt      time.Time  `gorm:"column:created_at"`
 	UpdatedAt      *time.Time `gorm:"column:updated_at"`
@@ -0,0 +1,23 @@
+package llm
+
+import "context"
+
+// operationKey 为上下文中保存调用操作类型的键。
+type operationKey struct{}
+
+// WithOperation 在上下文中标记本次模型调用所属的业务操作（interpret、generate 等），供用量流水归类。
+func WithOperation(ctx context.Context, operation string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationFromContext 读取上下文中的操作类型，未设置时返回空字符串。
+func OperationFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
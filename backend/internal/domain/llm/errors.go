@@ -0,0 +1,149 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind 对模型调用失败进行归类，供重试、回退、接口响应与监控标签共用。
+type ErrorKind string
+
+const (
+	ErrorKindUnknown         ErrorKind = "unknown"          // 无法归类的错误
+	ErrorKindRateLimited     ErrorKind = "rate_limited"     // 触发厂商限流，稍后可重试
+	ErrorKindAuthFailed      ErrorKind = "auth_failed"      // API Key 无效或无权限
+	ErrorKindQuotaExhausted  ErrorKind = "quota_exhausted"  // 余额或配额耗尽
+	ErrorKindContextTooLong  ErrorKind = "context_too_long" // 输入超出模型上下文窗口
+	ErrorKindContentFiltered ErrorKind = "content_filtered" // 被厂商内容安全策略拦截
+	ErrorKindTransient       ErrorKind = "transient"        // 5xx、超时、连接中断等瞬时故障
+)
+
+// Retryable 判断同一凭据上重试是否有意义，仅限流与瞬时故障会重试。
+func (k ErrorKind) Retryable() bool {
+	return k == ErrorKindRateLimited || k == ErrorKindTransient
+}
+
+// KindedError 由厂商错误类型实现，返回归一化后的错误类型。
+type KindedError interface {
+	error
+	Kind() ErrorKind
+}
+
+// RetryAfterError 由携带 Retry-After 提示的错误实现。
+type RetryAfterError interface {
+	error
+	RetryAfterHint() time.Duration
+}
+
+// KindOf 返回错误对应的类型：优先读取厂商错误自带的分类，其次识别超时与连接中断，err 为空时返回空字符串。
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+	var kinded KindedError
+	if errors.As(err, &kinded) {
+		if kind := kinded.Kind(); kind != "" {
+			return kind
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorKindTransient
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorKindTransient
+	}
+	return ErrorKindUnknown
+}
+
+// RetryAfterOf 返回错误携带的 Retry-After 提示，不存在时返回 0。
+func RetryAfterOf(err error) time.Duration {
+	var hinted RetryAfterError
+	if errors.As(err, &hinted) {
+		if hint := hinted.RetryAfterHint(); hint > 0 {
+			return hint
+		}
+	}
+	return 0
+}
+
+var (
+	quotaHints = []string{
+		"insufficient_quota", "insufficient balance", "insufficient_balance", "quotaexceeded",
+		"quota exceeded", "accountoverdue", "credit balance", "billing",
+	}
+	contextHints = []string{
+		"context_length_exceeded", "maximum context length", "context length", "context window",
+		"prompt is too long", "input is too long", "too many input tokens",
+	}
+	contentHints = []string{
+		"content_filter", "content_policy", "content policy", "sensitivecontent", "sensitive content",
+		"data_inspection_failed", "content management policy",
+	}
+)
+
+// ClassifyStatus 根据 HTTP 状态码与厂商返回的错误码/描述推断错误类型，供各客户端的 APIError 复用。
+func ClassifyStatus(status int, code, message string) ErrorKind {
+	if status > 0 && status < http.StatusBadRequest {
+		return ErrorKindUnknown
+	}
+	hint := strings.ToLower(code + " " + message)
+	switch {
+	case containsAny(hint, quotaHints):
+		return ErrorKindQuotaExhausted
+	case containsAny(hint, contextHints):
+		return ErrorKindContextTooLong
+	case containsAny(hint, contentHints):
+		return ErrorKindContentFiltered
+	}
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorKindAuthFailed
+	case status == http.StatusPaymentRequired:
+		return ErrorKindQuotaExhausted
+	case status == http.StatusRequestEntityTooLarge:
+		return ErrorKindContextTooLong
+	case status == http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	case status == http.StatusRequestTimeout || status >= http.StatusInternalServerError:
+		return ErrorKindTransient
+	default:
+		return ErrorKindUnknown
+	}
+}
+
+// ParseRetryAfter 解析 Retry-After 响应头，兼容秒数与 HTTP 日期两种写法，无法解析时返回 0。
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// containsAny 判断文本是否包含任一关键字。
+func containsAny(text string, needles []string) bool {
+	for _, needle := range needles {
+		if strings.Contains(text, needle) {
+			return true
+		}
+	}
+	return false
+}
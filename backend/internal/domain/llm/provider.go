@@ -0,0 +1,11 @@
+package llm
+
+import "context"
+
+// Provider 由各厂商客户端实现，负责在通用请求/响应与厂商协议之间互相转换。
+type Provider interface {
+	// Complete 发起一次性调用并返回完整响应。
+	Complete(ctx context.Context, req Request) (Response, error)
+	// CompleteStream 以流式方式调用，每收到一段增量文本即回调 onDelta，结束后返回拼装好的完整响应。
+	CompleteStream(ctx context.Context, req Request, onDelta StreamHandler) (Response, error)
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 2
+	defaultBaseDelay  = 500 * time.Millisecond
+	defaultMaxDelay   = 10 * time.Second
+)
+
+// RetryPolicy 描述模型客户端在同一凭据上的重试策略：指数退避叠加随机抖动，并优先遵循 Retry-After。
+type RetryPolicy struct {
+	MaxRetries int           // 首次调用失败后的最大重试次数，0 表示不重试
+	BaseDelay  time.Duration // 第一次重试前的基准等待时间，之后逐次翻倍
+	MaxDelay   time.Duration // 单次等待上限，Retry-After 超过该值时直接放弃重试
+}
+
+// DefaultRetryPolicy 返回默认策略：最多重试 2 次，等待 500ms 起步、上限 10s。
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: defaultMaxRetries,
+		BaseDelay:  defaultBaseDelay,
+		MaxDelay:   defaultMaxDelay,
+	}
+}
+
+// normalize 对缺省字段进行填充。
+func (p RetryPolicy) normalize() RetryPolicy {
+	if p.MaxRetries < 0 {
+		p.MaxRetries = 0
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultMaxDelay
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	return p
+}
+
+// Delay 返回第 retry 次重试（从 1 开始）前需要等待的时间，ok 为 false 表示不应继续重试。
+func (p RetryPolicy) Delay(retry int, err error) (time.Duration, bool) {
+	p = p.normalize()
+	if retry < 1 || retry > p.MaxRetries || !KindOf(err).Retryable() {
+		return 0, false
+	}
+	if hint := RetryAfterOf(err); hint > 0 {
+		if hint > p.MaxDelay {
+			return 0, false
+		}
+		return hint, true
+	}
+	backoff := p.BaseDelay << (retry - 1)
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	// 在 [backoff/2, backoff] 区间随机取值，避免大量请求同时重试。
+	half := backoff / 2
+	return half + time.Duration(rand.Int64N(int64(backoff-half)+1)), true
+}
+
+// Retry 按策略执行 fn，遇到可重试错误时等待后重试；ctx 结束或重试耗尽时返回最后一次错误。
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for retry := 1; ; retry++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		delay, ok := policy.Delay(retry, err)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package llm
+
+// Message 表示与模型交互的单条对话消息，与具体厂商的协议无关。
+type Message struct {
+	Role       string     `json:"role"`                   // system/user/assistant/tool
+	Content    string     `json:"content"`                // 文本内容
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // assistant 发起的工具调用
+	ToolCallID string     `json:"tool_call_id,omitempty"` // tool 消息对应的调用 ID
+}
+
+// Tool 描述可供模型调用的函数工具。
+type Tool struct {
+	Type     string       `json:"type"`     // 目前固定为 function
+	Function ToolFunction `json:"function"` // 函数签名
+}
+
+// ToolFunction 描述函数工具的名称、用途与 JSON Schema 参数。
+type ToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// ToolCall 表示模型返回的一次函数调用。
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction 记录被调用的函数名与 JSON 字符串形式的参数。
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Request 为各厂商通用的对话请求，provider 负责翻译为各自的协议。
+type Request struct {
+	Model            string         `json:"model"`                       // 模型标识
+	Messages         []Message      `json:"messages"`                    // 对话消息
+	MaxTokens        int            `json:"max_tokens,omitempty"`        // 最大输出 token
+	Temperature      float64        `json:"temperature,omitempty"`       // 采样温度
+	TopP             float64        `json:"top_p,omitempty"`             // 核采样阈值
+	PresencePenalty  float64        `json:"presence_penalty,omitempty"`  // 存在惩罚
+	FrequencyPenalty float64        `json:"frequency_penalty,omitempty"` // 频率惩罚
+	ResponseFormat   map[string]any `json:"response_format,omitempty"`   // OpenAI 风格的输出格式约束
+	Stop             []string       `json:"stop,omitempty"`              // 停止序列
+	Tools            []Tool         `json:"tools,omitempty"`             // 可用工具
+	ToolChoice       any            `json:"tool_choice,omitempty"`       // 工具选择策略
+	ExtraFields      map[string]any `json:"-"`                           // 厂商特有的扩展参数，原样透传
+}
+
+// Reasoning 记录推理模型输出的思考过程。
+type Reasoning struct {
+	Content   string `json:"content"`             // 思考内容
+	Signature string `json:"signature,omitempty"` // 部分厂商（如 Anthropic）返回的签名，多轮对话需原样回传
+}
+
+// Choice 描述模型返回的单个候选。
+type Choice struct {
+	Index        int        `json:"index"`
+	Message      Message    `json:"message"`
+	Reasoning    *Reasoning `json:"reasoning,omitempty"`
+	FinishReason string     `json:"finish_reason"` // 统一为 stop/length/tool_calls/content_filter
+}
+
+// Usage 统计一次调用的 token 消耗，缓存与推理 token 独立成字段而非混入明细映射。
+type Usage struct {
+	PromptTokens        int64            `json:"prompt_tokens"`                   // 输入 token（含缓存命中部分）
+	CompletionTokens    int64            `json:"completion_tokens"`               // 输出 token（含推理部分）
+	TotalTokens         int64            `json:"total_tokens"`                    // 合计
+	CachedTokens        int64            `json:"cached_tokens,omitempty"`         // 命中缓存的输入 token
+	CacheCreationTokens int64            `json:"cache_creation_tokens,omitempty"` // 写入缓存的输入 token
+	ReasoningTokens     int64            `json:"reasoning_tokens,omitempty"`      // 推理过程消耗的输出 token
+	Extra               map[string]int64 `json:"extra,omitempty"`                 // 厂商特有的统计项，如火山引擎的预留 token
+}
+
+// Cost 为按价格表估算的一次调用费用。
+type Cost struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// Response 为各厂商通用的对话响应。
+type Response struct {
+	ID                string         `json:"id"`
+	Created           int64          `json:"created"`
+	Model             string         `json:"model"`
+	Choices           []Choice       `json:"choices"`
+	Usage             *Usage         `json:"usage,omitempty"`
+	Cost              *Cost          `json:"cost,omitempty"`               // 按价格表估算的费用，模型服务调用后填充
+	ServiceTier       string         `json:"service_tier,omitempty"`       // 服务等级（OpenAI/火山引擎）
+	SystemFingerprint string         `json:"system_fingerprint,omitempty"` // 后端配置指纹（OpenAI 兼容协议）
+	Raw               map[string]any `json:"-"`
+}
+
+// Text 返回首个候选的文本内容，没有候选时返回空串。
+func (r Response) Text() string {
+	if len(r.Choices) == 0 {
+		return ""
+	}
+	return r.Choices[0].Message.Content
+}
+
+// StreamHandler 接收流式生成过程中的增量文本，返回错误会中断后续读取。
+type StreamHandler func(delta string) error
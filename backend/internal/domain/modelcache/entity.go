@@ -0,0 +1,16 @@
+package modelcache
+
+import "time"
+
+// Entry 映射 model_response_cache 表，仅本地（SQLite）模式使用，在线模式的响应缓存存放在 Redis。
+type Entry struct {
+	CacheKey  string    `gorm:"column:cache_key;primaryKey;size:64"` // 规范化请求的 SHA-256
+	Payload   string    `gorm:"column:payload;type:text"`            // JSON 编码的模型响应
+	ExpiresAt time.Time `gorm:"column:expires_at;index"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// TableName 返回响应缓存表名称。
+func (Entry) TableName() string {
+	return "model_response_cache"
+}
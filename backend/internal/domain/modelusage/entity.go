@@ -0,0 +1,30 @@
+package modelusage
+
+import "time"
+
+// Record 映射 model_usage_records 表，记录每一次模型调用的 token 消耗与结果。
+type Record struct {
+	ID               uint      `gorm:"column:id;primaryKey"`
+	UserID           uint      `gorm:"column:user_id;index:idx_model_usage_user_created"`
+	CredentialID     *uint     `gorm:"column:credential_id;index:idx_model_usage_credential_created"` // 平台内置模型（免费额度、独立审核模型）为空
+	Provider         string    `gorm:"column:provider;size:64"`
+	ModelKey         string    `gorm:"column:model_key;size:128"`
+	Model            string    `gorm:"column:model;size:128"` // 厂商实际返回的模型标识
+	Operation        string    `gorm:"column:operation;size:32"`
+	PromptTokens     int64     `gorm:"column:prompt_tokens"`
+	CompletionTokens int64     `gorm:"column:completion_tokens"`
+	ReasoningTokens  int64     `gorm:"column:reasoning_tokens"`
+	CachedTokens     int64     `gorm:"column:cached_tokens"`
+	TotalTokens      int64     `gorm:"column:total_tokens"`
+	EstimatedCost    *float64  `gorm:"column:estimated_cost"` // 按价格表估算的费用，价格表未覆盖的模型为空
+	Currency         string    `gorm:"column:currency;size:8"`
+	LatencyMillis    int64     `gorm:"column:latency_ms"`
+	Success          bool      `gorm:"column:success"`
+	ErrorKind        string    `gorm:"column:error_kind;size:32"`
+	CreatedAt        time.Time `gorm:"column:created_at;index:idx_model_usage_user_created;index:idx_model_usage_credential_created"`
+}
+
+// TableName 返回用量流水表名称。
+func (Record) TableName() string {
+	return "model_usage_records"
+}
@@ -61,6 +61,7 @@ type Prompt struct {
 	LikeCount         uint       `gorm:"not null;default:0"`                     // 点赞数量。
 	VisitCount        uint64     `gorm:"not null;default:0"`                     // 访问次数。
 	GenerationProfile string     `gorm:"type:text"`                              // 生成配置 JSON。
+	Variables         string     `gorm:"type:text"`                              // 模板变量定义 JSON。
 	PublishedAt       *time.Time // 最近发布的时间戳。
 	CreatedAt         time.Time  // 创建时间。
 	UpdatedAt         time.Time  // 最近更新时间。
@@ -149,5 +150,6 @@ type PromptVersion struct {
 	NegativeKeywords  string    `gorm:"type:text;not null"`                                  // 负向关键词快照。
 	Model             string    `gorm:"size:64;not null"`                                    // 生成使用的模型。
 	GenerationProfile string    `gorm:"type:text"`                                           // 生成配置快照。
+	Variables         string    `gorm:"type:text"`                                           // 模板变量定义快照。
 	CreatedAt         time.Time // 版本创建时间。
 }
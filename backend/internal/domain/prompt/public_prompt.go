@@ -28,6 +28,7 @@ type PublicPrompt struct {
 	PositiveKeywords string `gorm:"type:text;not null"`                                             // 正向关键词 JSON
 	NegativeKeywords string `gorm:"type:text;not null"`                                             // 负向关键词 JSON
 	Tags             string `gorm:"type:text;not null"`                                             // 标签 JSON
+	Variables        string `gorm:"type:text"`                                                      // 模板变量定义 JSON
 	Model            string `gorm:"size:64;not null"`                                               // 使用模型标识
 	Language         string `gorm:"size:16;not null;default:'zh-CN'"`                               // 内容语言
 	// 审核状态（pending/approved/rejected），同时作为多种排序索引的第一列
@@ -0,0 +1,37 @@
+package prompt
+
+import "time"
+
+// PromptRunStatus 标记试运行的调用结果。
+const (
+	PromptRunStatusSucceeded = "succeeded"
+	PromptRunStatusFailed    = "failed"
+)
+
+// PromptRun 记录一次 Prompt 试运行：渲染所用的变量值、调用的模型与参数、模型输出以及用量和耗时。
+type PromptRun struct {
+	ID                uint      `gorm:"primaryKey"`                                                         // 自增主键。
+	PromptID          uint      `gorm:"not null;index:idx_prompt_runs_prompt_version,priority:1"`           // 关联 Prompt。
+	VersionNo         int       `gorm:"not null;default:0;index:idx_prompt_runs_prompt_version,priority:2"` // 运行的版本号，0 表示当前正文。
+	UserID            uint      `gorm:"not null;index"`                                                     // 发起运行的用户。
+	ModelKey          string    `gorm:"size:128;not null"`                                                  // 调用的模型凭据标识。
+	Model             string    `gorm:"size:128"`                                                           // 厂商实际返回的模型标识。
+	Values            string    `gorm:"type:text"`                                                          // 渲染使用的变量值 JSON。
+	GenerationProfile string    `gorm:"type:text"`                                                          // 调用使用的生成配置 JSON。
+	Input             string    `gorm:"type:text;not null"`                                                 // 渲染后发送给模型的正文。
+	Output            string    `gorm:"type:text"`                                                          // 模型输出文本。
+	PromptTokens      int64     `gorm:"not null;default:0"`                                                 // 输入 token。
+	CompletionTokens  int64     `gorm:"not null;default:0"`                                                 // 输出 token。
+	TotalTokens       int64     `gorm:"not null;default:0"`                                                 // 合计 token。
+	EstimatedCost     *float64  `gorm:"column:estimated_cost"`                                              // 按价格表估算的费用，价格表未覆盖的模型为空。
+	Currency          string    `gorm:"size:8"`                                                             // 费用币种。
+	LatencyMillis     int64     `gorm:"column:latency_ms;not null;default:0"`                               // 调用耗时（毫秒）。
+	Status            string    `gorm:"size:16;not null"`                                                   // 调用结果：succeeded/failed。
+	Error             string    `gorm:"type:text"`                                                          // 失败原因。
+	CreatedAt         time.Time `gorm:"index:idx_prompt_runs_prompt_version,priority:3"`                    // 运行时间。
+}
+
+// TableName 返回试运行记录表名称。
+func (PromptRun) TableName() string {
+	return "prompt_runs"
+}
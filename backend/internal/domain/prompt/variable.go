@@ -0,0 +1,60 @@
+package prompt
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// PromptVariableType 定义模板变量支持的取值类型。
+const (
+	PromptVariableTypeString  = "string"
+	PromptVariableTypeNumber  = "number"
+	PromptVariableTypeBoolean = "boolean"
+	PromptVariableTypeEnum    = "enum"
+)
+
+// PromptVariablePattern 匹配正文中的 {{name}} 占位符，变量名由字母、数字与下划线组成且不能以数字开头。
+var PromptVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// PromptVariable 描述 Prompt 正文中单个模板变量的定义。
+type PromptVariable struct {
+	Name        string   `json:"name"`                  // 变量名，对应正文中的 {{name}}
+	Type        string   `json:"type"`                  // 取值类型：string/number/boolean/enum
+	Default     string   `json:"default,omitempty"`     // 默认值，渲染时未传值则使用
+	Required    bool     `json:"required,omitempty"`    // 是否必填
+	Enum        []string `json:"enum,omitempty"`        // 可选值列表，enum 类型必填
+	Description string   `json:"description,omitempty"` // 变量说明
+}
+
+// DetectPromptVariables 按首次出现的顺序返回正文中引用的变量名，重复引用只保留一次。
+func DetectPromptVariables(body string) []string {
+	matches := PromptVariablePattern.FindAllStringSubmatch(body, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(matches))
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		name := match[1]
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
+}
+
+// DecodePromptVariables 解析数据库或请求中保存的变量定义 JSON，空串视为没有变量。
+func DecodePromptVariables(raw string) ([]PromptVariable, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	var variables []PromptVariable
+	if err := json.Unmarshal([]byte(raw), &variables); err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
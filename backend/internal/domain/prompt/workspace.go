@@ -1,6 +1,72 @@
 package prompt
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+)
+
+// ErrWorkspaceVersionConflict 表示写入时携带的期望版本与工作区当前版本不一致，通常是其它窗口已修改了同一工作区。
+var ErrWorkspaceVersionConflict = errors.New("workspace version conflict")
+
+// WorkspaceAnyVersion 表示写入工作区时不校验版本，用于服务端内部回写（生成结果、保存元数据等）以及未携带版本号的客户端请求。
+// 工作区版本从 1 开始，取负值使其与显式传入的任何版本号区分开。
+const WorkspaceAnyVersion int64 = -1
+
+// ErrWorkspaceHistoryEmpty 表示工作区没有可撤销或可重做的操作。
+var ErrWorkspaceHistoryEmpty = errors.New("workspace history empty")
+
+// WorkspaceHistoryLimit 为每个工作区撤销/重做栈各自保留的最大步数，超出后丢弃最早的记录。
+const WorkspaceHistoryLimit = 20
+
+// 工作区历史记录的操作类型。
+const (
+	WorkspaceOpMergeKeywords   = "merge_keywords"   // 新增/补充关键词
+	WorkspaceOpReplaceKeywords = "replace_keywords" // 同步排序与权重
+	WorkspaceOpRemoveKeyword   = "remove_keyword"   // 删除关键词
+	WorkspaceOpUpdateDraft     = "update_draft"     // 替换草稿正文
+	WorkspaceOpSetAttributes   = "set_attributes"   // 修改标签等属性
+	WorkspaceOpUndo            = "undo"             // 撤销
+	WorkspaceOpRedo            = "redo"             // 重做
+)
+
+// MergeWorkspaceAttributes 将 attrs 以补丁方式合并进已编码的属性 JSON，值为空表示删除对应属性，返回合并后的编码以及内容是否变化。
+func MergeWorkspaceAttributes(raw string, attrs map[string]string) (string, bool, error) {
+	existing := make(map[string]string)
+	if strings.TrimSpace(raw) != "" {
+		if err := json.Unmarshal([]byte(raw), &existing); err != nil {
+			existing = make(map[string]string)
+		}
+	}
+	merged := maps.Clone(existing)
+	for key, value := range attrs {
+		if strings.TrimSpace(value) == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	if maps.Equal(existing, merged) {
+		return raw, false, nil
+	}
+	if len(merged) == 0 {
+		return "", true, nil
+	}
+	encoded, err := json.Marshal(merged)
+	if err != nil {
+		return "", false, fmt.Errorf("encode workspace attributes: %w", err)
+	}
+	return string(encoded), true, nil
+}
+
+// WorkspaceHistoryStackUndo 与 WorkspaceHistoryStackRedo 标识本地模式历史记录所属的栈。
+const (
+	WorkspaceHistoryStackUndo = "undo"
+	WorkspaceHistoryStackRedo = "redo"
+)
 
 // WorkspaceKeyword 描述缓存在 Redis 中的单个关键词。
 type WorkspaceKeyword struct {
@@ -24,30 +90,223 @@ type WorkspaceSnapshot struct {
 	Negative   []WorkspaceKeyword `json:"negative"`             // 负向关键词列表
 	PromptID   uint               `json:"prompt_id,omitempty"`  // 关联 Prompt ID
 	Status     string             `json:"status,omitempty"`     // 草稿状态
-	Version    int64              `json:"version"`              // 快照版本号
+	Version    int64              `json:"version"`              // 快照版本号，每次内容写入加一，用于乐观并发控制
 	UpdatedAt  time.Time          `json:"updated_at"`           // 最近更新时间
 	Attributes map[string]string  `json:"attributes,omitempty"` // 额外属性（标签、补充说明等）
 }
 
+// WorkspaceDraftPreviewRunes 为工作区列表中草稿预览保留的最大字符数。
+const WorkspaceDraftPreviewRunes = 120
+
+// WorkspaceSummary 描述工作区列表中的单个条目，便于关闭页面后找回仍在有效期内的工作区。
+type WorkspaceSummary struct {
+	Token        string    `json:"workspace_token"`         // 工作区 token
+	Topic        string    `json:"topic"`                   // 当前主题
+	PromptID     uint      `json:"prompt_id,omitempty"`     // 关联 Prompt ID
+	Status       string    `json:"status,omitempty"`        // 草稿状态
+	DraftPreview string    `json:"draft_preview,omitempty"` // 草稿正文开头的预览
+	Version      int64     `json:"version"`                 // 快照版本号
+	UpdatedAt    time.Time `json:"updated_at"`              // 最近更新时间
+	ExpiresAt    time.Time `json:"expires_at"`              // 过期时间
+}
+
+// WorkspaceDraftPreview 截取草稿正文开头作为列表预览，超出 WorkspaceDraftPreviewRunes 时以省略号结尾。
+func WorkspaceDraftPreview(body string) string {
+	runes := []rune(strings.TrimSpace(body))
+	if len(runes) <= WorkspaceDraftPreviewRunes {
+		return string(runes)
+	}
+	return string(runes[:WorkspaceDraftPreviewRunes]) + "…"
+}
+
+// WorkspaceHistoryEntry 记录某次修改发生前工作区的可编辑内容，撤销/重做时据此整体恢复关键词、草稿正文与属性。
+type WorkspaceHistoryEntry struct {
+	Op         string             `json:"op"`                   // 产生该记录的操作
+	Positive   []WorkspaceKeyword `json:"positive,omitempty"`   // 正向关键词（含排序分数）
+	Negative   []WorkspaceKeyword `json:"negative,omitempty"`   // 负向关键词（含排序分数）
+	DraftBody  string             `json:"draft_body,omitempty"` // 草稿正文
+	Attributes map[string]string  `json:"attributes,omitempty"` // 额外属性
+	CreatedAt  time.Time          `json:"created_at"`           // 记录时间
+}
+
 // PersistenceTask 描述需要异步落库的任务。
 type PersistenceTask struct {
-	TaskID            string    `json:"task_id"`                      // 队列任务 ID
-	UserID            uint      `json:"user_id"`                      // 用户 ID
-	PromptID          uint      `json:"prompt_id,omitempty"`          // 目标 Prompt（为空表示创建）
-	WorkspaceToken    string    `json:"workspace_token"`              // 工作区 token
-	Publish           bool      `json:"publish"`                      // 是否发布
-	Topic             string    `json:"topic"`                        // 主题
-	Body              string    `json:"body"`                         // 正文
-	Instructions      string    `json:"instructions"`                 // 补充要求
-	Model             string    `json:"model"`                        // 模型键
-	Status            string    `json:"status"`                       // 目标状态
-	Tags              []string  `json:"tags,omitempty"`               // 标签
-	RequestedAt       time.Time `json:"requested_at"`                 // 入队时间
-	Action            string    `json:"action,omitempty"`             // 动作类型（create/update）
-	GenerationProfile string    `json:"generation_profile,omitempty"` // 生成配置 JSON
+	TaskID            string           `json:"task_id"`                      // 队列任务 ID
+	UserID            uint             `json:"user_id"`                      // 用户 ID
+	PromptID          uint             `json:"prompt_id,omitempty"`          // 目标 Prompt（为空表示创建）
+	WorkspaceToken    string           `json:"workspace_token"`              // 工作区 token
+	Publish           bool             `json:"publish"`                      // 是否发布
+	Topic             string           `json:"topic"`                        // 主题
+	Body              string           `json:"body"`                         // 正文
+	Instructions      string           `json:"instructions"`                 // 补充要求
+	Model             string           `json:"model"`                        // 模型键
+	Status            string           `json:"status"`                       // 目标状态
+	Tags              []string         `json:"tags,omitempty"`               // 标签
+	RequestedAt       time.Time        `json:"requested_at"`                 // 入队时间
+	Action            string           `json:"action,omitempty"`             // 动作类型（create/update）
+	GenerationProfile string           `json:"generation_profile,omitempty"` // 生成配置 JSON
+	Variables         []PromptVariable `json:"variables,omitempty"`          // 显式提交的模板变量定义
+	Attempts          int              `json:"attempts,omitempty"`           // 已失败的处理次数
+	LastError         string           `json:"last_error,omitempty"`         // 最近一次处理失败的原因
+	Receipt           string           `json:"-"`                            // 出队回执，由队列填充，Ack/Retry 时据此定位任务
 }
 
 const (
 	TaskActionCreate = "create"
 	TaskActionUpdate = "update"
 )
+
+// ErrPersistenceTaskStalled 记录在被回收任务的 LastError 中，表示上一次处理的 worker 崩溃或卡住而未确认任务。
+var ErrPersistenceTaskStalled = errors.New("persistence worker stalled before acknowledging task")
+
+// PersistenceRetryPolicy 描述落库任务失败后的重试策略，达到最大次数后任务转入死信队列。
+type PersistenceRetryPolicy struct {
+	MaxAttempts int           // 最多处理次数（含首次）
+	BaseDelay   time.Duration // 首次重试前的等待时间，之后按指数递增
+	MaxDelay    time.Duration // 单次等待上限
+}
+
+// DefaultPersistenceRetryPolicy 返回默认重试策略：最多处理 5 次，等待 2s 起步、上限 5 分钟。
+func DefaultPersistenceRetryPolicy() PersistenceRetryPolicy {
+	return PersistenceRetryPolicy{MaxAttempts: 5, BaseDelay: 2 * time.Second, MaxDelay: 5 * time.Minute}
+}
+
+// Exhausted 判断失败 attempts 次后是否已用尽重试机会。
+func (p PersistenceRetryPolicy) Exhausted(attempts int) bool {
+	return p.MaxAttempts <= 0 || attempts >= p.MaxAttempts
+}
+
+// Backoff 返回第 attempts 次失败后的等待时间。
+func (p PersistenceRetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// WorkspaceRecord 映射 prompt_workspaces 表，仅本地（SQLite）模式使用，在线模式的工作区存放在 Redis。
+type WorkspaceRecord struct {
+	UserID     uint      `gorm:"column:user_id;primaryKey;autoIncrement:false"`
+	Token      string    `gorm:"column:token;primaryKey;size:64"`
+	Topic      string    `gorm:"column:topic;size:255"`
+	Language   string    `gorm:"column:language;size:32"`
+	ModelKey   string    `gorm:"column:model_key;size:128"`
+	DraftBody  string    `gorm:"column:draft_body;type:text"`
+	Version    int64     `gorm:"column:version"`
+	PromptID   uint      `gorm:"column:prompt_id"`
+	Status     string    `gorm:"column:status;size:32"`
+	Attributes string    `gorm:"column:attributes;type:text"` // JSON 编码的额外属性
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime:false"`
+	ExpiresAt  time.Time `gorm:"column:expires_at;index"` // 与 Redis TTL 等价，过期后视为不存在
+}
+
+// TableName 返回本地工作区表名称。
+func (WorkspaceRecord) TableName() string {
+	return "prompt_workspaces"
+}
+
+// WorkspaceKeywordRecord 映射 prompt_workspace_keywords 表，按 score 升序还原关键词顺序。
+type WorkspaceKeywordRecord struct {
+	UserID   uint    `gorm:"column:user_id;primaryKey;autoIncrement:false"`
+	Token    string  `gorm:"column:token;primaryKey;size:64"`
+	Polarity string  `gorm:"column:polarity;primaryKey;size:16"`
+	Word     string  `gorm:"column:word;primaryKey;size:255"` // 小写后的关键词，与 Redis ZSET 成员一致
+	Payload  string  `gorm:"column:payload;type:text"`        // JSON 编码的 WorkspaceKeyword
+	Score    float64 `gorm:"column:score"`
+}
+
+// TableName 返回本地工作区关键词表名称。
+func (WorkspaceKeywordRecord) TableName() string {
+	return "prompt_workspace_keywords"
+}
+
+// WorkspaceHistoryRecord 映射 prompt_workspace_history 表，保存本地模式工作区的撤销/重做栈，随工作区一同过期删除。
+type WorkspaceHistoryRecord struct {
+	ID        uint      `gorm:"column:id;primaryKey"`
+	UserID    uint      `gorm:"column:user_id;index:idx_workspace_history_owner"`
+	Token     string    `gorm:"column:token;size:64;index:idx_workspace_history_owner"`
+	Stack     string    `gorm:"column:stack;size:8;index:idx_workspace_history_owner"` // undo 或 redo
+	Payload   string    `gorm:"column:payload;type:text"`                              // JSON 编码的 WorkspaceHistoryEntry
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// TableName 返回本地工作区历史表名称。
+func (WorkspaceHistoryRecord) TableName() string {
+	return "prompt_workspace_history"
+}
+
+// PersistenceTaskRecord 映射 prompt_persistence_tasks 表，作为本地模式的异步落库队列，按自增主键先进先出。
+type PersistenceTaskRecord struct {
+	ID          uint       `gorm:"column:id;primaryKey"`
+	TaskID      string     `gorm:"column:task_id;size:64;uniqueIndex"`
+	Payload     string     `gorm:"column:payload;type:text"`                            // JSON 编码的 PersistenceTask
+	State       string     `gorm:"column:state;size:16;index:idx_persistence_task_due"` // queued/processing/dead
+	AvailableAt time.Time  `gorm:"column:available_at;index:idx_persistence_task_due"`  // 重试任务在此之前不会被取出
+	ClaimedAt   *time.Time `gorm:"column:claimed_at"`                                   // 被 worker 取出的时间
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+}
+
+// 本地落库队列中任务的状态。
+const (
+	PersistenceTaskQueued     = "queued"
+	PersistenceTaskProcessing = "processing"
+	PersistenceTaskDead       = "dead"
+)
+
+// TableName 返回本地落库队列表名称。
+func (PersistenceTaskRecord) TableName() string {
+	return "prompt_persistence_tasks"
+}
+
+// PersistenceTaskStatus 描述异步落库任务的处理进度，供客户端查询保存结果。
+type PersistenceTaskStatus struct {
+	TaskID         string    `json:"task_id"`                   // 队列任务 ID
+	UserID         uint      `json:"user_id"`                   // 任务所属用户
+	State          string    `json:"state"`                     // pending/running/succeeded/failed
+	Attempts       int       `json:"attempts"`                  // 已失败的处理次数
+	Error          string    `json:"error,omitempty"`           // 最近一次失败原因
+	PromptID       uint      `json:"prompt_id,omitempty"`       // 落库后的 Prompt ID
+	Version        int       `json:"version,omitempty"`         // 落库后的版本号
+	Status         string    `json:"status,omitempty"`          // 落库后的 Prompt 状态
+	WorkspaceToken string    `json:"workspace_token,omitempty"` // 关联的工作区 token
+	CreatedAt      time.Time `json:"created_at"`                // 入队时间
+	UpdatedAt      time.Time `json:"updated_at"`                // 状态最近更新时间
+}
+
+// 异步落库任务对外暴露的状态。
+const (
+	PersistenceTaskStatePending   = "pending"
+	PersistenceTaskStateRunning   = "running"
+	PersistenceTaskStateSucceeded = "succeeded"
+	PersistenceTaskStateFailed    = "failed"
+)
+
+// Finished 判断任务是否已处于终态，终态之后状态不会再变化。
+func (s PersistenceTaskStatus) Finished() bool {
+	return s.State == PersistenceTaskStateSucceeded || s.State == PersistenceTaskStateFailed
+}
+
+// PersistenceTaskStatusRecord 映射 prompt_persistence_task_statuses 表，仅本地（SQLite）模式使用，在线模式存放在 Redis。
+type PersistenceTaskStatusRecord struct {
+	TaskID         string    `gorm:"column:task_id;primaryKey;size:64"`
+	UserID         uint      `gorm:"column:user_id;index"`
+	State          string    `gorm:"column:state;size:16"`
+	Attempts       int       `gorm:"column:attempts"`
+	Error          string    `gorm:"column:error;size:1024"`
+	PromptID       uint      `gorm:"column:prompt_id"`
+	Version        int       `gorm:"column:version"`
+	Status         string    `gorm:"column:status;size:32"`
+	WorkspaceToken string    `gorm:"column:workspace_token;size:64"`
+	CreatedAt      time.Time `gorm:"column:created_at;autoCreateTime:false"`
+	UpdatedAt      time.Time `gorm:"column:updated_at;autoUpdateTime:false"`
+	ExpiresAt      time.Time `gorm:"column:expires_at;index"` // 与 Redis TTL 等价，过期后视为不存在
+}
+
+// TableName 返回本地任务状态表名称。
+func (PersistenceTaskStatusRecord) TableName() string {
+	return "prompt_persistence_task_statuses"
+}
@@ -46,18 +46,24 @@ type EmailVerificationToken struct {
 
 // UserModelCredential 保存用户为特定模型配置的访问凭据。
 type UserModelCredential struct {
-	ID             uint       `gorm:"primaryKey" json:"id"`                     // 主键
-	UserID         uint       `gorm:"index" json:"user_id"`                     // 所属用户 ID
-	Provider       string     `gorm:"size:64;index" json:"provider"`            // 模型服务提供方，如 openai、deepseek
-	ModelKey       string     `gorm:"size:128" json:"model_key"`                // 用户自定义的模型键（用于前端偏好引用）
-	DisplayName    string     `gorm:"size:128" json:"display_name"`             // 前端展示名称
-	BaseURL        string     `gorm:"size:512" json:"base_url"`                 // 可选，自定义 API Base URL
-	APIKeyCipher   []byte     `gorm:"column:api_key_cipher;type:blob" json:"-"` // 加密后的 API Key（二进制存储）
-	ExtraConfig    string     `gorm:"type:text" json:"extra_config"`            // 扩展配置（JSON 字符串）
-	Status         string     `gorm:"size:16" json:"status"`                    // 状态：enabled/disabled
-	LastVerifiedAt *time.Time `json:"last_verified_at"`                         // 最近一次连通性校验时间
-	CreatedAt      time.Time  `json:"created_at"`                               // 创建时间（gorm 自动维护）
-	UpdatedAt      time.Time  `json:"updated_at"`                               // 更新时间（gorm 自动维护）
+	ID              uint       `gorm:"primaryKey" json:"id"`                     // 主键
+	UserID          uint       `gorm:"index" json:"user_id"`                     // 所属用户 ID
+	Provider        string     `gorm:"size:64;index" json:"provider"`            // 模型服务提供方，如 openai、deepseek
+	ModelKey        string     `gorm:"size:128" json:"model_key"`                // 用户自定义的模型键（用于前端偏好引用）
+	DisplayName     string     `gorm:"size:128" json:"display_name"`             // 前端展示名称
+	BaseURL         string     `gorm:"size:512" json:"base_url"`                 // 可选，自定义 API Base URL
+	APIKeyCipher    []byte     `gorm:"column:api_key_cipher;type:blob" json:"-"` // 加密后的 API Key（二进制存储）
+	ExtraConfig     string     `gorm:"type:text" json:"extra_config"`            // 扩展配置（JSON 字符串）
+	Status          string     `gorm:"size:16" json:"status"`                    // 状态：enabled/disabled
+	LastVerifiedAt  *time.Time `json:"last_verified_at"`                         // 最近一次连通性校验时间
+	MonthlyBudget   *float64   `json:"monthly_budget"`                           // 每月预算上限（价格表币种），为空表示不限制
+	HealthStatus    string     `gorm:"size:16" json:"health_status"`             // 健康检查结果：healthy/unhealthy/revoked，为空表示尚未检查
+	HealthCheckedAt *time.Time `json:"health_checked_at"`                        // 最近一次健康检查时间
+	HealthLatencyMs int64      `json:"health_latency_ms"`                        // 最近一次健康检查耗时（毫秒）
+	HealthError     string     `gorm:"size:512" json:"health_error"`             // 最近一次健康检查的错误信息，成功时清空
+	HealthFailures  int        `json:"health_failures"`                          // 连续失败次数，成功时归零
+	CreatedAt       time.Time  `json:"created_at"`                               // 创建时间（gorm 自动维护）
+	UpdatedAt       time.Time  `json:"updated_at"`                               // 更新时间（gorm 自动维护）
 }
 
 // TableName overrides default naming for clarity when syncing with PRD。
@@ -65,10 +71,41 @@ func (UserModelCredential) TableName() string {
 	return "user_model_credentials"
 }
 
+// 回退链适用的操作类型，default 作用于未单独配置的操作。
+const (
+	FallbackOperationDefault   = "default"
+	FallbackOperationInterpret = "interpret"
+	FallbackOperationAugment   = "augment"
+	FallbackOperationGenerate  = "generate"
+	FallbackOperationAudit     = "audit"
+)
+
+// MaxFallbackChainLength 限制单条回退链的长度，避免一次请求串行等待过多模型。
+const MaxFallbackChainLength = 5
+
 // Settings 描述用户可自定义的配置项，会以 JSON 字符串形式持久化在 User.Settings 字段中。
 type Settings struct {
-	PreferredModel   string `json:"preferred_model"`   // 默认模型 key
-	EnableAnimations bool   `json:"enable_animations"` // 是否启用界面动效
+	PreferredModel   string              `json:"preferred_model"`           // 默认模型 key
+	EnableAnimations bool                `json:"enable_animations"`         // 是否启用界面动效
+	FallbackChains   map[string][]string `json:"fallback_chains,omitempty"` // 按操作配置的有序回退模型 key
+}
+
+// IsFallbackOperation 判断操作名是否可以配置回退链。
+func IsFallbackOperation(operation string) bool {
+	switch operation {
+	case FallbackOperationDefault, FallbackOperationInterpret, FallbackOperationAugment, FallbackOperationGenerate, FallbackOperationAudit:
+		return true
+	default:
+		return false
+	}
+}
+
+// FallbackChain 返回指定操作的回退链，未单独配置时退回 default。
+func (s Settings) FallbackChain(operation string) []string {
+	if chain, ok := s.FallbackChains[operation]; ok {
+		return chain
+	}
+	return s.FallbackChains[FallbackOperationDefault]
 }
 
 // DefaultSettings 返回默认的用户设置。
@@ -85,8 +122,9 @@ func ParseSettings(raw string) (Settings, error) {
 		return DefaultSettings(), nil
 	}
 	type payload struct {
-		PreferredModel   string `json:"preferred_model"`
-		EnableAnimations *bool  `json:"enable_animations"`
+		PreferredModel   string              `json:"preferred_model"`
+		EnableAnimations *bool               `json:"enable_animations"`
+		FallbackChains   map[string][]string `json:"fallback_chains"`
 	}
 	var dto payload
 	if err := json.Unmarshal([]byte(raw), &dto); err != nil {
@@ -99,6 +137,9 @@ func ParseSettings(raw string) (Settings, error) {
 	if dto.EnableAnimations != nil {
 		settings.EnableAnimations = *dto.EnableAnimations
 	}
+	if len(dto.FallbackChains) > 0 {
+		settings.FallbackChains = dto.FallbackChains
+	}
 	return settings, nil
 }
 
@@ -14,9 +14,9 @@ import (
 	"strconv"
 	"strings"
 
+	"electron-go-app/backend/internal/domain/llm"
 	response "electron-go-app/backend/internal/infra/common"
 	appLogger "electron-go-app/backend/internal/infra/logger"
-	"electron-go-app/backend/internal/infra/model/deepseek"
 	changelogsvc "electron-go-app/backend/internal/service/changelog"
 	modelsvc "electron-go-app/backend/internal/service/model"
 
@@ -227,7 +227,7 @@ func (h *ChangelogHandler) requestTranslation(ctx context.Context, userID uint,
 		return changelogsvc.CreateEntryParams{}, fmt.Errorf("encode payload: %w", err)
 	}
 
-	messages := []deepseek.ChatMessage{
+	messages := []llm.Message{
 		{
 			Role:    "system",
 			Content: "You are a professional localization assistant. Translate release notes while preserving marketing tone. Always respond using strict JSON.",
@@ -238,7 +238,7 @@ func (h *ChangelogHandler) requestTranslation(ctx context.Context, userID uint,
 		},
 	}
 
-	request := deepseek.ChatCompletionRequest{
+	request := llm.Request{
 		Model:    modelKey,
 		Messages: messages,
 	}
@@ -16,9 +16,13 @@ import (
 	"strings"
 	"time"
 
+	"electron-go-app/backend/internal/domain/llm"
 	response "electron-go-app/backend/internal/infra/common"
 	appLogger "electron-go-app/backend/internal/infra/logger"
+	"electron-go-app/backend/internal/infra/model/anthropic"
 	deepseek "electron-go-app/backend/internal/infra/model/deepseek"
+	"electron-go-app/backend/internal/infra/model/ollama"
+	"electron-go-app/backend/internal/infra/model/volcengine"
 	modelsvc "electron-go-app/backend/internal/service/model"
 	promptsvc "electron-go-app/backend/internal/service/prompt"
 
@@ -126,12 +130,14 @@ func (h *ModelHandler) List(c *gin.Context) {
 
 // CreateRequest 表示创建模型凭据的请求体。
 type CreateRequest struct {
-	Provider    string                 `json:"provider" binding:"required"`
-	ModelKey    string                 `json:"model_key" binding:"required"`
-	DisplayName string                 `json:"display_name" binding:"required"`
-	BaseURL     string                 `json:"base_url"`
-	APIKey      string                 `json:"api_key" binding:"required"`
-	ExtraConfig map[string]interface{} `json:"extra_config"`
+	Provider      string                 `json:"provider" binding:"required"`
+	ModelKey      string                 `json:"model_key" binding:"required"`
+	DisplayName   string                 `json:"display_name" binding:"required"`
+	BaseURL       string                 `json:"base_url"`
+	APIKey        string                 `json:"api_key" binding:"required"`
+	ExtraConfig   map[string]interface{} `json:"extra_config"`
+	MonthlyBudget *float64               `json:"monthly_budget"`
+	ValidateModel bool                   `json:"validate_model"` // 为 true 时先确认 model_key 出现在提供方的模型列表中
 }
 
 // Create 新增模型凭据。
@@ -148,14 +154,19 @@ func (h *ModelHandler) Create(c *gin.Context) {
 		return
 	}
 	cred, err := h.service.Create(c.Request.Context(), userID, modelsvc.CreateInput{
-		Provider:    req.Provider,
-		ModelKey:    req.ModelKey,
-		DisplayName: req.DisplayName,
-		BaseURL:     req.BaseURL,
-		APIKey:      req.APIKey,
-		ExtraConfig: req.ExtraConfig,
+		Provider:      req.Provider,
+		ModelKey:      req.ModelKey,
+		DisplayName:   req.DisplayName,
+		BaseURL:       req.BaseURL,
+		APIKey:        req.APIKey,
+		ExtraConfig:   req.ExtraConfig,
+		MonthlyBudget: req.MonthlyBudget,
+		ValidateModel: req.ValidateModel,
 	})
 	if err != nil {
+		if h.writeCatalogError(c, err) {
+			return
+		}
 		switch err {
 		case modelsvc.ErrDuplicatedModelKey:
 			response.Fail(c, http.StatusConflict, response.ErrConflict, err.Error(), gin.H{"field": "model_key"})
@@ -163,6 +174,12 @@ func (h *ModelHandler) Create(c *gin.Context) {
 		case modelsvc.ErrUnsupportedProvider:
 			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"field": "provider"})
 			return
+		case modelsvc.ErrBaseURLRequired:
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"field": "base_url"})
+			return
+		case modelsvc.ErrInvalidBudget:
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"field": "monthly_budget"})
+			return
 		default:
 			log.Errorw("create credential failed", "error", err, "user_id", userID)
 			response.Fail(c, http.StatusInternalServerError, response.ErrInternal, err.Error(), nil)
@@ -174,18 +191,20 @@ func (h *ModelHandler) Create(c *gin.Context) {
 
 // UpdateRequest 表示更新模型凭据的请求体。
 type UpdateRequest struct {
-	DisplayName *string                `json:"display_name"`
-	BaseURL     *string                `json:"base_url"`
-	APIKey      *string                `json:"api_key"`
-	ExtraConfig map[string]interface{} `json:"extra_config"`
-	Status      *string                `json:"status"`
+	DisplayName   *string                `json:"display_name"`
+	BaseURL       *string                `json:"base_url"`
+	APIKey        *string                `json:"api_key"`
+	ExtraConfig   map[string]interface{} `json:"extra_config"`
+	Status        *string                `json:"status"`
+	MonthlyBudget *float64               `json:"monthly_budget"` // 传 0 取消预算
+	ValidateModel bool                   `json:"validate_model"`
 }
 
 // TestConnectionRequest 允许前端自定义测试 prompt 或消息体。
 type TestConnectionRequest struct {
-	Model    string                 `json:"model"`
-	Prompt   string                 `json:"prompt"`
-	Messages []deepseek.ChatMessage `json:"messages"`
+	Model    string        `json:"model"`
+	Prompt   string        `json:"prompt"`
+	Messages []llm.Message `json:"messages"`
 }
 
 // Update 修改指定凭据。
@@ -207,13 +226,18 @@ func (h *ModelHandler) Update(c *gin.Context) {
 		return
 	}
 	cred, err := h.service.Update(c.Request.Context(), userID, id, modelsvc.UpdateInput{
-		DisplayName: req.DisplayName,
-		BaseURL:     req.BaseURL,
-		APIKey:      req.APIKey,
-		ExtraConfig: req.ExtraConfig,
-		Status:      req.Status,
+		DisplayName:   req.DisplayName,
+		BaseURL:       req.BaseURL,
+		APIKey:        req.APIKey,
+		ExtraConfig:   req.ExtraConfig,
+		Status:        req.Status,
+		MonthlyBudget: req.MonthlyBudget,
+		ValidateModel: req.ValidateModel,
 	})
 	if err != nil {
+		if h.writeCatalogError(c, err) {
+			return
+		}
 		switch err {
 		case modelsvc.ErrCredentialNotFound:
 			response.Fail(c, http.StatusNotFound, response.ErrNotFound, err.Error(), nil)
@@ -223,6 +247,12 @@ func (h *ModelHandler) Update(c *gin.Context) {
 		case modelsvc.ErrUnsupportedProvider:
 			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"field": "provider"})
 			return
+		case modelsvc.ErrBaseURLRequired:
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"field": "base_url"})
+			return
+		case modelsvc.ErrInvalidBudget:
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"field": "monthly_budget"})
+			return
 		default:
 			log.Errorw("update credential failed", "error", err, "user_id", userID, "credential_id", id)
 			response.Fail(c, http.StatusInternalServerError, response.ErrInternal, err.Error(), nil)
@@ -252,7 +282,7 @@ func (h *ModelHandler) TestConnection(c *gin.Context) {
 		return
 	}
 
-	chatReq := deepseek.ChatCompletionRequest{
+	chatReq := llm.Request{
 		Model: strings.TrimSpace(req.Model),
 	}
 	if len(req.Messages) > 0 {
@@ -265,7 +295,7 @@ func (h *ModelHandler) TestConnection(c *gin.Context) {
 			prompt = "请回复“pong”以确认连通性。"
 		}
 		// 默认拼出最简对话，让测试在没有自定义消息时也能正常执行。
-		chatReq.Messages = []deepseek.ChatMessage{
+		chatReq.Messages = []llm.Message{
 			{Role: "system", Content: "你正在协助验证 API 凭据是否可用，请简短确认。"},
 			{Role: "user", Content: prompt},
 		}
@@ -278,13 +308,10 @@ func (h *ModelHandler) TestConnection(c *gin.Context) {
 			response.Fail(c, http.StatusNotFound, response.ErrNotFound, err.Error(), nil)
 		case errors.Is(err, modelsvc.ErrCredentialDisabled):
 			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+		case errors.Is(err, modelsvc.ErrModelUnavailable):
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"field": "model_key"})
 		default:
-			if apiErr, ok := err.(*deepseek.APIError); ok {
-				response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, apiErr.Error(), gin.H{
-					"status_code": apiErr.StatusCode,
-					"type":        apiErr.Type,
-					"code":        apiErr.Code,
-				})
+			if writeProviderError(c, err) {
 				return
 			}
 			log.Errorw("test credential failed", "error", err, "user_id", userID, "credential_id", id)
@@ -323,6 +350,125 @@ func (h *ModelHandler) Delete(c *gin.Context) {
 	response.NoContent(c)
 }
 
+// Usage 返回凭据最近 days 天的按日 token 用量，days 默认 30，最大 90。
+func (h *ModelHandler) Usage(c *gin.Context) {
+	log := h.scope("usage")
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+		return
+	}
+	days := 0
+	if raw := strings.TrimSpace(c.Query("days")); raw != "" {
+		parsed, convErr := strconv.Atoi(raw)
+		if convErr != nil {
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, "days must be an integer", gin.H{"field": "days"})
+			return
+		}
+		days = parsed
+	}
+	usage, err := h.service.Usage(c.Request.Context(), userID, id, days)
+	if err != nil {
+		switch {
+		case errors.Is(err, modelsvc.ErrInvalidUsageRange):
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, fmt.Sprintf("days must be between 1 and %d", modelsvc.MaxUsageDays), gin.H{"field": "days"})
+		case errors.Is(err, modelsvc.ErrCredentialNotFound):
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, err.Error(), nil)
+		default:
+			log.Errorw("load credential usage failed", "error", err, "user_id", userID, "credential_id", id)
+			response.Fail(c, http.StatusInternalServerError, response.ErrInternal, err.Error(), nil)
+		}
+		return
+	}
+	response.Success(c, http.StatusOK, usage, nil)
+}
+
+// Catalog 返回凭据可用的模型列表，refresh=true 时跳过缓存重新请求提供方。
+func (h *ModelHandler) Catalog(c *gin.Context) {
+	log := h.scope("catalog")
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+		return
+	}
+	refresh, _ := strconv.ParseBool(strings.TrimSpace(c.Query("refresh")))
+	catalog, err := h.service.Catalog(c.Request.Context(), userID, id, refresh)
+	if err != nil {
+		if errors.Is(err, modelsvc.ErrCredentialNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, err.Error(), nil)
+			return
+		}
+		if h.writeCatalogError(c, err) {
+			return
+		}
+		log.Errorw("load model catalog failed", "error", err, "user_id", userID, "credential_id", id)
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal, err.Error(), nil)
+		return
+	}
+	response.Success(c, http.StatusOK, catalog, nil)
+}
+
+// writeCatalogError 处理模型目录查询与 model_key 校验产生的错误，已写响应时返回 true。
+func (h *ModelHandler) writeCatalogError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, modelsvc.ErrModelUnavailable):
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"field": "model_key"})
+		return true
+	case errors.Is(err, modelsvc.ErrCatalogUnsupported):
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"field": "provider"})
+		return true
+	default:
+		return writeProviderError(c, err)
+	}
+}
+
+// writeProviderError 将各厂商的 APIError 转为 400 响应并附带状态码与错误类型，非厂商错误时返回 false。
+func writeProviderError(c *gin.Context, err error) bool {
+	if apiErr, ok := err.(*anthropic.APIError); ok {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, apiErr.Message, gin.H{
+			"status_code": apiErr.StatusCode,
+			"type":        apiErr.Type,
+			"kind":        apiErr.Kind(),
+		})
+		return true
+	}
+	if apiErr, ok := err.(*ollama.APIError); ok {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, apiErr.Message, gin.H{
+			"status_code": apiErr.StatusCode,
+			"kind":        apiErr.Kind(),
+		})
+		return true
+	}
+	if apiErr, ok := err.(*deepseek.APIError); ok {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, apiErr.Error(), gin.H{
+			"status_code": apiErr.StatusCode,
+			"type":        apiErr.Type,
+			"code":        apiErr.Code,
+			"kind":        apiErr.Kind(),
+		})
+		return true
+	}
+	if apiErr, ok := err.(*volcengine.APIError); ok {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, apiErr.Error(), gin.H{
+			"status_code": apiErr.StatusCode,
+			"code":        apiErr.Code,
+			"kind":        apiErr.Kind(),
+		})
+		return true
+	}
+	return false
+}
+
 // scope 派生带操作标签的日志实例，方便排查请求行为。
 func (h *ModelHandler) scope(operation string) *zap.SugaredLogger {
 	if h.logger == nil {
@@ -1,18 +1,22 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"electron-go-app/backend/internal/domain/llm"
 	promptdomain "electron-go-app/backend/internal/domain/prompt"
 	response "electron-go-app/backend/internal/infra/common"
 	appLogger "electron-go-app/backend/internal/infra/logger"
 	"electron-go-app/backend/internal/infra/ratelimit"
+	modelsvc "electron-go-app/backend/internal/service/model"
 	promptsvc "electron-go-app/backend/internal/service/prompt"
 
 	"github.com/gin-gonic/gin"
@@ -128,13 +132,15 @@ type interpretRequest struct {
 	Description string `json:"description" binding:"required"`
 	ModelKey    string `json:"model_key" binding:"required"`
 	Language    string `json:"language"`
+	BypassCache bool   `json:"bypass_cache"`
 }
 
 // ingestPromptRequest 描述成品 Prompt 自动解析的请求体。
 type ingestPromptRequest struct {
-	Body     string `json:"body" binding:"required"`
-	ModelKey string `json:"model_key"`
-	Language string `json:"language"`
+	Body        string `json:"body" binding:"required"`
+	ModelKey    string `json:"model_key"`
+	Language    string `json:"language"`
+	BypassCache bool   `json:"bypass_cache"`
 }
 
 // KeywordPayload 复用前端传递的关键词结构。
@@ -156,29 +162,33 @@ type augmentRequest struct {
 	ExistingPositive []KeywordPayload `json:"existing_positive"`
 	ExistingNegative []KeywordPayload `json:"existing_negative"`
 	WorkspaceToken   string           `json:"workspace_token"`
+	BypassCache      bool             `json:"bypass_cache"`
 }
 
 // manualKeywordRequest 负责接收手动新增关键词的参数。
 type manualKeywordRequest struct {
-	Topic          string `json:"topic" binding:"required"`
-	Word           string `json:"word" binding:"required"`
-	Polarity       string `json:"polarity"`
-	Language       string `json:"language"`
-	PromptID       uint   `json:"prompt_id"`
-	WorkspaceToken string `json:"workspace_token"`
-	Weight         int    `json:"weight"`
+	Topic            string `json:"topic" binding:"required"`
+	Word             string `json:"word" binding:"required"`
+	Polarity         string `json:"polarity"`
+	Language         string `json:"language"`
+	PromptID         uint   `json:"prompt_id"`
+	WorkspaceToken   string `json:"workspace_token"`
+	WorkspaceVersion *int64 `json:"workspace_version" binding:"omitempty,min=0"`
+	Weight           int    `json:"weight"`
 }
 
 // removeKeywordRequest 用于同步移除工作区中的关键词。
 type removeKeywordRequest struct {
-	Word           string `json:"word" binding:"required"`
-	Polarity       string `json:"polarity"`
-	WorkspaceToken string `json:"workspace_token"`
+	Word             string `json:"word" binding:"required"`
+	Polarity         string `json:"polarity"`
+	WorkspaceToken   string `json:"workspace_token"`
+	WorkspaceVersion *int64 `json:"workspace_version" binding:"omitempty,min=0"`
 }
 
 // syncWorkspaceRequest 用于同步工作区关键词的排序与权重。
 type syncWorkspaceRequest struct {
 	WorkspaceToken   string           `json:"workspace_token" binding:"required"`
+	WorkspaceVersion *int64           `json:"workspace_version" binding:"omitempty,min=0"`
 	PositiveKeywords []KeywordPayload `json:"positive_keywords"`
 	NegativeKeywords []KeywordPayload `json:"negative_keywords"`
 }
@@ -210,21 +220,51 @@ type generateRequest struct {
 	PositiveKeywords  []KeywordPayload          `json:"positive_keywords" binding:"required,dive"`
 	NegativeKeywords  []KeywordPayload          `json:"negative_keywords"`
 	WorkspaceToken    string                    `json:"workspace_token"`
+	Candidates        int                       `json:"candidates"`
+	RankBy            string                    `json:"rank_by"`
+}
+
+// selectCandidateRequest 接收用户选定的候选正文，用于写回工作区草稿。
+type selectCandidateRequest struct {
+	WorkspaceToken   string `json:"workspace_token" binding:"required"`
+	WorkspaceVersion *int64 `json:"workspace_version" binding:"omitempty,min=0"`
+	Prompt           string `json:"prompt" binding:"required"`
+}
+
+// workspaceHistoryRequest 接收撤销/重做请求的可选参数，请求体可以为空。
+type workspaceHistoryRequest struct {
+	WorkspaceVersion *int64 `json:"workspace_version" binding:"omitempty,min=0"`
 }
 
 // saveRequest 接收保存草稿或发布 Prompt 的参数。
 type saveRequest struct {
-	PromptID          uint                      `json:"prompt_id"`
-	Topic             string                    `json:"topic"`
-	Body              string                    `json:"body"`
-	Instructions      string                    `json:"instructions"`
-	Model             string                    `json:"model"`
-	Status            string                    `json:"status"`
-	Publish           bool                      `json:"publish"`
-	Tags              []string                  `json:"tags"`
-	PositiveKeywords  []KeywordPayload          `json:"positive_keywords" binding:"required,dive"`
-	NegativeKeywords  []KeywordPayload          `json:"negative_keywords"`
-	WorkspaceToken    string                    `json:"workspace_token"`
+	PromptID          uint                          `json:"prompt_id"`
+	Topic             string                        `json:"topic"`
+	Body              string                        `json:"body"`
+	Instructions      string                        `json:"instructions"`
+	Model             string                        `json:"model"`
+	Status            string                        `json:"status"`
+	Publish           bool                          `json:"publish"`
+	Tags              []string                      `json:"tags"`
+	PositiveKeywords  []KeywordPayload              `json:"positive_keywords" binding:"required,dive"`
+	NegativeKeywords  []KeywordPayload              `json:"negative_keywords"`
+	WorkspaceToken    string                        `json:"workspace_token"`
+	GenerationProfile *generationProfilePayload     `json:"generation_profile"`
+	Variables         []promptdomain.PromptVariable `json:"variables"`
+	Async             bool                          `json:"async"`
+}
+
+// renderRequest 接收渲染 Prompt 模板时传入的变量值，version 为空时渲染当前正文。
+type renderRequest struct {
+	Version int            `json:"version"`
+	Values  map[string]any `json:"values"`
+}
+
+// runRequest 描述一次 Prompt 试运行，model_key 与 generation_profile 为空时沿用 Prompt 保存的配置。
+type runRequest struct {
+	Version           int                       `json:"version"`
+	ModelKey          string                    `json:"model_key"`
+	Values            map[string]any            `json:"values"`
 	GenerationProfile *generationProfilePayload `json:"generation_profile"`
 }
 
@@ -546,6 +586,7 @@ func (h *PromptHandler) GetPromptVersion(c *gin.Context) {
 		"negative_keywords":  toKeywordResponse(detail.NegativeKeywords),
 		"created_at":         detail.CreatedAt,
 		"generation_profile": detail.Generation,
+		"variables":          detail.Variables,
 	}, nil)
 }
 
@@ -591,13 +632,179 @@ func (h *PromptHandler) GetPrompt(c *gin.Context) {
 		"is_liked":           detail.IsLiked,
 		"like_count":         detail.LikeCount,
 		"workspace_token":    detail.WorkspaceToken,
+		"workspace_version":  detail.WorkspaceVersion,
 		"created_at":         detail.CreatedAt,
 		"updated_at":         detail.UpdatedAt,
 		"published_at":       detail.PublishedAt,
 		"generation_profile": detail.Generation,
+		"variables":          detail.Variables,
 	}, nil)
 }
 
+// RenderPrompt 使用请求中的变量值渲染 Prompt 正文，并返回缺失与未定义的变量。
+func (h *PromptHandler) RenderPrompt(c *gin.Context) {
+	log := h.scope("render")
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+	promptID, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || promptID == 0 {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, "invalid prompt id", nil)
+		return
+	}
+	var req renderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+		return
+	}
+	if req.Version < 0 {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, "invalid version", nil)
+		return
+	}
+
+	result, err := h.service.RenderPrompt(c.Request.Context(), promptsvc.RenderPromptInput{
+		UserID:    userID,
+		PromptID:  uint(promptID),
+		VersionNo: req.Version,
+		Values:    req.Values,
+	})
+	if err != nil {
+		if errors.Is(err, promptsvc.ErrPromptNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "prompt not found", nil)
+			return
+		}
+		if errors.Is(err, promptsvc.ErrPromptVersionNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "prompt version not found", nil)
+			return
+		}
+		log.Errorw("render prompt failed", "error", err, "user_id", userID, "prompt_id", promptID, "version", req.Version)
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal, "渲染 Prompt 失败", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, result, nil)
+}
+
+// RunPrompt 渲染 Prompt 后发送给指定模型，返回模型输出并记录本次试运行。
+func (h *PromptHandler) RunPrompt(c *gin.Context) {
+	log := h.scope("run")
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+	promptID, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || promptID == 0 {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, "invalid prompt id", nil)
+		return
+	}
+	var req runRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+		return
+	}
+	if req.Version < 0 {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, "invalid version", nil)
+		return
+	}
+
+	result, err := h.service.RunPrompt(c.Request.Context(), promptsvc.RunPromptInput{
+		UserID:            userID,
+		PromptID:          uint(promptID),
+		VersionNo:         req.Version,
+		ModelKey:          req.ModelKey,
+		Values:            req.Values,
+		GenerationProfile: toGenerationProfilePayload(req.GenerationProfile, false, 0, 0, 0),
+	})
+	if err != nil {
+		if errors.Is(err, promptsvc.ErrPromptNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "prompt not found", nil)
+			return
+		}
+		if errors.Is(err, promptsvc.ErrPromptVersionNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "prompt version not found", nil)
+			return
+		}
+		var missingErr *promptsvc.PromptVariablesMissingError
+		if errors.As(err, &missingErr) {
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, missingErr.Error(), gin.H{"missing": missingErr.Missing})
+			return
+		}
+		if errors.Is(err, modelsvc.ErrCredentialNotFound) || errors.Is(err, modelsvc.ErrCredentialDisabled) {
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, "模型凭据不存在或已禁用，请选择其它模型。", nil)
+			return
+		}
+		log.Errorw("run prompt failed", "error", err, "user_id", userID, "prompt_id", promptID, "version", req.Version, "model", req.ModelKey)
+		if respondModelError(c, err) {
+			return
+		}
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+		return
+	}
+	response.Success(c, http.StatusOK, result, nil)
+}
+
+// ListPromptRuns 分页返回 Prompt 的试运行记录，可通过 version 查询参数筛选单个版本。
+func (h *PromptHandler) ListPromptRuns(c *gin.Context) {
+	log := h.scope("list_runs")
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+	promptID, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || promptID == 0 {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, "invalid prompt id", nil)
+		return
+	}
+	var versionNo *int
+	if raw := strings.TrimSpace(c.Query("version")); raw != "" {
+		version, err := strconv.Atoi(raw)
+		if err != nil || version < 0 {
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, "invalid version", nil)
+			return
+		}
+		versionNo = &version
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	out, err := h.service.ListPromptRuns(c.Request.Context(), promptsvc.ListPromptRunsInput{
+		UserID:    userID,
+		PromptID:  uint(promptID),
+		VersionNo: versionNo,
+		Page:      page,
+		PageSize:  pageSize,
+	})
+	if err != nil {
+		if errors.Is(err, promptsvc.ErrPromptNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "prompt not found", nil)
+			return
+		}
+		log.Errorw("list prompt runs failed", "error", err, "user_id", userID, "prompt_id", promptID)
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal, "获取试运行记录失败", nil)
+		return
+	}
+
+	totalPages := 0
+	if out.PageSize > 0 {
+		totalPages = int((out.Total + int64(out.PageSize) - 1) / int64(out.PageSize))
+	}
+	response.Success(
+		c,
+		http.StatusOK,
+		gin.H{"items": out.Items},
+		response.MetaPagination{
+			Page:         out.Page,
+			PageSize:     out.PageSize,
+			TotalItems:   int(out.Total),
+			TotalPages:   totalPages,
+			CurrentCount: len(out.Items),
+		},
+	)
+}
+
 // DeletePrompt 删除指定 Prompt。
 func (h *PromptHandler) DeletePrompt(c *gin.Context) {
 	log := h.scope("delete")
@@ -745,6 +952,7 @@ func (h *PromptHandler) Interpret(c *gin.Context) {
 		Description: req.Description,
 		ModelKey:    req.ModelKey,
 		Language:    req.Language,
+		BypassCache: req.BypassCache,
 	})
 	if err != nil {
 		if errors.Is(err, promptsvc.ErrContentRejected) {
@@ -765,8 +973,7 @@ func (h *PromptHandler) Interpret(c *gin.Context) {
 			return
 		}
 		log.Errorw("interpret failed", "error", err, "user_id", userID)
-		if errors.Is(err, promptsvc.ErrModelInvocationFailed) {
-			response.Fail(c, http.StatusServiceUnavailable, response.ErrInternal, "调用模型失败，请检查网络连接或模型凭据。", nil)
+		if respondModelError(c, err) {
 			return
 		}
 		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
@@ -779,8 +986,12 @@ func (h *PromptHandler) Interpret(c *gin.Context) {
 		"negative_keywords": toKeywordResponse(result.NegativeKeywords),
 		"confidence":        result.Confidence,
 		"workspace_token":   result.WorkspaceToken,
+		"workspace_version": result.WorkspaceVersion,
 		"instructions":      result.Instructions,
 		"tags":              result.Tags,
+		"served_model_key":  result.ServedModelKey,
+		"fallback_used":     result.FallbackUsed,
+		"cached":            result.Cached,
 	}, nil)
 }
 
@@ -801,10 +1012,11 @@ func (h *PromptHandler) IngestPrompt(c *gin.Context) {
 		return
 	}
 	detail, err := h.service.IngestPrompt(c.Request.Context(), promptsvc.IngestPromptInput{
-		UserID:   userID,
-		Body:     req.Body,
-		ModelKey: req.ModelKey,
-		Language: req.Language,
+		UserID:      userID,
+		Body:        req.Body,
+		ModelKey:    req.ModelKey,
+		Language:    req.Language,
+		BypassCache: req.BypassCache,
 	})
 	if err != nil {
 		if errors.Is(err, promptsvc.ErrContentRejected) {
@@ -825,8 +1037,7 @@ func (h *PromptHandler) IngestPrompt(c *gin.Context) {
 			return
 		}
 		log.Errorw("ingest prompt failed", "error", err, "user_id", userID)
-		if errors.Is(err, promptsvc.ErrModelInvocationFailed) {
-			response.Fail(c, http.StatusServiceUnavailable, response.ErrInternal, "调用模型失败，请检查网络连接或模型凭据。", nil)
+		if respondModelError(c, err) {
 			return
 		}
 		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
@@ -850,6 +1061,7 @@ func (h *PromptHandler) IngestPrompt(c *gin.Context) {
 		"updated_at":         detail.UpdatedAt,
 		"published_at":       detail.PublishedAt,
 		"generation_profile": detail.Generation,
+		"cached":             detail.Cached,
 	}, nil)
 }
 
@@ -889,6 +1101,7 @@ func (h *PromptHandler) AugmentKeywords(c *gin.Context) {
 		RequestedNegative: req.NegativeLimit,
 		ExistingPositive:  toServiceKeywords(req.ExistingPositive),
 		ExistingNegative:  toServiceKeywords(req.ExistingNegative),
+		BypassCache:       req.BypassCache,
 	})
 	if err != nil {
 		var quotaErr *promptsvc.FreeTierQuotaExceededError
@@ -904,18 +1117,24 @@ func (h *PromptHandler) AugmentKeywords(c *gin.Context) {
 			return
 		}
 		log.Errorw("augment keywords failed", "error", err, "user_id", userID)
-		if errors.Is(err, promptsvc.ErrModelInvocationFailed) {
-			response.Fail(c, http.StatusServiceUnavailable, response.ErrInternal, "调用模型失败，请检查网络连接或模型凭据。", nil)
+		if respondModelError(c, err) {
 			return
 		}
 		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
 		return
 	}
 
-	response.Success(c, http.StatusOK, gin.H{
-		"positive": toKeywordResponse(out.Positive),
-		"negative": toKeywordResponse(out.Negative),
-	}, nil)
+	payload := gin.H{
+		"positive":         toKeywordResponse(out.Positive),
+		"negative":         toKeywordResponse(out.Negative),
+		"served_model_key": out.ServedModelKey,
+		"fallback_used":    out.FallbackUsed,
+		"cached":           out.Cached,
+	}
+	if out.WorkspaceVersion > 0 {
+		payload["workspace_version"] = out.WorkspaceVersion
+	}
+	response.Success(c, http.StatusOK, payload, nil)
 }
 
 // AddManualKeyword 处理手动关键词录入，立即落库供后续复用。
@@ -935,16 +1154,20 @@ func (h *PromptHandler) AddManualKeyword(c *gin.Context) {
 	}
 
 	item, err := h.service.AddManualKeyword(c.Request.Context(), promptsvc.ManualKeywordInput{
-		UserID:         userID,
-		Topic:          req.Topic,
-		Word:           req.Word,
-		Polarity:       req.Polarity,
-		Language:       req.Language,
-		PromptID:       req.PromptID,
-		WorkspaceToken: strings.TrimSpace(req.WorkspaceToken),
-		Weight:         req.Weight,
+		UserID:           userID,
+		Topic:            req.Topic,
+		Word:             req.Word,
+		Polarity:         req.Polarity,
+		Language:         req.Language,
+		PromptID:         req.PromptID,
+		WorkspaceToken:   strings.TrimSpace(req.WorkspaceToken),
+		WorkspaceVersion: workspaceVersionOrAny(req.WorkspaceVersion),
+		Weight:           req.Weight,
 	})
 	if err != nil {
+		if h.workspaceConflictError(c, err) {
+			return
+		}
 		if errors.Is(err, promptsvc.ErrPositiveKeywordLimit) {
 			h.keywordLimitError(c, promptdomain.KeywordPolarityPositive, -1)
 			return
@@ -981,18 +1204,23 @@ func (h *PromptHandler) RemoveKeyword(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RemoveWorkspaceKeyword(c.Request.Context(), promptsvc.RemoveKeywordInput{
-		UserID:         userID,
-		Word:           req.Word,
-		Polarity:       req.Polarity,
-		WorkspaceToken: strings.TrimSpace(req.WorkspaceToken),
-	}); err != nil {
+	version, err := h.service.RemoveWorkspaceKeyword(c.Request.Context(), promptsvc.RemoveKeywordInput{
+		UserID:           userID,
+		Word:             req.Word,
+		Polarity:         req.Polarity,
+		WorkspaceToken:   strings.TrimSpace(req.WorkspaceToken),
+		WorkspaceVersion: workspaceVersionOrAny(req.WorkspaceVersion),
+	})
+	if err != nil {
+		if h.workspaceConflictError(c, err) {
+			return
+		}
 		log.Warnw("remove keyword failed", "error", err, "user_id", userID)
 		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
 		return
 	}
 
-	response.NoContent(c)
+	h.workspaceVersionResponse(c, version)
 }
 
 // SyncKeywords 将关键词的排序与权重同步到 Redis 工作区。
@@ -1011,18 +1239,23 @@ func (h *PromptHandler) SyncKeywords(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.SyncWorkspaceKeywords(c.Request.Context(), promptsvc.SyncWorkspaceInput{
-		UserID:         userID,
-		WorkspaceToken: strings.TrimSpace(req.WorkspaceToken),
-		Positive:       toServiceKeywords(req.PositiveKeywords),
-		Negative:       toServiceKeywords(req.NegativeKeywords),
-	}); err != nil {
+	version, err := h.service.SyncWorkspaceKeywords(c.Request.Context(), promptsvc.SyncWorkspaceInput{
+		UserID:           userID,
+		WorkspaceToken:   strings.TrimSpace(req.WorkspaceToken),
+		WorkspaceVersion: workspaceVersionOrAny(req.WorkspaceVersion),
+		Positive:         toServiceKeywords(req.PositiveKeywords),
+		Negative:         toServiceKeywords(req.NegativeKeywords),
+	})
+	if err != nil {
+		if h.workspaceConflictError(c, err) {
+			return
+		}
 		log.Warnw("sync workspace keywords failed", "error", err, "user_id", userID)
 		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
 		return
 	}
 
-	response.NoContent(c)
+	h.workspaceVersionResponse(c, version)
 }
 
 // GeneratePrompt 调用大模型生成 Prompt，带限流保护。
@@ -1035,22 +1268,95 @@ func (h *PromptHandler) GeneratePrompt(c *gin.Context) {
 		return
 	}
 
-	if !h.allow(c, fmt.Sprintf("generate:%d", userID), h.generateLimit, h.generateWindow) {
+	req, input, ok := h.bindGenerateInput(c, userID)
+	if !ok {
+		return
+	}
+	out, err := h.service.GeneratePrompt(c.Request.Context(), input)
+	if err != nil {
+		h.respondGenerateError(c, log, userID, req, err)
+		return
+	}
+	response.Success(c, http.StatusOK, generateResultPayload(out, req), nil)
+}
+
+// GeneratePromptStream 以 SSE 推送生成过程：delta 事件携带增量文本，usage 事件携带 token 统计，
+// done 事件携带与 GeneratePrompt 一致的完整结果；开始推送前的失败仍以普通 JSON 错误返回。
+func (h *PromptHandler) GeneratePromptStream(c *gin.Context) {
+	log := h.scope("generate_stream")
+
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+
+	req, input, ok := h.bindGenerateInput(c, userID)
+	if !ok {
+		return
+	}
+
+	streaming := false
+	startStream := func() {
+		if streaming {
+			return
+		}
+		streaming = true
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+	}
+	out, err := h.service.GeneratePromptStream(c.Request.Context(), input, func(delta string) error {
+		if err := c.Request.Context().Err(); err != nil {
+			return err
+		}
+		startStream()
+		c.SSEvent("delta", gin.H{"content": delta})
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !streaming {
+			h.respondGenerateError(c, log, userID, req, err)
+			return
+		}
+		log.Warnw("generate prompt stream failed", "error", err, "user_id", userID)
+		code, message := streamGenerateError(err)
+		c.SSEvent("error", gin.H{"code": code, "message": message})
+		c.Writer.Flush()
 		return
 	}
 
+	startStream()
+	c.SSEvent("usage", gin.H{
+		"model":       out.Model,
+		"duration_ms": out.Duration.Milliseconds(),
+		"usage":       out.Usage,
+	})
+	c.SSEvent("done", generateResultPayload(out, req))
+	c.Writer.Flush()
+}
+
+// bindGenerateInput 解析生成请求并完成限流与关键词数量校验，失败时已直接写出响应。
+func (h *PromptHandler) bindGenerateInput(c *gin.Context, userID uint) (generateRequest, promptsvc.GenerateInput, bool) {
+	if !h.allow(c, fmt.Sprintf("generate:%d", userID), h.generateLimit, h.generateWindow) {
+		return generateRequest{}, promptsvc.GenerateInput{}, false
+	}
+
 	var req generateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
-		return
+		return generateRequest{}, promptsvc.GenerateInput{}, false
 	}
 
 	if !h.validateKeywordLimit(c, req.PositiveKeywords, req.NegativeKeywords) {
-		return
+		return generateRequest{}, promptsvc.GenerateInput{}, false
 	}
 
 	generationProfile := toGenerationProfilePayload(req.GenerationProfile, req.StepwiseReasoning, req.Temperature, req.TopP, req.MaxTokens)
-	out, err := h.service.GeneratePrompt(c.Request.Context(), promptsvc.GenerateInput{
+	return req, promptsvc.GenerateInput{
 		UserID:            userID,
 		Topic:             req.Topic,
 		ModelKey:          req.ModelKey,
@@ -1065,42 +1371,113 @@ func (h *PromptHandler) GeneratePrompt(c *gin.Context) {
 		NegativeKeywords:  toServiceKeywords(req.NegativeKeywords),
 		WorkspaceToken:    strings.TrimSpace(req.WorkspaceToken),
 		GenerationProfile: generationProfile,
-	})
-	if err != nil {
-		if errors.Is(err, promptsvc.ErrContentRejected) {
-			reason := extractContentRejectReason(err)
-			response.Fail(c, http.StatusBadRequest, response.ErrContentRejected, reason, gin.H{"reason": reason})
-			return
-		}
-		if errors.Is(err, promptsvc.ErrPositiveKeywordLimit) {
-			h.keywordLimitError(c, promptdomain.KeywordPolarityPositive, len(req.PositiveKeywords))
-			return
+		Candidates:        req.Candidates,
+		RankBy:            req.RankBy,
+	}, true
+}
+
+// respondGenerateError 将生成失败映射为统一的错误响应。
+func (h *PromptHandler) respondGenerateError(c *gin.Context, log *zap.SugaredLogger, userID uint, req generateRequest, err error) {
+	if errors.Is(err, promptsvc.ErrContentRejected) {
+		reason := extractContentRejectReason(err)
+		response.Fail(c, http.StatusBadRequest, response.ErrContentRejected, reason, gin.H{"reason": reason})
+		return
+	}
+	if errors.Is(err, promptsvc.ErrPositiveKeywordLimit) {
+		h.keywordLimitError(c, promptdomain.KeywordPolarityPositive, len(req.PositiveKeywords))
+		return
+	}
+	if errors.Is(err, promptsvc.ErrNegativeKeywordLimit) {
+		h.keywordLimitError(c, promptdomain.KeywordPolarityNegative, len(req.NegativeKeywords))
+		return
+	}
+	if errors.Is(err, promptsvc.ErrInvalidCandidateCount) || errors.Is(err, promptsvc.ErrInvalidRankMode) || errors.Is(err, promptsvc.ErrCandidatesNotStreamable) {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), gin.H{"max_candidates": promptsvc.MaxGenerateCandidates})
+		return
+	}
+	var quotaErr *promptsvc.FreeTierQuotaExceededError
+	if errors.As(err, &quotaErr) {
+		retry := int(quotaErr.RetryAfter.Seconds())
+		if retry < 0 {
+			retry = 0
 		}
-		if errors.Is(err, promptsvc.ErrNegativeKeywordLimit) {
-			h.keywordLimitError(c, promptdomain.KeywordPolarityNegative, len(req.NegativeKeywords))
-			return
+		response.Fail(c, http.StatusTooManyRequests, response.ErrTooManyRequests, "今日免费额度已用尽，请配置模型凭据或等待额度重置。", gin.H{
+			"retry_after_seconds": retry,
+			"remaining":           quotaErr.Remaining,
+		})
+		return
+	}
+	log.Errorw("generate prompt failed", "error", err, "user_id", userID)
+	if respondModelError(c, err) {
+		return
+	}
+	response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+}
+
+// streamGenerateError 为已开始推送的 SSE 流选择错误码与提示文案。
+func streamGenerateError(err error) (response.ErrorCode, string) {
+	if errors.Is(err, promptsvc.ErrContentRejected) {
+		return response.ErrContentRejected, extractContentRejectReason(err)
+	}
+	if _, code, message, _, ok := classifyModelError(err); ok {
+		return code, message
+	}
+	return response.ErrBadRequest, err.Error()
+}
+
+// respondModelError 按模型错误类型返回对应的状态码与错误码，非模型错误时返回 false 交由调用方处理。
+func respondModelError(c *gin.Context, err error) bool {
+	status, code, message, details, ok := classifyModelError(err)
+	if !ok {
+		return false
+	}
+	response.Fail(c, status, code, message, details)
+	return true
+}
+
+// classifyModelError 将模型调用错误映射为 HTTP 状态码、错误码与提示文案，未识别的模型错误统一返回 503。
+func classifyModelError(err error) (int, response.ErrorCode, string, gin.H, bool) {
+	kind := llm.KindOf(err)
+	if kind == llm.ErrorKindUnknown && !errors.Is(err, promptsvc.ErrModelInvocationFailed) {
+		return 0, "", "", nil, false
+	}
+	details := gin.H{"kind": string(kind)}
+	if budgetErr := (*modelsvc.BudgetExceededError)(nil); errors.As(err, &budgetErr) {
+		details["budget"] = budgetErr.Budget
+		details["spent"] = budgetErr.Spent
+		details["currency"] = budgetErr.Currency
+		return http.StatusPaymentRequired, response.ErrModelBudgetExceeded, "该模型本月估算费用已达到预算上限，请调整预算或更换模型。", details, true
+	}
+	switch kind {
+	case llm.ErrorKindRateLimited:
+		if retry := llm.RetryAfterOf(err); retry > 0 {
+			details["retry_after_seconds"] = int(math.Ceil(retry.Seconds()))
 		}
-		var quotaErr *promptsvc.FreeTierQuotaExceededError
-		if errors.As(err, &quotaErr) {
-			retry := int(quotaErr.RetryAfter.Seconds())
-			if retry < 0 {
-				retry = 0
-			}
-			response.Fail(c, http.StatusTooManyRequests, response.ErrTooManyRequests, "今日免费额度已用尽，请配置模型凭据或等待额度重置。", gin.H{
-				"retry_after_seconds": retry,
-				"remaining":           quotaErr.Remaining,
-			})
-			return
+		return http.StatusTooManyRequests, response.ErrModelRateLimited, "模型服务繁忙（触发限流），请稍后重试。", details, true
+	case llm.ErrorKindAuthFailed:
+		return http.StatusBadGateway, response.ErrModelAuthFailed, "模型凭据无效或无权访问该模型，请检查 API Key。", details, true
+	case llm.ErrorKindQuotaExhausted:
+		return http.StatusPaymentRequired, response.ErrModelQuotaExhausted, "模型账户余额或配额不足，请充值或更换模型凭据。", details, true
+	case llm.ErrorKindContextTooLong:
+		return http.StatusRequestEntityTooLarge, response.ErrModelContextTooLong, "输入内容超出模型上下文长度，请精简后重试。", details, true
+	case llm.ErrorKindContentFiltered:
+		return http.StatusUnprocessableEntity, response.ErrModelContentFiltered, "内容被模型服务的安全策略拦截，请调整后重试。", details, true
+	case llm.ErrorKindTransient:
+		if retry := llm.RetryAfterOf(err); retry > 0 {
+			details["retry_after_seconds"] = int(math.Ceil(retry.Seconds()))
 		}
-		log.Errorw("generate prompt failed", "error", err, "user_id", userID)
-		if errors.Is(err, promptsvc.ErrModelInvocationFailed) {
-			response.Fail(c, http.StatusServiceUnavailable, response.ErrInternal, "调用模型失败，请检查网络连接或模型凭据。", nil)
-			return
+		if errors.Is(err, modelsvc.ErrCircuitOpen) {
+			details["circuit_open"] = true
+			return http.StatusServiceUnavailable, response.ErrModelUnavailable, "模型服务已降级（连续失败触发熔断），请稍后重试或切换模型。", details, true
 		}
-		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
-		return
+		return http.StatusServiceUnavailable, response.ErrModelUnavailable, "模型服务暂时不可用，请稍后重试。", details, true
+	default:
+		return http.StatusServiceUnavailable, response.ErrInternal, "调用模型失败，请检查网络连接或模型凭据。", nil, true
 	}
+}
 
+// generateResultPayload 组装生成成功后的响应体。
+func generateResultPayload(out promptsvc.GenerateOutput, req generateRequest) gin.H {
 	payload := gin.H{
 		"prompt":            out.Prompt,
 		"model":             out.Model,
@@ -1108,14 +1485,225 @@ func (h *PromptHandler) GeneratePrompt(c *gin.Context) {
 		"positive_keywords": toKeywordResponse(out.PositiveUsed),
 		"negative_keywords": toKeywordResponse(out.NegativeUsed),
 		"topic":             strings.TrimSpace(req.Topic),
+		"served_model_key":  out.ServedModelKey,
+		"fallback_used":     out.FallbackUsed,
 	}
 	if out.Usage != nil {
 		payload["usage"] = out.Usage
 	}
+	if out.Cost != nil {
+		payload["cost"] = out.Cost
+	}
 	if token := strings.TrimSpace(req.WorkspaceToken); token != "" {
 		payload["workspace_token"] = token
 	}
-	response.Success(c, http.StatusOK, payload, nil)
+	if out.WorkspaceVersion > 0 {
+		payload["workspace_version"] = out.WorkspaceVersion
+	}
+	if len(out.Candidates) > 0 {
+		candidates := make([]gin.H, 0, len(out.Candidates))
+		for _, candidate := range out.Candidates {
+			item := gin.H{
+				"index":            candidate.Index,
+				"prompt":           candidate.Prompt,
+				"model":            candidate.Model,
+				"served_model_key": candidate.ServedModelKey,
+				"fallback_used":    candidate.FallbackUsed,
+				"temperature":      candidate.Temperature,
+				"duration_ms":      candidate.Duration.Milliseconds(),
+			}
+			if candidate.Usage != nil {
+				item["usage"] = candidate.Usage
+			}
+			if candidate.Cost != nil {
+				item["cost"] = candidate.Cost
+			}
+			if candidate.Score != nil {
+				item["score"] = *candidate.Score
+				item["score_reason"] = candidate.ScoreReason
+			}
+			candidates = append(candidates, item)
+		}
+		payload["candidates"] = candidates
+		payload["selected_index"] = out.SelectedIndex
+		if out.RankedBy != "" {
+			payload["ranked_by"] = out.RankedBy
+		}
+	}
+	return payload
+}
+
+// SelectCandidate 将用户从多个候选中选定的正文写回工作区草稿。
+func (h *PromptHandler) SelectCandidate(c *gin.Context) {
+	log := h.scope("select_candidate")
+
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+
+	var req selectCandidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+		return
+	}
+
+	version, err := h.service.SelectCandidate(c.Request.Context(), promptsvc.SelectCandidateInput{
+		UserID:           userID,
+		WorkspaceToken:   strings.TrimSpace(req.WorkspaceToken),
+		WorkspaceVersion: workspaceVersionOrAny(req.WorkspaceVersion),
+		Prompt:           req.Prompt,
+	})
+	if err != nil {
+		if h.workspaceConflictError(c, err) {
+			return
+		}
+		if errors.Is(err, promptsvc.ErrWorkspaceNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "workspace not found", nil)
+			return
+		}
+		if errors.Is(err, promptsvc.ErrCandidatePromptEmpty) {
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+			return
+		}
+		log.Errorw("select generate candidate failed", "error", err, "user_id", userID)
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal, "写回候选正文失败", nil)
+		return
+	}
+
+	h.workspaceVersionResponse(c, version)
+}
+
+// ListWorkspaces 返回当前用户仍在有效期内的工作区，便于关闭页面后继续编辑。
+func (h *PromptHandler) ListWorkspaces(c *gin.Context) {
+	log := h.scope("list_workspaces")
+
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+
+	items, err := h.service.ListWorkspaces(c.Request.Context(), userID)
+	if err != nil {
+		log.Errorw("list workspaces failed", "error", err, "user_id", userID)
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal, "获取工作区列表失败", nil)
+		return
+	}
+	if items == nil {
+		items = []promptdomain.WorkspaceSummary{}
+	}
+	response.Success(c, http.StatusOK, gin.H{"items": items}, nil)
+}
+
+// DiscardWorkspace 主动丢弃指定工作区。
+func (h *PromptHandler) DiscardWorkspace(c *gin.Context) {
+	log := h.scope("discard_workspace")
+
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+
+	token := strings.TrimSpace(c.Param("token"))
+	if err := h.service.DiscardWorkspace(c.Request.Context(), userID, token); err != nil {
+		if errors.Is(err, promptsvc.ErrWorkspaceNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "workspace not found", nil)
+			return
+		}
+		log.Errorw("discard workspace failed", "error", err, "user_id", userID, "token", token)
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal, "丢弃工作区失败", nil)
+		return
+	}
+	response.NoContent(c)
+}
+
+// PromoteWorkspace 将工作区内容保存为草稿 Prompt，已关联 Prompt 时更新该 Prompt。
+func (h *PromptHandler) PromoteWorkspace(c *gin.Context) {
+	log := h.scope("promote_workspace")
+
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+
+	token := strings.TrimSpace(c.Param("token"))
+	result, err := h.service.PromoteWorkspace(c.Request.Context(), userID, token)
+	if err != nil {
+		if errors.Is(err, promptsvc.ErrWorkspaceNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "workspace not found", nil)
+			return
+		}
+		if errors.Is(err, promptsvc.ErrPromptNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "prompt not found", nil)
+			return
+		}
+		if errors.Is(err, promptsvc.ErrPositiveKeywordLimit) || errors.Is(err, promptsvc.ErrNegativeKeywordLimit) || errors.Is(err, promptsvc.ErrTagLimitExceeded) {
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+			return
+		}
+		log.Errorw("promote workspace failed", "error", err, "user_id", userID, "token", token)
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal, "保存工作区失败", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, result, nil)
+}
+
+// UndoWorkspace 撤销工作区最近一次关键词、草稿正文或属性修改，返回恢复后的工作区。
+func (h *PromptHandler) UndoWorkspace(c *gin.Context) {
+	h.travelWorkspace(c, "undo_workspace", h.service.UndoWorkspace)
+}
+
+// RedoWorkspace 重做工作区最近一次被撤销的修改，返回恢复后的工作区。
+func (h *PromptHandler) RedoWorkspace(c *gin.Context) {
+	h.travelWorkspace(c, "redo_workspace", h.service.RedoWorkspace)
+}
+
+// travelWorkspace 是撤销/重做接口的共享实现。
+func (h *PromptHandler) travelWorkspace(c *gin.Context, op string, travel func(context.Context, promptsvc.WorkspaceHistoryInput) (promptdomain.WorkspaceSnapshot, error)) {
+	log := h.scope(op)
+
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+
+	var req workspaceHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+		return
+	}
+
+	snapshot, err := travel(c.Request.Context(), promptsvc.WorkspaceHistoryInput{
+		UserID:           userID,
+		WorkspaceToken:   strings.TrimSpace(c.Param("token")),
+		WorkspaceVersion: workspaceVersionOrAny(req.WorkspaceVersion),
+	})
+	if err != nil {
+		if h.workspaceConflictError(c, err) {
+			return
+		}
+		if errors.Is(err, promptsvc.ErrWorkspaceNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "workspace not found", nil)
+			return
+		}
+		if errors.Is(err, promptsvc.ErrNothingToUndo) || errors.Is(err, promptsvc.ErrNothingToRedo) {
+			response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+			return
+		}
+		log.Warnw("travel workspace history failed", "error", err, "user_id", userID)
+		response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"workspace":         snapshot,
+		"workspace_version": snapshot.Version,
+	}, nil)
 }
 
 // SavePrompt 保存或发布 Prompt 草稿，并同步工作区元数据。
@@ -1163,6 +1751,8 @@ func (h *PromptHandler) SavePrompt(c *gin.Context) {
 		WorkspaceToken:           strings.TrimSpace(req.WorkspaceToken),
 		EnforcePublishValidation: true,
 		GenerationProfile:        generationProfile,
+		Variables:                req.Variables,
+		Async:                    req.Async,
 	})
 	if err != nil {
 		if errors.Is(err, promptsvc.ErrPositiveKeywordLimit) {
@@ -1182,9 +1772,79 @@ func (h *PromptHandler) SavePrompt(c *gin.Context) {
 		return
 	}
 
+	if result.TaskID != "" {
+		response.Success(c, http.StatusAccepted, result, nil)
+		return
+	}
 	response.Success(c, http.StatusOK, result, nil)
 }
 
+// GetSaveTask 查询异步保存任务的处理状态。
+func (h *PromptHandler) GetSaveTask(c *gin.Context) {
+	log := h.scope("save_task")
+
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+	taskID := strings.TrimSpace(c.Param("id"))
+	status, err := h.service.GetSaveTask(c.Request.Context(), userID, taskID)
+	if err != nil {
+		if errors.Is(err, promptsvc.ErrSaveTaskNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "save task not found", nil)
+			return
+		}
+		log.Errorw("get save task failed", "error", err, "user_id", userID, "task_id", taskID)
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal, "获取保存任务状态失败", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, status, nil)
+}
+
+// StreamSaveTask 以 SSE 推送异步保存任务的状态变化：每次变化发送 status 事件，任务结束后连接关闭；
+// 首次查询失败时仍以普通 JSON 错误返回。
+func (h *PromptHandler) StreamSaveTask(c *gin.Context) {
+	log := h.scope("save_task_stream")
+
+	userID, ok := extractUserID(c)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, response.ErrUnauthorized, "missing user id", nil)
+		return
+	}
+	taskID := strings.TrimSpace(c.Param("id"))
+
+	streaming := false
+	err := h.service.WatchSaveTask(c.Request.Context(), userID, taskID, func(status promptdomain.PersistenceTaskStatus) error {
+		if !streaming {
+			streaming = true
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+		}
+		c.SSEvent("status", status)
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	if !streaming {
+		if errors.Is(err, promptsvc.ErrSaveTaskNotFound) {
+			response.Fail(c, http.StatusNotFound, response.ErrNotFound, "save task not found", nil)
+			return
+		}
+		log.Errorw("watch save task failed", "error", err, "user_id", userID, "task_id", taskID)
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal, "获取保存任务状态失败", nil)
+		return
+	}
+	log.Warnw("save task stream interrupted", "error", err, "user_id", userID, "task_id", taskID)
+	c.SSEvent("error", gin.H{"message": err.Error()})
+	c.Writer.Flush()
+}
+
 // allow 根据限流配置判断当前请求是否放行。
 func (h *PromptHandler) allow(c *gin.Context, key string, limit int, window time.Duration) bool {
 	if h.limiter == nil || limit <= 0 {
@@ -1232,6 +1892,14 @@ func (h *PromptHandler) readImportPayload(c *gin.Context) ([]byte, error) {
 	return data, nil
 }
 
+// workspaceVersionOrAny 将请求中的期望版本转换为服务层参数，未携带时不校验版本，兼容尚未回传版本号的旧客户端。
+func workspaceVersionOrAny(version *int64) int64 {
+	if version == nil {
+		return promptdomain.WorkspaceAnyVersion
+	}
+	return *version
+}
+
 // scope 派生带行动标签的日志实例，便于排查具体操作。
 func (h *PromptHandler) scope(action string) *zap.SugaredLogger {
 	return h.logger.With("action", action)
@@ -1307,6 +1975,27 @@ func (h *PromptHandler) keywordDuplicateError(c *gin.Context, polarity, word str
 	response.Fail(c, http.StatusBadRequest, response.ErrBadRequest, message, details)
 }
 
+// workspaceConflictError 在工作区版本冲突时返回 409 与最新快照，便于前端合并或重新加载。
+func (h *PromptHandler) workspaceConflictError(c *gin.Context, err error) bool {
+	var conflict *promptsvc.WorkspaceConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	response.Fail(c, http.StatusConflict, response.ErrConflict, conflict.Error(), gin.H{
+		"workspace": conflict.Snapshot,
+	})
+	return true
+}
+
+// workspaceVersionResponse 返回工作区写入后的版本号；未启用工作区时保持 204。
+func (h *PromptHandler) workspaceVersionResponse(c *gin.Context, version int64) {
+	if version <= 0 {
+		response.NoContent(c)
+		return
+	}
+	response.Success(c, http.StatusOK, gin.H{"workspace_version": version}, nil)
+}
+
 // extractContentRejectReason 提取内容审核错误中的具体原因，便于返回给前端提示。
 func extractContentRejectReason(err error) string {
 	if err == nil {
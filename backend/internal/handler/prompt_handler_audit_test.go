@@ -8,9 +8,9 @@ import (
 	"strings"
 	"testing"
 
+	"electron-go-app/backend/internal/domain/llm"
 	promptdomain "electron-go-app/backend/internal/domain/prompt"
 	response "electron-go-app/backend/internal/infra/common"
-	deepseek "electron-go-app/backend/internal/infra/model/deepseek"
 	"electron-go-app/backend/internal/repository"
 	promptsvc "electron-go-app/backend/internal/service/prompt"
 
@@ -20,15 +20,15 @@ import (
 )
 
 type handlerModelStub struct {
-	responses []deepseek.ChatCompletionResponse
-	requests  []deepseek.ChatCompletionRequest
+	responses []llm.Response
+	requests  []llm.Request
 	err       error
 }
 
-func (f *handlerModelStub) InvokeChatCompletion(ctx context.Context, userID uint, modelKey string, req deepseek.ChatCompletionRequest) (deepseek.ChatCompletionResponse, error) {
+func (f *handlerModelStub) InvokeChatCompletion(ctx context.Context, userID uint, modelKey string, req llm.Request) (llm.Response, error) {
 	f.requests = append(f.requests, req)
 	if len(f.responses) == 0 {
-		return deepseek.ChatCompletionResponse{}, f.err
+		return llm.Response{}, f.err
 	}
 	res := f.responses[0]
 	f.responses = f.responses[1:]
@@ -70,7 +70,7 @@ func newTestService(t *testing.T, stub *handlerModelStub) (*promptsvc.Service, *
 	return service, db
 }
 
-func buildAuditResponsePayload(t *testing.T, allowed bool, reason string) deepseek.ChatCompletionResponse {
+func buildAuditResponsePayload(t *testing.T, allowed bool, reason string) llm.Response {
 	t.Helper()
 	payload := map[string]any{
 		"allowed": allowed,
@@ -80,15 +80,15 @@ func buildAuditResponsePayload(t *testing.T, allowed bool, reason string) deepse
 	if err != nil {
 		t.Fatalf("marshal audit payload: %v", err)
 	}
-	return deepseek.ChatCompletionResponse{
+	return llm.Response{
 		Model: "audit-model",
-		Choices: []deepseek.ChatCompletionChoice{
-			{Message: deepseek.ChatMessage{Role: "assistant", Content: string(raw)}},
+		Choices: []llm.Choice{
+			{Message: llm.Message{Role: "assistant", Content: string(raw)}},
 		},
 	}
 }
 
-func buildInterpretationResponse(t *testing.T) deepseek.ChatCompletionResponse {
+func buildInterpretationResponse(t *testing.T) llm.Response {
 	t.Helper()
 	payload := map[string]any{
 		"topic":             "良性话题",
@@ -102,17 +102,17 @@ func buildInterpretationResponse(t *testing.T) deepseek.ChatCompletionResponse {
 	if err != nil {
 		t.Fatalf("marshal interpretation payload: %v", err)
 	}
-	return deepseek.ChatCompletionResponse{
+	return llm.Response{
 		Model: "deepseek-chat",
-		Choices: []deepseek.ChatCompletionChoice{
-			{Message: deepseek.ChatMessage{Role: "assistant", Content: string(raw)}},
+		Choices: []llm.Choice{
+			{Message: llm.Message{Role: "assistant", Content: string(raw)}},
 		},
 	}
 }
 
 func TestInterpret_AuditPass(t *testing.T) {
 	stub := &handlerModelStub{
-		responses: []deepseek.ChatCompletionResponse{
+		responses: []llm.Response{
 			buildAuditResponsePayload(t, true, ""),
 			buildInterpretationResponse(t),
 		},
@@ -158,7 +158,7 @@ func TestInterpret_AuditPass(t *testing.T) {
 
 func TestInterpret_AuditRejected(t *testing.T) {
 	stub := &handlerModelStub{
-		responses: []deepseek.ChatCompletionResponse{
+		responses: []llm.Response{
 			buildAuditResponsePayload(t, false, "文本命中敏感词"),
 		},
 	}
@@ -213,11 +213,11 @@ func TestInterpret_AuditRejected(t *testing.T) {
 
 func TestGenerate_AuditSuccess(t *testing.T) {
 	stub := &handlerModelStub{
-		responses: []deepseek.ChatCompletionResponse{
+		responses: []llm.Response{
 			{
 				Model: "deepseek-chat",
-				Choices: []deepseek.ChatCompletionChoice{
-					{Message: deepseek.ChatMessage{Role: "assistant", Content: "这是合规 Prompt"}},
+				Choices: []llm.Choice{
+					{Message: llm.Message{Role: "assistant", Content: "这是合规 Prompt"}},
 				},
 			},
 			buildAuditResponsePayload(t, true, ""),
@@ -265,11 +265,11 @@ func TestGenerate_AuditSuccess(t *testing.T) {
 
 func TestGenerate_AuditRejected(t *testing.T) {
 	stub := &handlerModelStub{
-		responses: []deepseek.ChatCompletionResponse{
+		responses: []llm.Response{
 			{
 				Model: "deepseek-chat",
-				Choices: []deepseek.ChatCompletionChoice{
-					{Message: deepseek.ChatMessage{Role: "assistant", Content: "违规 Prompt"}},
+				Choices: []llm.Choice{
+					{Message: llm.Message{Role: "assistant", Content: "违规 Prompt"}},
 				},
 			},
 			buildAuditResponsePayload(t, false, "涉及违规内容"),
@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
+	response "electron-go-app/backend/internal/infra/common"
+	"electron-go-app/backend/internal/infra/model/deepseek"
 	promptsvc "electron-go-app/backend/internal/service/prompt"
 )
 
@@ -29,3 +34,37 @@ func TestExtractContentRejectReason_Empty(t *testing.T) {
 		t.Fatalf("expected empty string, got %q", got)
 	}
 }
+
+func TestClassifyModelError_RateLimitedWithRetryAfter(t *testing.T) {
+	apiErr := &deepseek.APIError{StatusCode: http.StatusTooManyRequests, Message: "rate limit reached", RetryAfter: 1500 * time.Millisecond}
+	err := fmt.Errorf("%w: %w", promptsvc.ErrModelInvocationFailed, apiErr)
+	status, code, _, details, ok := classifyModelError(err)
+	if !ok || status != http.StatusTooManyRequests || code != response.ErrModelRateLimited {
+		t.Fatalf("unexpected classification: ok=%v status=%d code=%s", ok, status, code)
+	}
+	if details["kind"] != "rate_limited" || details["retry_after_seconds"] != 2 {
+		t.Fatalf("unexpected details: %+v", details)
+	}
+}
+
+func TestClassifyModelError_Kinds(t *testing.T) {
+	cases := []struct {
+		status int
+		want   int
+		code   response.ErrorCode
+	}{
+		{http.StatusUnauthorized, http.StatusBadGateway, response.ErrModelAuthFailed},
+		{http.StatusPaymentRequired, http.StatusPaymentRequired, response.ErrModelQuotaExhausted},
+		{http.StatusServiceUnavailable, http.StatusServiceUnavailable, response.ErrModelUnavailable},
+	}
+	for _, tc := range cases {
+		err := fmt.Errorf("%w: %w", promptsvc.ErrModelInvocationFailed, &deepseek.APIError{StatusCode: tc.status})
+		status, code, _, _, ok := classifyModelError(err)
+		if !ok || status != tc.want || code != tc.code {
+			t.Fatalf("status %d: got ok=%v status=%d code=%s", tc.status, ok, status, code)
+		}
+	}
+	if _, _, _, _, ok := classifyModelError(errors.New("boom")); ok {
+		t.Fatalf("expected unrelated error to be ignored")
+	}
+}
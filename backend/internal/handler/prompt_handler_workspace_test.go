@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+	"electron-go-app/backend/internal/repository"
+	promptsvc "electron-go-app/backend/internal/service/prompt"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newWorkspaceTestRouter 构造挂载关键词工作区接口的路由，工作区使用本地 SQLite 实现。
+func newWorkspaceTestRouter(t *testing.T) (*gin.Engine, *repository.PromptWorkspaceRepository) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&promptdomain.WorkspaceRecord{}, &promptdomain.WorkspaceKeywordRecord{}, &promptdomain.WorkspaceHistoryRecord{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+
+	workspace := repository.NewPromptWorkspaceRepository(db, time.Hour)
+	service, err := promptsvc.NewServiceWithConfig(nil, nil, &handlerModelStub{}, workspace, nil, nil, nil, nil, promptsvc.Config{})
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+
+	handler := NewPromptHandler(service, nil, PromptRateLimit{})
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		c.Next()
+	})
+	router.POST("/prompts/keywords/remove", handler.RemoveKeyword)
+	router.POST("/prompts/keywords/sync", handler.SyncKeywords)
+	return router, workspace
+}
+
+func postWorkspaceJSON(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestWorkspaceKeywords_WorkbenchPayloadWithoutVersion 验证工作台不携带 workspace_version 的删除与同步请求照常写入，显式携带过期版本时仍返回 409。
+func TestWorkspaceKeywords_WorkbenchPayloadWithoutVersion(t *testing.T) {
+	router, workspace := newWorkspaceTestRouter(t)
+	ctx := context.Background()
+	token, err := workspace.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{
+		Topic:   "工作台",
+		Version: 1,
+		Positive: []promptdomain.WorkspaceKeyword{
+			{Word: "React", Polarity: promptdomain.KeywordPolarityPositive, Score: 2, Weight: 5},
+			{Word: "Vue", Polarity: promptdomain.KeywordPolarityPositive, Score: 1, Weight: 5},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+
+	w := postWorkspaceJSON(router, "/prompts/keywords/remove", `{"word":"Vue","polarity":"positive","workspace_token":"`+token+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected remove without version to succeed, got %d body=%s", w.Code, w.Body.String())
+	}
+
+	w = postWorkspaceJSON(router, "/prompts/keywords/sync", `{"workspace_token":"`+token+`","positive_keywords":[{"word":"React","polarity":"positive","source":"local","weight":3}],"negative_keywords":[]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected sync without version to succeed, got %d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			WorkspaceVersion int64 `json:"workspace_version"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.WorkspaceVersion != 3 {
+		t.Fatalf("expected workspace version 3 after two writes, got %d", resp.Data.WorkspaceVersion)
+	}
+
+	w = postWorkspaceJSON(router, "/prompts/keywords/remove", `{"word":"React","polarity":"positive","workspace_token":"`+token+`","workspace_version":1}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected stale explicit version to conflict, got %d body=%s", w.Code, w.Body.String())
+	}
+	snapshot, err := workspace.Snapshot(ctx, 1, token)
+	if err != nil {
+		t.Fatalf("load snapshot: %v", err)
+	}
+	if len(snapshot.Positive) != 1 || snapshot.Positive[0].Word != "React" || snapshot.Positive[0].Weight != 3 {
+		t.Fatalf("unexpected workspace keywords: %+v", snapshot.Positive)
+	}
+}
@@ -293,6 +293,7 @@ func (h *PublicPromptHandler) Get(c *gin.Context) {
 		"positive_keywords": entity.PositiveKeywords,
 		"negative_keywords": entity.NegativeKeywords,
 		"tags":              entity.Tags,
+		"variables":         entity.Variables,
 		"model":             entity.Model,
 		"language":          entity.Language,
 		"status":            entity.Status,
@@ -320,6 +321,7 @@ type SubmitRequest struct {
 	PositiveKeywords string `json:"positive_keywords" binding:"required"`
 	NegativeKeywords string `json:"negative_keywords" binding:"required"`
 	Tags             string `json:"tags" binding:"required"`
+	Variables        string `json:"variables"`
 	Model            string `json:"model" binding:"required"`
 	Language         string `json:"language"`
 }
@@ -358,6 +360,7 @@ func (h *PublicPromptHandler) Submit(c *gin.Context) {
 		PositiveKeywords: req.PositiveKeywords,
 		NegativeKeywords: req.NegativeKeywords,
 		Tags:             req.Tags,
+		Variables:        req.Variables,
 		Model:            req.Model,
 		Language:         req.Language,
 	})
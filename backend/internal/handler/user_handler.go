@@ -7,6 +7,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -65,16 +66,17 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 
 // UpdateMeRequest 描述更新当前登录用户资料与设置的请求体。
 type UpdateMeRequest struct {
-	Username         *string `json:"username" binding:"omitempty,min=2,max=64"`
-	Email            *string `json:"email" binding:"omitempty,email"`
-	AvatarURL        *string `json:"avatar_url"`
-	PreferredModel   string  `json:"preferred_model" binding:"omitempty,min=1"`
-	EnableAnimations *bool   `json:"enable_animations"`
-	ProfileHeadline  *string `json:"profile_headline"`
-	ProfileBio       *string `json:"profile_bio"`
-	ProfileLocation  *string `json:"profile_location"`
-	ProfileWebsite   *string `json:"profile_website"`
-	ProfileBannerURL *string `json:"profile_banner_url"`
+	Username         *string             `json:"username" binding:"omitempty,min=2,max=64"`
+	Email            *string             `json:"email" binding:"omitempty,email"`
+	AvatarURL        *string             `json:"avatar_url"`
+	PreferredModel   string              `json:"preferred_model" binding:"omitempty,min=1"`
+	EnableAnimations *bool               `json:"enable_animations"`
+	FallbackChains   map[string][]string `json:"fallback_chains"`
+	ProfileHeadline  *string             `json:"profile_headline"`
+	ProfileBio       *string             `json:"profile_bio"`
+	ProfileLocation  *string             `json:"profile_location"`
+	ProfileWebsite   *string             `json:"profile_website"`
+	ProfileBannerURL *string             `json:"profile_banner_url"`
 }
 
 // UpdateMe 更新当前用户的资料、偏好模型及同步开关。
@@ -205,8 +207,12 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 	if req.EnableAnimations != nil {
 		settings.EnableAnimations = *req.EnableAnimations
 	}
+	if req.FallbackChains != nil {
+		// 传入空对象表示清空全部回退链。
+		settings.FallbackChains = req.FallbackChains
+	}
 
-	if req.PreferredModel != "" || req.EnableAnimations != nil {
+	if req.PreferredModel != "" || req.EnableAnimations != nil || req.FallbackChains != nil {
 		updatedSettings, updateErr := h.service.UpdateSettings(c.Request.Context(), userID, settings)
 		if updateErr != nil {
 			status := http.StatusInternalServerError
@@ -228,6 +234,15 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 				details = gin.H{"field": "preferred_model"}
 				log.Warnw("preferred model disabled", "preferred_model", req.PreferredModel)
 			default:
+				if errors.Is(updateErr, usersvc.ErrFallbackChainInvalid) ||
+					errors.Is(updateErr, usersvc.ErrFallbackModelNotFound) ||
+					errors.Is(updateErr, usersvc.ErrFallbackModelDisabled) {
+					status = http.StatusBadRequest
+					code = response.ErrBadRequest
+					details = gin.H{"field": "fallback_chains"}
+					log.Warnw("fallback chain rejected", "error", updateErr)
+					break
+				}
 				log.Errorw("update settings failed", "error", updateErr)
 			}
 			response.Fail(c, status, code, updateErr.Error(), details)
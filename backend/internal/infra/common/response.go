@@ -37,6 +37,13 @@ const (
 	ErrVerificationTokenInvalid ErrorCode = "VERIFICATION_TOKEN_INVALID"
 	ErrContentRejected          ErrorCode = "CONTENT_REJECTED"
 	ErrInvalidCredentials       ErrorCode = "INVALID_CREDENTIALS"
+	ErrModelRateLimited         ErrorCode = "MODEL_RATE_LIMITED"
+	ErrModelAuthFailed          ErrorCode = "MODEL_AUTH_FAILED"
+	ErrModelQuotaExhausted      ErrorCode = "MODEL_QUOTA_EXHAUSTED"
+	ErrModelContextTooLong      ErrorCode = "MODEL_CONTEXT_TOO_LONG"
+	ErrModelContentFiltered     ErrorCode = "MODEL_CONTENT_FILTERED"
+	ErrModelUnavailable         ErrorCode = "MODEL_UNAVAILABLE"
+	ErrModelBudgetExceeded      ErrorCode = "MODEL_BUDGET_EXCEEDED"
 )
 
 // Error 描述错误响应的统一结构。
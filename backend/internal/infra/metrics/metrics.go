@@ -5,7 +5,7 @@ import (
 	"sync"
 	"time"
 
-	modeldomain "electron-go-app/backend/internal/infra/model/deepseek"
+	"electron-go-app/backend/internal/domain/llm"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
@@ -17,6 +17,8 @@ var (
 	promptGenerateDuration *prometheus.HistogramVec
 	promptGenerateTokens   *prometheus.CounterVec
 	promptSaveRequests     *prometheus.CounterVec
+	modelInvocationErrors  *prometheus.CounterVec
+	modelResponseCache     *prometheus.CounterVec
 	defaultDurationBuckets = prometheus.DefBuckets
 )
 
@@ -72,13 +74,35 @@ func MustRegister() {
 				[]string{"result"},
 			),
 		)
+		modelInvocationErrors = registerCounterVec(
+			prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: namespaceMetrics,
+					Subsystem: "model",
+					Name:      "invocation_errors_total",
+					Help:      "模型调用失败次数，按操作与错误类型（限流、鉴权、配额、上下文、内容拦截、瞬时故障）统计。",
+				},
+				[]string{"operation", "kind"},
+			),
+		)
+		modelResponseCache = registerCounterVec(
+			prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: namespaceMetrics,
+					Subsystem: "model",
+					Name:      "response_cache_total",
+					Help:      "模型响应缓存的查询次数，按操作与命中结果（hit/miss）统计。",
+				},
+				[]string{"operation", "result"},
+			),
+		)
 
 		registerRuntimeCollectors()
 	})
 }
 
 // ObservePromptGenerate 记录生成接口的调用结果、耗时与 token 消耗。
-func ObservePromptGenerate(status, model string, duration time.Duration, usage *modeldomain.ChatCompletionUsage) {
+func ObservePromptGenerate(status, model string, duration time.Duration, usage *llm.Usage) {
 	if promptGenerateRequests == nil || promptGenerateDuration == nil {
 		return
 	}
@@ -100,6 +124,28 @@ func ObservePromptGenerate(status, model string, duration time.Duration, usage *
 	if usage.TotalTokens > 0 {
 		promptGenerateTokens.WithLabelValues("total").Add(float64(usage.TotalTokens))
 	}
+	if usage.CachedTokens > 0 {
+		promptGenerateTokens.WithLabelValues("cached").Add(float64(usage.CachedTokens))
+	}
+	if usage.ReasoningTokens > 0 {
+		promptGenerateTokens.WithLabelValues("reasoning").Add(float64(usage.ReasoningTokens))
+	}
+}
+
+// RecordModelError 记录一次模型调用失败，kind 取自 llm.ErrorKind。
+func RecordModelError(operation string, kind llm.ErrorKind) {
+	if modelInvocationErrors == nil {
+		return
+	}
+	modelInvocationErrors.WithLabelValues(normalizeLabel(operation, "unknown"), normalizeLabel(string(kind), "unknown")).Inc()
+}
+
+// RecordResponseCache 记录一次模型响应缓存查询，result 为 hit 或 miss。
+func RecordResponseCache(operation, result string) {
+	if modelResponseCache == nil {
+		return
+	}
+	modelResponseCache.WithLabelValues(normalizeLabel(operation, "unknown"), normalizeLabel(result, "unknown")).Inc()
 }
 
 // RecordPromptSave 记录保存或发布 Prompt 的结果分布。
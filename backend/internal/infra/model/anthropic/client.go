@@ -0,0 +1,349 @@
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+const (
+	// defaultBaseURL 为 Anthropic Messages API 的默认入口地址。
+	defaultBaseURL = "https://api.anthropic.com/v1"
+	// defaultVersion 为请求头 anthropic-version 的默认取值。
+	defaultVersion = "2023-06-01"
+	// defaultTimeout 控制非流式请求的超时时间。
+	defaultTimeout = 60 * time.Second
+	// maxStreamLineSize 限制单行 SSE 数据的最大字节数。
+	maxStreamLineSize = 1 << 20
+)
+
+// Client 封装与 Anthropic Messages API 的 HTTP 交互。
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	headers    map[string]string
+	retry      llm.RetryPolicy
+}
+
+// Option 用于自定义 Client 行为。
+type Option func(*Client)
+
+// WithBaseURL 设置自定义基础地址，便于接入代理或兼容网关。
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		trimmed := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+		if trimmed == "" {
+			return
+		}
+		c.baseURL = trimmed
+	}
+}
+
+// WithHTTPClient 允许传入调用方自定义的 http.Client。
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithHeaders 追加自定义请求头，如 anthropic-beta。
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		for key, value := range headers {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			c.headers[key] = value
+		}
+	}
+}
+
+// WithRetryPolicy 设置限流、过载与瞬时故障时的重试策略，MaxRetries 为 0 表示不重试。
+func WithRetryPolicy(policy llm.RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// NewClient 构造 Anthropic 客户端，默认携带 anthropic-version 请求头并使用 llm.DefaultRetryPolicy。
+func NewClient(apiKey string, opts ...Option) *Client {
+	client := &Client{
+		apiKey:     strings.TrimSpace(apiKey),
+		baseURL:    defaultBaseURL,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		headers:    map[string]string{"anthropic-version": defaultVersion},
+		retry:      llm.DefaultRetryPolicy(),
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	if client.httpClient == nil {
+		client.httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	return client
+}
+
+// APIError 封装 Anthropic 返回的错误响应。
+type APIError struct {
+	StatusCode int             `json:"-"`
+	Type       string          `json:"type,omitempty"`
+	Message    string          `json:"message"`
+	Raw        json.RawMessage `json:"raw,omitempty"`
+	RetryAfter time.Duration   `json:"-"`
+}
+
+var _ llm.KindedError = (*APIError)(nil)
+
+// Kind 优先按 Anthropic 的错误类型归类（流式 error 事件不带状态码），其余交给状态码与描述判断。
+func (e *APIError) Kind() llm.ErrorKind {
+	if e == nil {
+		return ""
+	}
+	switch e.Type {
+	case "rate_limit_error":
+		return llm.ErrorKindRateLimited
+	case "overloaded_error", "api_error":
+		return llm.ErrorKindTransient
+	case "authentication_error", "permission_error":
+		return llm.ErrorKindAuthFailed
+	case "request_too_large":
+		return llm.ErrorKindContextTooLong
+	}
+	return llm.ClassifyStatus(e.StatusCode, e.Type, e.Message)
+}
+
+// RetryAfterHint 返回服务端通过 Retry-After 建议的等待时间。
+func (e *APIError) RetryAfterHint() time.Duration {
+	if e == nil {
+		return 0
+	}
+	return e.RetryAfter
+}
+
+// Error 实现 error 接口。
+func (e *APIError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Type != "" {
+		return fmt.Sprintf("%s [%s]", e.Message, e.Type)
+	}
+	return e.Message
+}
+
+// CreateMessage 调用 /messages 接口并返回完整结果。
+func (c *Client) CreateMessage(ctx context.Context, req MessagesRequest) (MessagesResponse, error) {
+	req.Stream = false
+	resp, err := c.do(ctx, req, c.httpClient)
+	if err != nil {
+		return MessagesResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return MessagesResponse{}, fmt.Errorf("read response: %w", err)
+	}
+	var message MessagesResponse
+	if err := json.Unmarshal(rawBody, &message); err != nil {
+		return MessagesResponse{}, fmt.Errorf("decode response: %w", err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(rawBody, &raw); err == nil {
+		message.Raw = raw
+	}
+	return message, nil
+}
+
+// CreateMessageStream 以流式方式调用 /messages，解析 SSE 事件并回调文本增量，
+// 结束后返回拼装好的完整消息（含 message_start 与 message_delta 中的 usage）。
+func (c *Client) CreateMessageStream(ctx context.Context, req MessagesRequest, onDelta StreamHandler) (MessagesResponse, error) {
+	if c == nil {
+		return MessagesResponse{}, fmt.Errorf("anthropic client is nil")
+	}
+	req.Stream = true
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	resp, err := c.do(ctx, req, &httpClient)
+	if err != nil {
+		return MessagesResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var (
+		assembled MessagesResponse
+		blocks    []ContentBlock
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+		var event streamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return MessagesResponse{}, fmt.Errorf("decode stream event: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				assembled = *event.Message
+			}
+		case "content_block_start":
+			for len(blocks) <= event.Index {
+				blocks = append(blocks, ContentBlock{})
+			}
+			if event.ContentBlock != nil {
+				blocks[event.Index].Type = event.ContentBlock.Type
+			}
+		case "content_block_delta":
+			for len(blocks) <= event.Index {
+				blocks = append(blocks, ContentBlock{Type: "text"})
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				blocks[event.Index].Text += event.Delta.Text
+				if onDelta != nil && event.Delta.Text != "" {
+					if err := onDelta(event.Delta.Text); err != nil {
+						return MessagesResponse{}, err
+					}
+				}
+			case "thinking_delta":
+				blocks[event.Index].Thinking += event.Delta.Thinking
+			case "signature_delta":
+				blocks[event.Index].Signature += event.Delta.Signature
+			}
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				assembled.StopReason = event.Delta.StopReason
+				assembled.StopSequence = event.Delta.StopSequence
+			}
+			if event.Usage != nil {
+				// message_delta 中的 output_tokens 为累计值，input 侧以 message_start 为准。
+				assembled.Usage.OutputTokens = event.Usage.OutputTokens
+			}
+		case "error":
+			apiErr := &APIError{StatusCode: resp.StatusCode, Raw: json.RawMessage(data)}
+			if event.Error != nil {
+				apiErr.Type = event.Error.Type
+				apiErr.Message = event.Error.Message
+			}
+			return MessagesResponse{}, apiErr
+		case "message_stop":
+			assembled.Content = blocks
+			return assembled, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return MessagesResponse{}, fmt.Errorf("read stream: %w", err)
+	}
+	assembled.Content = blocks
+	return assembled, nil
+}
+
+// do 校验参数、序列化请求后按重试策略发送，状态码异常时解析为 *APIError。
+func (c *Client) do(ctx context.Context, req MessagesRequest, httpClient *http.Client) (*http.Response, error) {
+	if c == nil {
+		return nil, fmt.Errorf("anthropic client is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if strings.TrimSpace(req.Model) == "" {
+		return nil, fmt.Errorf("model 字段不能为空")
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("messages 至少需要一条消息")
+	}
+	if req.MaxTokens <= 0 {
+		return nil, fmt.Errorf("max_tokens 必须大于 0")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	// 建立连接阶段尚未读取任何事件，限流与瞬时故障可以按重试策略安全重发。
+	var resp *http.Response
+	err = llm.Retry(ctx, c.retry, func() error {
+		var attemptErr error
+		resp, attemptErr = c.send(ctx, body, req.Stream, httpClient)
+		return attemptErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// send 发起单次 HTTP 请求，状态码异常时解析为 *APIError。
+func (c *Client) send(ctx context.Context, body []byte, stream bool, httpClient *http.Client) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	for key, value := range c.headers {
+		httpReq.Header.Set(key, value)
+	}
+	httpReq.Header.Set("x-api-key", c.apiKey)
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		rawBody, readErr := io.ReadAll(resp.Body)
+		if readErr != nil {
+			return nil, fmt.Errorf("read response: %w", readErr)
+		}
+		apiErr := parseAPIError(resp.StatusCode, rawBody)
+		apiErr.RetryAfter = llm.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// parseAPIError 将 {"type":"error","error":{...}} 包裹解析为 *APIError。
+func parseAPIError(status int, payload []byte) *APIError {
+	var env struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(payload, &env); err != nil || env.Error.Message == "" {
+		return &APIError{
+			StatusCode: status,
+			Message:    fmt.Sprintf("anthropic api error: status %d, body: %s", status, string(payload)),
+			Raw:        payload,
+		}
+	}
+	return &APIError{
+		StatusCode: status,
+		Type:       env.Error.Type,
+		Message:    env.Error.Message,
+		Raw:        payload,
+	}
+}
@@ -0,0 +1,84 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+// listModelsPageSize 为 GET /models 单页条数，Anthropic 允许的最大值为 1000。
+const listModelsPageSize = 1000
+
+// ListModels 调用 GET /models 并按 after_id 翻页，返回账号可用的全部模型。
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if c == nil {
+		return nil, fmt.Errorf("anthropic client is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var models []ModelInfo
+	afterID := ""
+	for {
+		query := url.Values{"limit": {fmt.Sprint(listModelsPageSize)}}
+		if afterID != "" {
+			query.Set("after_id", afterID)
+		}
+		page, err := c.listModelsPage(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, page.Data...)
+		if !page.HasMore || page.LastID == "" {
+			return models, nil
+		}
+		afterID = page.LastID
+	}
+}
+
+// modelsPage 对应 GET /models 的分页响应。
+type modelsPage struct {
+	Data    []ModelInfo `json:"data"`
+	HasMore bool        `json:"has_more"`
+	LastID  string      `json:"last_id"`
+}
+
+// listModelsPage 请求单页模型列表，状态码异常时解析为 *APIError。
+func (c *Client) listModelsPage(ctx context.Context, query url.Values) (modelsPage, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/models?"+query.Encode(), nil)
+	if err != nil {
+		return modelsPage{}, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	for key, value := range c.headers {
+		httpReq.Header.Set(key, value)
+	}
+	httpReq.Header.Set("x-api-key", c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return modelsPage{}, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return modelsPage{}, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := parseAPIError(resp.StatusCode, rawBody)
+		apiErr.RetryAfter = llm.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return modelsPage{}, apiErr
+	}
+	var page modelsPage
+	if err := json.Unmarshal(rawBody, &page); err != nil {
+		return modelsPage{}, fmt.Errorf("decode response: %w", err)
+	}
+	return page, nil
+}
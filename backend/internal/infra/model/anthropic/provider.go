@@ -0,0 +1,163 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+const (
+	// defaultMaxTokens Messages API 要求必须显式给出 max_tokens，未配置时使用该值。
+	defaultMaxTokens = 4096
+	// jsonOutputHint 在请求 JSON 输出时追加到 system，弥补 Messages API 缺少 response_format 的问题。
+	jsonOutputHint = "请仅输出一个合法的 JSON 对象，不要包含 Markdown 代码块或任何额外说明。"
+)
+
+var _ llm.Provider = (*Client)(nil)
+
+// Complete 实现 llm.Provider：将通用请求翻译为 Messages API 请求后发起一次性调用。
+func (c *Client) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	resp, err := c.CreateMessage(ctx, newMessagesRequest(req))
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return toLLMResponse(resp), nil
+}
+
+// CompleteStream 实现 llm.Provider 的流式调用。
+func (c *Client) CompleteStream(ctx context.Context, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	resp, err := c.CreateMessageStream(ctx, newMessagesRequest(req), StreamHandler(onDelta))
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return toLLMResponse(resp), nil
+}
+
+// newMessagesRequest 将通用请求映射为 Messages API 请求：system 消息提升为顶层字段，
+// 其余消息仅保留 user/assistant 角色并合并相邻的同角色消息以满足交替要求。
+func newMessagesRequest(req llm.Request) MessagesRequest {
+	var systemParts []string
+	messages := make([]Message, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		content := msg.Content
+		role := strings.ToLower(strings.TrimSpace(msg.Role))
+		switch role {
+		case "system", "developer":
+			if strings.TrimSpace(content) != "" {
+				systemParts = append(systemParts, content)
+			}
+			continue
+		case "assistant":
+		default:
+			// tool 等其它角色折叠为 user，保证请求可被 Messages API 接受。
+			role = "user"
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content += "\n\n" + content
+			continue
+		}
+		messages = append(messages, Message{Role: role, Content: content})
+	}
+
+	if typ, _ := req.ResponseFormat["type"].(string); typ == "json_object" || typ == "json_schema" {
+		systemParts = append(systemParts, jsonOutputHint)
+	}
+
+	converted := MessagesRequest{
+		Model:         req.Model,
+		System:        strings.Join(systemParts, "\n\n"),
+		Messages:      messages,
+		MaxTokens:     req.MaxTokens,
+		StopSequences: req.Stop,
+		ExtraFields:   req.ExtraFields,
+	}
+	if converted.MaxTokens <= 0 {
+		converted.MaxTokens = defaultMaxTokens
+	}
+	if req.Temperature > 0 {
+		temperature := req.Temperature
+		if temperature > 1 {
+			// Anthropic 的 temperature 取值范围为 0~1，超出部分截断。
+			temperature = 1
+		}
+		converted.Temperature = &temperature
+	}
+	if req.TopP > 0 {
+		topP := req.TopP
+		converted.TopP = &topP
+	}
+	return converted
+}
+
+// toLLMResponse 拼接文本内容块，thinking 块写入 Reasoning，并换算 stop_reason 与 usage。
+func toLLMResponse(resp MessagesResponse) llm.Response {
+	var text, thinking, signature strings.Builder
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "thinking":
+			thinking.WriteString(block.Thinking)
+			signature.WriteString(block.Signature)
+		}
+	}
+
+	id := resp.ID
+	if id == "" {
+		id = fmt.Sprintf("anthropic-%d", time.Now().UnixNano())
+	}
+	choice := llm.Choice{
+		Index: 0,
+		Message: llm.Message{
+			Role:    "assistant",
+			Content: text.String(),
+		},
+		FinishReason: finishReason(resp.StopReason),
+	}
+	if thinking.Len() > 0 {
+		choice.Reasoning = &llm.Reasoning{
+			Content:   thinking.String(),
+			Signature: signature.String(),
+		}
+	}
+
+	converted := llm.Response{
+		ID:      id,
+		Created: time.Now().Unix(),
+		Model:   resp.Model,
+		Choices: []llm.Choice{choice},
+		Raw:     resp.Raw,
+	}
+	usage := resp.Usage
+	if usage.InputTokens > 0 || usage.OutputTokens > 0 || usage.CacheReadInputTokens > 0 || usage.CacheCreationInputTokens > 0 {
+		// Anthropic 的 input_tokens 不含缓存部分，这里合并后与 OpenAI 口径保持一致。
+		promptTokens := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+		converted.Usage = &llm.Usage{
+			PromptTokens:        promptTokens,
+			CompletionTokens:    usage.OutputTokens,
+			TotalTokens:         promptTokens + usage.OutputTokens,
+			CachedTokens:        usage.CacheReadInputTokens,
+			CacheCreationTokens: usage.CacheCreationInputTokens,
+		}
+	}
+	return converted
+}
+
+// finishReason 将 stop_reason 映射为通用的 finish_reason。
+func finishReason(reason string) string {
+	switch reason {
+	case "", "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return reason
+	}
+}
@@ -0,0 +1,103 @@
+package anthropic
+
+import "encoding/json"
+
+// Message 表示 Messages API 中的单条对话消息，Content 使用纯文本形式。
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// MessagesRequest 对应 Anthropic Messages API 的请求体，system 提示位于顶层字段。
+type MessagesRequest struct {
+	Model         string         `json:"model"`
+	System        string         `json:"system,omitempty"`
+	Messages      []Message      `json:"messages"`
+	MaxTokens     int            `json:"max_tokens"`
+	StopSequences []string       `json:"stop_sequences,omitempty"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	TopP          *float64       `json:"top_p,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	ExtraFields   map[string]any `json:"-"`
+}
+
+// MarshalJSON 将 ExtraFields 合并到请求体，便于透传 thinking、top_k 等扩展参数。
+func (r MessagesRequest) MarshalJSON() ([]byte, error) {
+	type alias MessagesRequest
+	payload := map[string]any{}
+
+	base, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(base, &payload); err != nil {
+		return nil, err
+	}
+	for k, v := range r.ExtraFields {
+		if _, exists := payload[k]; !exists {
+			payload[k] = v
+		}
+	}
+	return json.Marshal(payload)
+}
+
+// ContentBlock 描述响应中的内容块，常见类型为 text 与 thinking。
+type ContentBlock struct {
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Usage 统计 Messages API 的 token 消耗。
+type Usage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens,omitempty"`
+}
+
+// MessagesResponse 映射 Messages API 的返回结构。
+type MessagesResponse struct {
+	ID           string         `json:"id"`
+	Type         string         `json:"type"`
+	Role         string         `json:"role"`
+	Model        string         `json:"model"`
+	Content      []ContentBlock `json:"content"`
+	StopReason   string         `json:"stop_reason"`
+	StopSequence string         `json:"stop_sequence,omitempty"`
+	Usage        Usage          `json:"usage"`
+	Raw          map[string]any `json:"-"`
+}
+
+// streamEvent 对应 SSE 中 data 字段的通用结构，按 type 区分各类事件。
+type streamEvent struct {
+	Type         string            `json:"type"`
+	Index        int               `json:"index"`
+	Message      *MessagesResponse `json:"message,omitempty"`
+	ContentBlock *ContentBlock     `json:"content_block,omitempty"`
+	Delta        struct {
+		Type         string `json:"type"`
+		Text         string `json:"text,omitempty"`
+		Thinking     string `json:"thinking,omitempty"`
+		Signature    string `json:"signature,omitempty"`
+		StopReason   string `json:"stop_reason,omitempty"`
+		StopSequence string `json:"stop_sequence,omitempty"`
+	} `json:"delta"`
+	Usage *Usage `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// StreamHandler 接收流式生成过程中的增量文本，返回错误会中断后续读取。
+type StreamHandler func(delta string) error
+
+// ModelInfo 描述 GET /models 返回的模型条目。
+type ModelInfo struct {
+	ID          string `json:"id"`
+	Type        string `json:"type,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
 )
 
 const (
@@ -23,6 +25,8 @@ type Client struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	headers    map[string]string
+	retry      llm.RetryPolicy
 }
 
 // Option 用于自定义 Client 行为。
@@ -42,7 +46,33 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
-// NewClient 构造 DeepSeek 客户端，默认使用 30 秒超时。
+// WithHeaders 追加自定义请求头，常用于 OpenAI 兼容服务的组织/项目标识。
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		if len(headers) == 0 {
+			return
+		}
+		if c.headers == nil {
+			c.headers = make(map[string]string, len(headers))
+		}
+		for key, value := range headers {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			c.headers[key] = value
+		}
+	}
+}
+
+// WithRetryPolicy 设置限流与瞬时故障时的重试策略，MaxRetries 为 0 表示不重试。
+func WithRetryPolicy(policy llm.RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// NewClient 构造 DeepSeek 客户端，默认使用 30 秒超时与 llm.DefaultRetryPolicy。
 func NewClient(apiKey string, opts ...Option) *Client {
 	client := &Client{
 		apiKey:  strings.TrimSpace(apiKey),
@@ -50,6 +80,7 @@ func NewClient(apiKey string, opts ...Option) *Client {
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
+		retry: llm.DefaultRetryPolicy(),
 	}
 	for _, opt := range opts {
 		opt(client)
@@ -75,6 +106,25 @@ type APIError struct {
 	Code       string          `json:"code,omitempty"`
 	Message    string          `json:"message"`
 	Raw        json.RawMessage `json:"raw,omitempty"`
+	RetryAfter time.Duration   `json:"-"`
+}
+
+var _ llm.KindedError = (*APIError)(nil)
+
+// Kind 根据状态码与错误码归类错误。
+func (e *APIError) Kind() llm.ErrorKind {
+	if e == nil {
+		return ""
+	}
+	return llm.ClassifyStatus(e.StatusCode, e.Type+" "+e.Code, e.Message)
+}
+
+// RetryAfterHint 返回服务端通过 Retry-After 建议的等待时间。
+func (e *APIError) RetryAfterHint() time.Duration {
+	if e == nil {
+		return 0
+	}
+	return e.RetryAfter
 }
 
 // Error 实现 error 接口。
@@ -93,7 +143,7 @@ func (e *APIError) Error() string {
 }
 
 // ChatCompletion 调用 DeepSeek Chat Completion 接口并返回解析结果。
-// 核心步骤：校验参数 → 序列化 JSON → 补充认证头发起请求 → 判断状态码并解析成功或错误响应。
+// 核心步骤：校验参数 → 序列化 JSON → 按重试策略发起请求 → 判断状态码并解析成功或错误响应。
 func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest) (ChatCompletionResponse, error) {
 	// 1. 兜底上下文、校验必要字段，确保不会向 DeepSeek 发送无效请求。
 	if c == nil {
@@ -115,13 +165,30 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 		return ChatCompletionResponse{}, fmt.Errorf("marshal request: %w", err)
 	}
 
-	// 3. 拼装 HTTP 请求，补充认证信息与通用头部。
+	// 3. 限流与瞬时故障按重试策略退避后重发，其余错误直接返回。
+	var completion ChatCompletionResponse
+	err = llm.Retry(ctx, c.retry, func() error {
+		var attemptErr error
+		completion, attemptErr = c.doChatCompletion(ctx, body)
+		return attemptErr
+	})
+	if err != nil {
+		return ChatCompletionResponse{}, err
+	}
+	return completion, nil
+}
+
+// doChatCompletion 发起单次 HTTP 请求：补充认证头、判断状态码并解析成功或错误响应。
+func (c *Client) doChatCompletion(ctx context.Context, body []byte) (ChatCompletionResponse, error) {
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint("/chat/completions"), bytes.NewReader(body))
 	if err != nil {
 		return ChatCompletionResponse{}, fmt.Errorf("build request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "application/json")
+	for key, value := range c.headers {
+		httpReq.Header.Set(key, value)
+	}
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 
 	resp, err := c.httpClient.Do(httpReq)
@@ -130,14 +197,14 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 	}
 	defer resp.Body.Close()
 
-	// 4. 统一读取响应体，便于成功与错误场景共享原始 payload。
+	// 统一读取响应体，便于成功与错误场景共享原始 payload。
 	rawBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return ChatCompletionResponse{}, fmt.Errorf("read response: %w", err)
 	}
 
 	if resp.StatusCode >= http.StatusBadRequest {
-		return ChatCompletionResponse{}, c.parseAPIError(resp.StatusCode, rawBody)
+		return ChatCompletionResponse{}, c.parseAPIError(resp.StatusCode, resp.Header, rawBody)
 	}
 
 	var completion ChatCompletionResponse
@@ -152,8 +219,9 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 	return completion, nil
 }
 
-// parseAPIError 将 DeepSeek 的错误包裹解析为 *APIError，方便上层做类型化处理。
-func (c *Client) parseAPIError(status int, payload []byte) error {
+// parseAPIError 将 DeepSeek 的错误包裹解析为 *APIError，并记录 Retry-After，方便上层做类型化处理。
+func (c *Client) parseAPIError(status int, header http.Header, payload []byte) error {
+	retryAfter := llm.ParseRetryAfter(header.Get("Retry-After"), time.Now())
 	type errorEnvelope struct {
 		Error struct {
 			Message string          `json:"message"`
@@ -167,6 +235,7 @@ func (c *Client) parseAPIError(status int, payload []byte) error {
 		return &APIError{
 			StatusCode: status,
 			Message:    fmt.Sprintf("deepseek api error: status %d", status),
+			RetryAfter: retryAfter,
 		}
 	}
 	var env errorEnvelope
@@ -175,6 +244,7 @@ func (c *Client) parseAPIError(status int, payload []byte) error {
 			StatusCode: status,
 			Message:    fmt.Sprintf("deepseek api error: status %d, body: %s", status, string(payload)),
 			Raw:        payload,
+			RetryAfter: retryAfter,
 		}
 	}
 	apiErr := &APIError{
@@ -183,6 +253,7 @@ func (c *Client) parseAPIError(status int, payload []byte) error {
 		Type:       env.Error.Type,
 		Code:       env.Error.Code,
 		Raw:        payload,
+		RetryAfter: retryAfter,
 	}
 	return apiErr
 }
@@ -0,0 +1,49 @@
+package deepseek
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ListModels 调用 OpenAI 风格的 GET /models，返回服务端可用的模型列表。
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if c == nil {
+		return nil, fmt.Errorf("deepseek client is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint("/models"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	for key, value := range c.headers {
+		httpReq.Header.Set(key, value)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, c.parseAPIError(resp.StatusCode, resp.Header, rawBody)
+	}
+	var payload struct {
+		Data []ModelInfo `json:"data"`
+	}
+	if err := json.Unmarshal(rawBody, &payload); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return payload.Data, nil
+}
@@ -0,0 +1,183 @@
+package deepseek
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+var _ llm.Provider = (*Client)(nil)
+
+// Complete 实现 llm.Provider：将通用请求翻译为 Chat Completion 协议后发起一次性调用。
+func (c *Client) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	resp, err := c.ChatCompletion(ctx, newChatCompletionRequest(req))
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return toLLMResponse(resp), nil
+}
+
+// CompleteStream 实现 llm.Provider 的流式调用。
+func (c *Client) CompleteStream(ctx context.Context, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	resp, err := c.ChatCompletionStream(ctx, newChatCompletionRequest(req), StreamHandler(onDelta))
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return toLLMResponse(resp), nil
+}
+
+// newChatCompletionRequest 将通用请求映射为 OpenAI 兼容协议的请求体。
+func newChatCompletionRequest(req llm.Request) ChatCompletionRequest {
+	converted := ChatCompletionRequest{
+		Model:            req.Model,
+		Messages:         make([]ChatMessage, 0, len(req.Messages)),
+		MaxTokens:        req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		ResponseFormat:   req.ResponseFormat,
+		ToolChoice:       req.ToolChoice,
+		ExtraFields:      req.ExtraFields,
+	}
+	for _, msg := range req.Messages {
+		converted.Messages = append(converted.Messages, ChatMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCalls:  fromLLMToolCalls(msg.ToolCalls),
+			ToolCallID: msg.ToolCallID,
+		})
+	}
+	if len(req.Stop) > 0 {
+		converted.Stop = req.Stop
+	}
+	if len(req.Tools) > 0 {
+		converted.Tools = req.Tools
+	}
+	return converted
+}
+
+// toLLMResponse 将 Chat Completion 响应折叠为通用结构，reasoning_content 写入 Reasoning。
+func toLLMResponse(resp ChatCompletionResponse) llm.Response {
+	converted := llm.Response{
+		ID:                resp.ID,
+		Created:           resp.Created,
+		Model:             resp.Model,
+		Choices:           make([]llm.Choice, 0, len(resp.Choices)),
+		Usage:             toLLMUsage(resp.Usage),
+		ServiceTier:       resp.ServiceTier,
+		SystemFingerprint: resp.SystemFingerprint,
+		Raw:               resp.Raw,
+	}
+	for _, choice := range resp.Choices {
+		item := llm.Choice{
+			Index: choice.Index,
+			Message: llm.Message{
+				Role:      choice.Message.Role,
+				Content:   choice.Message.Content,
+				ToolCalls: toLLMToolCalls(choice.Message.ToolCalls),
+			},
+			FinishReason: choice.FinishReason,
+		}
+		if choice.Message.ReasoningContent != "" {
+			item.Reasoning = &llm.Reasoning{Content: choice.Message.ReasoningContent}
+		}
+		converted.Choices = append(converted.Choices, item)
+	}
+	return converted
+}
+
+// fromLLMToolCalls 将通用工具调用转换为请求体中 assistant 消息的 tool_calls。
+func fromLLMToolCalls(calls []llm.ToolCall) []ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	converted := make([]ToolCall, 0, len(calls))
+	for _, call := range calls {
+		callType := call.Type
+		if callType == "" {
+			callType = "function"
+		}
+		converted = append(converted, ToolCall{
+			ID:   call.ID,
+			Type: callType,
+			Function: ToolCallFunction{
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			},
+		})
+	}
+	return converted
+}
+
+// toLLMToolCalls 将响应中的 tool_calls 转换为通用结构。
+func toLLMToolCalls(calls []ToolCall) []llm.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	converted := make([]llm.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		callType := call.Type
+		if callType == "" {
+			callType = "function"
+		}
+		converted = append(converted, llm.ToolCall{
+			ID:   call.ID,
+			Type: callType,
+			Function: llm.ToolCallFunction{
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			},
+		})
+	}
+	return converted
+}
+
+// toLLMUsage 统一缓存与推理 token 的口径：DeepSeek 使用 prompt_cache_hit_tokens，
+// OpenAI 使用 prompt_tokens_details.cached_tokens 与 completion_tokens_details.reasoning_tokens。
+func toLLMUsage(usage *ChatCompletionUsage) *llm.Usage {
+	if usage == nil {
+		return nil
+	}
+	converted := &llm.Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		CachedTokens:     usage.PromptCacheHitTokens,
+	}
+	if converted.CachedTokens == 0 {
+		converted.CachedTokens = detailInt(usage.PromptTokensDetails["cached_tokens"])
+	}
+	converted.ReasoningTokens = detailInt(usage.CompletionTokensDetails["reasoning_tokens"])
+	if converted.ReasoningTokens == 0 {
+		if value, ok := usage.CompletionTokensByType["reasoning_tokens"]; ok {
+			converted.ReasoningTokens, _ = value.Int64()
+		}
+	}
+	if converted.TotalTokens == 0 {
+		converted.TotalTokens = converted.PromptTokens + converted.CompletionTokens
+	}
+	return converted
+}
+
+// detailInt 将 JSON 解码出的明细数值统一转换为 int64。
+func detailInt(value any) int64 {
+	switch v := value.(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case json.Number:
+		i64, _ := v.Int64()
+		return i64
+	case string:
+		i64, _ := strconv.ParseInt(v, 10, 64)
+		return i64
+	default:
+		return 0
+	}
+}
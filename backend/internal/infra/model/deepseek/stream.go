@@ -0,0 +1,243 @@
+package deepseek
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+const (
+	// streamDataPrefix 为 SSE 数据行的固定前缀。
+	streamDataPrefix = "data:"
+	// streamDoneMarker 标记服务端推送结束。
+	streamDoneMarker = "[DONE]"
+	// maxStreamLineSize 限制单行 SSE 数据的最大字节数，避免异常响应撑爆内存。
+	maxStreamLineSize = 1 << 20
+)
+
+// ChatCompletionStream 以 stream 模式调用 Chat Completion 接口，逐段回调增量文本，
+// 结束后返回拼装好的完整响应（含最后一个片段携带的 usage），便于上层复用非流式的后处理逻辑。
+func (c *Client) ChatCompletionStream(ctx context.Context, req ChatCompletionRequest, onDelta StreamHandler) (ChatCompletionResponse, error) {
+	if c == nil {
+		return ChatCompletionResponse{}, fmt.Errorf("deepseek client is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if req.Model == "" {
+		return ChatCompletionResponse{}, fmt.Errorf("model 字段不能为空")
+	}
+	if len(req.Messages) == 0 {
+		return ChatCompletionResponse{}, fmt.Errorf("messages 至少需要一条消息")
+	}
+
+	// 1. 强制开启 stream，并要求服务端在末尾推送 usage 片段。
+	req.Stream = true
+	streamOptions := make(map[string]any, len(req.StreamOptions)+1)
+	for k, v := range req.StreamOptions {
+		streamOptions[k] = v
+	}
+	if _, exists := streamOptions["include_usage"]; !exists {
+		streamOptions["include_usage"] = true
+	}
+	req.StreamOptions = streamOptions
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return ChatCompletionResponse{}, fmt.Errorf("marshal request: %w", err)
+	}
+
+	// 建立连接阶段尚未推送任何增量，可以安全地按重试策略重发。
+	var resp *http.Response
+	err = llm.Retry(ctx, c.retry, func() error {
+		var attemptErr error
+		resp, attemptErr = c.openStream(ctx, body)
+		return attemptErr
+	})
+	if err != nil {
+		return ChatCompletionResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	// 2. 逐行解析 SSE，按 choice 下标累积内容。
+	assembled := ChatCompletionResponse{Object: "chat.completion"}
+	contents := map[int]*strings.Builder{}
+	reasoning := map[int]*strings.Builder{}
+	toolCalls := map[int]*toolCallAccumulator{}
+	choices := map[int]*ChatCompletionChoice{}
+	order := make([]int, 0, 1)
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, streamDataPrefix) {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, streamDataPrefix))
+		if data == "" {
+			continue
+		}
+		if data == streamDoneMarker {
+			break
+		}
+
+		var chunk ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return ChatCompletionResponse{}, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if assembled.ID == "" {
+			assembled.ID = chunk.ID
+			assembled.Created = chunk.Created
+		}
+		if chunk.Model != "" {
+			assembled.Model = chunk.Model
+		}
+		if chunk.SystemFingerprint != "" {
+			assembled.SystemFingerprint = chunk.SystemFingerprint
+		}
+		if chunk.ServiceTier != "" {
+			assembled.ServiceTier = chunk.ServiceTier
+		}
+		if chunk.Usage != nil {
+			assembled.Usage = chunk.Usage
+		}
+		for _, delta := range chunk.Choices {
+			choice, ok := choices[delta.Index]
+			if !ok {
+				choice = &ChatCompletionChoice{Index: delta.Index, Message: ChatMessage{Role: "assistant"}}
+				choices[delta.Index] = choice
+				contents[delta.Index] = &strings.Builder{}
+				reasoning[delta.Index] = &strings.Builder{}
+				toolCalls[delta.Index] = &toolCallAccumulator{}
+				order = append(order, delta.Index)
+			}
+			if delta.Delta.Role != "" {
+				choice.Message.Role = delta.Delta.Role
+			}
+			if delta.FinishReason != "" {
+				choice.FinishReason = delta.FinishReason
+			}
+			reasoning[delta.Index].WriteString(delta.Delta.ReasoningContent)
+			toolCalls[delta.Index].add(delta.Delta.ToolCalls)
+			if delta.Delta.Content == "" {
+				continue
+			}
+			contents[delta.Index].WriteString(delta.Delta.Content)
+			// 仅向调用方推送首个候选的增量，与非流式场景只取 choices[0] 的约定保持一致。
+			if onDelta != nil && delta.Index == order[0] {
+				if err := onDelta(delta.Delta.Content); err != nil {
+					return ChatCompletionResponse{}, err
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ChatCompletionResponse{}, fmt.Errorf("read stream: %w", err)
+	}
+
+	assembled.Choices = make([]ChatCompletionChoice, 0, len(order))
+	for _, index := range order {
+		choice := choices[index]
+		choice.Message.Content = contents[index].String()
+		choice.Message.ReasoningContent = reasoning[index].String()
+		choice.Message.ToolCalls = toolCalls[index].calls()
+		assembled.Choices = append(assembled.Choices, *choice)
+	}
+	return assembled, nil
+}
+
+// toolCallAccumulator 按 index 拼接流式返回的函数调用：ID 与函数名只在首个片段出现，参数分多段推送。
+type toolCallAccumulator struct {
+	items map[int]*ToolCall
+	order []int
+}
+
+// add 合并一批 tool_calls 增量，缺少 index 的片段视为独立调用。
+func (a *toolCallAccumulator) add(deltas []ToolCall) {
+	for _, delta := range deltas {
+		if a.items == nil {
+			a.items = map[int]*ToolCall{}
+		}
+		index := len(a.order)
+		if delta.Index != nil {
+			index = *delta.Index
+		}
+		call, ok := a.items[index]
+		if !ok {
+			call = &ToolCall{}
+			a.items[index] = call
+			a.order = append(a.order, index)
+		}
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		if delta.Function.Name != "" {
+			call.Function.Name = delta.Function.Name
+		}
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+// calls 按首次出现的顺序返回拼装完成的函数调用。
+func (a *toolCallAccumulator) calls() []ToolCall {
+	if len(a.order) == 0 {
+		return nil
+	}
+	out := make([]ToolCall, 0, len(a.order))
+	for _, index := range a.order {
+		out = append(out, *a.items[index])
+	}
+	return out
+}
+
+// openStream 发起单次流式请求，状态码异常时读取错误体并转换为 *APIError。
+func (c *Client) openStream(ctx context.Context, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint("/chat/completions"), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	for key, value := range c.headers {
+		httpReq.Header.Set(key, value)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.streamHTTPClient().Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		rawBody, readErr := io.ReadAll(resp.Body)
+		if readErr != nil {
+			return nil, fmt.Errorf("read response: %w", readErr)
+		}
+		return nil, c.parseAPIError(resp.StatusCode, resp.Header, rawBody)
+	}
+	return resp, nil
+}
+
+// streamHTTPClient 返回用于流式请求的 http.Client：流式生成耗时较长，交由 ctx 控制超时而非固定 Timeout。
+func (c *Client) streamHTTPClient() *http.Client {
+	if c.httpClient == nil {
+		return http.DefaultClient
+	}
+	if c.httpClient.Timeout == 0 {
+		return c.httpClient
+	}
+	clone := *c.httpClient
+	clone.Timeout = 0
+	return &clone
+}
@@ -4,8 +4,25 @@ import "encoding/json"
 
 // ChatMessage 表示与 DeepSeek 模型交互的单条对话消息。
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role             string     `json:"role"`
+	Content          string     `json:"content"`
+	ReasoningContent string     `json:"reasoning_content,omitempty"` // deepseek-reasoner 等推理模型返回的思考过程
+	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`        // assistant 发起的函数调用
+	ToolCallID       string     `json:"tool_call_id,omitempty"`      // tool 消息对应的调用 ID
+}
+
+// ToolCall 描述一次函数调用，流式片段通过 Index 标识同一调用的多个增量。
+type ToolCall struct {
+	Index    *int             `json:"index,omitempty"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction 记录被调用的函数名与 JSON 字符串形式的参数。
+type ToolCallFunction struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
 }
 
 // ChatCompletionRequest 对应 DeepSeek Chat Completion API 的请求体。
@@ -58,6 +75,7 @@ type ChatCompletionResponse struct {
 	Model             string                 `json:"model"`
 	Choices           []ChatCompletionChoice `json:"choices"`
 	Usage             *ChatCompletionUsage   `json:"usage,omitempty"`
+	ServiceTier       string                 `json:"service_tier,omitempty"`
 	SystemFingerprint string                 `json:"system_fingerprint,omitempty"`
 	Raw               map[string]any         `json:"-"`
 }
@@ -72,11 +90,45 @@ type ChatCompletionChoice struct {
 
 // ChatCompletionUsage 统计 token 消耗情况。
 type ChatCompletionUsage struct {
-	PromptTokens           int64                  `json:"prompt_tokens"`
-	CompletionTokens       int64                  `json:"completion_tokens"`
-	TotalTokens            int64                  `json:"total_tokens"`
-	PromptTokensDetails    map[string]any         `json:"prompt_tokens_details,omitempty"`
-	PromptCacheHitTokens   int64                  `json:"prompt_cache_hit_tokens,omitempty"`
-	PromptCacheMissTokens  int64                  `json:"prompt_cache_miss_tokens,omitempty"`
-	CompletionTokensByType map[string]json.Number `json:"completion_tokens_by_type,omitempty"`
+	PromptTokens            int64                  `json:"prompt_tokens"`
+	CompletionTokens        int64                  `json:"completion_tokens"`
+	TotalTokens             int64                  `json:"total_tokens"`
+	PromptTokensDetails     map[string]any         `json:"prompt_tokens_details,omitempty"`
+	PromptCacheHitTokens    int64                  `json:"prompt_cache_hit_tokens,omitempty"`
+	PromptCacheMissTokens   int64                  `json:"prompt_cache_miss_tokens,omitempty"`
+	CompletionTokensDetails map[string]any         `json:"completion_tokens_details,omitempty"`
+	CompletionTokensByType  map[string]json.Number `json:"completion_tokens_by_type,omitempty"`
+}
+
+// StreamHandler 接收流式生成过程中的增量文本，返回错误会中断后续读取。
+type StreamHandler func(delta string) error
+
+// ChatCompletionChunk 对应流式接口中单个 SSE data 片段。
+type ChatCompletionChunk struct {
+	ID                string                      `json:"id"`
+	Object            string                      `json:"object"`
+	Created           int64                       `json:"created"`
+	Model             string                      `json:"model"`
+	Choices           []ChatCompletionChunkChoice `json:"choices"`
+	Usage             *ChatCompletionUsage        `json:"usage,omitempty"`
+	ServiceTier       string                      `json:"service_tier,omitempty"`
+	SystemFingerprint string                      `json:"system_fingerprint,omitempty"`
+}
+
+// ChatCompletionChunkChoice 描述流式片段中的单个候选增量。
+type ChatCompletionChunkChoice struct {
+	Index        int         `json:"index"`
+	Delta        ChatMessage `json:"delta"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// ModelInfo 描述 OpenAI 风格 /models 返回的模型条目，上下文长度字段仅部分兼容服务（vLLM、OpenRouter 等）提供。
+type ModelInfo struct {
+	ID            string `json:"id"`
+	Object        string `json:"object,omitempty"`
+	Created       int64  `json:"created,omitempty"`
+	OwnedBy       string `json:"owned_by,omitempty"`
+	ContextLength int    `json:"context_length,omitempty"` // OpenRouter
+	ContextWindow int    `json:"context_window,omitempty"` // Groq 等
+	MaxModelLen   int    `json:"max_model_len,omitempty"`  // vLLM
 }
@@ -0,0 +1,276 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+const (
+	// defaultBaseURL 为本机 Ollama 服务的默认监听地址。
+	defaultBaseURL = "http://127.0.0.1:11434"
+	// defaultTimeout 控制非流式请求的超时时间，本地模型首次加载较慢，适当放宽。
+	defaultTimeout = 120 * time.Second
+	// maxStreamLineSize 限制单行 NDJSON 的最大字节数。
+	maxStreamLineSize = 1 << 20
+)
+
+// Client 封装与 Ollama HTTP 接口的交互。
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Option 用于自定义 Client 行为。
+type Option func(*Client)
+
+// WithBaseURL 设置 Ollama 服务地址，兼容误填的 /api 或 /v1 后缀。
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		trimmed := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+		trimmed = strings.TrimSuffix(trimmed, "/api")
+		trimmed = strings.TrimSuffix(trimmed, "/v1")
+		if trimmed == "" {
+			return
+		}
+		c.baseURL = trimmed
+	}
+}
+
+// WithHTTPClient 允许传入调用方自定义的 http.Client。
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// NewClient 构造 Ollama 客户端；apiKey 可为空，仅在经过鉴权代理访问时需要。
+func NewClient(apiKey string, opts ...Option) *Client {
+	client := &Client{
+		apiKey:     strings.TrimSpace(apiKey),
+		baseURL:    defaultBaseURL,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	if client.httpClient == nil {
+		client.httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	return client
+}
+
+// APIError 封装 Ollama 返回的错误响应。
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"error"`
+}
+
+// Error 实现 error 接口。
+func (e *APIError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("ollama api error: status %d, %s", e.StatusCode, e.Message)
+}
+
+var _ llm.KindedError = (*APIError)(nil)
+
+// Kind 根据状态码与错误描述归类错误，本地服务的 5xx 通常意味着模型加载失败或内存不足。
+func (e *APIError) Kind() llm.ErrorKind {
+	if e == nil {
+		return ""
+	}
+	return llm.ClassifyStatus(e.StatusCode, "", e.Message)
+}
+
+// Chat 调用 /api/chat（非流式）并返回完整结果。
+func (c *Client) Chat(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	req.Stream = false
+	resp, err := c.doChat(ctx, req, c.httpClient)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ChatResponse{}, fmt.Errorf("read response: %w", err)
+	}
+	var chat ChatResponse
+	if err := json.Unmarshal(rawBody, &chat); err != nil {
+		return ChatResponse{}, fmt.Errorf("decode response: %w", err)
+	}
+	if chat.Error != "" {
+		return ChatResponse{}, &APIError{StatusCode: resp.StatusCode, Message: chat.Error}
+	}
+	return chat, nil
+}
+
+// ChatStream 以流式方式调用 /api/chat，逐行解析 NDJSON 并回调增量文本，
+// 返回值为拼装好的完整消息以及最后一个片段携带的统计信息。
+func (c *Client) ChatStream(ctx context.Context, req ChatRequest, onDelta StreamHandler) (ChatResponse, error) {
+	if c == nil {
+		return ChatResponse{}, fmt.Errorf("ollama client is nil")
+	}
+	req.Stream = true
+	// 流式生成耗时取决于输出长度，交由 ctx 控制超时。
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	resp, err := c.doChat(ctx, req, &httpClient)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var (
+		assembled ChatResponse
+		content   strings.Builder
+		thinking  strings.Builder
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return ChatResponse{}, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return ChatResponse{}, &APIError{StatusCode: resp.StatusCode, Message: chunk.Error}
+		}
+		thinking.WriteString(chunk.Message.Thinking)
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			if onDelta != nil {
+				if err := onDelta(chunk.Message.Content); err != nil {
+					return ChatResponse{}, err
+				}
+			}
+		}
+		if chunk.Done {
+			assembled = chunk
+			break
+		}
+		if assembled.Model == "" {
+			assembled.Model = chunk.Model
+			assembled.CreatedAt = chunk.CreatedAt
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ChatResponse{}, fmt.Errorf("read stream: %w", err)
+	}
+	assembled.Message.Role = "assistant"
+	assembled.Message.Content = content.String()
+	assembled.Message.Thinking = thinking.String()
+	return assembled, nil
+}
+
+// ListModels 调用 /api/tags 返回本地已拉取的模型列表。
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if c == nil {
+		return nil, fmt.Errorf("ollama client is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	c.applyHeaders(httpReq)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, parseAPIError(resp.StatusCode, rawBody)
+	}
+	var payload struct {
+		Models []ModelInfo `json:"models"`
+	}
+	if err := json.Unmarshal(rawBody, &payload); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return payload.Models, nil
+}
+
+// doChat 校验参数并发起 /api/chat 请求，状态码异常时直接解析为 *APIError。
+func (c *Client) doChat(ctx context.Context, req ChatRequest, httpClient *http.Client) (*http.Response, error) {
+	if c == nil {
+		return nil, fmt.Errorf("ollama client is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if strings.TrimSpace(req.Model) == "" {
+		return nil, fmt.Errorf("model 字段不能为空")
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("messages 至少需要一条消息")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	c.applyHeaders(httpReq)
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		rawBody, readErr := io.ReadAll(resp.Body)
+		if readErr != nil {
+			return nil, fmt.Errorf("read response: %w", readErr)
+		}
+		return nil, parseAPIError(resp.StatusCode, rawBody)
+	}
+	return resp, nil
+}
+
+// applyHeaders 补充通用请求头，配置了 API Key 时附带 Bearer 认证。
+func (c *Client) applyHeaders(req *http.Request) {
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+}
+
+// parseAPIError 将 Ollama 的 {"error": "..."} 响应转换为 *APIError。
+func parseAPIError(status int, payload []byte) error {
+	apiErr := &APIError{StatusCode: status}
+	if err := json.Unmarshal(payload, apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(payload))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(status)
+	}
+	return apiErr
+}
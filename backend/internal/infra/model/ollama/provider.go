@@ -0,0 +1,153 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+var _ llm.Provider = (*Client)(nil)
+
+// Complete 实现 llm.Provider：将通用请求映射为 /api/chat 请求后发起一次性调用。
+func (c *Client) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	resp, err := c.Chat(ctx, newChatRequest(req))
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return toLLMResponse(resp), nil
+}
+
+// CompleteStream 实现 llm.Provider 的流式调用。
+func (c *Client) CompleteStream(ctx context.Context, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	resp, err := c.ChatStream(ctx, newChatRequest(req), StreamHandler(onDelta))
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return toLLMResponse(resp), nil
+}
+
+// newChatRequest 将通用请求映射为 Ollama 请求：采样参数写入 options，
+// ExtraFields 中的 keep_alive/format 保持顶层，其余未知字段一并视为 options。
+func newChatRequest(req llm.Request) ChatRequest {
+	chatReq := ChatRequest{
+		Model:    req.Model,
+		Messages: make([]ChatMessage, 0, len(req.Messages)),
+		Options:  map[string]any{},
+	}
+	for _, msg := range req.Messages {
+		chatReq.Messages = append(chatReq.Messages, ChatMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+
+	for key, value := range req.ExtraFields {
+		switch strings.ToLower(key) {
+		case "keep_alive":
+			chatReq.KeepAlive = value
+		case "format":
+			chatReq.Format = value
+		case "options":
+			if v, ok := value.(map[string]any); ok {
+				for optKey, optValue := range v {
+					chatReq.Options[optKey] = optValue
+				}
+			}
+		default:
+			chatReq.Options[key] = value
+		}
+	}
+
+	// 显式参数优先级高于 extra_config 中的 options。
+	if req.Temperature > 0 {
+		chatReq.Options["temperature"] = req.Temperature
+	}
+	if req.TopP > 0 {
+		chatReq.Options["top_p"] = req.TopP
+	}
+	if req.MaxTokens > 0 {
+		chatReq.Options["num_predict"] = req.MaxTokens
+	}
+	if req.PresencePenalty != 0 {
+		chatReq.Options["presence_penalty"] = req.PresencePenalty
+	}
+	if req.FrequencyPenalty != 0 {
+		chatReq.Options["frequency_penalty"] = req.FrequencyPenalty
+	}
+	if len(req.Stop) > 0 {
+		chatReq.Options["stop"] = req.Stop
+	}
+	if chatReq.Format == nil {
+		chatReq.Format = formatFromResponseFormat(req.ResponseFormat)
+	}
+	if len(chatReq.Options) == 0 {
+		chatReq.Options = nil
+	}
+	return chatReq
+}
+
+// formatFromResponseFormat 将 OpenAI 风格的 response_format 映射为 Ollama 的 format 字段。
+func formatFromResponseFormat(responseFormat map[string]any) any {
+	if responseFormat == nil {
+		return nil
+	}
+	switch typ, _ := responseFormat["type"].(string); typ {
+	case "json_object":
+		return "json"
+	case "json_schema":
+		if wrapper, ok := responseFormat["json_schema"].(map[string]any); ok {
+			if schema, ok := wrapper["schema"]; ok {
+				return schema
+			}
+		}
+		return "json"
+	default:
+		return nil
+	}
+}
+
+// toLLMResponse 将 Ollama 的返回值折叠为通用结构，eval 计数换算为 usage。
+func toLLMResponse(resp ChatResponse) llm.Response {
+	finishReason := strings.TrimSpace(resp.DoneReason)
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+	role := resp.Message.Role
+	if role == "" {
+		role = "assistant"
+	}
+	choice := llm.Choice{
+		Index: 0,
+		Message: llm.Message{
+			Role:    role,
+			Content: resp.Message.Content,
+		},
+		FinishReason: finishReason,
+	}
+	if resp.Message.Thinking != "" {
+		choice.Reasoning = &llm.Reasoning{Content: resp.Message.Thinking}
+	}
+	converted := llm.Response{
+		ID:      fmt.Sprintf("ollama-%d", resp.CreatedAt.UnixNano()),
+		Created: resp.CreatedAt.Unix(),
+		Model:   resp.Model,
+		Choices: []llm.Choice{choice},
+	}
+	if resp.PromptEvalCount > 0 || resp.EvalCount > 0 {
+		converted.Usage = &llm.Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		}
+	}
+	if rawBytes, err := json.Marshal(resp); err == nil {
+		var raw map[string]any
+		if err := json.Unmarshal(rawBytes, &raw); err == nil {
+			converted.Raw = raw
+		}
+	}
+	return converted
+}
@@ -0,0 +1,57 @@
+package ollama
+
+import "time"
+
+// ChatMessage 表示 Ollama /api/chat 中的单条消息。
+type ChatMessage struct {
+	Role     string `json:"role"`
+	Content  string `json:"content"`
+	Thinking string `json:"thinking,omitempty"` // 开启 think 时推理模型返回的思考过程
+}
+
+// ChatRequest 对应 Ollama /api/chat 的请求体，采样参数统一放在 Options 中。
+type ChatRequest struct {
+	Model     string         `json:"model"`
+	Messages  []ChatMessage  `json:"messages"`
+	Stream    bool           `json:"stream"`
+	Format    any            `json:"format,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+	KeepAlive any            `json:"keep_alive,omitempty"`
+}
+
+// ChatResponse 映射 Ollama /api/chat 的返回结构（流式场景下为单个 NDJSON 片段）。
+type ChatResponse struct {
+	Model              string      `json:"model"`
+	CreatedAt          time.Time   `json:"created_at"`
+	Message            ChatMessage `json:"message"`
+	Done               bool        `json:"done"`
+	DoneReason         string      `json:"done_reason,omitempty"`
+	TotalDuration      int64       `json:"total_duration,omitempty"`
+	LoadDuration       int64       `json:"load_duration,omitempty"`
+	PromptEvalCount    int64       `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64       `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int64       `json:"eval_count,omitempty"`
+	EvalDuration       int64       `json:"eval_duration,omitempty"`
+	Error              string      `json:"error,omitempty"`
+}
+
+// ModelInfo 描述 /api/tags 返回的本地模型条目。
+type ModelInfo struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+// ModelDetails 描述模型的格式、参数规模与量化信息。
+type ModelDetails struct {
+	Format            string `json:"format,omitempty"`
+	Family            string `json:"family,omitempty"`
+	ParameterSize     string `json:"parameter_size,omitempty"`
+	QuantizationLevel string `json:"quantization_level,omitempty"`
+}
+
+// StreamHandler 接收流式生成过程中的增量文本，返回错误会中断后续读取。
+type StreamHandler func(delta string) error
@@ -0,0 +1,116 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// cassettes 按绝对路径缓存已加载的 cassette，同一文件在进程内只加载一次，并发录制共享同一把锁。
+var cassettes sync.Map
+
+// cassette 为内存中的 cassette 文件，录制时整体重写到磁盘。
+type cassette struct {
+	path    string
+	mu      sync.Mutex
+	loaded  bool
+	entries map[string]Interaction
+}
+
+// openCassette 返回路径对应的 cassette，实际读取延迟到首次访问。
+func openCassette(path string) *cassette {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	value, _ := cassettes.LoadOrStore(path, &cassette{path: path})
+	return value.(*cassette)
+}
+
+// lookup 按请求哈希查找已录制的响应。
+func (c *cassette) lookup(key string) (Interaction, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadLocked(); err != nil {
+		return Interaction{}, false, err
+	}
+	interaction, ok := c.entries[key]
+	return interaction, ok, nil
+}
+
+// store 写入一条交互并立即落盘，已存在的 key 会被覆盖。
+func (c *cassette) store(interaction Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadLocked(); err != nil {
+		return err
+	}
+	c.entries[interaction.Key] = interaction
+	return c.saveLocked()
+}
+
+// loadLocked 读取 cassette 文件，文件不存在时视为空 cassette。
+func (c *cassette) loadLocked() error {
+	if c.loaded {
+		return nil
+	}
+	c.entries = map[string]Interaction{}
+	raw, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			c.loaded = true
+			return nil
+		}
+		return fmt.Errorf("read cassette: %w", err)
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return fmt.Errorf("decode cassette %s: %w", c.path, err)
+	}
+	if file.Version != cassetteVersion {
+		return fmt.Errorf("%w: %s has version %d", ErrCassetteVersion, c.path, file.Version)
+	}
+	for _, interaction := range file.Interactions {
+		c.entries[interaction.Key] = interaction
+	}
+	c.loaded = true
+	return nil
+}
+
+// saveLocked 先写临时文件再重命名，避免进程中断时留下半截 JSON。
+func (c *cassette) saveLocked() error {
+	file := cassetteFile{Version: cassetteVersion, Interactions: make([]Interaction, 0, len(c.entries))}
+	for _, interaction := range c.entries {
+		file.Interactions = append(file.Interactions, interaction)
+	}
+	sort.Slice(file.Interactions, func(i, j int) bool {
+		return file.Interactions[i].Key < file.Interactions[j].Key
+	})
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("create cassette dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create cassette temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("replace cassette: %w", err)
+	}
+	return nil
+}
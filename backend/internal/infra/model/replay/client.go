@@ -0,0 +1,83 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+// Mode 控制客户端如何使用 cassette。
+type Mode string
+
+const (
+	// ModeReplay 只从 cassette 回放，未录制的请求直接报错，适合 CI 与离线演示。
+	ModeReplay Mode = "replay"
+	// ModeRecord 总是调用上游模型并覆盖写入 cassette。
+	ModeRecord Mode = "record"
+	// ModeAuto 优先回放，未命中时调用上游并录制。
+	ModeAuto Mode = "auto"
+)
+
+var (
+	ErrCassetteMiss     = errors.New("replay cassette has no recorded response")
+	ErrUpstreamRequired = errors.New("replay upstream provider is required for recording")
+	ErrInvalidMode      = errors.New("invalid replay mode")
+	ErrCassetteVersion  = errors.New("unsupported cassette version")
+)
+
+// ParseMode 解析配置中的模式字符串，空串视为 ModeReplay。
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return ModeReplay, nil
+	case ModeReplay, ModeRecord, ModeAuto:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidMode, value)
+	}
+}
+
+// Client 以 cassette 文件录制与回放模型调用，录制时将请求转发给上游 provider。
+type Client struct {
+	cassette *cassette
+	mode     Mode
+	upstream llm.Provider
+}
+
+// Option 用于自定义 Client 行为。
+type Option func(*Client)
+
+// WithMode 设置录制/回放模式。
+func WithMode(mode Mode) Option {
+	return func(c *Client) {
+		if mode != "" {
+			c.mode = mode
+		}
+	}
+}
+
+// WithUpstream 设置录制时实际调用的模型客户端。
+func WithUpstream(upstream llm.Provider) Option {
+	return func(c *Client) {
+		c.upstream = upstream
+	}
+}
+
+// NewClient 构造指向 cassette 文件的客户端，默认只回放。
+func NewClient(path string, opts ...Option) *Client {
+	client := &Client{
+		cassette: openCassette(path),
+		mode:     ModeReplay,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// Mode 返回客户端当前的模式。
+func (c *Client) Mode() Mode {
+	return c.mode
+}
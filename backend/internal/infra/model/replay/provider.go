@@ -0,0 +1,98 @@
+package replay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+var _ llm.Provider = (*Client)(nil)
+
+// Complete 实现 llm.Provider：命中 cassette 时直接返回录制的响应，否则按模式调用上游并录制。
+func (c *Client) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	return c.do(ctx, req, nil)
+}
+
+// CompleteStream 实现 llm.Provider 的流式调用，回放时把完整文本作为一段增量推送。
+func (c *Client) CompleteStream(ctx context.Context, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	if onDelta == nil {
+		onDelta = func(string) error { return nil }
+	}
+	return c.do(ctx, req, onDelta)
+}
+
+// do 串起查找、回放与录制，流式与非流式共用同一条录制记录。
+func (c *Client) do(ctx context.Context, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	key, err := RequestKey(req)
+	if err != nil {
+		return llm.Response{}, err
+	}
+	if c.mode != ModeRecord {
+		interaction, ok, err := c.cassette.lookup(key)
+		if err != nil {
+			return llm.Response{}, err
+		}
+		if ok {
+			return replayResponse(interaction.Response, onDelta)
+		}
+		if c.mode == ModeReplay {
+			return llm.Response{}, fmt.Errorf("%w: key %s in %s", ErrCassetteMiss, key, c.cassette.path)
+		}
+	}
+	if c.upstream == nil {
+		return llm.Response{}, ErrUpstreamRequired
+	}
+
+	var resp llm.Response
+	if onDelta != nil {
+		resp, err = c.upstream.CompleteStream(ctx, req, onDelta)
+	} else {
+		resp, err = c.upstream.Complete(ctx, req)
+	}
+	if err != nil {
+		return llm.Response{}, err
+	}
+	recorded := resp
+	// 费用由模型服务按当时的价格表估算，不写入 cassette。
+	recorded.Cost = nil
+	if err := c.cassette.store(Interaction{
+		Key:        key,
+		Request:    req,
+		Extra:      req.ExtraFields,
+		Response:   recorded,
+		RecordedAt: time.Now().UTC(),
+	}); err != nil {
+		return llm.Response{}, err
+	}
+	return resp, nil
+}
+
+// replayResponse 返回录制的响应，流式调用时先推送完整文本。
+func replayResponse(resp llm.Response, onDelta llm.StreamHandler) (llm.Response, error) {
+	if onDelta != nil {
+		if text := resp.Text(); text != "" {
+			if err := onDelta(text); err != nil {
+				return llm.Response{}, err
+			}
+		}
+	}
+	return resp, nil
+}
+
+// RequestKey 对请求（含 ExtraFields）求 SHA-256，作为 cassette 中的查找键。
+func RequestKey(req llm.Request) (string, error) {
+	payload, err := json.Marshal(struct {
+		Request llm.Request    `json:"request"`
+		Extra   map[string]any `json:"extra,omitempty"`
+	}{Request: req, Extra: req.ExtraFields})
+	if err != nil {
+		return "", fmt.Errorf("encode replay request: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
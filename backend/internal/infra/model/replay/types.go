@@ -0,0 +1,25 @@
+package replay
+
+import (
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+// cassetteVersion 为当前 cassette 文件的格式版本。
+const cassetteVersion = 1
+
+// Interaction 记录一次模型调用：请求哈希、规范化后的请求与厂商返回的通用响应。
+type Interaction struct {
+	Key        string         `json:"key"`
+	Request    llm.Request    `json:"request"`
+	Extra      map[string]any `json:"extra,omitempty"`
+	Response   llm.Response   `json:"response"`
+	RecordedAt time.Time      `json:"recorded_at"`
+}
+
+// cassetteFile 为 cassette 文件的 JSON 结构，interactions 按 key 排序以便代码评审时对比差异。
+type cassetteFile struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
@@ -3,11 +3,16 @@ package volcengine
 import (
 	context "context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
+	"electron-go-app/backend/internal/domain/llm"
+
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime"
 	arkmodel "github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
+	"github.com/volcengine/volcengine-go-sdk/service/arkruntime/utils"
 	"github.com/volcengine/volcengine-go-sdk/volcengine"
 	"github.com/volcengine/volcengine-go-sdk/volcengine/volcengineerr"
 )
@@ -18,6 +23,7 @@ const defaultBaseURL = "https://ark.cn-beijing.volces.com/api/v3"
 type Client struct {
 	apiKey  string
 	baseURL string
+	retry   llm.RetryPolicy
 	sdk     *arkruntime.Client
 }
 
@@ -35,12 +41,20 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithRetryPolicy 设置限流与瞬时故障时的重试策略，MaxRetries 为 0 表示不重试。
+func WithRetryPolicy(policy llm.RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
 // NewClient 以 API Key 初始化火山引擎客户端。
-// 默认指向华北地域，可使用 Option 覆盖基础地址等参数。
+// 默认指向华北地域并使用 llm.DefaultRetryPolicy，可使用 Option 覆盖基础地址等参数。
 func NewClient(apiKey string, opts ...Option) *Client {
 	client := &Client{
 		apiKey:  strings.TrimSpace(apiKey),
 		baseURL: defaultBaseURL,
+		retry:   llm.DefaultRetryPolicy(),
 	}
 	for _, opt := range opts {
 		opt(client)
@@ -54,7 +68,8 @@ func (c *Client) ensureSDK() {
 	if c.sdk != nil {
 		return
 	}
-	options := []arkruntime.ConfigOption{}
+	// 关闭 SDK 内置重试，统一由 llm.Retry 按策略退避，避免两层重试叠加。
+	options := []arkruntime.ConfigOption{arkruntime.WithRetryTimes(0)}
 	if c.baseURL != "" {
 		options = append(options, arkruntime.WithBaseUrl(c.baseURL))
 	}
@@ -82,6 +97,132 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 
 	c.ensureSDK()
 
+	arkReq := buildArkRequest(req)
+	var resp arkmodel.ChatCompletionResponse
+	err := llm.Retry(ctx, c.retry, func() error {
+		var attemptErr error
+		resp, attemptErr = c.sdk.CreateChatCompletion(ctx, arkReq)
+		if attemptErr != nil {
+			return wrapSDKError(attemptErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return ChatCompletionResponse{}, err
+	}
+
+	return convertResponse(resp)
+}
+
+// ChatCompletionStream 以流式方式调用方舟接口，逐段回调增量文本，结束后返回拼装好的完整响应。
+func (c *Client) ChatCompletionStream(ctx context.Context, req ChatCompletionRequest, onDelta StreamHandler) (ChatCompletionResponse, error) {
+	if c == nil {
+		return ChatCompletionResponse{}, fmt.Errorf("volcengine client is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.apiKey == "" {
+		return ChatCompletionResponse{}, fmt.Errorf("volcengine api key is empty")
+	}
+	if strings.TrimSpace(req.Model) == "" {
+		return ChatCompletionResponse{}, fmt.Errorf("model 字段不能为空")
+	}
+	if len(req.Messages) == 0 {
+		return ChatCompletionResponse{}, fmt.Errorf("messages 至少需要一条消息")
+	}
+
+	c.ensureSDK()
+
+	arkReq := buildArkRequest(req)
+	arkReq.StreamOptions = &arkmodel.StreamOptions{IncludeUsage: true}
+	// 建立流之前尚未推送任何增量，可以安全地重试。
+	var stream *utils.ChatCompletionStreamReader
+	err := llm.Retry(ctx, c.retry, func() error {
+		var attemptErr error
+		stream, attemptErr = c.sdk.CreateChatCompletionStream(ctx, arkReq)
+		if attemptErr != nil {
+			return wrapSDKError(attemptErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return ChatCompletionResponse{}, err
+	}
+	defer stream.Close()
+
+	var (
+		assembled ChatCompletionResponse
+		content   strings.Builder
+		reasoning strings.Builder
+		toolCalls toolCallAccumulator
+		role      = arkmodel.ChatMessageRoleAssistant
+		finish    string
+	)
+	for {
+		chunk, recvErr := stream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		if recvErr != nil {
+			return ChatCompletionResponse{}, wrapSDKError(recvErr)
+		}
+		if assembled.ID == "" {
+			assembled.ID = chunk.ID
+			assembled.Object = chunk.Object
+			assembled.Created = chunk.Created
+		}
+		if chunk.Model != "" {
+			assembled.Model = chunk.Model
+		}
+		if chunk.ServiceTier != "" {
+			assembled.ServiceTier = chunk.ServiceTier
+		}
+		if chunk.Usage != nil {
+			assembled.Usage = convertUsage(*chunk.Usage)
+		}
+		for _, choice := range chunk.Choices {
+			// 与非流式场景保持一致，只关注首个候选。
+			if choice == nil || choice.Index != 0 {
+				continue
+			}
+			if choice.Delta.Role != "" {
+				role = choice.Delta.Role
+			}
+			if choice.FinishReason != "" {
+				finish = string(choice.FinishReason)
+			}
+			if choice.Delta.ReasoningContent != nil {
+				reasoning.WriteString(*choice.Delta.ReasoningContent)
+			}
+			toolCalls.add(choice.Delta.ToolCalls)
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if onDelta != nil {
+				if err := onDelta(choice.Delta.Content); err != nil {
+					return ChatCompletionResponse{}, err
+				}
+			}
+		}
+	}
+
+	assembled.Choices = []ChatCompletionChoice{{
+		Index: 0,
+		Message: ChatMessage{
+			Role:             role,
+			Content:          content.String(),
+			ReasoningContent: reasoning.String(),
+			ToolCalls:        toolCalls.calls(),
+		},
+		FinishReason: finish,
+	}}
+	return assembled, nil
+}
+
+// buildArkRequest 将本地请求结构映射为方舟 SDK 的请求体。
+func buildArkRequest(req ChatCompletionRequest) arkmodel.CreateChatCompletionRequest {
 	arkReq := arkmodel.CreateChatCompletionRequest{
 		Model:    req.Model,
 		Messages: make([]*arkmodel.ChatCompletionMessage, 0, len(req.Messages)),
@@ -95,8 +236,24 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 			Content: &arkmodel.ChatCompletionMessageContent{
 				StringValue: volcengine.String(content),
 			},
+			ToolCalls:  toArkToolCalls(msg.ToolCalls),
+			ToolCallID: msg.ToolCallID,
 		})
 	}
+	for _, tool := range req.Tools {
+		arkReq.Tools = append(arkReq.Tools, &arkmodel.Tool{
+			Type: arkmodel.ToolTypeFunction,
+			Function: &arkmodel.FunctionDefinition{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  tool.Function.Parameters,
+			},
+		})
+	}
+	if req.ToolChoice != nil {
+		// 方舟与 OpenAI 的 tool_choice 结构一致（"auto"/"none"/"required" 或指定函数的对象），原样透传。
+		arkReq.ToolChoice = req.ToolChoice
+	}
 
 	if req.MaxTokens > 0 {
 		arkReq.MaxTokens = volcengine.Int(req.MaxTokens)
@@ -116,20 +273,129 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 	if len(req.Stop) > 0 {
 		arkReq.Stop = append(arkReq.Stop, req.Stop...)
 	}
+	return arkReq
+}
 
-	resp, err := c.sdk.CreateChatCompletion(ctx, arkReq)
-	if err != nil {
-		if rf, ok := err.(volcengineerr.RequestFailure); ok {
-			return ChatCompletionResponse{}, &APIError{
-				StatusCode: rf.StatusCode(),
-				Code:       rf.Code(),
-				Message:    rf.Message(),
-			}
+// toArkToolCalls 将通用工具调用转换为方舟 assistant 消息中的 tool_calls。
+func toArkToolCalls(calls []llm.ToolCall) []*arkmodel.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	converted := make([]*arkmodel.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		converted = append(converted, &arkmodel.ToolCall{
+			ID:   call.ID,
+			Type: arkmodel.ToolTypeFunction,
+			Function: arkmodel.FunctionCall{
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			},
+		})
+	}
+	return converted
+}
+
+// fromArkToolCalls 将方舟响应中的 tool_calls 转换为通用结构。
+func fromArkToolCalls(calls []*arkmodel.ToolCall) []llm.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	converted := make([]llm.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		if call == nil {
+			continue
 		}
-		return ChatCompletionResponse{}, fmt.Errorf("volcengine chat completion: %w", err)
+		converted = append(converted, llm.ToolCall{
+			ID:   call.ID,
+			Type: string(arkmodel.ToolTypeFunction),
+			Function: llm.ToolCallFunction{
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			},
+		})
 	}
+	return converted
+}
 
-	return convertResponse(resp)
+// toolCallAccumulator 按 index 拼接流式返回的函数调用：ID 与函数名只在首个片段出现，参数分多段推送。
+type toolCallAccumulator struct {
+	items map[int]*llm.ToolCall
+	order []int
+}
+
+// add 合并一批 tool_calls 增量，缺少 index 的片段视为独立调用。
+func (a *toolCallAccumulator) add(deltas []*arkmodel.ToolCall) {
+	for _, delta := range deltas {
+		if delta == nil {
+			continue
+		}
+		if a.items == nil {
+			a.items = map[int]*llm.ToolCall{}
+		}
+		index := len(a.order)
+		if delta.Index != nil {
+			index = *delta.Index
+		}
+		call, ok := a.items[index]
+		if !ok {
+			call = &llm.ToolCall{Type: string(arkmodel.ToolTypeFunction)}
+			a.items[index] = call
+			a.order = append(a.order, index)
+		}
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Function.Name != "" {
+			call.Function.Name = delta.Function.Name
+		}
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+// calls 按首次出现的顺序返回拼装完成的函数调用。
+func (a *toolCallAccumulator) calls() []llm.ToolCall {
+	if len(a.order) == 0 {
+		return nil
+	}
+	out := make([]llm.ToolCall, 0, len(a.order))
+	for _, index := range a.order {
+		out = append(out, *a.items[index])
+	}
+	return out
+}
+
+// wrapSDKError 将 SDK 的请求失败转换为 *APIError，上下文取消与超时保留原始错误链。
+func wrapSDKError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("volcengine chat completion: %w", err)
+	}
+	var arkErr *arkmodel.APIError
+	if errors.As(err, &arkErr) {
+		return &APIError{
+			StatusCode: arkErr.HTTPStatusCode,
+			Code:       arkErr.Code,
+			Message:    arkErr.Message,
+		}
+	}
+	var reqErr *arkmodel.RequestError
+	if errors.As(err, &reqErr) {
+		message := err.Error()
+		if reqErr.Err != nil {
+			message = reqErr.Err.Error()
+		}
+		return &APIError{
+			StatusCode: reqErr.HTTPStatusCode,
+			Message:    message,
+		}
+	}
+	if rf, ok := err.(volcengineerr.RequestFailure); ok {
+		return &APIError{
+			StatusCode: rf.StatusCode(),
+			Code:       rf.Code(),
+			Message:    rf.Message(),
+		}
+	}
+	return fmt.Errorf("volcengine chat completion: %w", err)
 }
 
 // normalizeRole 将调用方传入的角色名称转换为方舟 SDK 识别的常量。
@@ -172,25 +438,14 @@ func convertResponse(resp arkmodel.ChatCompletionResponse) (ChatCompletionRespon
 					Role:             choice.Message.Role,
 					Content:          content,
 					ReasoningContent: reasoning,
+					ToolCalls:        fromArkToolCalls(choice.Message.ToolCalls),
 				},
 				FinishReason: string(choice.FinishReason),
 			})
 		}
 	}
 
-	usageData := resp.Usage
-	if usageData.PromptTokens != 0 || usageData.CompletionTokens != 0 || usageData.TotalTokens != 0 || usageData.PromptTokensDetails.CachedTokens != 0 || usageData.CompletionTokensDetails.ReasoningTokens != 0 {
-		usage := &ChatCompletionUsage{
-			PromptTokens:     usageData.PromptTokens,
-			CompletionTokens: usageData.CompletionTokens,
-			TotalTokens:      usageData.TotalTokens,
-			CachedTokens:     usageData.PromptTokensDetails.CachedTokens,
-			ReasoningTokens:  usageData.CompletionTokensDetails.ReasoningTokens,
-		}
-		usage.ProvisionedPromptTokens = usageData.PromptTokensDetails.ProvisionedTokens
-		usage.ProvisionedCompTokens = usageData.CompletionTokensDetails.ProvisionedTokens
-		converted.Usage = usage
-	}
+	converted.Usage = convertUsage(resp.Usage)
 
 	if rawBytes, err := json.Marshal(resp); err == nil {
 		var raw map[string]any
@@ -201,3 +456,20 @@ func convertResponse(resp arkmodel.ChatCompletionResponse) (ChatCompletionRespon
 
 	return converted, nil
 }
+
+// convertUsage 转换 token 统计，全部为零时返回 nil。
+func convertUsage(usageData arkmodel.Usage) *ChatCompletionUsage {
+	if usageData.PromptTokens == 0 && usageData.CompletionTokens == 0 && usageData.TotalTokens == 0 && usageData.PromptTokensDetails.CachedTokens == 0 && usageData.CompletionTokensDetails.ReasoningTokens == 0 {
+		return nil
+	}
+	usage := &ChatCompletionUsage{
+		PromptTokens:     usageData.PromptTokens,
+		CompletionTokens: usageData.CompletionTokens,
+		TotalTokens:      usageData.TotalTokens,
+		CachedTokens:     usageData.PromptTokensDetails.CachedTokens,
+		ReasoningTokens:  usageData.CompletionTokensDetails.ReasoningTokens,
+	}
+	usage.ProvisionedPromptTokens = usageData.PromptTokensDetails.ProvisionedTokens
+	usage.ProvisionedCompTokens = usageData.CompletionTokensDetails.ProvisionedTokens
+	return usage
+}
@@ -0,0 +1,62 @@
+package volcengine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// listModelsTimeout 控制模型列表请求的超时时间。
+const listModelsTimeout = 30 * time.Second
+
+// ListModels 调用方舟 OpenAI 兼容的 GET /models。SDK 未封装该接口，这里直接发起 HTTP 请求；
+// 部分地域或账号未开放该接口时返回 404，由上层视为不支持。
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if c == nil {
+		return nil, fmt.Errorf("volcengine client is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := (&http.Client{Timeout: listModelsTimeout}).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var env struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("volcengine api error: status %d", resp.StatusCode)}
+		if err := json.Unmarshal(rawBody, &env); err == nil && env.Error.Message != "" {
+			apiErr.Code = env.Error.Code
+			apiErr.Message = env.Error.Message
+		}
+		return nil, apiErr
+	}
+	var payload struct {
+		Data []ModelInfo `json:"data"`
+	}
+	if err := json.Unmarshal(rawBody, &payload); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return payload.Data, nil
+}
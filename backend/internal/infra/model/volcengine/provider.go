@@ -0,0 +1,102 @@
+package volcengine
+
+import (
+	"context"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+var _ llm.Provider = (*Client)(nil)
+
+// Complete 实现 llm.Provider：将通用请求映射到方舟 SDK 后发起一次性调用。
+func (c *Client) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	resp, err := c.ChatCompletion(ctx, newChatCompletionRequest(req))
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return toLLMResponse(resp), nil
+}
+
+// CompleteStream 实现 llm.Provider 的流式调用。
+func (c *Client) CompleteStream(ctx context.Context, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	resp, err := c.ChatCompletionStream(ctx, newChatCompletionRequest(req), StreamHandler(onDelta))
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return toLLMResponse(resp), nil
+}
+
+// newChatCompletionRequest 将通用请求映射为方舟请求结构。
+func newChatCompletionRequest(req llm.Request) ChatCompletionRequest {
+	converted := ChatCompletionRequest{
+		Model:            req.Model,
+		Messages:         make([]ChatMessage, 0, len(req.Messages)),
+		MaxTokens:        req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		Stop:             req.Stop,
+		ResponseFormat:   req.ResponseFormat,
+		Tools:            req.Tools,
+		ToolChoice:       req.ToolChoice,
+		ExtraFields:      req.ExtraFields,
+	}
+	for _, msg := range req.Messages {
+		converted.Messages = append(converted.Messages, ChatMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCalls:  msg.ToolCalls,
+			ToolCallID: msg.ToolCallID,
+		})
+	}
+	return converted
+}
+
+// toLLMResponse 将方舟响应折叠为通用结构：reasoning_content 写入 Reasoning，服务等级写入 ServiceTier。
+func toLLMResponse(resp ChatCompletionResponse) llm.Response {
+	converted := llm.Response{
+		ID:          resp.ID,
+		Created:     resp.Created,
+		Model:       resp.Model,
+		Choices:     make([]llm.Choice, 0, len(resp.Choices)),
+		ServiceTier: resp.ServiceTier,
+		Raw:         resp.Raw,
+	}
+	for _, choice := range resp.Choices {
+		item := llm.Choice{
+			Index: choice.Index,
+			Message: llm.Message{
+				Role:      choice.Message.Role,
+				Content:   choice.Message.Content,
+				ToolCalls: choice.Message.ToolCalls,
+			},
+			FinishReason: choice.FinishReason,
+		}
+		if choice.Message.ReasoningContent != "" {
+			item.Reasoning = &llm.Reasoning{Content: choice.Message.ReasoningContent}
+		}
+		converted.Choices = append(converted.Choices, item)
+	}
+
+	if resp.Usage != nil {
+		usage := &llm.Usage{
+			PromptTokens:     int64(resp.Usage.PromptTokens),
+			CompletionTokens: int64(resp.Usage.CompletionTokens),
+			TotalTokens:      int64(resp.Usage.TotalTokens),
+			CachedTokens:     int64(resp.Usage.CachedTokens),
+			ReasoningTokens:  int64(resp.Usage.ReasoningTokens),
+		}
+		if resp.Usage.ProvisionedPromptTokens != nil || resp.Usage.ProvisionedCompTokens != nil {
+			usage.Extra = map[string]int64{}
+			if resp.Usage.ProvisionedPromptTokens != nil {
+				usage.Extra["provisioned_prompt_tokens"] = int64(*resp.Usage.ProvisionedPromptTokens)
+			}
+			if resp.Usage.ProvisionedCompTokens != nil {
+				usage.Extra["provisioned_completion_tokens"] = int64(*resp.Usage.ProvisionedCompTokens)
+			}
+		}
+		converted.Usage = usage
+	}
+	return converted
+}
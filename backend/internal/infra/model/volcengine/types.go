@@ -1,12 +1,18 @@
 package volcengine
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
 
 // ChatMessage 表示向火山引擎发起请求或从响应中解析出的单条消息。
 type ChatMessage struct {
-	Role             string `json:"role"`
-	Content          string `json:"content"`
-	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Role             string         `json:"role"`
+	Content          string         `json:"content"`
+	ReasoningContent string         `json:"reasoning_content,omitempty"`
+	ToolCalls        []llm.ToolCall `json:"tool_calls,omitempty"`   // assistant 发起的函数调用
+	ToolCallID       string         `json:"tool_call_id,omitempty"` // tool 消息对应的调用 ID
 }
 
 // ChatCompletionRequest 封装火山引擎聊天补全 API 所需的参数。
@@ -20,6 +26,8 @@ type ChatCompletionRequest struct {
 	FrequencyPenalty float64        `json:"frequency_penalty,omitempty"`
 	Stop             []string       `json:"stop,omitempty"`
 	ResponseFormat   map[string]any `json:"response_format,omitempty"`
+	Tools            []llm.Tool     `json:"tools,omitempty"`
+	ToolChoice       any            `json:"tool_choice,omitempty"`
 	ExtraFields      map[string]any `json:"-"`
 }
 
@@ -92,3 +100,24 @@ func (e *APIError) Error() string {
 	}
 	return e.Message
 }
+
+var _ llm.KindedError = (*APIError)(nil)
+
+// Kind 根据状态码与方舟错误码（如 SensitiveContentDetected、AccountOverdueError）归类错误。
+func (e *APIError) Kind() llm.ErrorKind {
+	if e == nil {
+		return ""
+	}
+	return llm.ClassifyStatus(e.StatusCode, e.Code, e.Message)
+}
+
+// StreamHandler 接收流式生成过程中的增量文本，返回错误会中断后续读取。
+type StreamHandler func(delta string) error
+
+// ModelInfo 描述方舟 OpenAI 兼容接口 GET /models 返回的模型（或推理接入点）条目。
+type ModelInfo struct {
+	ID      string `json:"id"`
+	Object  string `json:"object,omitempty"`
+	Created int64  `json:"created,omitempty"`
+	OwnedBy string `json:"owned_by,omitempty"`
+}
@@ -3,7 +3,10 @@ package promptinfra
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	promptdomain "electron-go-app/backend/internal/domain/prompt"
@@ -12,26 +15,86 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-const defaultQueueKey = "prompt:persistence:queue"
+const (
+	defaultQueueKey = "prompt:persistence:queue"
+	// queueGroup 为消费落库任务的 Stream 消费组名称。
+	queueGroup = "persistence-workers"
+	// queuePayloadField 为 Stream 消息中存放任务 JSON 的字段名。
+	queuePayloadField = "payload"
+	// defaultQueueClaimIdle 为判定 worker 已崩溃的空闲时长，超过后其未确认的任务会被回收。
+	defaultQueueClaimIdle = 5 * time.Minute
+	// queuePromoteBatch 限制每次出队前搬运的到期重试任务数量。
+	queuePromoteBatch = 50
+)
+
+// promoteDueScript 原子地把到期的重试任务从延迟 ZSET 搬回 Stream，避免搬运途中崩溃导致任务丢失。
+var promoteDueScript = redis.NewScript(`
+local items = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, item in ipairs(items) do
+	redis.call('ZREM', KEYS[1], item)
+	redis.call('XADD', KEYS[2], '*', 'payload', item)
+end
+return #items
+`)
 
-// PersistenceQueue 使用 Redis List 承载异步落库任务。
+// PersistenceQueue 使用 Redis Stream + 消费组承载异步落库任务：出队后的任务在 Ack 前一直处于 pending 状态，
+// 失败的任务按退避时间写入延迟 ZSET 等待重试，超过最大次数后转入死信 List，崩溃 worker 遗留的任务由 Reclaim 回收。
 type PersistenceQueue struct {
-	client *redis.Client
-	key    string
+	client    *redis.Client
+	key       string
+	consumer  string
+	retry     promptdomain.PersistenceRetryPolicy
+	claimIdle time.Duration
+}
+
+// QueueOption 自定义 PersistenceQueue 的行为。
+type QueueOption func(*PersistenceQueue)
+
+// WithQueueRetryPolicy 覆盖默认的重试策略。
+func WithQueueRetryPolicy(policy promptdomain.PersistenceRetryPolicy) QueueOption {
+	return func(queue *PersistenceQueue) {
+		queue.retry = policy
+	}
+}
+
+// WithQueueClaimIdle 设置回收崩溃 worker 任务前需要等待的空闲时长。
+func WithQueueClaimIdle(idle time.Duration) QueueOption {
+	return func(queue *PersistenceQueue) {
+		if idle > 0 {
+			queue.claimIdle = idle
+		}
+	}
+}
+
+// WithQueueConsumer 指定消费组中的消费者名称，默认使用主机名加随机后缀。
+func WithQueueConsumer(name string) QueueOption {
+	return func(queue *PersistenceQueue) {
+		if strings.TrimSpace(name) != "" {
+			queue.consumer = strings.TrimSpace(name)
+		}
+	}
 }
 
-// NewPersistenceQueue 构造队列。
-func NewPersistenceQueue(client *redis.Client, key string) *PersistenceQueue {
+// NewPersistenceQueue 构造队列，key 为空时使用默认前缀。
+func NewPersistenceQueue(client *redis.Client, key string, opts ...QueueOption) *PersistenceQueue {
 	if key == "" {
 		key = defaultQueueKey
 	}
-	return &PersistenceQueue{
-		client: client,
-		key:    key,
+	host, _ := os.Hostname()
+	queue := &PersistenceQueue{
+		client:    client,
+		key:       key,
+		consumer:  fmt.Sprintf("%s-%s", host, uuid.NewString()[:8]),
+		retry:     promptdomain.DefaultPersistenceRetryPolicy(),
+		claimIdle: defaultQueueClaimIdle,
 	}
+	for _, opt := range opts {
+		opt(queue)
+	}
+	return queue
 }
 
-// Enqueue 将任务编码后推入队列末尾。
+// Enqueue 将任务编码后追加到 Stream 末尾。
 func (q *PersistenceQueue) Enqueue(ctx context.Context, task promptdomain.PersistenceTask) (string, error) {
 	if q == nil || q.client == nil {
 		return "", fmt.Errorf("persistence queue not initialised")
@@ -46,29 +109,238 @@ func (q *PersistenceQueue) Enqueue(ctx context.Context, task promptdomain.Persis
 	if err != nil {
 		return "", fmt.Errorf("encode persistence task: %w", err)
 	}
-	if err := q.client.RPush(ctx, q.key, payload).Err(); err != nil {
+	if err := q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.streamKey(),
+		Values: map[string]any{queuePayloadField: payload},
+	}).Err(); err != nil {
 		return "", fmt.Errorf("enqueue persistence task: %w", err)
 	}
 	return task.TaskID, nil
 }
 
-// BlockingPop 阻塞式弹出一个任务，timeout 为 0 表示阻塞等待。
+// BlockingPop 阻塞式取出一个任务，timeout 为 0 表示阻塞等待，超时返回 redis.Nil。
+// 任务在调用 Ack 或 Retry 之前不会从消费组的 pending 列表中移除。
 func (q *PersistenceQueue) BlockingPop(ctx context.Context, timeout time.Duration) (promptdomain.PersistenceTask, error) {
 	if q == nil || q.client == nil {
 		return promptdomain.PersistenceTask{}, fmt.Errorf("persistence queue not initialised")
 	}
-	var (
-		result promptdomain.PersistenceTask
-	)
-	values, err := q.client.BLPop(ctx, timeout, q.key).Result()
+	if err := q.promoteDue(ctx); err != nil {
+		return promptdomain.PersistenceTask{}, err
+	}
+	args := &redis.XReadGroupArgs{
+		Group:    queueGroup,
+		Consumer: q.consumer,
+		Streams:  []string{q.streamKey(), ">"},
+		Count:    1,
+		Block:    timeout,
+	}
+	streams, err := q.client.XReadGroup(ctx, args).Result()
+	if err != nil && isNoGroupError(err) {
+		if groupErr := q.ensureGroup(ctx); groupErr != nil {
+			return promptdomain.PersistenceTask{}, groupErr
+		}
+		streams, err = q.client.XReadGroup(ctx, args).Result()
+	}
 	if err != nil {
-		return result, err
+		return promptdomain.PersistenceTask{}, err
 	}
-	if len(values) != 2 {
-		return result, fmt.Errorf("unexpected blpop response: %#v", values)
+	if len(streams) == 0 || len(streams[0].Messages) == 0 {
+		return promptdomain.PersistenceTask{}, redis.Nil
 	}
-	if err := json.Unmarshal([]byte(values[1]), &result); err != nil {
+	message := streams[0].Messages[0]
+	payload, _ := message.Values[queuePayloadField].(string)
+	var task promptdomain.PersistenceTask
+	if err := json.Unmarshal([]byte(payload), &task); err != nil {
+		// 无法解码的消息无法重试，直接转入死信，避免反复被取出。
+		pipe := q.client.TxPipeline()
+		pipe.RPush(ctx, q.deadKey(), payload)
+		q.ackInPipe(ctx, pipe, message.ID)
+		if _, execErr := pipe.Exec(ctx); execErr != nil {
+			return promptdomain.PersistenceTask{}, fmt.Errorf("dead-letter undecodable task: %w", execErr)
+		}
 		return promptdomain.PersistenceTask{}, fmt.Errorf("decode persistence task: %w", err)
 	}
-	return result, nil
+	task.Receipt = message.ID
+	return task, nil
+}
+
+// Ack 确认任务处理成功，将其从 pending 列表与 Stream 中删除。
+func (q *PersistenceQueue) Ack(ctx context.Context, task promptdomain.PersistenceTask) error {
+	if q == nil || q.client == nil {
+		return fmt.Errorf("persistence queue not initialised")
+	}
+	if task.Receipt == "" {
+		return errors.New("persistence task receipt is empty")
+	}
+	pipe := q.client.TxPipeline()
+	q.ackInPipe(ctx, pipe, task.Receipt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("ack persistence task: %w", err)
+	}
+	return nil
+}
+
+// Retry 记录一次失败：未用尽次数时按退避时间写入延迟队列，否则转入死信 List；返回任务是否已进入死信。
+func (q *PersistenceQueue) Retry(ctx context.Context, task promptdomain.PersistenceTask, cause error) (bool, error) {
+	if q == nil || q.client == nil {
+		return false, fmt.Errorf("persistence queue not initialised")
+	}
+	if task.Receipt == "" {
+		return false, errors.New("persistence task receipt is empty")
+	}
+	receipt := task.Receipt
+	task.Attempts++
+	if cause != nil {
+		task.LastError = cause.Error()
+	}
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return false, fmt.Errorf("encode persistence task: %w", err)
+	}
+	dead := q.retry.Exhausted(task.Attempts)
+	pipe := q.client.TxPipeline()
+	if dead {
+		pipe.RPush(ctx, q.deadKey(), payload)
+	} else {
+		pipe.ZAdd(ctx, q.delayedKey(), redis.Z{
+			Score:  float64(time.Now().Add(q.retry.Backoff(task.Attempts)).UnixMilli()),
+			Member: payload,
+		})
+	}
+	q.ackInPipe(ctx, pipe, receipt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("retry persistence task: %w", err)
+	}
+	return dead, nil
+}
+
+// Reclaim 回收崩溃 worker 遗留的任务：空闲超过 claimIdle 的 pending 消息计为一次失败，未用尽次数时重新追加到
+// Stream 末尾，否则与 Retry 一样转入死信 List；同时把旧版本 List 队列中残留的任务迁移到 Stream。返回回收的任务数量。
+func (q *PersistenceQueue) Reclaim(ctx context.Context) (int, error) {
+	if q == nil || q.client == nil {
+		return 0, fmt.Errorf("persistence queue not initialised")
+	}
+	if err := q.ensureGroup(ctx); err != nil {
+		return 0, err
+	}
+	reclaimed, err := q.migrateLegacyList(ctx)
+	if err != nil {
+		return reclaimed, err
+	}
+	start := "0-0"
+	for {
+		messages, next, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   q.streamKey(),
+			Group:    queueGroup,
+			Consumer: q.consumer,
+			MinIdle:  q.claimIdle,
+			Start:    start,
+			Count:    queuePromoteBatch,
+		}).Result()
+		if err != nil {
+			return reclaimed, fmt.Errorf("claim pending persistence tasks: %w", err)
+		}
+		for _, message := range messages {
+			pipe := q.client.TxPipeline()
+			q.requeueStalledInPipe(ctx, pipe, message)
+			q.ackInPipe(ctx, pipe, message.ID)
+			if _, err := pipe.Exec(ctx); err != nil {
+				return reclaimed, fmt.Errorf("requeue pending persistence task: %w", err)
+			}
+			reclaimed++
+		}
+		if next == "" || next == "0-0" {
+			return reclaimed, nil
+		}
+		start = next
+	}
+}
+
+// requeueStalledInPipe 为被回收的任务累加一次处理次数：未用尽重试次数时追加回 Stream，用尽或无法解码时转入死信 List。
+func (q *PersistenceQueue) requeueStalledInPipe(ctx context.Context, pipe redis.Pipeliner, message redis.XMessage) {
+	payload, _ := message.Values[queuePayloadField].(string)
+	var task promptdomain.PersistenceTask
+	if err := json.Unmarshal([]byte(payload), &task); err != nil {
+		pipe.RPush(ctx, q.deadKey(), payload)
+		return
+	}
+	task.Attempts++
+	task.LastError = promptdomain.ErrPersistenceTaskStalled.Error()
+	encoded, err := json.Marshal(task)
+	if err != nil || q.retry.Exhausted(task.Attempts) {
+		if err != nil {
+			encoded = []byte(payload)
+		}
+		pipe.RPush(ctx, q.deadKey(), encoded)
+		return
+	}
+	pipe.XAdd(ctx, &redis.XAddArgs{Stream: q.streamKey(), Values: map[string]any{queuePayloadField: encoded}})
+}
+
+// migrateLegacyList 将旧版 List 队列中尚未消费的任务迁移到 Stream。
+func (q *PersistenceQueue) migrateLegacyList(ctx context.Context) (int, error) {
+	migrated := 0
+	for {
+		payload, err := q.client.LPop(ctx, q.key).Result()
+		if errors.Is(err, redis.Nil) {
+			return migrated, nil
+		}
+		if err != nil {
+			if strings.Contains(err.Error(), "WRONGTYPE") {
+				return migrated, nil
+			}
+			return migrated, fmt.Errorf("migrate legacy persistence queue: %w", err)
+		}
+		if err := q.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: q.streamKey(),
+			Values: map[string]any{queuePayloadField: payload},
+		}).Err(); err != nil {
+			return migrated, fmt.Errorf("migrate legacy persistence queue: %w", err)
+		}
+		migrated++
+	}
+}
+
+// promoteDue 把到期的重试任务搬回 Stream。
+func (q *PersistenceQueue) promoteDue(ctx context.Context) error {
+	now := time.Now().UnixMilli()
+	if err := promoteDueScript.Run(ctx, q.client, []string{q.delayedKey(), q.streamKey()}, now, queuePromoteBatch).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("promote delayed persistence tasks: %w", err)
+	}
+	return nil
+}
+
+// ensureGroup 创建消费组（Stream 不存在时一并创建），已存在时忽略。
+func (q *PersistenceQueue) ensureGroup(ctx context.Context) error {
+	err := q.client.XGroupCreateMkStream(ctx, q.streamKey(), queueGroup, "0").Err()
+	if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create persistence consumer group: %w", err)
+	}
+	return nil
+}
+
+// ackInPipe 在事务管道中确认并删除消息。
+func (q *PersistenceQueue) ackInPipe(ctx context.Context, pipe redis.Pipeliner, id string) {
+	pipe.XAck(ctx, q.streamKey(), queueGroup, id)
+	pipe.XDel(ctx, q.streamKey(), id)
+}
+
+// streamKey 返回承载任务的 Stream key。
+func (q *PersistenceQueue) streamKey() string {
+	return q.key + ":stream"
+}
+
+// delayedKey 返回等待重试任务的 ZSET key，score 为可重新投递的毫秒时间戳。
+func (q *PersistenceQueue) delayedKey() string {
+	return q.key + ":delayed"
+}
+
+// deadKey 返回死信 List 的 key。
+func (q *PersistenceQueue) deadKey() string {
+	return q.key + ":dead"
+}
+
+// isNoGroupError 判断错误是否由消费组不存在引起。
+func isNoGroupError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "NOGROUP")
 }
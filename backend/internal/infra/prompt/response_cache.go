@@ -0,0 +1,50 @@
+package promptinfra
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultResponseCachePrefix = "prompt:model_cache"
+
+// ResponseCache 使用 Redis 缓存模型响应，过期时间交由 Redis TTL 管理。
+type ResponseCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewResponseCache 构造 Redis 响应缓存，prefix 为空时使用默认前缀。
+func NewResponseCache(client *redis.Client, prefix string) *ResponseCache {
+	prefix = strings.TrimSuffix(strings.TrimSpace(prefix), ":")
+	if prefix == "" {
+		prefix = defaultResponseCachePrefix
+	}
+	return &ResponseCache{client: client, prefix: prefix}
+}
+
+// Get 读取缓存内容，key 不存在时返回 false。
+func (c *ResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if c == nil || c.client == nil {
+		return nil, false, nil
+	}
+	value, err := c.client.Get(ctx, c.prefix+":"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set 写入缓存内容并设置过期时间。
+func (c *ResponseCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if c == nil || c.client == nil {
+		return nil
+	}
+	return c.client.Set(ctx, c.prefix+":"+key, value, ttl).Err()
+}
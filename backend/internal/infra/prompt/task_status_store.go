@@ -0,0 +1,88 @@
+package promptinfra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultTaskStatusTTL    = 24 * time.Hour
+	defaultTaskStatusPrefix = "prompt:task"
+)
+
+// TaskStatusStore 在 Redis 中记录异步落库任务的处理状态，过期后自动清理。
+type TaskStatusStore struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// TaskStatusOption 自定义 TaskStatusStore 的行为。
+type TaskStatusOption func(*TaskStatusStore)
+
+// WithTaskStatusTTL 设置任务状态的保留时间。
+func WithTaskStatusTTL(ttl time.Duration) TaskStatusOption {
+	return func(store *TaskStatusStore) {
+		if ttl > 0 {
+			store.ttl = ttl
+		}
+	}
+}
+
+// NewTaskStatusStore 构造 TaskStatusStore。
+func NewTaskStatusStore(client *redis.Client, opts ...TaskStatusOption) *TaskStatusStore {
+	store := &TaskStatusStore{
+		client: client,
+		prefix: defaultTaskStatusPrefix,
+		ttl:    defaultTaskStatusTTL,
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
+}
+
+// Save 覆盖写入任务状态并刷新过期时间。
+func (s *TaskStatusStore) Save(ctx context.Context, status promptdomain.PersistenceTaskStatus) error {
+	if s == nil || s.client == nil {
+		return fmt.Errorf("task status store not initialised")
+	}
+	if strings.TrimSpace(status.TaskID) == "" {
+		return fmt.Errorf("task id required")
+	}
+	payload, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("encode task status: %w", err)
+	}
+	if err := s.client.Set(ctx, s.key(status.TaskID), payload, s.ttl).Err(); err != nil {
+		return fmt.Errorf("save task status: %w", err)
+	}
+	return nil
+}
+
+// Get 读取任务状态，不存在或已过期时返回 redis.Nil。
+func (s *TaskStatusStore) Get(ctx context.Context, taskID string) (promptdomain.PersistenceTaskStatus, error) {
+	if s == nil || s.client == nil {
+		return promptdomain.PersistenceTaskStatus{}, fmt.Errorf("task status store not initialised")
+	}
+	raw, err := s.client.Get(ctx, s.key(taskID)).Bytes()
+	if err != nil {
+		return promptdomain.PersistenceTaskStatus{}, err
+	}
+	var status promptdomain.PersistenceTaskStatus
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return promptdomain.PersistenceTaskStatus{}, fmt.Errorf("decode task status: %w", err)
+	}
+	return status, nil
+}
+
+func (s *TaskStatusStore) key(taskID string) string {
+	return fmt.Sprintf("%s:%s", s.prefix, strings.TrimSpace(taskID))
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -27,8 +28,13 @@ const (
 	workspaceFieldPromptID       = "prompt_id"
 	workspaceFieldStatus         = "status"
 	workspaceFieldAttributesJSON = "attributes"
+	// workspaceWatchRetries 控制 WATCH 事务因并发写入失败后的重试次数。
+	workspaceWatchRetries = 5
 )
 
+// errWorkspaceUnchanged 用于在写入内容无变化时放弃事务。
+var errWorkspaceUnchanged = errors.New("workspace unchanged")
+
 // WorkspaceStore 提供 Prompt 工作区的 Redis 存取能力。
 type WorkspaceStore struct {
 	client *redis.Client
@@ -104,6 +110,8 @@ func (s *WorkspaceStore) CreateOrReplace(ctx context.Context, userID uint, snaps
 
 	pipe := s.client.TxPipeline()
 	pipe.HSet(ctx, baseKey, payload)
+	// 覆盖工作区时旧的撤销/重做记录已不再适用，一并清空。
+	pipe.Del(ctx, s.keyUndo(baseKey), s.keyRedo(baseKey))
 	if err := s.replaceKeywords(ctx, pipe, baseKey, snapshot.Positive, true); err != nil {
 		pipe.Discard()
 		return "", err
@@ -112,47 +120,62 @@ func (s *WorkspaceStore) CreateOrReplace(ctx context.Context, userID uint, snaps
 		pipe.Discard()
 		return "", err
 	}
-	s.applyTTL(ctx, pipe, baseKey)
+	s.applyTTL(ctx, pipe, userID, token)
 	if _, err := pipe.Exec(ctx); err != nil {
 		return "", fmt.Errorf("store workspace snapshot: %w", err)
 	}
 	return token, nil
 }
 
-// MergeKeywords 将关键词合并到现有工作区中（存在则覆盖来源/权重信息）。
-func (s *WorkspaceStore) MergeKeywords(ctx context.Context, userID uint, token string, keywords []promptdomain.WorkspaceKeyword) error {
+// MergeKeywords 将关键词合并到现有工作区中（存在则覆盖来源/权重信息），返回写入后的版本号。
+// expectedVersion 不为 WorkspaceAnyVersion 时与当前版本比对，不一致返回 ErrWorkspaceVersionConflict。
+func (s *WorkspaceStore) MergeKeywords(ctx context.Context, userID uint, token string, expectedVersion int64, keywords []promptdomain.WorkspaceKeyword) (int64, error) {
 	if s == nil || s.client == nil {
-		return fmt.Errorf("workspace store not initialised")
+		return 0, fmt.Errorf("workspace store not initialised")
 	}
 	if len(keywords) == 0 {
-		return nil
+		return 0, nil
 	}
 	baseKey := s.baseKey(userID, token)
-	pipe := s.client.TxPipeline()
-	if err := s.mergeKeywords(ctx, pipe, baseKey, keywords); err != nil {
-		pipe.Discard()
-		return err
+	version, err := s.mutate(ctx, userID, token, expectedVersion, promptdomain.WorkspaceOpMergeKeywords, func(_ *redis.Tx, pipe redis.Pipeliner) (bool, error) {
+		return true, s.mergeKeywords(ctx, pipe, baseKey, keywords)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("merge keywords: %w", err)
 	}
-	touchUpdatedAt(ctx, pipe, baseKey)
-	s.applyTTL(ctx, pipe, baseKey)
-	if _, err := pipe.Exec(ctx); err != nil {
-		return fmt.Errorf("merge keywords: %w", err)
+	return version, nil
+}
+
+// ReplaceKeywords 用给定的正/负向关键词整体替换工作区关键词（保留排序与权重），返回写入后的版本号。
+func (s *WorkspaceStore) ReplaceKeywords(ctx context.Context, userID uint, token string, expectedVersion int64, positive, negative []promptdomain.WorkspaceKeyword) (int64, error) {
+	if s == nil || s.client == nil {
+		return 0, fmt.Errorf("workspace store not initialised")
 	}
-	return nil
+	baseKey := s.baseKey(userID, token)
+	version, err := s.mutate(ctx, userID, token, expectedVersion, promptdomain.WorkspaceOpReplaceKeywords, func(_ *redis.Tx, pipe redis.Pipeliner) (bool, error) {
+		if err := s.replaceKeywords(ctx, pipe, baseKey, positive, true); err != nil {
+			return false, err
+		}
+		return true, s.replaceKeywords(ctx, pipe, baseKey, negative, false)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("replace workspace keywords: %w", err)
+	}
+	return version, nil
 }
 
-// RemoveKeyword 将指定关键词从工作区移除，保持前端与 Redis 数据同步。
-func (s *WorkspaceStore) RemoveKeyword(ctx context.Context, userID uint, token, polarity, word string) error {
+// RemoveKeyword 将指定关键词从工作区移除，保持前端与 Redis 数据同步，返回写入后的版本号。
+func (s *WorkspaceStore) RemoveKeyword(ctx context.Context, userID uint, token string, expectedVersion int64, polarity, word string) (int64, error) {
 	if s == nil || s.client == nil {
-		return fmt.Errorf("workspace store not initialised")
+		return 0, fmt.Errorf("workspace store not initialised")
 	}
 	token = strings.TrimSpace(token)
 	if token == "" {
-		return errors.New("workspace token is empty")
+		return 0, errors.New("workspace token is empty")
 	}
 	lowered := strings.ToLower(strings.TrimSpace(word))
 	if lowered == "" {
-		return nil
+		return 0, nil
 	}
 	pol := normalizePolarity(polarity)
 	baseKey := s.baseKey(userID, token)
@@ -162,81 +185,90 @@ func (s *WorkspaceStore) RemoveKeyword(ctx context.Context, userID uint, token,
 		targetZSet = s.keyNegative(baseKey)
 	}
 
-	pipe := s.client.TxPipeline()
-	pipe.HDel(ctx, s.keyKeywords(baseKey), field)
-	pipe.ZRem(ctx, targetZSet, lowered)
-	touchUpdatedAt(ctx, pipe, baseKey)
-	s.applyTTL(ctx, pipe, baseKey)
-	if _, err := pipe.Exec(ctx); err != nil {
-		return fmt.Errorf("remove workspace keyword: %w", err)
+	version, err := s.mutate(ctx, userID, token, expectedVersion, promptdomain.WorkspaceOpRemoveKeyword, func(_ *redis.Tx, pipe redis.Pipeliner) (bool, error) {
+		pipe.HDel(ctx, s.keyKeywords(baseKey), field)
+		pipe.ZRem(ctx, targetZSet, lowered)
+		return true, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("remove workspace keyword: %w", err)
 	}
-	return nil
+	return version, nil
+}
+
+// UpdateDraftBody 更新草稿正文，并刷新更新时间，返回写入后的版本号。
+func (s *WorkspaceStore) UpdateDraftBody(ctx context.Context, userID uint, token string, expectedVersion int64, body string) (int64, error) {
+	return s.UpdateDraft(ctx, userID, token, expectedVersion, body, nil)
 }
 
-// UpdateDraftBody 更新草稿正文，并刷新更新时间。
-func (s *WorkspaceStore) UpdateDraftBody(ctx context.Context, userID uint, token, body string) error {
+// UpdateDraft 在同一次写入中替换草稿正文并合并 attributes，只产生一条撤销记录，返回写入后的版本号。
+func (s *WorkspaceStore) UpdateDraft(ctx context.Context, userID uint, token string, expectedVersion int64, body string, attrs map[string]string) (int64, error) {
 	if s == nil || s.client == nil {
-		return fmt.Errorf("workspace store not initialised")
+		return 0, fmt.Errorf("workspace store not initialised")
 	}
 	baseKey := s.baseKey(userID, token)
-	pipe := s.client.TxPipeline()
-	pipe.HSet(ctx, baseKey, map[string]any{
-		workspaceFieldDraftBody: body,
-		workspaceFieldUpdatedAt: time.Now().Unix(),
+	version, err := s.mutate(ctx, userID, token, expectedVersion, promptdomain.WorkspaceOpUpdateDraft, func(tx *redis.Tx, pipe redis.Pipeliner) (bool, error) {
+		pipe.HSet(ctx, baseKey, workspaceFieldDraftBody, body)
+		if len(attrs) == 0 {
+			return true, nil
+		}
+		existingRaw, err := tx.HGet(ctx, baseKey, workspaceFieldAttributesJSON).Result()
+		if err != nil && err != redis.Nil {
+			return false, fmt.Errorf("load workspace attributes: %w", err)
+		}
+		encoded, changed, err := promptdomain.MergeWorkspaceAttributes(existingRaw, attrs)
+		if err != nil {
+			return false, err
+		}
+		if changed {
+			pipe.HSet(ctx, baseKey, workspaceFieldAttributesJSON, encoded)
+		}
+		return true, nil
 	})
-	s.applyTTL(ctx, pipe, baseKey)
-	if _, err := pipe.Exec(ctx); err != nil {
-		return fmt.Errorf("update workspace draft: %w", err)
+	if err != nil {
+		return 0, fmt.Errorf("update workspace draft: %w", err)
 	}
-	return nil
+	return version, nil
 }
 
-// SetAttributes 合并写入工作区的 attributes 字段，并刷新更新时间与 TTL。
-// 会先读出旧配置，把新改动“打补丁”合进去，
-func (s *WorkspaceStore) SetAttributes(ctx context.Context, userID uint, token string, attrs map[string]string) error {
+// SetAttributes 合并写入工作区的 attributes 字段，并刷新更新时间与 TTL，返回写入后的版本号。
+// 会先读出旧配置，把新改动“打补丁”合进去；合并结果与原值相同时不写入，版本号保持不变。
+func (s *WorkspaceStore) SetAttributes(ctx context.Context, userID uint, token string, expectedVersion int64, attrs map[string]string) (int64, error) {
 	if s == nil || s.client == nil {
-		return fmt.Errorf("workspace store not initialised")
+		return 0, fmt.Errorf("workspace store not initialised")
 	}
 	if len(attrs) == 0 {
-		return nil
+		return 0, nil
 	}
 	baseKey := s.baseKey(userID, token)
-	existingRaw, err := s.client.HGet(ctx, baseKey, workspaceFieldAttributesJSON).Result()
-	if err != nil && err != redis.Nil {
-		return fmt.Errorf("load workspace attributes: %w", err)
-	}
-	merged := make(map[string]string)
-	if strings.TrimSpace(existingRaw) != "" {
-		if decodeErr := json.Unmarshal([]byte(existingRaw), &merged); decodeErr != nil {
-			merged = make(map[string]string)
-		}
-	}
-	for key, value := range attrs {
-		if strings.TrimSpace(value) == "" {
-			delete(merged, key)
-			continue
+	version, err := s.mutate(ctx, userID, token, expectedVersion, promptdomain.WorkspaceOpSetAttributes, func(tx *redis.Tx, pipe redis.Pipeliner) (bool, error) {
+		existingRaw, err := tx.HGet(ctx, baseKey, workspaceFieldAttributesJSON).Result()
+		if err != nil && err != redis.Nil {
+			return false, fmt.Errorf("load workspace attributes: %w", err)
 		}
-		merged[key] = value
-	}
-	encoded := ""
-	if len(merged) > 0 {
-		raw, err := json.Marshal(merged)
-		if err != nil {
-			return fmt.Errorf("encode workspace attributes: %w", err)
+		encoded, changed, err := promptdomain.MergeWorkspaceAttributes(existingRaw, attrs)
+		if err != nil || !changed {
+			return false, err
 		}
-		encoded = string(raw)
-	}
-	payload := map[string]any{
-		workspaceFieldAttributesJSON: encoded,
-		workspaceFieldUpdatedAt:      time.Now().Unix(),
-	}
-	pipe := s.client.TxPipeline()
-	pipe.HSet(ctx, baseKey, payload)
-	s.applyTTL(ctx, pipe, baseKey)
-	if _, err := pipe.Exec(ctx); err != nil {
-		return fmt.Errorf("set workspace attributes: %w", err)
+		pipe.HSet(ctx, baseKey, workspaceFieldAttributesJSON, encoded)
+		return true, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("set workspace attributes: %w", err)
 	}
-	return nil
+	return version, nil
+}
+
+// Undo 撤销最近一次内容修改：恢复修改前的关键词、草稿正文与属性，并把当前内容压入重做栈，返回写入后的版本号。
+// 没有可撤销的操作时返回 ErrWorkspaceHistoryEmpty。
+func (s *WorkspaceStore) Undo(ctx context.Context, userID uint, token string, expectedVersion int64) (int64, error) {
+	return s.travel(ctx, userID, token, expectedVersion, promptdomain.WorkspaceOpUndo)
+}
+
+// Redo 重做最近一次被撤销的修改，并把当前内容压回撤销栈，返回写入后的版本号。
+// 没有可重做的操作时返回 ErrWorkspaceHistoryEmpty。
+func (s *WorkspaceStore) Redo(ctx context.Context, userID uint, token string, expectedVersion int64) (int64, error) {
+	return s.travel(ctx, userID, token, expectedVersion, promptdomain.WorkspaceOpRedo)
 }
 
 // Touch 刷新工作区的 TTL。
@@ -245,8 +277,7 @@ func (s *WorkspaceStore) Touch(ctx context.Context, userID uint, token string) e
 	if s == nil || s.client == nil {
 		return fmt.Errorf("workspace store not initialised")
 	}
-	baseKey := s.baseKey(userID, token)
-	if err := s.touch(ctx, baseKey); err != nil {
+	if err := s.touch(ctx, userID, token); err != nil {
 		return err
 	}
 	return nil
@@ -302,11 +333,11 @@ func (s *WorkspaceStore) Snapshot(ctx context.Context, userID uint, token string
 		}
 	}
 
-	positive, err := s.readKeywords(ctx, baseKey, true)
+	positive, err := s.readKeywords(ctx, s.client, baseKey, true)
 	if err != nil {
 		return promptdomain.WorkspaceSnapshot{}, err
 	}
-	negative, err := s.readKeywords(ctx, baseKey, false)
+	negative, err := s.readKeywords(ctx, s.client, baseKey, false)
 	if err != nil {
 		return promptdomain.WorkspaceSnapshot{}, err
 	}
@@ -315,6 +346,80 @@ func (s *WorkspaceStore) Snapshot(ctx context.Context, userID uint, token string
 	return snapshot, nil
 }
 
+// List 返回用户仍在有效期内的工作区摘要，按更新时间倒序；索引中已过期或已被删除的 token 会顺带清理。
+func (s *WorkspaceStore) List(ctx context.Context, userID uint) ([]promptdomain.WorkspaceSummary, error) {
+	if s == nil || s.client == nil {
+		return nil, fmt.Errorf("workspace store not initialised")
+	}
+	indexKey := s.keyIndex(userID)
+	if err := s.client.ZRemRangeByScore(ctx, indexKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10)).Err(); err != nil {
+		return nil, fmt.Errorf("prune workspace index: %w", err)
+	}
+	members, err := s.client.ZRangeWithScores(ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("load workspace index: %w", err)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(members))
+	for idx, member := range members {
+		cmds[idx] = pipe.HMGet(ctx, s.baseKey(userID, fmt.Sprintf("%v", member.Member)),
+			workspaceFieldTopic,
+			workspaceFieldPromptID,
+			workspaceFieldStatus,
+			workspaceFieldDraftBody,
+			workspaceFieldVersion,
+			workspaceFieldUpdatedAt,
+		)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("load workspace summaries: %w", err)
+	}
+	summaries := make([]promptdomain.WorkspaceSummary, 0, len(members))
+	var stale []any
+	for idx, member := range members {
+		values := make([]string, 6)
+		found := false
+		for i, raw := range cmds[idx].Val() {
+			if str, ok := raw.(string); ok {
+				values[i] = str
+				found = true
+			}
+		}
+		if !found {
+			stale = append(stale, member.Member)
+			continue
+		}
+		summary := promptdomain.WorkspaceSummary{
+			Token:        fmt.Sprintf("%v", member.Member),
+			Topic:        values[0],
+			Status:       values[2],
+			DraftPreview: promptdomain.WorkspaceDraftPreview(values[3]),
+			ExpiresAt:    time.Unix(int64(member.Score), 0),
+		}
+		if parsed, convErr := parseUint(values[1]); convErr == nil {
+			summary.PromptID = parsed
+		}
+		if version, convErr := parseInt64(values[4]); convErr == nil {
+			summary.Version = version
+		}
+		if unix, convErr := parseInt64(values[5]); convErr == nil {
+			summary.UpdatedAt = time.Unix(unix, 0)
+		}
+		summaries = append(summaries, summary)
+	}
+	if len(stale) > 0 {
+		// 工作区 key 已过期而索引仍保留的 token 直接移除，失败不影响本次结果。
+		_ = s.client.ZRem(ctx, indexKey, stale...).Err()
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+	return summaries, nil
+}
+
 // Delete 移除整个工作区。
 func (s *WorkspaceStore) Delete(ctx context.Context, userID uint, token string) error {
 	if s == nil || s.client == nil {
@@ -326,8 +431,13 @@ func (s *WorkspaceStore) Delete(ctx context.Context, userID uint, token string)
 		s.keyPositive(baseKey),
 		s.keyNegative(baseKey),
 		s.keyKeywords(baseKey),
+		s.keyUndo(baseKey),
+		s.keyRedo(baseKey),
 	}
-	if err := s.client.Del(ctx, keys...).Err(); err != nil && err != redis.Nil {
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.ZRem(ctx, s.keyIndex(userID), token)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
 		return fmt.Errorf("delete workspace: %w", err)
 	}
 	return nil
@@ -346,7 +456,7 @@ func (s *WorkspaceStore) SetPromptMeta(ctx context.Context, userID uint, token s
 	}
 	pipe := s.client.TxPipeline()
 	pipe.HSet(ctx, baseKey, values)
-	s.applyTTL(ctx, pipe, baseKey)
+	s.applyTTL(ctx, pipe, userID, token)
 	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("set prompt meta: %w", err)
 	}
@@ -383,6 +493,161 @@ func (s *WorkspaceStore) GetPromptMeta(ctx context.Context, userID uint, token s
 	return promptID, status, nil
 }
 
+// mutate 在 WATCH 事务中校验并递增工作区版本号：expected 不为 WorkspaceAnyVersion 且与当前版本不一致时返回
+// ErrWorkspaceVersionConflict；fn 中用 tx 读取、向 pipe 写入，返回 false 表示无需写入。
+// op 不为空时，写入前的内容会作为一条历史压入撤销栈并清空重做栈。
+// 事务因并发写入失败时重试，重试时会读到新的版本号，因此携带期望版本的写入最终以冲突返回。
+func (s *WorkspaceStore) mutate(ctx context.Context, userID uint, token string, expected int64, op string, fn func(tx *redis.Tx, pipe redis.Pipeliner) (bool, error)) (int64, error) {
+	baseKey := s.baseKey(userID, token)
+	var version int64
+	txf := func(tx *redis.Tx) error {
+		current, err := tx.HGet(ctx, baseKey, workspaceFieldVersion).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("load workspace version: %w", err)
+		}
+		if expected != promptdomain.WorkspaceAnyVersion && current != expected {
+			return promptdomain.ErrWorkspaceVersionConflict
+		}
+		version = current
+		var entry promptdomain.WorkspaceHistoryEntry
+		if op != "" {
+			if entry, err = s.captureHistory(ctx, tx, baseKey, op); err != nil {
+				return err
+			}
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			changed, err := fn(tx, pipe)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				return errWorkspaceUnchanged
+			}
+			if op != "" {
+				if err := s.pushHistory(ctx, pipe, s.keyUndo(baseKey), entry); err != nil {
+					return err
+				}
+				pipe.Del(ctx, s.keyRedo(baseKey))
+			}
+			pipe.HSet(ctx, baseKey, map[string]any{
+				workspaceFieldVersion:   current + 1,
+				workspaceFieldUpdatedAt: time.Now().Unix(),
+			})
+			s.applyTTL(ctx, pipe, userID, token)
+			return nil
+		})
+		if errors.Is(err, errWorkspaceUnchanged) {
+			return nil
+		}
+		if err == nil {
+			version = current + 1
+		}
+		return err
+	}
+	for attempt := 0; attempt < workspaceWatchRetries; attempt++ {
+		err := s.client.Watch(ctx, txf, baseKey)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		return version, nil
+	}
+	return 0, fmt.Errorf("workspace busy after %d attempts: %w", workspaceWatchRetries, redis.TxFailedErr)
+}
+
+// travel 从撤销栈（op 为 undo）或重做栈（op 为 redo）弹出最近一条历史恢复到工作区，并把当前内容压入另一个栈。
+func (s *WorkspaceStore) travel(ctx context.Context, userID uint, token string, expectedVersion int64, op string) (int64, error) {
+	if s == nil || s.client == nil {
+		return 0, fmt.Errorf("workspace store not initialised")
+	}
+	baseKey := s.baseKey(userID, token)
+	from, to := s.keyUndo(baseKey), s.keyRedo(baseKey)
+	if op == promptdomain.WorkspaceOpRedo {
+		from, to = to, from
+	}
+	version, err := s.mutate(ctx, userID, token, expectedVersion, "", func(tx *redis.Tx, pipe redis.Pipeliner) (bool, error) {
+		raw, err := tx.LIndex(ctx, from, 0).Result()
+		if errors.Is(err, redis.Nil) {
+			return false, promptdomain.ErrWorkspaceHistoryEmpty
+		}
+		if err != nil {
+			return false, fmt.Errorf("load workspace history: %w", err)
+		}
+		var entry promptdomain.WorkspaceHistoryEntry
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			return false, fmt.Errorf("decode workspace history: %w", err)
+		}
+		current, err := s.captureHistory(ctx, tx, baseKey, entry.Op)
+		if err != nil {
+			return false, err
+		}
+		pipe.LPop(ctx, from)
+		if err := s.pushHistory(ctx, pipe, to, current); err != nil {
+			return false, err
+		}
+		return true, s.restoreHistory(ctx, pipe, baseKey, entry)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s workspace: %w", op, err)
+	}
+	return version, nil
+}
+
+// captureHistory 读取工作区当前的关键词、草稿正文与属性，生成一条历史记录。
+func (s *WorkspaceStore) captureHistory(ctx context.Context, cmd redis.Cmdable, baseKey, op string) (promptdomain.WorkspaceHistoryEntry, error) {
+	entry := promptdomain.WorkspaceHistoryEntry{Op: op, CreatedAt: time.Now()}
+	values, err := cmd.HMGet(ctx, baseKey, workspaceFieldDraftBody, workspaceFieldAttributesJSON).Result()
+	if err != nil {
+		return entry, fmt.Errorf("load workspace history fields: %w", err)
+	}
+	if body, ok := values[0].(string); ok {
+		entry.DraftBody = body
+	}
+	if attrRaw, ok := values[1].(string); ok && strings.TrimSpace(attrRaw) != "" {
+		var attrs map[string]string
+		if decodeErr := json.Unmarshal([]byte(attrRaw), &attrs); decodeErr == nil {
+			entry.Attributes = attrs
+		}
+	}
+	if entry.Positive, err = s.readKeywords(ctx, cmd, baseKey, true); err != nil {
+		return entry, err
+	}
+	if entry.Negative, err = s.readKeywords(ctx, cmd, baseKey, false); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// pushHistory 将历史记录压入指定栈，并裁剪到 WorkspaceHistoryLimit 条。
+func (s *WorkspaceStore) pushHistory(ctx context.Context, pipe redis.Pipeliner, key string, entry promptdomain.WorkspaceHistoryEntry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode workspace history: %w", err)
+	}
+	pipe.LPush(ctx, key, payload)
+	pipe.LTrim(ctx, key, 0, promptdomain.WorkspaceHistoryLimit-1)
+	return nil
+}
+
+// restoreHistory 用历史记录整体覆盖工作区的关键词、草稿正文与属性。
+func (s *WorkspaceStore) restoreHistory(ctx context.Context, pipe redis.Pipeliner, baseKey string, entry promptdomain.WorkspaceHistoryEntry) error {
+	attributes := ""
+	if len(entry.Attributes) > 0 {
+		raw, err := json.Marshal(entry.Attributes)
+		if err != nil {
+			return fmt.Errorf("encode workspace attributes: %w", err)
+		}
+		attributes = string(raw)
+	}
+	pipe.HSet(ctx, baseKey, workspaceFieldDraftBody, entry.DraftBody, workspaceFieldAttributesJSON, attributes)
+	if err := s.replaceKeywords(ctx, pipe, baseKey, entry.Positive, true); err != nil {
+		return err
+	}
+	return s.replaceKeywords(ctx, pipe, baseKey, entry.Negative, false)
+}
+
 // replaceKeywords 使用提供的关键词集合重建指定极性的 ZSET。
 func (s *WorkspaceStore) replaceKeywords(ctx context.Context, pipe redis.Pipeliner, baseKey string, keywords []promptdomain.WorkspaceKeyword, positive bool) error {
 	zsetKey := s.keyPositive(baseKey)
@@ -435,13 +700,13 @@ func (s *WorkspaceStore) enqueueKeyword(ctx context.Context, pipe redis.Pipeline
 	return nil
 }
 
-// readKeywords 读取指定极性的关键词集合，并恢复 payload 信息。
-func (s *WorkspaceStore) readKeywords(ctx context.Context, baseKey string, positive bool) ([]promptdomain.WorkspaceKeyword, error) {
+// readKeywords 读取指定极性的关键词集合，并恢复 payload 信息；cmd 可以是普通客户端或 WATCH 事务。
+func (s *WorkspaceStore) readKeywords(ctx context.Context, cmd redis.Cmdable, baseKey string, positive bool) ([]promptdomain.WorkspaceKeyword, error) {
 	zsetKey := s.keyPositive(baseKey)
 	if !positive {
 		zsetKey = s.keyNegative(baseKey)
 	}
-	members, err := s.client.ZRangeWithScores(ctx, zsetKey, 0, -1).Result()
+	members, err := cmd.ZRangeWithScores(ctx, zsetKey, 0, -1).Result()
 	if err != nil && err != redis.Nil {
 		return nil, fmt.Errorf("read workspace keywords: %w", err)
 	}
@@ -457,7 +722,7 @@ func (s *WorkspaceStore) readKeywords(ctx context.Context, baseKey string, posit
 		}
 		fields = append(fields, fmt.Sprintf("%s|%s", polarity, word))
 	}
-	values, err := s.client.HMGet(ctx, s.keyKeywords(baseKey), fields...).Result()
+	values, err := cmd.HMGet(ctx, s.keyKeywords(baseKey), fields...).Result()
 	if err != nil {
 		return nil, fmt.Errorf("read keyword payloads: %w", err)
 	}
@@ -481,22 +746,28 @@ func (s *WorkspaceStore) readKeywords(ctx context.Context, baseKey string, posit
 }
 
 // touch 刷新工作区所有相关 key 的 TTL。
-func (s *WorkspaceStore) touch(ctx context.Context, baseKey string) error {
+func (s *WorkspaceStore) touch(ctx context.Context, userID uint, token string) error {
 	pipe := s.client.TxPipeline()
-	s.applyTTL(ctx, pipe, baseKey)
+	s.applyTTL(ctx, pipe, userID, token)
 	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("touch workspace ttl: %w", err)
 	}
 	return nil
 }
 
-// applyTTL 批量更新工作区核心 key 的过期时间。
-func (s *WorkspaceStore) applyTTL(ctx context.Context, pipe redis.Pipeliner, baseKey string) {
+// applyTTL 批量更新工作区核心 key 的过期时间，并在用户的工作区索引中记录新的过期时间。
+func (s *WorkspaceStore) applyTTL(ctx context.Context, pipe redis.Pipeliner, userID uint, token string) {
+	baseKey := s.baseKey(userID, token)
+	indexKey := s.keyIndex(userID)
+	pipe.ZAdd(ctx, indexKey, redis.Z{Score: float64(time.Now().Add(s.ttl).Unix()), Member: token})
+	pipe.Expire(ctx, indexKey, s.ttl)
 	keys := []string{
 		baseKey,
 		s.keyPositive(baseKey),
 		s.keyNegative(baseKey),
 		s.keyKeywords(baseKey),
+		s.keyUndo(baseKey),
+		s.keyRedo(baseKey),
 	}
 	for _, key := range keys {
 		pipe.Expire(ctx, key, s.ttl)
@@ -508,6 +779,11 @@ func (s *WorkspaceStore) baseKey(userID uint, token string) string {
 	return fmt.Sprintf("%s:%d:%s", s.prefix, userID, token)
 }
 
+// keyIndex 返回用户工作区索引 ZSET 的 key，成员为 token，分数为过期时间（Unix 秒）。
+func (s *WorkspaceStore) keyIndex(userID uint) string {
+	return fmt.Sprintf("%s:index:%d", s.prefix, userID)
+}
+
 // keyPositive 返回正向关键词 ZSET 的 key。
 func (s *WorkspaceStore) keyPositive(baseKey string) string {
 	return baseKey + ":positive"
@@ -523,6 +799,16 @@ func (s *WorkspaceStore) keyKeywords(baseKey string) string {
 	return baseKey + ":keywords"
 }
 
+// keyUndo 返回撤销栈 List 的 key。
+func (s *WorkspaceStore) keyUndo(baseKey string) string {
+	return baseKey + ":undo"
+}
+
+// keyRedo 返回重做栈 List 的 key。
+func (s *WorkspaceStore) keyRedo(baseKey string) string {
+	return baseKey + ":redo"
+}
+
 // normalizePolarity 规范化极性字段，缺省时视为正向。
 func normalizePolarity(p string) string {
 	if strings.EqualFold(strings.TrimSpace(p), promptdomain.KeywordPolarityNegative) {
@@ -544,8 +830,3 @@ func parseUint(val string) (uint, error) {
 	}
 	return uint(parsed), nil
 }
-
-// touchUpdatedAt 更新工作区 Hash 的最近修改时间。
-func touchUpdatedAt(ctx context.Context, pipe redis.Pipeliner, baseKey string) {
-	pipe.HSet(ctx, baseKey, workspaceFieldUpdatedAt, time.Now().Unix())
-}
@@ -7,90 +7,235 @@
 package security
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 )
 
+const (
+	masterKeyEnv  = "MODEL_CREDENTIAL_MASTER_KEY"
+	legacyKeysEnv = "MODEL_CREDENTIAL_LEGACY_KEYS"
+	// cipherMagic 为版本化密文的前缀，其后依次是 1 字节 key ID 长度、key ID、nonce 与密文。
+	cipherMagic = "PGK1"
+)
+
 var (
-	masterKeyOnce sync.Once
-	masterKey     []byte
-	masterKeyErr  error
+	ErrUnknownKeyID       = errors.New("unknown master key id")
+	ErrCiphertextTooShort = errors.New("ciphertext too short")
 )
 
-const masterKeyEnv = "MODEL_CREDENTIAL_MASTER_KEY"
+var (
+	keyringMu  sync.RWMutex
+	keyring    *masterKeyring
+	keyringErr error
+	keyLoaded  bool
+)
+
+// masterKey 为一把可用的主密钥，id 由密钥内容派生，无需额外配置。
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// masterKeyring 持有当前主密钥与仅用于解密的历史密钥，keys 中主密钥排在首位。
+type masterKeyring struct {
+	primary masterKey
+	keys    []masterKey
+	byID    map[string]masterKey
+}
+
+// KeyID 返回密钥的标识：SHA-256 指纹的前 8 字节（16 位十六进制）。
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// loadKeyring 首次调用时从环境变量加载密钥，之后复用结果。
+func loadKeyring() (*masterKeyring, error) {
+	keyringMu.RLock()
+	if keyLoaded {
+		defer keyringMu.RUnlock()
+		return keyring, keyringErr
+	}
+	keyringMu.RUnlock()
+
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	if !keyLoaded {
+		keyring, keyringErr = readKeyring()
+		keyLoaded = true
+	}
+	return keyring, keyringErr
+}
+
+// ReloadKeys 重新读取主密钥与历史密钥，供轮换命令与测试在修改环境变量后调用。
+func ReloadKeys() error {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	keyring, keyringErr = readKeyring()
+	keyLoaded = true
+	return keyringErr
+}
 
-// loadMasterKey 从环境变量加载 AES-256-GCM 主密钥，要求 base64 编码。
-func loadMasterKey() ([]byte, error) {
-	masterKeyOnce.Do(func() {
-		raw := os.Getenv(masterKeyEnv)
-		if raw == "" {
-			masterKeyErr = fmt.Errorf("%s not configured", masterKeyEnv)
-			return
+// readKeyring 解析 MODEL_CREDENTIAL_MASTER_KEY（主密钥）与 MODEL_CREDENTIAL_LEGACY_KEYS（逗号分隔的历史密钥），均为 base64 编码的 32 字节密钥。
+func readKeyring() (*masterKeyring, error) {
+	raw := strings.TrimSpace(os.Getenv(masterKeyEnv))
+	if raw == "" {
+		return nil, fmt.Errorf("%s not configured", masterKeyEnv)
+	}
+	primary, err := newMasterKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode master key: %w", err)
+	}
+	ring := &masterKeyring{
+		primary: primary,
+		keys:    []masterKey{primary},
+		byID:    map[string]masterKey{primary.id: primary},
+	}
+	for _, item := range strings.Split(os.Getenv(legacyKeysEnv), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
 		}
-		key, err := base64.StdEncoding.DecodeString(raw)
+		legacy, err := newMasterKey(item)
 		if err != nil {
-			masterKeyErr = fmt.Errorf("decode master key: %w", err)
-			return
+			return nil, fmt.Errorf("decode %s: %w", legacyKeysEnv, err)
 		}
-		if len(key) != 32 {
-			masterKeyErr = fmt.Errorf("master key must be 32 bytes, got %d", len(key))
-			return
+		if _, exists := ring.byID[legacy.id]; exists {
+			continue
 		}
-		masterKey = key
-	})
-	return masterKey, masterKeyErr
+		ring.keys = append(ring.keys, legacy)
+		ring.byID[legacy.id] = legacy
+	}
+	return ring, nil
 }
 
-// Encrypt 将明文加密为 nonce|ciphertext 格式的字节切片。
-func Encrypt(plaintext []byte) ([]byte, error) {
-	key, err := loadMasterKey()
+// newMasterKey 解码 base64 密钥并构造 AES-256-GCM。
+func newMasterKey(encoded string) (masterKey, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
-		return nil, err
+		return masterKey{}, err
+	}
+	if len(key) != 32 {
+		return masterKey{}, fmt.Errorf("master key must be 32 bytes, got %d", len(key))
 	}
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return nil, fmt.Errorf("new cipher: %w", err)
+		return masterKey{}, fmt.Errorf("new cipher: %w", err)
 	}
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return nil, fmt.Errorf("new gcm: %w", err)
+		return masterKey{}, fmt.Errorf("new gcm: %w", err)
+	}
+	return masterKey{id: KeyID(key), aead: gcm}, nil
+}
+
+// PrimaryKeyID 返回当前主密钥的标识。
+func PrimaryKeyID() (string, error) {
+	ring, err := loadKeyring()
+	if err != nil {
+		return "", err
 	}
-	nonce := make([]byte, gcm.NonceSize())
+	return ring.primary.id, nil
+}
+
+// Encrypt 使用主密钥加密，输出 magic|len(id)|id|nonce|ciphertext，头部同时作为附加认证数据。
+func Encrypt(plaintext []byte) ([]byte, error) {
+	ring, err := loadKeyring()
+	if err != nil {
+		return nil, err
+	}
+	header := encodeHeader(ring.primary.id)
+	nonce := make([]byte, ring.primary.aead.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, fmt.Errorf("read nonce: %w", err)
 	}
-	sealed := gcm.Seal(nil, nonce, plaintext, nil)
-	return append(nonce, sealed...), nil
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+ring.primary.aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return ring.primary.aead.Seal(out, nonce, plaintext, header), nil
 }
 
-// Decrypt 解析 nonce|ciphertext 并返回明文。
+// Decrypt 按密文头部的 key ID 选择密钥解密；无头部的旧格式（nonce|ciphertext）依次尝试所有密钥。
 func Decrypt(ciphertext []byte) ([]byte, error) {
-	key, err := loadMasterKey()
+	ring, err := loadKeyring()
 	if err != nil {
 		return nil, err
 	}
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, fmt.Errorf("new cipher: %w", err)
+	var versionedErr error
+	if id, header, body, ok := parseHeader(ciphertext); ok {
+		key, found := ring.byID[id]
+		if !found {
+			versionedErr = fmt.Errorf("%w: %s", ErrUnknownKeyID, id)
+		} else if plain, err := open(key.aead, body, header); err != nil {
+			versionedErr = err
+		} else {
+			return plain, nil
+		}
 	}
-	gcm, err := cipher.NewGCM(block)
+	// 旧格式的随机 nonce 极小概率以 magic 开头，头部解析失败时仍按旧格式兜底。
+	var legacyErr error
+	for _, key := range ring.keys {
+		plain, err := open(key.aead, ciphertext, nil)
+		if err == nil {
+			return plain, nil
+		}
+		legacyErr = err
+	}
+	if versionedErr != nil {
+		return nil, versionedErr
+	}
+	return nil, legacyErr
+}
+
+// NeedsRotation 判断密文是否仍为旧格式或由非主密钥加密。
+func NeedsRotation(ciphertext []byte) (bool, error) {
+	ring, err := loadKeyring()
 	if err != nil {
-		return nil, fmt.Errorf("new gcm: %w", err)
+		return false, err
 	}
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return nil, errors.New("ciphertext too short")
+	id, _, _, ok := parseHeader(ciphertext)
+	return !ok || id != ring.primary.id, nil
+}
+
+// encodeHeader 构造版本化密文头部。
+func encodeHeader(id string) []byte {
+	header := make([]byte, 0, len(cipherMagic)+1+len(id))
+	header = append(header, cipherMagic...)
+	header = append(header, byte(len(id)))
+	return append(header, id...)
+}
+
+// parseHeader 拆分版本化密文，返回 key ID、头部与剩余的 nonce|ciphertext。
+func parseHeader(ciphertext []byte) (string, []byte, []byte, bool) {
+	if !bytes.HasPrefix(ciphertext, []byte(cipherMagic)) || len(ciphertext) <= len(cipherMagic) {
+		return "", nil, nil, false
+	}
+	idLen := int(ciphertext[len(cipherMagic)])
+	headerLen := len(cipherMagic) + 1 + idLen
+	if idLen == 0 || len(ciphertext) < headerLen {
+		return "", nil, nil, false
+	}
+	return string(ciphertext[len(cipherMagic)+1 : headerLen]), ciphertext[:headerLen], ciphertext[headerLen:], true
+}
+
+// open 解析 nonce|ciphertext 并返回明文。
+func open(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, ErrCiphertextTooShort
 	}
-	nonce := ciphertext[:nonceSize]
-	data := ciphertext[nonceSize:]
-	plain, err := gcm.Open(nil, nonce, data, nil)
+	plain, err := aead.Open(nil, data[:nonceSize], data[nonceSize:], additional)
 	if err != nil {
 		return nil, fmt.Errorf("decrypt: %w", err)
 	}
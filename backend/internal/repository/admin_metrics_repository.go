@@ -58,7 +58,7 @@ func (r *AdminMetricsRepository) UpsertDaily(ctx context.Context, record adminme
 	return r.db.WithContext(ctx).
 		Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "metrics_date"}},
-			DoUpdates: clause.AssignmentColumns([]string{"active_users", "generate_total", "generate_success", "generate_success_rate", "average_latency_ms", "save_total"}),
+			DoUpdates: clause.AssignmentColumns([]string{"active_users", "generate_total", "generate_success", "generate_success_rate", "average_latency_ms", "save_total", "generate_cost"}),
 		}).
 		Create(&record).Error
 }
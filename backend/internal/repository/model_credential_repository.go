@@ -113,6 +113,71 @@ func (r *ModelCredentialRepository) UpdateStatus(ctx context.Context, userID, id
 	return nil
 }
 
+// ListAfterID 按主键升序分批读取所有用户的凭据，供主密钥轮换等批处理使用。
+func (r *ModelCredentialRepository) ListAfterID(ctx context.Context, afterID uint, limit int) ([]user.UserModelCredential, error) {
+	var credentials []user.UserModelCredential
+	err := r.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// ListEnabledAfterID 按主键升序分批读取启用中的凭据，excludeProviders 中的提供方会被跳过，供健康检查等后台任务使用。
+func (r *ModelCredentialRepository) ListEnabledAfterID(ctx context.Context, afterID uint, limit int, excludeProviders ...string) ([]user.UserModelCredential, error) {
+	var credentials []user.UserModelCredential
+	query := r.db.WithContext(ctx).
+		Where("id > ? AND status = ?", afterID, "enabled")
+	if len(excludeProviders) > 0 {
+		query = query.Where("provider NOT IN ?", excludeProviders)
+	}
+	err := query.
+		Order("id ASC").
+		Limit(limit).
+		Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// UpdateHealth 仅写回健康检查相关字段与最近验证时间，避免覆盖用户并发修改的其它配置。
+func (r *ModelCredentialRepository) UpdateHealth(ctx context.Context, credential *user.UserModelCredential) error {
+	return r.db.WithContext(ctx).
+		Model(&user.UserModelCredential{ID: credential.ID}).
+		Select("health_status", "health_checked_at", "health_latency_ms", "health_error", "health_failures", "last_verified_at").
+		UpdateColumns(credential).Error
+}
+
+// UpdateHealthIfUnchanged 仅当凭据自读取后未被修改（updated_at 未变）时写回健康字段，返回是否更新成功。
+func (r *ModelCredentialRepository) UpdateHealthIfUnchanged(ctx context.Context, credential *user.UserModelCredential) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&user.UserModelCredential{}).
+		Where("id = ? AND updated_at = ?", credential.ID, credential.UpdatedAt).
+		Select("health_status", "health_checked_at", "health_latency_ms", "health_error", "health_failures", "last_verified_at").
+		UpdateColumns(credential)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReplaceAPIKeyCipher 仅当密文未被并发修改时替换为新密文，返回是否更新成功。
+func (r *ModelCredentialRepository) ReplaceAPIKeyCipher(ctx context.Context, id uint, oldCipher, newCipher []byte) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&user.UserModelCredential{}).
+		Where("id = ? AND api_key_cipher = ?", id, oldCipher).
+		UpdateColumn("api_key_cipher", newCipher)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // WithTransaction 在事务中执行操作。
 func (r *ModelCredentialRepository) WithTransaction(ctx context.Context, fn func(txRepo *ModelCredentialRepository) error) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"electron-go-app/backend/internal/domain/modelcache"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// responseCachePurgeInterval 控制过期缓存的批量清理频率，读取时命中的过期条目会立即删除。
+const responseCachePurgeInterval = 10 * time.Minute
+
+// ModelResponseCacheRepository 基于数据库实现模型响应缓存，供本地 SQLite 模式使用。
+type ModelResponseCacheRepository struct {
+	db        *gorm.DB
+	lastPurge atomic.Int64
+}
+
+// NewModelResponseCacheRepository 构造响应缓存仓储。
+func NewModelResponseCacheRepository(db *gorm.DB) *ModelResponseCacheRepository {
+	if db == nil {
+		return nil
+	}
+	return &ModelResponseCacheRepository{db: db}
+}
+
+// Get 读取未过期的缓存内容，不存在或已过期时返回 false。
+func (r *ModelResponseCacheRepository) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if r == nil || r.db == nil {
+		return nil, false, nil
+	}
+	var entry modelcache.Entry
+	err := r.db.WithContext(ctx).Where("cache_key = ?", key).Take(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if !entry.ExpiresAt.After(time.Now()) {
+		if err := r.db.WithContext(ctx).Where("cache_key = ?", key).Delete(&modelcache.Entry{}).Error; err != nil {
+			return nil, false, err
+		}
+		return nil, false, nil
+	}
+	return []byte(entry.Payload), true, nil
+}
+
+// Set 写入或覆盖缓存内容，并按需清理已过期的条目。
+func (r *ModelResponseCacheRepository) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if r == nil || r.db == nil {
+		return nil
+	}
+	now := time.Now()
+	entry := modelcache.Entry{
+		CacheKey:  key,
+		Payload:   string(value),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cache_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"payload", "expires_at", "created_at"}),
+		}).
+		Create(&entry).Error
+	if err != nil {
+		return err
+	}
+	last := r.lastPurge.Load()
+	if now.UnixNano()-last < int64(responseCachePurgeInterval) || !r.lastPurge.CompareAndSwap(last, now.UnixNano()) {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&modelcache.Entry{}).Error
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"electron-go-app/backend/internal/domain/modelusage"
+
+	"gorm.io/gorm"
+)
+
+// ModelUsageRepository 负责模型调用用量流水的写入与查询。
+type ModelUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewModelUsageRepository 构造用量流水仓储。
+func NewModelUsageRepository(db *gorm.DB) *ModelUsageRepository {
+	if db == nil {
+		return nil
+	}
+	return &ModelUsageRepository{db: db}
+}
+
+// Append 写入一条用量流水。
+func (r *ModelUsageRepository) Append(ctx context.Context, record *modelusage.Record) error {
+	if r == nil || r.db == nil {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(record).Error
+}
+
+// ListByCredential 按时间范围读取指定凭据的用量流水，since 为闭区间、until 为开区间。
+func (r *ModelUsageRepository) ListByCredential(ctx context.Context, userID, credentialID uint, since, until time.Time) ([]modelusage.Record, error) {
+	if r == nil || r.db == nil {
+		return nil, nil
+	}
+	var records []modelusage.Record
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND credential_id = ? AND created_at >= ? AND created_at < ?", userID, credentialID, since, until).
+		Order("created_at ASC").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SumCostSince 汇总凭据自 since 起的估算费用。
+func (r *ModelUsageRepository) SumCostSince(ctx context.Context, credentialID uint, since time.Time) (float64, error) {
+	if r == nil || r.db == nil {
+		return 0, nil
+	}
+	var total float64
+	err := r.db.WithContext(ctx).
+		Model(&modelusage.Record{}).
+		Where("credential_id = ? AND created_at >= ?", credentialID, since).
+		Select("COALESCE(SUM(estimated_cost), 0)").
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// SumCostByCredentialSince 按凭据汇总用户自 since 起的估算费用。
+func (r *ModelUsageRepository) SumCostByCredentialSince(ctx context.Context, userID uint, since time.Time) (map[uint]float64, error) {
+	if r == nil || r.db == nil {
+		return nil, nil
+	}
+	var rows []struct {
+		CredentialID uint
+		Total        float64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&modelusage.Record{}).
+		Select("credential_id, COALESCE(SUM(estimated_cost), 0) AS total").
+		Where("user_id = ? AND credential_id IS NOT NULL AND created_at >= ?", userID, since).
+		Group("credential_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		totals[row.CredentialID] = row.Total
+	}
+	return totals, nil
+}
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// persistenceTaskPollInterval 控制队列为空时的轮询间隔，用于及时取出到期的重试任务。
+const persistenceTaskPollInterval = time.Second
+
+// PersistenceTaskRepository 基于数据库实现异步落库队列，供本地 SQLite 模式替代 Redis Stream 使用。
+// 任务按入队顺序出队，Ack 后删除；失败的任务按退避时间重新排队，用尽次数后标记为 dead 保留在表中。
+type PersistenceTaskRepository struct {
+	db        *gorm.DB
+	retry     promptdomain.PersistenceRetryPolicy
+	startedAt time.Time
+	notify    chan struct{}
+}
+
+// NewPersistenceTaskRepository 构造本地落库队列。
+func NewPersistenceTaskRepository(db *gorm.DB, retry promptdomain.PersistenceRetryPolicy) *PersistenceTaskRepository {
+	if db == nil {
+		return nil
+	}
+	return &PersistenceTaskRepository{db: db, retry: retry, startedAt: time.Now(), notify: make(chan struct{}, 1)}
+}
+
+// Enqueue 将任务编码后写入队列表末尾，并唤醒等待中的消费者。
+func (r *PersistenceTaskRepository) Enqueue(ctx context.Context, task promptdomain.PersistenceTask) (string, error) {
+	if r == nil || r.db == nil {
+		return "", fmt.Errorf("persistence queue not initialised")
+	}
+	if task.TaskID == "" {
+		task.TaskID = uuid.NewString()
+	}
+	if task.RequestedAt.IsZero() {
+		task.RequestedAt = time.Now()
+	}
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("encode persistence task: %w", err)
+	}
+	record := promptdomain.PersistenceTaskRecord{
+		TaskID:      task.TaskID,
+		Payload:     string(payload),
+		State:       promptdomain.PersistenceTaskQueued,
+		AvailableAt: task.RequestedAt,
+		CreatedAt:   task.RequestedAt,
+	}
+	if err := r.db.WithContext(ctx).Create(&record).Error; err != nil {
+		return "", fmt.Errorf("enqueue persistence task: %w", err)
+	}
+	r.wake()
+	return task.TaskID, nil
+}
+
+// BlockingPop 取出最早的可处理任务并标记为 processing，队列为空时等待；timeout 为 0 表示一直等待，超时返回 redis.Nil 与 Redis 实现保持一致。
+func (r *PersistenceTaskRepository) BlockingPop(ctx context.Context, timeout time.Duration) (promptdomain.PersistenceTask, error) {
+	if r == nil || r.db == nil {
+		return promptdomain.PersistenceTask{}, fmt.Errorf("persistence queue not initialised")
+	}
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	poll := time.NewTicker(persistenceTaskPollInterval)
+	defer poll.Stop()
+	for {
+		record, err := r.claim(ctx)
+		if err != nil {
+			return promptdomain.PersistenceTask{}, err
+		}
+		if record != nil {
+			var task promptdomain.PersistenceTask
+			if err := json.Unmarshal([]byte(record.Payload), &task); err != nil {
+				// 无法解码的任务无法重试，直接标记为 dead，避免反复被取出。
+				r.db.WithContext(ctx).Model(record).UpdateColumn("state", promptdomain.PersistenceTaskDead)
+				return promptdomain.PersistenceTask{}, fmt.Errorf("decode persistence task: %w", err)
+			}
+			task.Receipt = strconv.FormatUint(uint64(record.ID), 10)
+			return task, nil
+		}
+		select {
+		case <-ctx.Done():
+			return promptdomain.PersistenceTask{}, ctx.Err()
+		case <-deadline:
+			return promptdomain.PersistenceTask{}, redis.Nil
+		case <-r.notify:
+		case <-poll.C:
+		}
+	}
+}
+
+// Ack 确认任务处理成功并删除。
+func (r *PersistenceTaskRepository) Ack(ctx context.Context, task promptdomain.PersistenceTask) error {
+	if r == nil || r.db == nil {
+		return fmt.Errorf("persistence queue not initialised")
+	}
+	id, err := parseTaskReceipt(task.Receipt)
+	if err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Delete(&promptdomain.PersistenceTaskRecord{}, id).Error; err != nil {
+		return fmt.Errorf("ack persistence task: %w", err)
+	}
+	return nil
+}
+
+// Retry 记录一次失败：未用尽次数时按退避时间重新排队，否则标记为 dead；返回任务是否已进入死信。
+func (r *PersistenceTaskRepository) Retry(ctx context.Context, task promptdomain.PersistenceTask, cause error) (bool, error) {
+	if r == nil || r.db == nil {
+		return false, fmt.Errorf("persistence queue not initialised")
+	}
+	id, err := parseTaskReceipt(task.Receipt)
+	if err != nil {
+		return false, err
+	}
+	task.Attempts++
+	if cause != nil {
+		task.LastError = cause.Error()
+	}
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return false, fmt.Errorf("encode persistence task: %w", err)
+	}
+	dead := r.retry.Exhausted(task.Attempts)
+	updates := map[string]any{
+		"payload":    string(payload),
+		"state":      promptdomain.PersistenceTaskQueued,
+		"claimed_at": nil,
+	}
+	if dead {
+		updates["state"] = promptdomain.PersistenceTaskDead
+	} else {
+		updates["available_at"] = time.Now().Add(r.retry.Backoff(task.Attempts))
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&promptdomain.PersistenceTaskRecord{}).
+		Where("id = ?", id).
+		Updates(updates).Error; err != nil {
+		return false, fmt.Errorf("retry persistence task: %w", err)
+	}
+	return dead, nil
+}
+
+// Reclaim 将上一个进程取出但未确认的任务重新排队。本地模式只有一个进程访问数据库，
+// 因此启动前被取出的任务都视为崩溃遗留：每次回收计为一次失败，用尽重试次数后与 Retry 一样标记为 dead。
+// 返回回收的任务数量。
+func (r *PersistenceTaskRepository) Reclaim(ctx context.Context) (int, error) {
+	if r == nil || r.db == nil {
+		return 0, fmt.Errorf("persistence queue not initialised")
+	}
+	var records []promptdomain.PersistenceTaskRecord
+	if err := r.db.WithContext(ctx).
+		Where("state = ? AND claimed_at < ?", promptdomain.PersistenceTaskProcessing, r.startedAt).
+		Find(&records).Error; err != nil {
+		return 0, fmt.Errorf("reclaim persistence tasks: %w", err)
+	}
+	reclaimed, requeued := 0, 0
+	for _, record := range records {
+		updates := map[string]any{"state": promptdomain.PersistenceTaskQueued, "claimed_at": nil}
+		var task promptdomain.PersistenceTask
+		if err := json.Unmarshal([]byte(record.Payload), &task); err != nil {
+			updates["state"] = promptdomain.PersistenceTaskDead
+		} else {
+			task.Attempts++
+			task.LastError = promptdomain.ErrPersistenceTaskStalled.Error()
+			if payload, err := json.Marshal(task); err == nil {
+				updates["payload"] = string(payload)
+			}
+			if r.retry.Exhausted(task.Attempts) {
+				updates["state"] = promptdomain.PersistenceTaskDead
+			}
+		}
+		result := r.db.WithContext(ctx).
+			Model(&promptdomain.PersistenceTaskRecord{}).
+			Where("id = ? AND state = ?", record.ID, promptdomain.PersistenceTaskProcessing).
+			Updates(updates)
+		if result.Error != nil {
+			return reclaimed, fmt.Errorf("reclaim persistence tasks: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		reclaimed++
+		if updates["state"] == promptdomain.PersistenceTaskQueued {
+			requeued++
+		}
+	}
+	if requeued > 0 {
+		r.wake()
+	}
+	return reclaimed, nil
+}
+
+// claim 取出最早的到期任务并标记为 processing，队列为空时返回 nil。
+func (r *PersistenceTaskRepository) claim(ctx context.Context) (*promptdomain.PersistenceTaskRecord, error) {
+	var record promptdomain.PersistenceTaskRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Where("state = ? AND available_at <= ?", promptdomain.PersistenceTaskQueued, now).
+			Order("id ASC").
+			Take(&record).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&promptdomain.PersistenceTaskRecord{}).
+			Where("id = ? AND state = ?", record.ID, promptdomain.PersistenceTaskQueued).
+			Updates(map[string]any{"state": promptdomain.PersistenceTaskProcessing, "claimed_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pop persistence task: %w", err)
+	}
+	return &record, nil
+}
+
+// wake 唤醒等待中的消费者。
+func (r *PersistenceTaskRepository) wake() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// parseTaskReceipt 将出队回执解析为队列表主键。
+func parseTaskReceipt(receipt string) (uint, error) {
+	id, err := strconv.ParseUint(receipt, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid persistence task receipt %q", receipt)
+	}
+	return uint(id), nil
+}
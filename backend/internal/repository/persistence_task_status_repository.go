@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultLocalTaskStatusTTL 与 Redis 任务状态的默认保留时间保持一致。
+const defaultLocalTaskStatusTTL = 24 * time.Hour
+
+// PersistenceTaskStatusRepository 基于数据库记录异步落库任务的处理状态，供本地 SQLite 模式替代 Redis 使用。
+// 语义与 infra/prompt.TaskStatusStore 保持一致：任务不存在或已过期时 Get 返回 redis.Nil。
+type PersistenceTaskStatusRepository struct {
+	db        *gorm.DB
+	ttl       time.Duration
+	lastPurge atomic.Int64
+}
+
+// NewPersistenceTaskStatusRepository 构造本地任务状态仓储，ttl 非正数时使用默认值。
+func NewPersistenceTaskStatusRepository(db *gorm.DB, ttl time.Duration) *PersistenceTaskStatusRepository {
+	if db == nil {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = defaultLocalTaskStatusTTL
+	}
+	return &PersistenceTaskStatusRepository{db: db, ttl: ttl}
+}
+
+// Save 覆盖写入任务状态并刷新过期时间。
+func (r *PersistenceTaskStatusRepository) Save(ctx context.Context, status promptdomain.PersistenceTaskStatus) error {
+	if r == nil || r.db == nil {
+		return fmt.Errorf("task status store not initialised")
+	}
+	if strings.TrimSpace(status.TaskID) == "" {
+		return fmt.Errorf("task id required")
+	}
+	now := time.Now()
+	record := promptdomain.PersistenceTaskStatusRecord{
+		TaskID:         status.TaskID,
+		UserID:         status.UserID,
+		State:          status.State,
+		Attempts:       status.Attempts,
+		Error:          status.Error,
+		PromptID:       status.PromptID,
+		Version:        status.Version,
+		Status:         status.Status,
+		WorkspaceToken: status.WorkspaceToken,
+		CreatedAt:      status.CreatedAt,
+		UpdatedAt:      status.UpdatedAt,
+		ExpiresAt:      now.Add(r.ttl),
+	}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&record).Error; err != nil {
+		return fmt.Errorf("save task status: %w", err)
+	}
+	r.purgeExpired(ctx, now)
+	return nil
+}
+
+// Get 读取任务状态，不存在或已过期时返回 redis.Nil。
+func (r *PersistenceTaskStatusRepository) Get(ctx context.Context, taskID string) (promptdomain.PersistenceTaskStatus, error) {
+	if r == nil || r.db == nil {
+		return promptdomain.PersistenceTaskStatus{}, fmt.Errorf("task status store not initialised")
+	}
+	var record promptdomain.PersistenceTaskStatusRecord
+	err := r.db.WithContext(ctx).
+		Where("task_id = ? AND expires_at > ?", strings.TrimSpace(taskID), time.Now()).
+		Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return promptdomain.PersistenceTaskStatus{}, redis.Nil
+	}
+	if err != nil {
+		return promptdomain.PersistenceTaskStatus{}, fmt.Errorf("load task status: %w", err)
+	}
+	return promptdomain.PersistenceTaskStatus{
+		TaskID:         record.TaskID,
+		UserID:         record.UserID,
+		State:          record.State,
+		Attempts:       record.Attempts,
+		Error:          record.Error,
+		PromptID:       record.PromptID,
+		Version:        record.Version,
+		Status:         record.Status,
+		WorkspaceToken: record.WorkspaceToken,
+		CreatedAt:      record.CreatedAt,
+		UpdatedAt:      record.UpdatedAt,
+	}, nil
+}
+
+// purgeExpired 按固定频率清理过期的任务状态，清理失败不影响本次写入。
+func (r *PersistenceTaskStatusRepository) purgeExpired(ctx context.Context, now time.Time) {
+	last := r.lastPurge.Load()
+	if now.UnixNano()-last < int64(workspacePurgeInterval) || !r.lastPurge.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	_ = r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&promptdomain.PersistenceTaskStatusRecord{}).Error
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+
+	"gorm.io/gorm"
+)
+
+// PromptRunRepository 负责 Prompt 试运行记录的写入与查询。
+type PromptRunRepository struct {
+	db *gorm.DB
+}
+
+// NewPromptRunRepository 构造试运行记录仓储。
+func NewPromptRunRepository(db *gorm.DB) *PromptRunRepository {
+	return &PromptRunRepository{db: db}
+}
+
+// PromptRunListFilter 描述试运行记录的查询条件，VersionNo 为空时返回全部版本。
+type PromptRunListFilter struct {
+	VersionNo *int
+	Limit     int
+	Offset    int
+}
+
+// Create 写入一条试运行记录。
+func (r *PromptRunRepository) Create(ctx context.Context, run *promptdomain.PromptRun) error {
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		return fmt.Errorf("create prompt run: %w", err)
+	}
+	return nil
+}
+
+// ListByPrompt 按时间倒序分页返回指定 Prompt 的试运行记录以及总数。
+func (r *PromptRunRepository) ListByPrompt(ctx context.Context, promptID uint, filter PromptRunListFilter) ([]promptdomain.PromptRun, int64, error) {
+	query := r.db.WithContext(ctx).Model(&promptdomain.PromptRun{}).Where("prompt_id = ?", promptID)
+	if filter.VersionNo != nil {
+		query = query.Where("version_no = ?", *filter.VersionNo)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("count prompt runs: %w", err)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	var runs []promptdomain.PromptRun
+	if err := query.Order("created_at DESC").Order("id DESC").Find(&runs).Error; err != nil {
+		return nil, 0, fmt.Errorf("list prompt runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+// DeleteByPrompt 删除指定 Prompt 的全部试运行记录。
+func (r *PromptRunRepository) DeleteByPrompt(ctx context.Context, promptID uint) error {
+	if err := r.db.WithContext(ctx).Where("prompt_id = ?", promptID).Delete(&promptdomain.PromptRun{}).Error; err != nil {
+		return fmt.Errorf("delete prompt runs: %w", err)
+	}
+	return nil
+}
+
+// DeleteByUser 删除指定用户的全部试运行记录，配合导入覆盖模式清空 Prompt 时使用。
+func (r *PromptRunRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&promptdomain.PromptRun{}).Error; err != nil {
+		return fmt.Errorf("delete prompt runs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,616 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultLocalWorkspaceTTL 与 Redis 工作区的默认 TTL 保持一致。
+	defaultLocalWorkspaceTTL = 45 * time.Minute
+	// workspacePurgeInterval 控制过期工作区的批量清理频率，读取时过期的工作区直接视为不存在。
+	workspacePurgeInterval = 10 * time.Minute
+)
+
+// PromptWorkspaceRepository 基于数据库实现 Prompt 工作区，供本地 SQLite 模式替代 Redis 使用。
+// 语义与 infra/prompt.WorkspaceStore 保持一致：工作区不存在或已过期时 Snapshot 返回 redis.Nil，写操作会像 HSET 一样重新创建。
+type PromptWorkspaceRepository struct {
+	db        *gorm.DB
+	ttl       time.Duration
+	lastPurge atomic.Int64
+}
+
+// NewPromptWorkspaceRepository 构造本地工作区仓储，ttl 非正数时使用默认值。
+func NewPromptWorkspaceRepository(db *gorm.DB, ttl time.Duration) *PromptWorkspaceRepository {
+	if db == nil {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = defaultLocalWorkspaceTTL
+	}
+	return &PromptWorkspaceRepository{db: db, ttl: ttl}
+}
+
+// CreateOrReplace 创建新的工作区或覆盖已有工作区，返回最终的 workspace token。
+func (r *PromptWorkspaceRepository) CreateOrReplace(ctx context.Context, userID uint, snapshot promptdomain.WorkspaceSnapshot) (string, error) {
+	if r == nil || r.db == nil {
+		return "", fmt.Errorf("workspace store not initialised")
+	}
+	token := strings.TrimSpace(snapshot.Token)
+	if token == "" {
+		token = uuid.NewString()
+	}
+	if snapshot.UpdatedAt.IsZero() {
+		snapshot.UpdatedAt = time.Now()
+	}
+	var attributes string
+	if len(snapshot.Attributes) > 0 {
+		raw, err := json.Marshal(snapshot.Attributes)
+		if err != nil {
+			return "", fmt.Errorf("encode workspace attributes: %w", err)
+		}
+		attributes = string(raw)
+	}
+	_, err := r.mutate(ctx, userID, token, promptdomain.WorkspaceAnyVersion, false, "", func(tx *gorm.DB, record *promptdomain.WorkspaceRecord) (bool, error) {
+		record.Topic = strings.TrimSpace(snapshot.Topic)
+		record.Language = strings.TrimSpace(snapshot.Language)
+		record.ModelKey = strings.TrimSpace(snapshot.ModelKey)
+		record.DraftBody = snapshot.DraftBody
+		record.Version = snapshot.Version
+		record.PromptID = snapshot.PromptID
+		record.UpdatedAt = snapshot.UpdatedAt
+		if status := strings.TrimSpace(snapshot.Status); status != "" {
+			record.Status = status
+		}
+		if attributes != "" {
+			record.Attributes = attributes
+		}
+		if err := tx.Where("user_id = ? AND token = ?", userID, token).
+			Delete(&promptdomain.WorkspaceKeywordRecord{}).Error; err != nil {
+			return false, err
+		}
+		// 覆盖工作区时旧的撤销/重做记录已不再适用，一并清空。
+		if err := tx.Where("user_id = ? AND token = ?", userID, token).
+			Delete(&promptdomain.WorkspaceHistoryRecord{}).Error; err != nil {
+			return false, err
+		}
+		keywords := append(append([]promptdomain.WorkspaceKeyword{}, snapshot.Positive...), snapshot.Negative...)
+		return true, upsertWorkspaceKeywords(tx, userID, token, keywords)
+	})
+	if err != nil {
+		return "", fmt.Errorf("store workspace snapshot: %w", err)
+	}
+	return token, nil
+}
+
+// MergeKeywords 将关键词合并到现有工作区中（存在则覆盖来源/权重信息），返回写入后的版本号。
+func (r *PromptWorkspaceRepository) MergeKeywords(ctx context.Context, userID uint, token string, expectedVersion int64, keywords []promptdomain.WorkspaceKeyword) (int64, error) {
+	if r == nil || r.db == nil {
+		return 0, fmt.Errorf("workspace store not initialised")
+	}
+	if len(keywords) == 0 {
+		return 0, nil
+	}
+	version, err := r.mutate(ctx, userID, token, expectedVersion, true, promptdomain.WorkspaceOpMergeKeywords, func(tx *gorm.DB, record *promptdomain.WorkspaceRecord) (bool, error) {
+		record.UpdatedAt = time.Now()
+		return true, upsertWorkspaceKeywords(tx, userID, token, keywords)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("merge keywords: %w", err)
+	}
+	return version, nil
+}
+
+// ReplaceKeywords 用给定的正/负向关键词整体替换工作区关键词，返回写入后的版本号。
+func (r *PromptWorkspaceRepository) ReplaceKeywords(ctx context.Context, userID uint, token string, expectedVersion int64, positive, negative []promptdomain.WorkspaceKeyword) (int64, error) {
+	if r == nil || r.db == nil {
+		return 0, fmt.Errorf("workspace store not initialised")
+	}
+	version, err := r.mutate(ctx, userID, token, expectedVersion, true, promptdomain.WorkspaceOpReplaceKeywords, func(tx *gorm.DB, record *promptdomain.WorkspaceRecord) (bool, error) {
+		record.UpdatedAt = time.Now()
+		if err := tx.Where("user_id = ? AND token = ?", userID, token).
+			Delete(&promptdomain.WorkspaceKeywordRecord{}).Error; err != nil {
+			return false, err
+		}
+		keywords := append(append([]promptdomain.WorkspaceKeyword{}, positive...), negative...)
+		return true, upsertWorkspaceKeywords(tx, userID, token, keywords)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("replace workspace keywords: %w", err)
+	}
+	return version, nil
+}
+
+// RemoveKeyword 将指定关键词从工作区移除，返回写入后的版本号。
+func (r *PromptWorkspaceRepository) RemoveKeyword(ctx context.Context, userID uint, token string, expectedVersion int64, polarity, word string) (int64, error) {
+	if r == nil || r.db == nil {
+		return 0, fmt.Errorf("workspace store not initialised")
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return 0, errors.New("workspace token is empty")
+	}
+	lowered := strings.ToLower(strings.TrimSpace(word))
+	if lowered == "" {
+		return 0, nil
+	}
+	version, err := r.mutate(ctx, userID, token, expectedVersion, true, promptdomain.WorkspaceOpRemoveKeyword, func(tx *gorm.DB, record *promptdomain.WorkspaceRecord) (bool, error) {
+		record.UpdatedAt = time.Now()
+		return true, tx.Where("user_id = ? AND token = ? AND polarity = ? AND word = ?", userID, token, normalizeWorkspacePolarity(polarity), lowered).
+			Delete(&promptdomain.WorkspaceKeywordRecord{}).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("remove workspace keyword: %w", err)
+	}
+	return version, nil
+}
+
+// UpdateDraftBody 更新草稿正文，并刷新更新时间，返回写入后的版本号。
+func (r *PromptWorkspaceRepository) UpdateDraftBody(ctx context.Context, userID uint, token string, expectedVersion int64, body string) (int64, error) {
+	return r.UpdateDraft(ctx, userID, token, expectedVersion, body, nil)
+}
+
+// UpdateDraft 在同一次写入中替换草稿正文并合并 attributes，只产生一条撤销记录，返回写入后的版本号。
+func (r *PromptWorkspaceRepository) UpdateDraft(ctx context.Context, userID uint, token string, expectedVersion int64, body string, attrs map[string]string) (int64, error) {
+	if r == nil || r.db == nil {
+		return 0, fmt.Errorf("workspace store not initialised")
+	}
+	version, err := r.mutate(ctx, userID, token, expectedVersion, true, promptdomain.WorkspaceOpUpdateDraft, func(_ *gorm.DB, record *promptdomain.WorkspaceRecord) (bool, error) {
+		encoded, _, err := promptdomain.MergeWorkspaceAttributes(record.Attributes, attrs)
+		if err != nil {
+			return false, err
+		}
+		record.DraftBody = body
+		record.Attributes = encoded
+		record.UpdatedAt = time.Now()
+		return true, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("update workspace draft: %w", err)
+	}
+	return version, nil
+}
+
+// SetAttributes 合并写入工作区的 attributes 字段，值为空表示删除对应属性；合并结果与原值相同时不写入，版本号保持不变。
+func (r *PromptWorkspaceRepository) SetAttributes(ctx context.Context, userID uint, token string, expectedVersion int64, attrs map[string]string) (int64, error) {
+	if r == nil || r.db == nil {
+		return 0, fmt.Errorf("workspace store not initialised")
+	}
+	if len(attrs) == 0 {
+		return 0, nil
+	}
+	version, err := r.mutate(ctx, userID, token, expectedVersion, true, promptdomain.WorkspaceOpSetAttributes, func(_ *gorm.DB, record *promptdomain.WorkspaceRecord) (bool, error) {
+		encoded, changed, err := promptdomain.MergeWorkspaceAttributes(record.Attributes, attrs)
+		if err != nil || !changed {
+			return false, err
+		}
+		record.Attributes = encoded
+		record.UpdatedAt = time.Now()
+		return true, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("set workspace attributes: %w", err)
+	}
+	return version, nil
+}
+
+// Undo 撤销最近一次内容修改并把当前内容压入重做栈，返回写入后的版本号；没有可撤销的操作时返回 ErrWorkspaceHistoryEmpty。
+func (r *PromptWorkspaceRepository) Undo(ctx context.Context, userID uint, token string, expectedVersion int64) (int64, error) {
+	return r.travel(ctx, userID, token, expectedVersion, promptdomain.WorkspaceOpUndo)
+}
+
+// Redo 重做最近一次被撤销的修改并把当前内容压回撤销栈，返回写入后的版本号；没有可重做的操作时返回 ErrWorkspaceHistoryEmpty。
+func (r *PromptWorkspaceRepository) Redo(ctx context.Context, userID uint, token string, expectedVersion int64) (int64, error) {
+	return r.travel(ctx, userID, token, expectedVersion, promptdomain.WorkspaceOpRedo)
+}
+
+// Touch 刷新工作区的过期时间，工作区已过期时不做任何处理。
+func (r *PromptWorkspaceRepository) Touch(ctx context.Context, userID uint, token string) error {
+	if r == nil || r.db == nil {
+		return fmt.Errorf("workspace store not initialised")
+	}
+	now := time.Now()
+	err := r.db.WithContext(ctx).
+		Model(&promptdomain.WorkspaceRecord{}).
+		Where("user_id = ? AND token = ? AND expires_at > ?", userID, token, now).
+		UpdateColumn("expires_at", now.Add(r.ttl)).Error
+	if err != nil {
+		return fmt.Errorf("touch workspace ttl: %w", err)
+	}
+	return nil
+}
+
+// Snapshot 读取完整工作区数据，不存在或已过期时返回 redis.Nil。
+func (r *PromptWorkspaceRepository) Snapshot(ctx context.Context, userID uint, token string) (promptdomain.WorkspaceSnapshot, error) {
+	if r == nil || r.db == nil {
+		return promptdomain.WorkspaceSnapshot{}, fmt.Errorf("workspace store not initialised")
+	}
+	record, err := r.findLive(r.db.WithContext(ctx), userID, token)
+	if err != nil {
+		return promptdomain.WorkspaceSnapshot{}, fmt.Errorf("load workspace: %w", err)
+	}
+	if record == nil {
+		return promptdomain.WorkspaceSnapshot{}, redis.Nil
+	}
+	snapshot := promptdomain.WorkspaceSnapshot{
+		Token:     token,
+		UserID:    userID,
+		Topic:     record.Topic,
+		Language:  record.Language,
+		ModelKey:  record.ModelKey,
+		DraftBody: record.DraftBody,
+		PromptID:  record.PromptID,
+		Status:    record.Status,
+		Version:   record.Version,
+		UpdatedAt: record.UpdatedAt,
+	}
+	if record.Attributes != "" {
+		var attrs map[string]string
+		if decodeErr := json.Unmarshal([]byte(record.Attributes), &attrs); decodeErr == nil {
+			snapshot.Attributes = attrs
+		}
+	}
+
+	snapshot.Positive, snapshot.Negative, err = loadWorkspaceKeywords(r.db.WithContext(ctx), userID, token)
+	if err != nil {
+		return promptdomain.WorkspaceSnapshot{}, fmt.Errorf("read workspace keywords: %w", err)
+	}
+	return snapshot, nil
+}
+
+// List 返回用户仍在有效期内的工作区摘要，按更新时间倒序。
+func (r *PromptWorkspaceRepository) List(ctx context.Context, userID uint) ([]promptdomain.WorkspaceSummary, error) {
+	if r == nil || r.db == nil {
+		return nil, fmt.Errorf("workspace store not initialised")
+	}
+	var records []promptdomain.WorkspaceRecord
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("updated_at DESC").
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("list workspaces: %w", err)
+	}
+	summaries := make([]promptdomain.WorkspaceSummary, 0, len(records))
+	for _, record := range records {
+		summaries = append(summaries, promptdomain.WorkspaceSummary{
+			Token:        record.Token,
+			Topic:        record.Topic,
+			PromptID:     record.PromptID,
+			Status:       record.Status,
+			DraftPreview: promptdomain.WorkspaceDraftPreview(record.DraftBody),
+			Version:      record.Version,
+			UpdatedAt:    record.UpdatedAt,
+			ExpiresAt:    record.ExpiresAt,
+		})
+	}
+	return summaries, nil
+}
+
+// Delete 移除整个工作区。
+func (r *PromptWorkspaceRepository) Delete(ctx context.Context, userID uint, token string) error {
+	if r == nil || r.db == nil {
+		return fmt.Errorf("workspace store not initialised")
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteWorkspace(tx, userID, token)
+	})
+	if err != nil {
+		return fmt.Errorf("delete workspace: %w", err)
+	}
+	return nil
+}
+
+// SetPromptMeta 将 prompt_id/status 等元信息写入工作区。
+func (r *PromptWorkspaceRepository) SetPromptMeta(ctx context.Context, userID uint, token string, promptID uint, status string) error {
+	if r == nil || r.db == nil {
+		return fmt.Errorf("workspace store not initialised")
+	}
+	_, err := r.mutate(ctx, userID, token, promptdomain.WorkspaceAnyVersion, false, "", func(_ *gorm.DB, record *promptdomain.WorkspaceRecord) (bool, error) {
+		record.PromptID = promptID
+		record.Status = strings.TrimSpace(status)
+		record.UpdatedAt = time.Now()
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("set prompt meta: %w", err)
+	}
+	return nil
+}
+
+// GetPromptMeta 读取工作区内缓存的 prompt_id 与状态信息，工作区不存在时返回零值。
+func (r *PromptWorkspaceRepository) GetPromptMeta(ctx context.Context, userID uint, token string) (uint, string, error) {
+	if r == nil || r.db == nil {
+		return 0, "", fmt.Errorf("workspace store not initialised")
+	}
+	record, err := r.findLive(r.db.WithContext(ctx), userID, token)
+	if err != nil {
+		return 0, "", fmt.Errorf("get prompt meta: %w", err)
+	}
+	if record == nil {
+		return 0, "", nil
+	}
+	return record.PromptID, record.Status, nil
+}
+
+// mutate 在事务中读取工作区并交给 fn 修改，随后写回并续期；工作区不存在或已过期时从空工作区开始。
+// expected 不为 WorkspaceAnyVersion 且与当前版本不一致时返回 ErrWorkspaceVersionConflict；bump 为 true 时写入后版本号加一。
+// op 不为空时，写入前的内容会作为一条历史压入撤销栈并清空重做栈。fn 返回 false 表示无需写入，此时返回当前版本号。
+func (r *PromptWorkspaceRepository) mutate(ctx context.Context, userID uint, token string, expected int64, bump bool, op string, fn func(tx *gorm.DB, record *promptdomain.WorkspaceRecord) (bool, error)) (int64, error) {
+	now := time.Now()
+	var version int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record, err := r.findLive(tx, userID, token)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			// 过期的旧数据需要连同关键词一并清除，等价于 Redis key 过期后重新写入。
+			if err := deleteWorkspace(tx, userID, token); err != nil {
+				return err
+			}
+			record = &promptdomain.WorkspaceRecord{UserID: userID, Token: token, UpdatedAt: now}
+		}
+		if expected != promptdomain.WorkspaceAnyVersion && record.Version != expected {
+			return promptdomain.ErrWorkspaceVersionConflict
+		}
+		version = record.Version
+		var entry promptdomain.WorkspaceHistoryEntry
+		if op != "" {
+			if entry, err = captureWorkspaceHistory(tx, record, op); err != nil {
+				return err
+			}
+		}
+		changed, err := fn(tx, record)
+		if err != nil || !changed {
+			return err
+		}
+		if op != "" {
+			if err := pushWorkspaceHistory(tx, userID, token, promptdomain.WorkspaceHistoryStackUndo, entry); err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ? AND token = ? AND stack = ?", userID, token, promptdomain.WorkspaceHistoryStackRedo).
+				Delete(&promptdomain.WorkspaceHistoryRecord{}).Error; err != nil {
+				return err
+			}
+		}
+		if bump {
+			record.Version++
+		}
+		record.ExpiresAt = now.Add(r.ttl)
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(record).Error; err != nil {
+			return err
+		}
+		version = record.Version
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	r.purgeExpired(ctx, now)
+	return version, nil
+}
+
+// travel 从撤销栈（op 为 undo）或重做栈（op 为 redo）弹出最近一条历史恢复到工作区，并把当前内容压入另一个栈。
+func (r *PromptWorkspaceRepository) travel(ctx context.Context, userID uint, token string, expectedVersion int64, op string) (int64, error) {
+	if r == nil || r.db == nil {
+		return 0, fmt.Errorf("workspace store not initialised")
+	}
+	from, to := promptdomain.WorkspaceHistoryStackUndo, promptdomain.WorkspaceHistoryStackRedo
+	if op == promptdomain.WorkspaceOpRedo {
+		from, to = to, from
+	}
+	version, err := r.mutate(ctx, userID, token, expectedVersion, true, "", func(tx *gorm.DB, record *promptdomain.WorkspaceRecord) (bool, error) {
+		var latest promptdomain.WorkspaceHistoryRecord
+		err := tx.Where("user_id = ? AND token = ? AND stack = ?", userID, token, from).
+			Order("id DESC").
+			Take(&latest).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, promptdomain.ErrWorkspaceHistoryEmpty
+		}
+		if err != nil {
+			return false, err
+		}
+		var entry promptdomain.WorkspaceHistoryEntry
+		if err := json.Unmarshal([]byte(latest.Payload), &entry); err != nil {
+			return false, fmt.Errorf("decode workspace history: %w", err)
+		}
+		current, err := captureWorkspaceHistory(tx, record, entry.Op)
+		if err != nil {
+			return false, err
+		}
+		if err := tx.Delete(&latest).Error; err != nil {
+			return false, err
+		}
+		if err := pushWorkspaceHistory(tx, userID, token, to, current); err != nil {
+			return false, err
+		}
+		record.DraftBody = entry.DraftBody
+		record.Attributes = ""
+		if len(entry.Attributes) > 0 {
+			raw, err := json.Marshal(entry.Attributes)
+			if err != nil {
+				return false, fmt.Errorf("encode workspace attributes: %w", err)
+			}
+			record.Attributes = string(raw)
+		}
+		record.UpdatedAt = time.Now()
+		if err := tx.Where("user_id = ? AND token = ?", userID, token).
+			Delete(&promptdomain.WorkspaceKeywordRecord{}).Error; err != nil {
+			return false, err
+		}
+		keywords := append(append([]promptdomain.WorkspaceKeyword{}, entry.Positive...), entry.Negative...)
+		return true, upsertWorkspaceKeywords(tx, userID, token, keywords)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s workspace: %w", op, err)
+	}
+	return version, nil
+}
+
+// findLive 返回未过期的工作区，不存在或已过期时返回 nil。
+func (r *PromptWorkspaceRepository) findLive(db *gorm.DB, userID uint, token string) (*promptdomain.WorkspaceRecord, error) {
+	var record promptdomain.WorkspaceRecord
+	err := db.Where("user_id = ? AND token = ? AND expires_at > ?", userID, token, time.Now()).Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// purgeExpired 按固定频率清理过期的工作区及其关键词，清理失败不影响本次写入。
+func (r *PromptWorkspaceRepository) purgeExpired(ctx context.Context, now time.Time) {
+	last := r.lastPurge.Load()
+	if now.UnixNano()-last < int64(workspacePurgeInterval) || !r.lastPurge.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	_ = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at <= ?", now).Delete(&promptdomain.WorkspaceRecord{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("NOT EXISTS (?)",
+			tx.Model(&promptdomain.WorkspaceRecord{}).
+				Select("1").
+				Where("prompt_workspaces.user_id = prompt_workspace_keywords.user_id AND prompt_workspaces.token = prompt_workspace_keywords.token"),
+		).Delete(&promptdomain.WorkspaceKeywordRecord{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("NOT EXISTS (?)",
+			tx.Model(&promptdomain.WorkspaceRecord{}).
+				Select("1").
+				Where("prompt_workspaces.user_id = prompt_workspace_history.user_id AND prompt_workspaces.token = prompt_workspace_history.token"),
+		).Delete(&promptdomain.WorkspaceHistoryRecord{}).Error
+	})
+}
+
+// deleteWorkspace 删除工作区主记录、全部关键词与撤销/重做历史。
+func deleteWorkspace(tx *gorm.DB, userID uint, token string) error {
+	if err := tx.Where("user_id = ? AND token = ?", userID, token).Delete(&promptdomain.WorkspaceKeywordRecord{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ? AND token = ?", userID, token).Delete(&promptdomain.WorkspaceHistoryRecord{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ? AND token = ?", userID, token).Delete(&promptdomain.WorkspaceRecord{}).Error
+}
+
+// loadWorkspaceKeywords 按排序分数读取工作区的正/负向关键词。
+func loadWorkspaceKeywords(db *gorm.DB, userID uint, token string) ([]promptdomain.WorkspaceKeyword, []promptdomain.WorkspaceKeyword, error) {
+	var keywords []promptdomain.WorkspaceKeywordRecord
+	if err := db.Where("user_id = ? AND token = ?", userID, token).
+		Order("score ASC").Order("word ASC").
+		Find(&keywords).Error; err != nil {
+		return nil, nil, err
+	}
+	var positive, negative []promptdomain.WorkspaceKeyword
+	for _, item := range keywords {
+		var entity promptdomain.WorkspaceKeyword
+		if err := json.Unmarshal([]byte(item.Payload), &entity); err != nil {
+			continue
+		}
+		entity.Score = item.Score
+		if item.Polarity == promptdomain.KeywordPolarityNegative {
+			negative = append(negative, entity)
+		} else {
+			positive = append(positive, entity)
+		}
+	}
+	return positive, negative, nil
+}
+
+// captureWorkspaceHistory 读取工作区当前的关键词、草稿正文与属性，生成一条历史记录。
+func captureWorkspaceHistory(tx *gorm.DB, record *promptdomain.WorkspaceRecord, op string) (promptdomain.WorkspaceHistoryEntry, error) {
+	entry := promptdomain.WorkspaceHistoryEntry{Op: op, DraftBody: record.DraftBody, CreatedAt: time.Now()}
+	if strings.TrimSpace(record.Attributes) != "" {
+		var attrs map[string]string
+		if decodeErr := json.Unmarshal([]byte(record.Attributes), &attrs); decodeErr == nil {
+			entry.Attributes = attrs
+		}
+	}
+	positive, negative, err := loadWorkspaceKeywords(tx, record.UserID, record.Token)
+	if err != nil {
+		return entry, err
+	}
+	entry.Positive, entry.Negative = positive, negative
+	return entry, nil
+}
+
+// pushWorkspaceHistory 将历史记录压入指定栈，并删除超出 WorkspaceHistoryLimit 的最早记录。
+func pushWorkspaceHistory(tx *gorm.DB, userID uint, token, stack string, entry promptdomain.WorkspaceHistoryEntry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode workspace history: %w", err)
+	}
+	record := promptdomain.WorkspaceHistoryRecord{
+		UserID:    userID,
+		Token:     token,
+		Stack:     stack,
+		Payload:   string(payload),
+		CreatedAt: entry.CreatedAt,
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return err
+	}
+	var ids []uint
+	if err := tx.Model(&promptdomain.WorkspaceHistoryRecord{}).
+		Where("user_id = ? AND token = ? AND stack = ?", userID, token, stack).
+		Order("id DESC").
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) <= promptdomain.WorkspaceHistoryLimit {
+		return nil
+	}
+	return tx.Delete(&promptdomain.WorkspaceHistoryRecord{}, ids[promptdomain.WorkspaceHistoryLimit:]).Error
+}
+
+// upsertWorkspaceKeywords 写入关键词，已存在的同极性关键词会覆盖属性与排序分数。
+func upsertWorkspaceKeywords(tx *gorm.DB, userID uint, token string, keywords []promptdomain.WorkspaceKeyword) error {
+	for _, kw := range keywords {
+		if kw.Word == "" {
+			continue
+		}
+		payload, err := json.Marshal(kw)
+		if err != nil {
+			return fmt.Errorf("encode keyword: %w", err)
+		}
+		score := kw.Score
+		if score == 0 {
+			score = float64(time.Now().UnixNano())
+		}
+		record := promptdomain.WorkspaceKeywordRecord{
+			UserID:   userID,
+			Token:    token,
+			Polarity: normalizeWorkspacePolarity(kw.Polarity),
+			Word:     strings.ToLower(kw.Word),
+			Payload:  string(payload),
+			Score:    score,
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&record).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeWorkspacePolarity 规范化极性字段，缺省时视为正向。
+func normalizeWorkspacePolarity(p string) string {
+	if strings.EqualFold(strings.TrimSpace(p), promptdomain.KeywordPolarityNegative) {
+		return promptdomain.KeywordPolarityNegative
+	}
+	return promptdomain.KeywordPolarityPositive
+}
@@ -142,6 +142,8 @@ func NewRouter(opts RouterOptions) *gin.Engine {
 			models.GET("", opts.ModelHandler.List)
 			models.POST("", opts.ModelHandler.Create)
 			models.POST("/:id/test", opts.ModelHandler.TestConnection)
+			models.GET("/:id/usage", opts.ModelHandler.Usage)
+			models.GET("/:id/catalog", opts.ModelHandler.Catalog)
 			models.PUT("/:id", opts.ModelHandler.Update)
 			models.DELETE("/:id", opts.ModelHandler.Delete)
 		}
@@ -158,6 +160,9 @@ func NewRouter(opts RouterOptions) *gin.Engine {
 				prompts.POST("/export", opts.PromptHandler.ExportPrompts)
 				prompts.POST("/import", opts.PromptHandler.ImportPrompts)
 				prompts.POST("/:id/share", opts.PromptHandler.SharePrompt)
+				prompts.POST("/:id/render", opts.PromptHandler.RenderPrompt)
+				prompts.POST("/:id/run", opts.PromptHandler.RunPrompt)
+				prompts.GET("/:id/runs", opts.PromptHandler.ListPromptRuns)
 				prompts.POST("/share/import", opts.PromptHandler.ImportSharedPrompt)
 				prompts.POST("/interpret", opts.PromptHandler.Interpret)
 				prompts.POST("/ingest", opts.PromptHandler.IngestPrompt)
@@ -166,12 +171,21 @@ func NewRouter(opts RouterOptions) *gin.Engine {
 				prompts.POST("/keywords/remove", opts.PromptHandler.RemoveKeyword)
 				prompts.POST("/keywords/sync", opts.PromptHandler.SyncKeywords)
 				prompts.POST("/generate", opts.PromptHandler.GeneratePrompt)
+				prompts.POST("/generate/stream", opts.PromptHandler.GeneratePromptStream)
+				prompts.POST("/generate/select", opts.PromptHandler.SelectCandidate)
+				prompts.GET("/workspaces", opts.PromptHandler.ListWorkspaces)
+				prompts.DELETE("/workspaces/:token", opts.PromptHandler.DiscardWorkspace)
+				prompts.POST("/workspaces/:token/promote", opts.PromptHandler.PromoteWorkspace)
+				prompts.POST("/workspace/:token/undo", opts.PromptHandler.UndoWorkspace)
+				prompts.POST("/workspace/:token/redo", opts.PromptHandler.RedoWorkspace)
 				prompts.GET("/:id", opts.PromptHandler.GetPrompt)
 				prompts.PATCH("/:id/favorite", opts.PromptHandler.UpdateFavorite)
 				prompts.POST("/:id/like", opts.PromptHandler.LikePrompt)
 				prompts.DELETE("/:id/like", opts.PromptHandler.UnlikePrompt)
 				prompts.DELETE("/:id", opts.PromptHandler.DeletePrompt)
 				prompts.POST("", opts.PromptHandler.SavePrompt)
+				prompts.GET("/tasks/:id", opts.PromptHandler.GetSaveTask)
+				prompts.GET("/tasks/:id/stream", opts.PromptHandler.StreamSaveTask)
 			}
 			if opts.PromptCommentHandler != nil {
 				prompts.GET("/:id/comments", opts.PromptCommentHandler.List)
@@ -32,6 +32,7 @@ type ActivityEvent struct {
 	Kind      ActivityKind
 	Success   bool
 	Duration  time.Duration
+	Cost      float64 // 生成调用的估算费用，按模型价格表币种计。
 	Timestamp time.Time
 }
 
@@ -58,6 +59,7 @@ type DailyMetric struct {
 	GenerateSuccessRate  float64 `json:"generate_success_rate"`
 	AverageLatencyMillis float64 `json:"average_latency_ms"`
 	SaveRequests         int     `json:"save_requests"`
+	GenerateCost         float64 `json:"generate_cost"`
 }
 
 // Totals 汇总区间内的整体表现。
@@ -68,6 +70,7 @@ type Totals struct {
 	GenerateSuccessRate  float64 `json:"generate_success_rate"`
 	AverageLatencyMillis float64 `json:"average_latency_ms"`
 	SaveRequests         int     `json:"save_requests"`
+	GenerateCost         float64 `json:"generate_cost"`
 }
 
 // dailyBucket 存储某一天的原始事件聚合，用于后续生成快照。
@@ -79,6 +82,7 @@ type dailyBucket struct {
 	latencySum      time.Duration     // 生成请求耗时累加值。
 	latencySamples  int               // 记录了耗时的样本数量。
 	saveTotal       int               // 保存请求总次数。
+	generateCost    float64           // 生成调用估算费用累加值。
 }
 
 // Service 通过内存缓存维护管理员指标，并周期性刷新快照。
@@ -183,6 +187,9 @@ func (s *Service) hydrateFromRepository(ctx context.Context) {
 		if record.DurationMillis != nil {
 			event.Duration = time.Duration(*record.DurationMillis) * time.Millisecond
 		}
+		if record.Cost != nil {
+			event.Cost = *record.Cost
+		}
 		s.applyEventLocked(event)
 	}
 	if len(records) > 0 {
@@ -231,6 +238,7 @@ func (s *Service) applyEventLocked(event ActivityEvent) {
 			bucket.latencySum += event.Duration
 			bucket.latencySamples++
 		}
+		bucket.generateCost += event.Cost
 	case ActivitySave:
 		bucket.saveTotal++
 	}
@@ -252,6 +260,10 @@ func (s *Service) persistEvent(event ActivityEvent) {
 		ms := event.Duration.Milliseconds()
 		record.DurationMillis = &ms
 	}
+	if event.Cost > 0 {
+		cost := event.Cost
+		record.Cost = &cost
+	}
 
 	if err := s.repo.AppendEvent(context.Background(), record); err != nil {
 		s.logger.Warnw("append admin metrics event failed", "error", err)
@@ -282,6 +294,7 @@ func (s *Service) persistDailyMetrics(ctx context.Context, metrics []DailyMetric
 			GenerateSuccessRate:  metric.GenerateSuccessRate,
 			AverageLatencyMillis: metric.AverageLatencyMillis,
 			SaveTotal:            metric.SaveRequests,
+			GenerateCost:         metric.GenerateCost,
 		}
 		if err := s.repo.UpsertDaily(ctx, record); err != nil {
 			s.logger.Warnw("upsert admin metrics daily failed", "date", metric.Date, "error", err)
@@ -352,6 +365,7 @@ func (s *Service) rebuildSnapshotLocked(now time.Time) []DailyMetric {
 	var totalGenerate, totalSuccess, totalSave int
 	var totalLatency time.Duration
 	var totalSamples int
+	var totalCost float64
 
 	for _, key := range keys {
 		bucket := s.buckets[key]
@@ -370,6 +384,7 @@ func (s *Service) rebuildSnapshotLocked(now time.Time) []DailyMetric {
 			GenerateSuccessRate:  successRate,
 			AverageLatencyMillis: avgLatency,
 			SaveRequests:         bucket.saveTotal,
+			GenerateCost:         bucket.generateCost,
 		})
 
 		totalGenerate += bucket.generateTotal
@@ -377,6 +392,7 @@ func (s *Service) rebuildSnapshotLocked(now time.Time) []DailyMetric {
 		totalSave += bucket.saveTotal
 		totalLatency += bucket.latencySum
 		totalSamples += bucket.latencySamples
+		totalCost += bucket.generateCost
 	}
 
 	s.snapshot = Snapshot{
@@ -390,6 +406,7 @@ func (s *Service) rebuildSnapshotLocked(now time.Time) []DailyMetric {
 			GenerateSuccessRate:  computeRate(totalSuccess, totalGenerate),
 			AverageLatencyMillis: computeAverageMillis(totalLatency, totalSamples),
 			SaveRequests:         totalSave,
+			GenerateCost:         totalCost,
 		},
 	}
 	s.dirty = false
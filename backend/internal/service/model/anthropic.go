@@ -0,0 +1,82 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"electron-go-app/backend/internal/domain/llm"
+	domain "electron-go-app/backend/internal/domain/user"
+	"electron-go-app/backend/internal/infra/model/anthropic"
+	"electron-go-app/backend/internal/infra/security"
+)
+
+// defaultAnthropicModel 当用户未填写具体模型时的默认 Claude 模型。
+const defaultAnthropicModel = "claude-3-5-sonnet-latest"
+
+func prepareAnthropicRequest(req llm.Request, credential *domain.UserModelCredential) (llm.Request, error) {
+	return prepareModelRequest(req, credential, defaultAnthropicModel)
+}
+
+// newAnthropicProvider 解密 API Key 并构造 Anthropic Messages 客户端，
+// extra_config 中的版本与 beta 标记转为请求头。
+func (s *Service) newAnthropicProvider(credential *domain.UserModelCredential, req llm.Request) (llm.Provider, llm.Request, error) {
+	apiKey, err := security.Decrypt(credential.APIKeyCipher)
+	if err != nil {
+		return nil, llm.Request{}, fmt.Errorf("decrypt api key: %w", err)
+	}
+
+	prepared, err := prepareAnthropicRequest(req, credential)
+	if err != nil {
+		return nil, llm.Request{}, err
+	}
+	headers := extractAnthropicHeaders(&prepared)
+
+	opts := []anthropic.Option{anthropic.WithHeaders(headers), anthropic.WithRetryPolicy(s.retry)}
+	if credential.BaseURL != "" {
+		opts = append(opts, anthropic.WithBaseURL(credential.BaseURL))
+	}
+	return anthropic.NewClient(string(apiKey), opts...), prepared, nil
+}
+
+// extractAnthropicHeaders 从 extra_config 中取出 anthropic_version、anthropic_beta 与自定义 headers，
+// 这些字段需要以请求头形式发送，不能混入请求体。
+func extractAnthropicHeaders(request *llm.Request) map[string]string {
+	if request == nil || len(request.ExtraFields) == 0 {
+		return nil
+	}
+	headers := map[string]string{}
+	for key, value := range request.ExtraFields {
+		switch strings.ToLower(key) {
+		case "headers":
+			if v, ok := value.(map[string]any); ok {
+				for name, raw := range v {
+					if text, ok := raw.(string); ok && strings.TrimSpace(name) != "" {
+						headers[strings.TrimSpace(name)] = text
+					}
+				}
+			}
+		case "anthropic_version":
+			if v, ok := value.(string); ok && strings.TrimSpace(v) != "" {
+				headers["anthropic-version"] = strings.TrimSpace(v)
+			}
+		case "anthropic_beta":
+			switch v := value.(type) {
+			case string:
+				if strings.TrimSpace(v) != "" {
+					headers["anthropic-beta"] = strings.TrimSpace(v)
+				}
+			case []any:
+				if list := extractStopList(v); len(list) > 0 {
+					headers["anthropic-beta"] = strings.Join(list, ",")
+				}
+			}
+		default:
+			continue
+		}
+		delete(request.ExtraFields, key)
+	}
+	if len(request.ExtraFields) == 0 {
+		request.ExtraFields = nil
+	}
+	return headers
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+// 熔断器状态，closed 正常放行，open 直接失败，half_open 仅放行一次探测请求。
+const (
+	CircuitStateClosed   = "closed"
+	CircuitStateOpen     = "open"
+	CircuitStateHalfOpen = "half_open"
+)
+
+const (
+	defaultCircuitFailureThreshold = 3
+	defaultCircuitOpenTimeout      = 30 * time.Second
+)
+
+// ErrCircuitOpen 表示凭据处于熔断状态，本次调用未发往模型厂商。
+var ErrCircuitOpen = errors.New("model credential circuit open")
+
+// CircuitConfig 描述单个凭据熔断器的触发阈值与冷却时间。
+type CircuitConfig struct {
+	FailureThreshold int           // 连续失败多少次后熔断，0 使用默认值 3
+	OpenTimeout      time.Duration // 熔断后多久进入半开探测，0 使用默认值 30s
+}
+
+// DefaultCircuitConfig 返回默认熔断配置：连续 3 次失败熔断，30 秒后半开探测。
+func DefaultCircuitConfig() CircuitConfig {
+	return CircuitConfig{
+		FailureThreshold: defaultCircuitFailureThreshold,
+		OpenTimeout:      defaultCircuitOpenTimeout,
+	}
+}
+
+// normalize 对缺省字段进行填充。
+func (c CircuitConfig) normalize() CircuitConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = defaultCircuitFailureThreshold
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = defaultCircuitOpenTimeout
+	}
+	return c
+}
+
+// CircuitStatus 为 GET /api/models 返回的凭据健康状况。
+type CircuitStatus struct {
+	State               string     `json:"state"`                     // closed / open / half_open
+	ConsecutiveFailures int        `json:"consecutive_failures"`      // 连续失败次数
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"` // 最近一次失败时间
+	LastErrorKind       string     `json:"last_error_kind,omitempty"` // 最近一次失败的错误类型
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`       // 进入熔断的时间
+	RetryAt             *time.Time `json:"retry_at,omitempty"`        // 允许半开探测的时间
+}
+
+// CircuitOpenError 在熔断期间快速失败，归类为瞬时故障以便回退链切换到下一个模型。
+type CircuitOpenError struct {
+	ModelKey   string
+	RetryAfter time.Duration
+}
+
+// Error 实现 error 接口。
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrCircuitOpen.Error(), e.ModelKey)
+}
+
+// Unwrap 便于 errors.Is 匹配 ErrCircuitOpen。
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// Kind 实现 llm.KindedError。
+func (e *CircuitOpenError) Kind() llm.ErrorKind {
+	return llm.ErrorKindTransient
+}
+
+// RetryAfterHint 返回距离半开探测的剩余时间。
+func (e *CircuitOpenError) RetryAfterHint() time.Duration {
+	return e.RetryAfter
+}
+
+// circuit 记录单个凭据的熔断状态。
+type circuit struct {
+	state         string
+	failures      int
+	lastFailureAt time.Time
+	lastErrorKind llm.ErrorKind
+	openedAt      time.Time
+	probing       bool
+}
+
+// circuitBreakers 以凭据 ID 为键维护进程内的熔断器。
+type circuitBreakers struct {
+	mu       sync.Mutex
+	cfg      CircuitConfig
+	now      func() time.Time
+	circuits map[uint]*circuit
+}
+
+// newCircuitBreakers 创建熔断器集合。
+func newCircuitBreakers(cfg CircuitConfig) *circuitBreakers {
+	return &circuitBreakers{
+		cfg:      cfg.normalize(),
+		now:      time.Now,
+		circuits: make(map[uint]*circuit),
+	}
+}
+
+// allow 判断凭据当前是否允许调用，open 到期后转为 half_open 并只放行一次探测。
+func (b *circuitBreakers) allow(id uint, modelKey string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[id]
+	if !ok || c.state == CircuitStateClosed {
+		return nil
+	}
+	now := b.now()
+	if c.state == CircuitStateOpen {
+		retryAt := c.openedAt.Add(b.cfg.OpenTimeout)
+		if now.Before(retryAt) {
+			return &CircuitOpenError{ModelKey: modelKey, RetryAfter: retryAt.Sub(now)}
+		}
+		c.state = CircuitStateHalfOpen
+		c.probing = false
+	}
+	if c.probing {
+		return &CircuitOpenError{ModelKey: modelKey, RetryAfter: time.Second}
+	}
+	c.probing = true
+	return nil
+}
+
+// record 根据调用结果更新熔断器：限流与瞬时故障计为失败，其余结果说明厂商可达，视为成功。
+func (b *circuitBreakers) record(id uint, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[id]
+	if errors.Is(err, context.Canceled) {
+		// 调用方主动取消无法说明厂商健康状况，仅释放探测名额。
+		if ok {
+			c.probing = false
+		}
+		return
+	}
+	if !countsAsFailure(err) {
+		if ok {
+			delete(b.circuits, id)
+		}
+		return
+	}
+	if !ok {
+		c = &circuit{state: CircuitStateClosed}
+		b.circuits[id] = c
+	}
+	now := b.now()
+	c.failures++
+	c.lastFailureAt = now
+	c.lastErrorKind = llm.KindOf(err)
+	c.probing = false
+	if c.state == CircuitStateHalfOpen || c.failures >= b.cfg.FailureThreshold {
+		c.state = CircuitStateOpen
+		c.openedAt = now
+	}
+}
+
+// reset 清除凭据的熔断状态，在凭据被修改或删除后调用。
+func (b *circuitBreakers) reset(id uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.circuits, id)
+}
+
+// status 返回凭据的熔断快照，open 到期但尚未探测时按 half_open 展示。
+func (b *circuitBreakers) status(id uint) *CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[id]
+	if !ok {
+		return &CircuitStatus{State: CircuitStateClosed}
+	}
+	status := &CircuitStatus{
+		State:               c.state,
+		ConsecutiveFailures: c.failures,
+		LastErrorKind:       string(c.lastErrorKind),
+	}
+	lastFailureAt := c.lastFailureAt
+	status.LastFailureAt = &lastFailureAt
+	if c.state != CircuitStateClosed {
+		openedAt := c.openedAt
+		retryAt := openedAt.Add(b.cfg.OpenTimeout)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+		if c.state == CircuitStateOpen && !b.now().Before(retryAt) {
+			status.State = CircuitStateHalfOpen
+		}
+	}
+	return status
+}
+
+// countsAsFailure 判断错误是否说明厂商处于降级状态。
+func countsAsFailure(err error) bool {
+	switch llm.KindOf(err) {
+	case llm.ErrorKindTransient, llm.ErrorKindRateLimited:
+		return true
+	default:
+		return false
+	}
+}
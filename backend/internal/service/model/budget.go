@@ -0,0 +1,78 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+	domain "electron-go-app/backend/internal/domain/user"
+)
+
+var (
+	// ErrBudgetExceeded 表示凭据本月的估算费用已达到预算上限，本次调用未发往模型厂商。
+	ErrBudgetExceeded = errors.New("model credential monthly budget exceeded")
+	// ErrInvalidBudget 表示预算金额不合法。
+	ErrInvalidBudget = errors.New("monthly budget must not be negative")
+)
+
+// BudgetExceededError 携带预算与已用金额，归类为配额耗尽以便回退链切换到下一个模型。
+type BudgetExceededError struct {
+	ModelKey string
+	Budget   float64
+	Spent    float64
+	Currency string
+}
+
+// Error 实现 error 接口。
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s: %s (%.4f/%.4f %s)", ErrBudgetExceeded.Error(), e.ModelKey, e.Spent, e.Budget, e.Currency)
+}
+
+// Unwrap 便于 errors.Is 匹配 ErrBudgetExceeded。
+func (e *BudgetExceededError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// Kind 实现 llm.KindedError。
+func (e *BudgetExceededError) Kind() llm.ErrorKind {
+	return llm.ErrorKindQuotaExhausted
+}
+
+// startOfMonth 返回 t 所在自然月的第一天零点。
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// normalizeBudget 校验预算金额，0 表示取消预算。
+func normalizeBudget(budget *float64) (*float64, error) {
+	if budget == nil || *budget == 0 {
+		return nil, nil
+	}
+	if *budget < 0 {
+		return nil, ErrInvalidBudget
+	}
+	value := *budget
+	return &value, nil
+}
+
+// checkBudget 在调用前核对凭据本月估算费用，达到预算时返回 BudgetExceededError。
+func (s *Service) checkBudget(ctx context.Context, credential *domain.UserModelCredential) error {
+	if credential.MonthlyBudget == nil || s.usage == nil {
+		return nil
+	}
+	spent, err := s.usage.SumCostSince(ctx, credential.ID, startOfMonth(time.Now()))
+	if err != nil {
+		return fmt.Errorf("sum monthly cost: %w", err)
+	}
+	if spent >= *credential.MonthlyBudget {
+		return &BudgetExceededError{
+			ModelKey: credential.ModelKey,
+			Budget:   *credential.MonthlyBudget,
+			Spent:    spent,
+			Currency: s.prices.Currency,
+		}
+	}
+	return nil
+}
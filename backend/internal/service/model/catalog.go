@@ -0,0 +1,282 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+	domain "electron-go-app/backend/internal/domain/user"
+	"electron-go-app/backend/internal/infra/model/anthropic"
+	"electron-go-app/backend/internal/infra/model/deepseek"
+	"electron-go-app/backend/internal/infra/model/ollama"
+	volc "electron-go-app/backend/internal/infra/model/volcengine"
+
+	"gorm.io/gorm"
+)
+
+// DefaultCatalogTTL 为模型目录的默认缓存时长。
+const DefaultCatalogTTL = 10 * time.Minute
+
+// ErrCatalogUnsupported 表示提供方没有可用的模型列表接口。
+var ErrCatalogUnsupported = errors.New("model catalog not supported for provider")
+
+// 模型能力标签。
+const (
+	CapabilityChat       = "chat"
+	CapabilityReasoning  = "reasoning"
+	CapabilityVision     = "vision"
+	CapabilityTools      = "tools"
+	CapabilityJSONOutput = "json_output"
+)
+
+// CatalogModel 描述提供方可用的单个模型，上下文窗口与能力仅在已知时返回。
+type CatalogModel struct {
+	ID            string   `json:"id"`
+	DisplayName   string   `json:"display_name,omitempty"`
+	OwnedBy       string   `json:"owned_by,omitempty"`
+	ContextWindow int      `json:"context_window,omitempty"`
+	Capabilities  []string `json:"capabilities,omitempty"`
+}
+
+// Catalog 为 GET /api/models/:id/catalog 的返回结构。
+type Catalog struct {
+	CredentialID uint           `json:"credential_id"`
+	Provider     string         `json:"provider"`
+	Models       []CatalogModel `json:"models"`
+	FetchedAt    time.Time      `json:"fetched_at"`
+	Cached       bool           `json:"cached"`
+}
+
+// knownModel 为内置的模型元数据，厂商列表接口通常不返回上下文窗口与能力。
+type knownModel struct {
+	prefix        string
+	contextWindow int
+	capabilities  []string
+}
+
+// knownModels 按前缀匹配，较长的前缀优先。
+var knownModels = []knownModel{
+	{prefix: "deepseek-chat", contextWindow: 128000, capabilities: []string{CapabilityChat, CapabilityTools, CapabilityJSONOutput}},
+	{prefix: "deepseek-reasoner", contextWindow: 128000, capabilities: []string{CapabilityChat, CapabilityReasoning, CapabilityJSONOutput}},
+	{prefix: "gpt-4o", contextWindow: 128000, capabilities: []string{CapabilityChat, CapabilityVision, CapabilityTools, CapabilityJSONOutput}},
+	{prefix: "gpt-4.1", contextWindow: 1047576, capabilities: []string{CapabilityChat, CapabilityVision, CapabilityTools, CapabilityJSONOutput}},
+	{prefix: "o3", contextWindow: 200000, capabilities: []string{CapabilityChat, CapabilityReasoning, CapabilityVision, CapabilityTools, CapabilityJSONOutput}},
+	{prefix: "o4-mini", contextWindow: 200000, capabilities: []string{CapabilityChat, CapabilityReasoning, CapabilityVision, CapabilityTools, CapabilityJSONOutput}},
+	{prefix: "claude-3-5", contextWindow: 200000, capabilities: []string{CapabilityChat, CapabilityVision, CapabilityTools}},
+	{prefix: "claude-3-7", contextWindow: 200000, capabilities: []string{CapabilityChat, CapabilityReasoning, CapabilityVision, CapabilityTools}},
+	{prefix: "claude-sonnet-4", contextWindow: 200000, capabilities: []string{CapabilityChat, CapabilityReasoning, CapabilityVision, CapabilityTools}},
+	{prefix: "claude-opus-4", contextWindow: 200000, capabilities: []string{CapabilityChat, CapabilityReasoning, CapabilityVision, CapabilityTools}},
+	{prefix: "doubao-1-5-thinking", contextWindow: 128000, capabilities: []string{CapabilityChat, CapabilityReasoning}},
+	{prefix: "doubao-1-5-pro", contextWindow: 128000, capabilities: []string{CapabilityChat, CapabilityTools}},
+	{prefix: "doubao-seed-1-6", contextWindow: 256000, capabilities: []string{CapabilityChat, CapabilityReasoning, CapabilityVision, CapabilityTools}},
+}
+
+// lookupKnownModel 返回模型 ID 匹配到的最长前缀元数据。
+func lookupKnownModel(id string) (knownModel, bool) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	var best knownModel
+	found := false
+	for _, item := range knownModels {
+		if strings.HasPrefix(id, item.prefix) && len(item.prefix) > len(best.prefix) {
+			best = item
+			found = true
+		}
+	}
+	return best, found
+}
+
+// catalogEntry 为单个凭据缓存的模型目录。
+type catalogEntry struct {
+	models    []CatalogModel
+	fetchedAt time.Time
+}
+
+// catalogCache 按凭据缓存模型目录，避免每次打开设置页都请求厂商接口；凭据更新或删除时失效。
+type catalogCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[uint]catalogEntry
+}
+
+// newCatalogCache 构造模型目录缓存，ttl 非正数时使用默认值。
+func newCatalogCache(ttl time.Duration) *catalogCache {
+	if ttl <= 0 {
+		ttl = DefaultCatalogTTL
+	}
+	return &catalogCache{ttl: ttl, entries: map[uint]catalogEntry{}}
+}
+
+// get 返回未过期的缓存。
+func (c *catalogCache) get(id uint) (catalogEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[id]
+	if !ok || time.Since(entry.fetchedAt) > c.ttl {
+		return catalogEntry{}, false
+	}
+	return entry, true
+}
+
+// set 写入凭据的模型目录。
+func (c *catalogCache) set(id uint, entry catalogEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[id] = entry
+}
+
+// invalidate 删除凭据的缓存。
+func (c *catalogCache) invalidate(id uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+}
+
+// Catalog 返回凭据可用的模型列表，refresh 为 true 时跳过缓存重新请求厂商接口。
+func (s *Service) Catalog(ctx context.Context, userID, id uint, refresh bool) (Catalog, error) {
+	credential, err := s.repo.FindByID(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Catalog{}, ErrCredentialNotFound
+		}
+		return Catalog{}, fmt.Errorf("find credential: %w", err)
+	}
+	result := Catalog{CredentialID: credential.ID, Provider: normalizeProvider(credential.Provider)}
+	if !refresh {
+		if entry, ok := s.catalogs.get(credential.ID); ok {
+			result.Models = entry.models
+			result.FetchedAt = entry.fetchedAt
+			result.Cached = true
+			return result, nil
+		}
+	}
+	models, err := s.fetchCatalog(ctx, credential)
+	if err != nil {
+		return Catalog{}, err
+	}
+	entry := catalogEntry{models: models, fetchedAt: time.Now()}
+	s.catalogs.set(credential.ID, entry)
+	result.Models = entry.models
+	result.FetchedAt = entry.fetchedAt
+	return result, nil
+}
+
+// validateModelKey 确认凭据的 model_key 出现在提供方的模型列表中。
+func (s *Service) validateModelKey(ctx context.Context, credential *domain.UserModelCredential) ([]CatalogModel, error) {
+	models, err := s.fetchCatalog(ctx, credential)
+	if err != nil {
+		return nil, err
+	}
+	provider := normalizeProvider(credential.Provider)
+	for _, item := range models {
+		if provider == "ollama" && ollamaModelMatches(credential.ModelKey, item.ID) {
+			return models, nil
+		}
+		if strings.EqualFold(strings.TrimSpace(credential.ModelKey), item.ID) {
+			return models, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrModelUnavailable, credential.ModelKey)
+}
+
+// fetchCatalog 复用调用链的客户端构造逻辑（BaseURL、请求头、解密），再调用各厂商的模型列表接口。
+func (s *Service) fetchCatalog(ctx context.Context, credential *domain.UserModelCredential) ([]CatalogModel, error) {
+	if normalizeProvider(credential.Provider) == ProviderReplay {
+		return nil, ErrCatalogUnsupported
+	}
+	provider, _, err := s.newBaseProvider(credential, llm.Request{})
+	if err != nil {
+		return nil, err
+	}
+	var models []CatalogModel
+	switch client := provider.(type) {
+	case *deepseek.Client:
+		items, err := client.ListModels(ctx)
+		if err != nil {
+			return nil, catalogError(err)
+		}
+		for _, item := range items {
+			window := item.ContextLength
+			if window == 0 {
+				window = item.ContextWindow
+			}
+			if window == 0 {
+				window = item.MaxModelLen
+			}
+			models = append(models, CatalogModel{ID: item.ID, OwnedBy: item.OwnedBy, ContextWindow: window})
+		}
+	case *volc.Client:
+		items, err := client.ListModels(ctx)
+		if err != nil {
+			return nil, catalogError(err)
+		}
+		for _, item := range items {
+			models = append(models, CatalogModel{ID: item.ID, OwnedBy: item.OwnedBy})
+		}
+	case *anthropic.Client:
+		items, err := client.ListModels(ctx)
+		if err != nil {
+			return nil, catalogError(err)
+		}
+		for _, item := range items {
+			models = append(models, CatalogModel{ID: item.ID, DisplayName: item.DisplayName, OwnedBy: "anthropic"})
+		}
+	case *ollama.Client:
+		items, err := client.ListModels(ctx)
+		if err != nil {
+			return nil, catalogError(err)
+		}
+		for _, item := range items {
+			id := item.Name
+			if id == "" {
+				id = item.Model
+			}
+			models = append(models, CatalogModel{ID: id, OwnedBy: item.Details.Family})
+		}
+	default:
+		return nil, ErrCatalogUnsupported
+	}
+	for idx := range models {
+		known, ok := lookupKnownModel(models[idx].ID)
+		if !ok {
+			continue
+		}
+		if models[idx].ContextWindow == 0 {
+			models[idx].ContextWindow = known.contextWindow
+		}
+		models[idx].Capabilities = known.capabilities
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	if models == nil {
+		models = []CatalogModel{}
+	}
+	return models, nil
+}
+
+// catalogError 将模型列表接口的 404/405 视为提供方不支持，其余错误原样返回。
+func catalogError(err error) error {
+	var status int
+	var deepseekErr *deepseek.APIError
+	var volcErr *volc.APIError
+	var anthropicErr *anthropic.APIError
+	var ollamaErr *ollama.APIError
+	switch {
+	case errors.As(err, &deepseekErr):
+		status = deepseekErr.StatusCode
+	case errors.As(err, &volcErr):
+		status = volcErr.StatusCode
+	case errors.As(err, &anthropicErr):
+		status = anthropicErr.StatusCode
+	case errors.As(err, &ollamaErr):
+		status = ollamaErr.StatusCode
+	}
+	if status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+		return fmt.Errorf("%w: %v", ErrCatalogUnsupported, err)
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"electron-go-app/backend/internal/domain/llm"
+	domain "electron-go-app/backend/internal/domain/user"
+
+	"gorm.io/gorm"
+)
+
+// FallbackChain 返回用户为指定操作配置的回退模型列表，未配置时返回空切片。
+func (s *Service) FallbackChain(ctx context.Context, userID uint, operation string) ([]string, error) {
+	if s.users == nil {
+		return nil, nil
+	}
+	userEntity, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("find user: %w", err)
+	}
+	settings, err := domain.ParseSettings(userEntity.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("parse settings: %w", err)
+	}
+	return append([]string(nil), settings.FallbackChain(operation)...), nil
+}
+
+// IsRetryableError 判断模型调用错误是否值得切换到回退链中的下一个模型：
+// 限流、瞬时故障（5xx、超时）以及余额耗尽都可能在另一个凭据上成功。
+func IsRetryableError(err error) bool {
+	switch llm.KindOf(err) {
+	case llm.ErrorKindRateLimited, llm.ErrorKindTransient, llm.ErrorKindQuotaExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// removeFromFallbackChains 在凭据删除后把对应模型从用户所有回退链中移除。
+func (s *Service) removeFromFallbackChains(ctx context.Context, userID uint, modelKey string) error {
+	if s.users == nil {
+		return nil
+	}
+	userEntity, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("find user: %w", err)
+	}
+	settings, err := domain.ParseSettings(userEntity.Settings)
+	if err != nil {
+		return fmt.Errorf("parse settings: %w", err)
+	}
+	changed := false
+	for operation, chain := range settings.FallbackChains {
+		filtered := chain[:0:0]
+		for _, key := range chain {
+			if strings.EqualFold(strings.TrimSpace(key), modelKey) {
+				changed = true
+				continue
+			}
+			filtered = append(filtered, key)
+		}
+		if len(filtered) == 0 {
+			delete(settings.FallbackChains, operation)
+			continue
+		}
+		settings.FallbackChains[operation] = filtered
+	}
+	if !changed {
+		return nil
+	}
+	raw, err := domain.SettingsJSON(settings)
+	if err != nil {
+		return fmt.Errorf("encode settings: %w", err)
+	}
+	if err := s.users.UpdateSettings(ctx, userID, raw); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("update settings: %w", err)
+	}
+	return nil
+}
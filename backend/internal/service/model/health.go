@@ -0,0 +1,193 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"electron-go-app/backend/internal/domain/llm"
+	domain "electron-go-app/backend/internal/domain/user"
+	appLogger "electron-go-app/backend/internal/infra/logger"
+)
+
+const (
+	// HealthStatusHealthy 表示最近一次健康检查成功。
+	HealthStatusHealthy = "healthy"
+	// HealthStatusUnhealthy 表示最近一次健康检查失败，但不是鉴权问题（限流、超时、厂商故障等）。
+	HealthStatusUnhealthy = "unhealthy"
+	// HealthStatusRevoked 表示厂商返回 401/403，API Key 很可能已被吊销或失效，需要用户更换。
+	HealthStatusRevoked = "revoked"
+
+	// UsageOperationHealthCheck 标记后台健康检查产生的调用。
+	UsageOperationHealthCheck = "health_check"
+
+	// DefaultHealthCheckInterval 为后台健康检查的默认周期。
+	DefaultHealthCheckInterval = time.Hour
+	// DefaultHealthCheckTimeout 为单个凭据健康检查的默认超时。
+	DefaultHealthCheckTimeout = 15 * time.Second
+	// DefaultHealthCheckBatchSize 为每批读取的凭据数量。
+	DefaultHealthCheckBatchSize = 50
+
+	// healthErrorMaxRunes 与 health_error 列宽保持一致，避免厂商返回的长错误写库失败。
+	healthErrorMaxRunes = 512
+)
+
+// HealthConfig 描述后台凭据健康检查的参数，Interval 为 0 时关闭。
+type HealthConfig struct {
+	Interval  time.Duration // 检查周期
+	Timeout   time.Duration // 单个凭据的调用超时
+	BatchSize int           // 每批读取的凭据数量
+}
+
+// CredentialHealth 为对外返回的健康检查结果。
+type CredentialHealth struct {
+	Status              string     `json:"status"`                         // healthy/unhealthy/revoked
+	CheckedAt           *time.Time `json:"checked_at"`                     // 最近一次检查时间
+	LatencyMs           int64      `json:"latency_ms"`                     // 最近一次检查耗时（毫秒）
+	LastError           string     `json:"last_error,omitempty"`           // 最近一次失败的错误信息
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"` // 连续失败次数
+}
+
+// healthCheckRequest 为健康检查使用的最小请求，只要求模型返回 1 个 token。
+func healthCheckRequest() llm.Request {
+	return llm.Request{
+		Messages:  []llm.Message{{Role: "user", Content: "ping"}},
+		MaxTokens: 1,
+	}
+}
+
+// StartHealthChecker 启动后台健康检查任务：启动后立即探测一轮，之后周期性地用最小请求探测所有启用中的凭据。
+func (s *Service) StartHealthChecker(ctx context.Context) {
+	logger := appLogger.S().With("component", "model.health")
+	if s.health.Interval <= 0 {
+		logger.Infow("credential health checker disabled")
+		return
+	}
+	checkAll := func() {
+		checked, err := s.CheckCredentialsHealth(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			logger.Warnw("credential health check failed", "checked", checked, "error", err)
+		}
+	}
+	ticker := time.NewTicker(s.health.Interval)
+	go func() {
+		defer ticker.Stop()
+		// 首轮不等待周期，避免服务启动后健康状态在整个周期内保持过期或未知。
+		checkAll()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkAll()
+			}
+		}
+	}()
+}
+
+// CheckCredentialsHealth 按主键分批遍历所有启用中的凭据并逐一探测，返回本轮写回健康结果的凭据数量。
+// replay 凭据不会访问真实厂商，因此不参与检查。
+func (s *Service) CheckCredentialsHealth(ctx context.Context) (int, error) {
+	batchSize := s.health.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultHealthCheckBatchSize
+	}
+	checked := 0
+	var afterID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return checked, err
+		}
+		batch, err := s.repo.ListEnabledAfterID(ctx, afterID, batchSize, ProviderReplay)
+		if err != nil {
+			return checked, err
+		}
+		for idx := range batch {
+			saved, err := s.checkCredentialHealth(ctx, &batch[idx])
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					return checked, err
+				}
+				appLogger.S().With("component", "model.health").Warnw("save credential health failed", "credential_id", batch[idx].ID, "error", err)
+				continue
+			}
+			if saved {
+				checked++
+			}
+		}
+		if len(batch) < batchSize {
+			return checked, nil
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+}
+
+// checkCredentialHealth 对单个凭据发起一次最小调用，结果计入熔断器与用量流水并写回健康字段。
+// 探测期间凭据被修改（如用户更换 Key）时放弃写回，避免旧 Key 的结果覆盖新凭据，返回值表示是否已写回。
+func (s *Service) checkCredentialHealth(ctx context.Context, credential *domain.UserModelCredential) (bool, error) {
+	timeout := s.health.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req := healthCheckRequest()
+	start := time.Now()
+	resp, err := s.callProvider(callCtx, credential, req, nil)
+	latency := time.Since(start)
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	s.breakers.record(credential.ID, err)
+	if err == nil {
+		resp.Cost = s.prices.Estimate(credential.Provider, resp.Usage, resp.Model, req.Model, credential.ModelKey)
+	}
+	s.recordCredentialUsage(ctx, credential, UsageOperationHealthCheck, resp, latency, err)
+	applyHealthResult(credential, start, latency, err)
+	return s.repo.UpdateHealthIfUnchanged(ctx, credential)
+}
+
+// applyHealthResult 将一次调用结果写入凭据的健康字段；成功时同时刷新最近验证时间。
+func applyHealthResult(credential *domain.UserModelCredential, checkedAt time.Time, latency time.Duration, err error) {
+	credential.HealthCheckedAt = &checkedAt
+	credential.HealthLatencyMs = latency.Milliseconds()
+	if err == nil {
+		credential.HealthStatus = HealthStatusHealthy
+		credential.HealthError = ""
+		credential.HealthFailures = 0
+		credential.LastVerifiedAt = &checkedAt
+		return
+	}
+	credential.HealthStatus = HealthStatusUnhealthy
+	if llm.KindOf(err) == llm.ErrorKindAuthFailed {
+		credential.HealthStatus = HealthStatusRevoked
+	}
+	credential.HealthError = truncateHealthError(err.Error())
+	credential.HealthFailures++
+}
+
+// truncateHealthError 按字符截断错误信息，保证不超过列宽。
+func truncateHealthError(message string) string {
+	message = strings.TrimSpace(message)
+	if utf8.RuneCountInString(message) <= healthErrorMaxRunes {
+		return message
+	}
+	return string([]rune(message)[:healthErrorMaxRunes])
+}
+
+// toCredentialHealth 将数据库中的健康字段转换为对外结构，尚未检查过时返回 nil。
+func toCredentialHealth(entity domain.UserModelCredential) *CredentialHealth {
+	if entity.HealthStatus == "" {
+		return nil
+	}
+	return &CredentialHealth{
+		Status:              entity.HealthStatus,
+		CheckedAt:           entity.HealthCheckedAt,
+		LatencyMs:           entity.HealthLatencyMs,
+		LastError:           entity.HealthError,
+		ConsecutiveFailures: entity.HealthFailures,
+	}
+}
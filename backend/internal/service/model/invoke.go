@@ -5,10 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 
+	"electron-go-app/backend/internal/domain/llm"
 	domain "electron-go-app/backend/internal/domain/user"
 	"electron-go-app/backend/internal/infra/model/deepseek"
 	volc "electron-go-app/backend/internal/infra/model/volcengine"
@@ -21,55 +22,91 @@ const (
 	// defaultDeepSeekModel 当用户未填写具体模型时的默认型号。
 	defaultDeepSeekModel   = "deepseek-chat"
 	defaultVolcengineModel = "doubao-1-5-thinking-pro-250415"
+	defaultOpenAIModel     = "gpt-4o-mini"
+	// defaultOpenAIBaseURL 为官方 OpenAI 接口地址，openai-compatible 不使用该兜底。
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
 )
 
 // InvokeChatCompletion 根据模型 key 读取凭据并调用对应提供方的 Chat Completion 接口。
 // 关键流程：从数据库查凭据→解密 API Key→合并请求/扩展参数→构造客户端发起请求。
-func (s *Service) InvokeChatCompletion(ctx context.Context, userID uint, modelKey string, req deepseek.ChatCompletionRequest) (deepseek.ChatCompletionResponse, error) {
+func (s *Service) InvokeChatCompletion(ctx context.Context, userID uint, modelKey string, req llm.Request) (llm.Response, error) {
 	credential, err := s.repo.FindByModelKey(ctx, userID, modelKey)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return deepseek.ChatCompletionResponse{}, ErrCredentialNotFound
+			return llm.Response{}, ErrCredentialNotFound
 		}
-		return deepseek.ChatCompletionResponse{}, fmt.Errorf("find credential: %w", err)
+		return llm.Response{}, fmt.Errorf("find credential: %w", err)
 	}
-	return s.invokeProvider(ctx, credential, req)
+	return s.invokeProvider(ctx, credential, req, nil)
+}
+
+// InvokeChatCompletionStream 与 InvokeChatCompletion 共用凭据解析流程，但以流式方式调用模型：
+// 每收到一段增量文本即回调 onDelta，结束后返回拼装好的完整响应（含 usage）。
+func (s *Service) InvokeChatCompletionStream(ctx context.Context, userID uint, modelKey string, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	credential, err := s.repo.FindByModelKey(ctx, userID, modelKey)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return llm.Response{}, ErrCredentialNotFound
+		}
+		return llm.Response{}, fmt.Errorf("find credential: %w", err)
+	}
+	if onDelta == nil {
+		onDelta = func(string) error { return nil }
+	}
+	return s.invokeProvider(ctx, credential, req, onDelta)
 }
 
 // InvokeDeepSeekChatCompletion 保留旧接口，兼容已有调用逻辑。
-func (s *Service) InvokeDeepSeekChatCompletion(ctx context.Context, userID uint, modelKey string, req deepseek.ChatCompletionRequest) (deepseek.ChatCompletionResponse, error) {
+func (s *Service) InvokeDeepSeekChatCompletion(ctx context.Context, userID uint, modelKey string, req llm.Request) (llm.Response, error) {
 	return s.InvokeChatCompletion(ctx, userID, modelKey, req)
 }
 
 // TestConnection 尝试使用指定凭据发起一次调用，并记录最新验证时间。
 // 统一入口便于在此处完成多家模型的联调与状态更新。
-func (s *Service) TestConnection(ctx context.Context, userID, id uint, req deepseek.ChatCompletionRequest) (deepseek.ChatCompletionResponse, error) {
+func (s *Service) TestConnection(ctx context.Context, userID, id uint, req llm.Request) (llm.Response, error) {
 	credential, err := s.repo.FindByID(ctx, userID, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return deepseek.ChatCompletionResponse{}, ErrCredentialNotFound
+			return llm.Response{}, ErrCredentialNotFound
 		}
-		return deepseek.ChatCompletionResponse{}, fmt.Errorf("find credential: %w", err)
+		return llm.Response{}, fmt.Errorf("find credential: %w", err)
 	}
 
-	// 与在线调用共享调用链，确保所有校验逻辑一致。
-	resp, err := s.invokeProvider(ctx, credential, req)
-	if err != nil {
-		return deepseek.ChatCompletionResponse{}, err
+	// Ollama 先确认模型已在本地拉取，避免把“模型不存在”误判为网络故障。
+	if normalizeProvider(credential.Provider) == "ollama" && !strings.EqualFold(credential.Status, "disabled") {
+		if err := s.ensureOllamaModel(ctx, credential, req); err != nil {
+			return llm.Response{}, err
+		}
+	}
+
+	// 与在线调用共享调用链，确保所有校验逻辑一致；手动测试不受熔断限制，结果同样计入熔断器，可用于提前恢复。
+	start := time.Now()
+	resp, err := s.callProvider(ctx, credential, req, nil)
+	s.breakers.record(credential.ID, err)
+	if err == nil {
+		resp.Cost = s.prices.Estimate(credential.Provider, resp.Usage, resp.Model, req.Model, credential.ModelKey)
+	}
+	latency := time.Since(start)
+	s.recordCredentialUsage(ctx, credential, UsageOperationTestConnection, resp, latency, err)
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCredentialDisabled) {
+		return llm.Response{}, err
 	}
 
-	// 成功后刷新最近验证时间，帮助前端提示凭据连通状态。
-	now := time.Now()
-	credential.LastVerifiedAt = &now
-	if updateErr := s.repo.Update(ctx, credential); updateErr != nil {
-		return deepseek.ChatCompletionResponse{}, fmt.Errorf("update last_verified_at: %w", updateErr)
+	// 手动测试同样刷新健康状态，成功时更新最近验证时间，用户更换 Key 后可立即解除 revoked 标记。
+	applyHealthResult(credential, start, latency, err)
+	updateErr := s.repo.UpdateHealth(ctx, credential)
+	if err != nil {
+		return llm.Response{}, err
+	}
+	if updateErr != nil {
+		return llm.Response{}, fmt.Errorf("update last_verified_at: %w", updateErr)
 	}
 	return resp, nil
 }
 
 // prepareModelRequest 会根据用户请求与持久化配置补齐模型参数，确保模型字段永远有值。
 // fallbackModel 用于不同 provider 的默认模型兜底，兼容未显式填写的情况。
-func prepareModelRequest(req deepseek.ChatCompletionRequest, credential *domain.UserModelCredential, fallbackModel string) (deepseek.ChatCompletionRequest, error) {
+func prepareModelRequest(req llm.Request, credential *domain.UserModelCredential, fallbackModel string) (llm.Request, error) {
 	request := req
 	request.Model = strings.TrimSpace(request.Model)
 	if request.Model == "" {
@@ -82,21 +119,25 @@ func prepareModelRequest(req deepseek.ChatCompletionRequest, credential *domain.
 	request.ExtraFields = cloneMap(req.ExtraFields)
 
 	if err := mergeExtraConfig(&request, credential.ExtraConfig); err != nil {
-		return deepseek.ChatCompletionRequest{}, err
+		return llm.Request{}, err
 	}
 	return request, nil
 }
 
-func prepareDeepSeekRequest(req deepseek.ChatCompletionRequest, credential *domain.UserModelCredential) (deepseek.ChatCompletionRequest, error) {
+func prepareDeepSeekRequest(req llm.Request, credential *domain.UserModelCredential) (llm.Request, error) {
 	return prepareModelRequest(req, credential, defaultDeepSeekModel)
 }
 
-func prepareVolcengineRequest(req deepseek.ChatCompletionRequest, credential *domain.UserModelCredential) (deepseek.ChatCompletionRequest, error) {
+func prepareOpenAIRequest(req llm.Request, credential *domain.UserModelCredential) (llm.Request, error) {
+	return prepareModelRequest(req, credential, defaultOpenAIModel)
+}
+
+func prepareVolcengineRequest(req llm.Request, credential *domain.UserModelCredential) (llm.Request, error) {
 	return prepareModelRequest(req, credential, defaultVolcengineModel)
 }
 
 // mergeExtraConfig 将数据库中的 extra_config 映射到请求体，只有调用方未显式设置的字段才会被填充。
-func mergeExtraConfig(request *deepseek.ChatCompletionRequest, extraJSON string) error {
+func mergeExtraConfig(request *llm.Request, extraJSON string) error {
 	if strings.TrimSpace(extraJSON) == "" {
 		return nil
 	}
@@ -151,39 +192,22 @@ func mergeExtraConfig(request *deepseek.ChatCompletionRequest, extraJSON string)
 				}
 			}
 		case "stop":
-			if request.Stop == nil {
-				request.Stop = value
-			}
-		case "stream":
-			if !request.Stream {
-				if v, ok := asBool(value); ok {
-					request.Stream = v
-				}
-			}
-		case "stream_options":
-			if request.StreamOptions == nil {
-				if v, ok := value.(map[string]any); ok {
-					request.StreamOptions = v
-				}
+			if len(request.Stop) == 0 {
+				request.Stop = extractStopList(value)
 			}
+		case "stream", "stream_options":
+			// 是否流式由调用入口决定，忽略持久化配置中的同名字段。
+			continue
 		case "tools":
-			if request.Tools == nil {
-				request.Tools = value
+			if len(request.Tools) == 0 {
+				if v, ok := decodeTools(value); ok {
+					request.Tools = v
+				}
 			}
 		case "tool_choice":
 			if request.ToolChoice == nil {
 				request.ToolChoice = value
 			}
-		case "logprobs":
-			if !request.Logprobs {
-				if v, ok := asBool(value); ok {
-					request.Logprobs = v
-				}
-			}
-		case "top_logprobs":
-			if request.TopLogprobs == nil {
-				request.TopLogprobs = value
-			}
 		default:
 			if request.ExtraFields == nil {
 				request.ExtraFields = map[string]any{}
@@ -196,6 +220,19 @@ func mergeExtraConfig(request *deepseek.ChatCompletionRequest, extraJSON string)
 	return nil
 }
 
+// decodeTools 将 extra_config 中的 tools 数组解析为通用的工具定义。
+func decodeTools(value any) ([]llm.Tool, bool) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var tools []llm.Tool
+	if err := json.Unmarshal(raw, &tools); err != nil || len(tools) == 0 {
+		return nil, false
+	}
+	return tools, true
+}
+
 func cloneMap(src map[string]any) map[string]any {
 	if src == nil {
 		return nil
@@ -247,175 +284,188 @@ func asFloat(value any) (float64, bool) {
 	return 0, false
 }
 
-// asBool 尝试将任意类型转换为 bool。
-func asBool(value any) (bool, bool) {
-	switch v := value.(type) {
-	case bool:
-		return v, true
-	case float64:
-		return v != 0, true
-	case float32:
-		return v != 0, true
-	case int:
-		return v != 0, true
-	case int64:
-		return v != 0, true
-	case string:
-		lower := strings.ToLower(strings.TrimSpace(v))
-		if lower == "true" || lower == "1" {
-			return true, true
-		}
-		if lower == "false" || lower == "0" {
-			return false, true
-		}
+// invokeProvider 按 provider 构造对应的 llm.Provider，onDelta 为空时走一次性接口，否则走流式接口。
+func (s *Service) invokeProvider(ctx context.Context, credential *domain.UserModelCredential, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	if credential == nil {
+		return llm.Response{}, ErrCredentialNotFound
+	}
+	if strings.EqualFold(credential.Status, "disabled") {
+		return llm.Response{}, ErrCredentialDisabled
+	}
+	// 本月估算费用达到预算时直接拒绝，交由回退链切换到其它模型。
+	if err := s.checkBudget(ctx, credential); err != nil {
+		return llm.Response{}, err
+	}
+	// 熔断期间直接失败，避免每个请求都等满调用超时。
+	if err := s.breakers.allow(credential.ID, credential.ModelKey); err != nil {
+		return llm.Response{}, err
+	}
+	start := time.Now()
+	resp, err := s.callProvider(ctx, credential, req, onDelta)
+	s.breakers.record(credential.ID, err)
+	if err == nil {
+		resp.Cost = s.prices.Estimate(credential.Provider, resp.Usage, resp.Model, req.Model, credential.ModelKey)
 	}
-	return false, false
+	s.recordCredentialUsage(ctx, credential, llm.OperationFromContext(ctx), resp, time.Since(start), err)
+	return resp, err
 }
 
-func (s *Service) invokeDeepSeek(ctx context.Context, credential *domain.UserModelCredential, req deepseek.ChatCompletionRequest) (deepseek.ChatCompletionResponse, error) {
-	if credential == nil {
-		return deepseek.ChatCompletionResponse{}, ErrCredentialNotFound
-	}
+// callProvider 构造提供方客户端并发起调用，不经过熔断器。
+func (s *Service) callProvider(ctx context.Context, credential *domain.UserModelCredential, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
 	if strings.EqualFold(credential.Status, "disabled") {
-		return deepseek.ChatCompletionResponse{}, ErrCredentialDisabled
+		return llm.Response{}, ErrCredentialDisabled
+	}
+	provider, prepared, err := s.newProvider(credential, req)
+	if err != nil {
+		return llm.Response{}, err
+	}
+	prepared = withoutUnsupportedTools(prepared)
+	if onDelta != nil {
+		return provider.CompleteStream(ctx, prepared, onDelta)
+	}
+	return provider.Complete(ctx, prepared)
+}
+
+// withoutUnsupportedTools 对内置元数据标明不支持函数调用的模型（如 deepseek-reasoner）移除 tools 与 tool_choice，
+// 避免厂商直接拒绝请求；调用方需依靠 response_format 或正文 JSON 兜底。未知模型保持原样。
+func withoutUnsupportedTools(req llm.Request) llm.Request {
+	if len(req.Tools) == 0 && req.ToolChoice == nil {
+		return req
+	}
+	known, ok := lookupKnownModel(req.Model)
+	if !ok || slices.Contains(known.capabilities, CapabilityTools) {
+		return req
+	}
+	req.Tools = nil
+	req.ToolChoice = nil
+	return req
+}
+
+// newProvider 根据凭据的 provider 字段构造客户端，并返回已补齐模型与扩展参数的请求；全局录制/回放模式下会包装 cassette。
+func (s *Service) newProvider(credential *domain.UserModelCredential, req llm.Request) (llm.Provider, llm.Request, error) {
+	if normalizeProvider(credential.Provider) == ProviderReplay {
+		return s.newReplayProvider(credential, req)
+	}
+	provider, prepared, err := s.newBaseProvider(credential, req)
+	if err != nil {
+		return nil, llm.Request{}, err
+	}
+	wrapped, err := s.wrapReplay(provider)
+	if err != nil {
+		return nil, llm.Request{}, err
+	}
+	return wrapped, prepared, nil
+}
+
+// newBaseProvider 构造各厂商的原生客户端。
+func (s *Service) newBaseProvider(credential *domain.UserModelCredential, req llm.Request) (llm.Provider, llm.Request, error) {
+	switch normalizeProvider(credential.Provider) {
+	case "deepseek":
+		return s.newDeepSeekProvider(credential, req)
+	case "volcengine":
+		return s.newVolcengineProvider(credential, req)
+	case "openai", "openai-compatible":
+		return s.newOpenAICompatibleProvider(credential, req)
+	case "ollama":
+		return newOllamaProvider(credential, req)
+	case "anthropic":
+		return s.newAnthropicProvider(credential, req)
+	default:
+		return nil, llm.Request{}, ErrUnsupportedProvider
 	}
+}
 
+// newDeepSeekProvider 解密 API Key 并构造 DeepSeek 客户端。
+func (s *Service) newDeepSeekProvider(credential *domain.UserModelCredential, req llm.Request) (llm.Provider, llm.Request, error) {
 	apiKeyPlain, err := security.Decrypt(credential.APIKeyCipher)
 	if err != nil {
-		return deepseek.ChatCompletionResponse{}, fmt.Errorf("decrypt api key: %w", err)
+		return nil, llm.Request{}, fmt.Errorf("decrypt api key: %w", err)
 	}
 
 	prepared, err := prepareDeepSeekRequest(req, credential)
 	if err != nil {
-		return deepseek.ChatCompletionResponse{}, err
+		return nil, llm.Request{}, err
 	}
 
 	baseURL := strings.TrimSpace(credential.BaseURL)
-	client := deepseek.NewClient(string(apiKeyPlain), deepseek.WithBaseURL(baseURL))
-
-	return client.ChatCompletion(ctx, prepared)
+	return deepseek.NewClient(string(apiKeyPlain), deepseek.WithBaseURL(baseURL), deepseek.WithRetryPolicy(s.retry)), prepared, nil
 }
 
-// invokeVolcengine 将请求映射到方舟 SDK，再把返回值折叠为统一结构，前端无需区分具体厂商。
-func (s *Service) invokeVolcengine(ctx context.Context, credential *domain.UserModelCredential, req deepseek.ChatCompletionRequest) (deepseek.ChatCompletionResponse, error) {
-	if credential == nil {
-		return deepseek.ChatCompletionResponse{}, ErrCredentialNotFound
-	}
-	if strings.EqualFold(credential.Status, "disabled") {
-		return deepseek.ChatCompletionResponse{}, ErrCredentialDisabled
+// newOpenAICompatibleProvider 复用 DeepSeek 的 HTTP 客户端调用 OpenAI 及兼容协议的服务（如自建网关、vLLM）。
+// extra_config 中的 headers/organization/project 会转为请求头，不会写入请求体。
+func (s *Service) newOpenAICompatibleProvider(credential *domain.UserModelCredential, req llm.Request) (llm.Provider, llm.Request, error) {
+	baseURL := strings.TrimSpace(credential.BaseURL)
+	if baseURL == "" {
+		if normalizeProvider(credential.Provider) != "openai" {
+			return nil, llm.Request{}, ErrBaseURLRequired
+		}
+		baseURL = defaultOpenAIBaseURL
 	}
 
 	apiKeyPlain, err := security.Decrypt(credential.APIKeyCipher)
 	if err != nil {
-		return deepseek.ChatCompletionResponse{}, fmt.Errorf("decrypt api key: %w", err)
+		return nil, llm.Request{}, fmt.Errorf("decrypt api key: %w", err)
 	}
 
-	prepared, err := prepareVolcengineRequest(req, credential)
-	if err != nil {
-		return deepseek.ChatCompletionResponse{}, err
-	}
-
-	volcReq := volc.ChatCompletionRequest{
-		Model:            prepared.Model,
-		Messages:         make([]volc.ChatMessage, 0, len(prepared.Messages)),
-		MaxTokens:        prepared.MaxTokens,
-		Temperature:      prepared.Temperature,
-		TopP:             prepared.TopP,
-		PresencePenalty:  prepared.PresencePenalty,
-		FrequencyPenalty: prepared.FrequencyPenalty,
-		Stop:             extractStopList(prepared.Stop),
-		ResponseFormat:   prepared.ResponseFormat,
-		ExtraFields:      cloneMap(prepared.ExtraFields),
-	}
-	for _, msg := range prepared.Messages {
-		volcReq.Messages = append(volcReq.Messages, volc.ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
-	}
-
-	client := volc.NewClient(string(apiKeyPlain), volc.WithBaseURL(strings.TrimSpace(credential.BaseURL)))
-	resp, err := client.ChatCompletion(ctx, volcReq)
+	prepared, err := prepareOpenAIRequest(req, credential)
 	if err != nil {
-		return deepseek.ChatCompletionResponse{}, err
+		return nil, llm.Request{}, err
 	}
+	headers := extractOpenAIHeaders(&prepared)
 
-	return convertVolcengineResponse(resp), nil
+	client := deepseek.NewClient(string(apiKeyPlain), deepseek.WithBaseURL(baseURL), deepseek.WithHeaders(headers), deepseek.WithRetryPolicy(s.retry))
+	return client, prepared, nil
 }
 
-func (s *Service) invokeProvider(ctx context.Context, credential *domain.UserModelCredential, req deepseek.ChatCompletionRequest) (deepseek.ChatCompletionResponse, error) {
-	switch normalizeProvider(credential.Provider) {
-	case "deepseek":
-		return s.invokeDeepSeek(ctx, credential, req)
-	case "volcengine":
-		return s.invokeVolcengine(ctx, credential, req)
-	default:
-		return deepseek.ChatCompletionResponse{}, ErrUnsupportedProvider
+// extractOpenAIHeaders 从 ExtraFields 中摘出请求头相关配置，避免这些字段被当作模型参数提交。
+func extractOpenAIHeaders(request *llm.Request) map[string]string {
+	if request == nil || len(request.ExtraFields) == 0 {
+		return nil
 	}
-}
-
-// convertVolcengineResponse 负责把方舟的响应字段转换为 DeepSeek 兼容的结构。
-func convertVolcengineResponse(resp volc.ChatCompletionResponse) deepseek.ChatCompletionResponse {
-	converted := deepseek.ChatCompletionResponse{
-		ID:      resp.ID,
-		Object:  resp.Object,
-		Created: resp.Created,
-		Model:   resp.Model,
-		Raw:     resp.Raw,
-	}
-	if resp.ServiceTier != "" {
-		converted.SystemFingerprint = resp.ServiceTier // 复用现有字段透出服务等级
-	}
-
-	if len(resp.Choices) > 0 {
-		converted.Choices = make([]deepseek.ChatCompletionChoice, 0, len(resp.Choices))
-		for _, choice := range resp.Choices {
-			reasoningContent := strings.TrimSpace(choice.Message.ReasoningContent)
-			convertedChoice := deepseek.ChatCompletionChoice{
-				Index: choice.Index,
-				Message: deepseek.ChatMessage{
-					Role:    choice.Message.Role,
-					Content: choice.Message.Content,
-				},
-				FinishReason: choice.FinishReason,
-			}
-			if reasoningContent != "" {
-				// reasoning_content 为火山扩展字段，借用 Logprobs 透传给上层。
-				convertedChoice.Logprobs = map[string]any{
-					"reasoning_content": reasoningContent,
+	headers := map[string]string{}
+	for key, value := range request.ExtraFields {
+		switch strings.ToLower(key) {
+		case "headers":
+			if v, ok := value.(map[string]any); ok {
+				for name, raw := range v {
+					if text, ok := raw.(string); ok && strings.TrimSpace(name) != "" {
+						headers[strings.TrimSpace(name)] = text
+					}
 				}
 			}
-			converted.Choices = append(converted.Choices, convertedChoice)
-		}
-	}
-
-	if resp.Usage != nil {
-		usage := &deepseek.ChatCompletionUsage{
-			PromptTokens:     int64(resp.Usage.PromptTokens),
-			CompletionTokens: int64(resp.Usage.CompletionTokens),
-			TotalTokens:      int64(resp.Usage.TotalTokens),
-			PromptTokensDetails: map[string]any{
-				"cached_tokens": resp.Usage.CachedTokens,
-			},
-		}
-		if resp.Usage.ProvisionedPromptTokens != nil {
-			usage.PromptTokensDetails["provisioned_tokens"] = *resp.Usage.ProvisionedPromptTokens
-		}
-		if resp.Usage.ReasoningTokens > 0 {
-			usage.CompletionTokensByType = map[string]json.Number{
-				"reasoning_tokens": json.Number(strconv.FormatInt(int64(resp.Usage.ReasoningTokens), 10)),
+		case "organization", "organization_id":
+			if v, ok := value.(string); ok && strings.TrimSpace(v) != "" {
+				headers["OpenAI-Organization"] = strings.TrimSpace(v)
 			}
-		}
-		if resp.Usage.ProvisionedCompTokens != nil {
-			if usage.CompletionTokensByType == nil {
-				usage.CompletionTokensByType = map[string]json.Number{}
+		case "project", "project_id":
+			if v, ok := value.(string); ok && strings.TrimSpace(v) != "" {
+				headers["OpenAI-Project"] = strings.TrimSpace(v)
 			}
-			usage.CompletionTokensByType["provisioned_tokens"] = json.Number(strconv.FormatInt(int64(*resp.Usage.ProvisionedCompTokens), 10))
+		default:
+			continue
 		}
-		converted.Usage = usage
+		delete(request.ExtraFields, key)
+	}
+	if len(request.ExtraFields) == 0 {
+		request.ExtraFields = nil
+	}
+	return headers
+}
+
+// newVolcengineProvider 构造方舟客户端，请求与响应的映射由客户端完成，前端无需区分具体厂商。
+func (s *Service) newVolcengineProvider(credential *domain.UserModelCredential, req llm.Request) (llm.Provider, llm.Request, error) {
+	apiKeyPlain, err := security.Decrypt(credential.APIKeyCipher)
+	if err != nil {
+		return nil, llm.Request{}, fmt.Errorf("decrypt api key: %w", err)
+	}
+
+	prepared, err := prepareVolcengineRequest(req, credential)
+	if err != nil {
+		return nil, llm.Request{}, err
 	}
 
-	return converted
+	client := volc.NewClient(string(apiKeyPlain), volc.WithBaseURL(strings.TrimSpace(credential.BaseURL)), volc.WithRetryPolicy(s.retry))
+	return client, prepared, nil
 }
 
 // extractStopList 将 ExtraConfig/请求体中多样化的 stop 写法转换成字符串切片。
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"electron-go-app/backend/internal/infra/security"
+)
+
+// DefaultKeyRotationBatchSize 为主密钥轮换每批读取的凭据数量。
+const DefaultKeyRotationBatchSize = 200
+
+// KeyRotationOptions 控制主密钥轮换的批大小与是否只做检查。
+type KeyRotationOptions struct {
+	BatchSize int
+	DryRun    bool
+}
+
+// KeyRotationReport 汇总一次主密钥轮换的结果。
+type KeyRotationReport struct {
+	PrimaryKeyID string `json:"primary_key_id"`
+	Scanned      int    `json:"scanned"`
+	Rotated      int    `json:"rotated"`
+	UpToDate     int    `json:"up_to_date"`
+	Conflicts    int    `json:"conflicts"` // 轮换期间被用户修改的凭据，重新执行即可
+	Failed       []uint `json:"failed"`    // 无法用任何已配置密钥解密的凭据 ID
+}
+
+// RotateMasterKey 使用当前主密钥重新加密所有凭据的 API Key，旧格式或由历史密钥加密的记录都会被改写。
+// 解密失败的记录不会中断流程，ID 记录在 Failed 中以便人工处理。
+func (s *Service) RotateMasterKey(ctx context.Context, opts KeyRotationOptions) (KeyRotationReport, error) {
+	primaryID, err := security.PrimaryKeyID()
+	if err != nil {
+		return KeyRotationReport{}, err
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultKeyRotationBatchSize
+	}
+	report := KeyRotationReport{PrimaryKeyID: primaryID, Failed: []uint{}}
+	var afterID uint
+	for {
+		batch, err := s.repo.ListAfterID(ctx, afterID, batchSize)
+		if err != nil {
+			return report, fmt.Errorf("list credentials: %w", err)
+		}
+		for _, credential := range batch {
+			afterID = credential.ID
+			report.Scanned++
+			stale, err := security.NeedsRotation(credential.APIKeyCipher)
+			if err != nil {
+				return report, err
+			}
+			if !stale {
+				report.UpToDate++
+				continue
+			}
+			plain, err := security.Decrypt(credential.APIKeyCipher)
+			if err != nil {
+				report.Failed = append(report.Failed, credential.ID)
+				continue
+			}
+			if opts.DryRun {
+				report.Rotated++
+				continue
+			}
+			sealed, err := security.Encrypt(plain)
+			if err != nil {
+				return report, fmt.Errorf("encrypt api key: %w", err)
+			}
+			updated, err := s.repo.ReplaceAPIKeyCipher(ctx, credential.ID, credential.APIKeyCipher, sealed)
+			if err != nil {
+				return report, fmt.Errorf("update credential %d: %w", credential.ID, err)
+			}
+			if !updated {
+				report.Conflicts++
+				continue
+			}
+			report.Rotated++
+		}
+		if len(batch) < batchSize {
+			return report, nil
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"electron-go-app/backend/internal/domain/llm"
+	domain "electron-go-app/backend/internal/domain/user"
+	"electron-go-app/backend/internal/infra/model/ollama"
+	"electron-go-app/backend/internal/infra/security"
+)
+
+// defaultOllamaModel 当用户未填写具体模型时的默认本地模型。
+const defaultOllamaModel = "qwen2.5:7b"
+
+func prepareOllamaRequest(req llm.Request, credential *domain.UserModelCredential) (llm.Request, error) {
+	return prepareModelRequest(req, credential, defaultOllamaModel)
+}
+
+// newOllamaProvider 构造本地 Ollama 客户端，离线模式下无需云端凭据即可完成解析与生成。
+func newOllamaProvider(credential *domain.UserModelCredential, req llm.Request) (llm.Provider, llm.Request, error) {
+	client, err := newOllamaClient(credential)
+	if err != nil {
+		return nil, llm.Request{}, err
+	}
+	prepared, err := prepareOllamaRequest(req, credential)
+	if err != nil {
+		return nil, llm.Request{}, err
+	}
+	return client, prepared, nil
+}
+
+// ensureOllamaModel 通过 /api/tags 确认目标模型已在本地拉取，便于连通性测试给出明确提示。
+func (s *Service) ensureOllamaModel(ctx context.Context, credential *domain.UserModelCredential, req llm.Request) error {
+	client, err := newOllamaClient(credential)
+	if err != nil {
+		return err
+	}
+	prepared, err := prepareOllamaRequest(req, credential)
+	if err != nil {
+		return err
+	}
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return err
+	}
+	for _, item := range models {
+		if ollamaModelMatches(prepared.Model, item.Name) || ollamaModelMatches(prepared.Model, item.Model) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrModelUnavailable, prepared.Model)
+}
+
+// newOllamaClient 解密可选的 API Key 并构造客户端，本地直连时 API Key 为空。
+func newOllamaClient(credential *domain.UserModelCredential) (*ollama.Client, error) {
+	apiKey := ""
+	if len(credential.APIKeyCipher) > 0 {
+		plain, err := security.Decrypt(credential.APIKeyCipher)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt api key: %w", err)
+		}
+		apiKey = string(plain)
+	}
+	return ollama.NewClient(apiKey, ollama.WithBaseURL(credential.BaseURL)), nil
+}
+
+// ollamaModelMatches 判断模型名是否一致，未写 tag 时视为 latest。
+func ollamaModelMatches(want, have string) bool {
+	want = strings.TrimSpace(want)
+	have = strings.TrimSpace(have)
+	if want == "" || have == "" {
+		return false
+	}
+	if strings.EqualFold(want, have) {
+		return true
+	}
+	if !strings.Contains(want, ":") {
+		return strings.EqualFold(want+":latest", have)
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+// DefaultPriceCurrency 为内置价格表使用的币种。
+const DefaultPriceCurrency = "USD"
+
+// ModelPrice 描述单个模型每百万 token 的价格，cached_input 为命中缓存的输入价格，未配置时按 input 计费。
+type ModelPrice struct {
+	Provider    string   `json:"provider,omitempty"` // 为空时匹配任意提供方
+	Model       string   `json:"model,omitempty"`    // 为空时匹配该提供方下的所有模型
+	Input       float64  `json:"input"`
+	Output      float64  `json:"output"`
+	CachedInput *float64 `json:"cached_input,omitempty"`
+}
+
+// PriceCatalog 为按提供方/模型配置的价格表，用于估算每次调用的费用。
+type PriceCatalog struct {
+	Currency string       `json:"currency"`
+	Models   []ModelPrice `json:"models"`
+}
+
+// cachedPrice 便于声明内置价格中的缓存输入价格。
+func cachedPrice(v float64) *float64 {
+	return &v
+}
+
+// DefaultPriceCatalog 返回内置的参考价格（美元/百万 token，按官方公开价格折算），实际结算以厂商账单为准。
+func DefaultPriceCatalog() PriceCatalog {
+	return PriceCatalog{
+		Currency: DefaultPriceCurrency,
+		Models: []ModelPrice{
+			{Provider: "deepseek", Model: "deepseek-chat", Input: 0.27, Output: 1.10, CachedInput: cachedPrice(0.07)},
+			{Provider: "deepseek", Model: "deepseek-reasoner", Input: 0.55, Output: 2.19, CachedInput: cachedPrice(0.14)},
+			{Provider: "volcengine", Model: "doubao-1-5-thinking-pro", Input: 0.55, Output: 2.20},
+			{Provider: "volcengine", Model: "doubao-1-5-pro-32k", Input: 0.11, Output: 0.28},
+			{Provider: "volcengine", Model: "doubao-1-5-lite-32k", Input: 0.04, Output: 0.08},
+			{Model: "gpt-4o-mini", Input: 0.15, Output: 0.60, CachedInput: cachedPrice(0.075)},
+			{Model: "gpt-4o", Input: 2.50, Output: 10.00, CachedInput: cachedPrice(1.25)},
+			{Model: "o3-mini", Input: 1.10, Output: 4.40, CachedInput: cachedPrice(0.55)},
+			{Provider: "anthropic", Model: "claude-3-5-haiku", Input: 0.80, Output: 4.00, CachedInput: cachedPrice(0.08)},
+			{Provider: "anthropic", Model: "claude-3-5-sonnet", Input: 3.00, Output: 15.00, CachedInput: cachedPrice(0.30)},
+			{Provider: "anthropic", Model: "claude-sonnet-4", Input: 3.00, Output: 15.00, CachedInput: cachedPrice(0.30)},
+			{Provider: "anthropic", Model: "claude-opus-4", Input: 15.00, Output: 75.00, CachedInput: cachedPrice(1.50)},
+			{Provider: "ollama", Input: 0, Output: 0},
+		},
+	}
+}
+
+// LoadPriceCatalogFile 从 JSON 文件读取价格表，文件中的条目优先于内置价格匹配。
+func LoadPriceCatalogFile(path string) (PriceCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PriceCatalog{}, fmt.Errorf("read price catalog: %w", err)
+	}
+	var catalog PriceCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return PriceCatalog{}, fmt.Errorf("decode price catalog: %w", err)
+	}
+	for _, price := range catalog.Models {
+		if price.Input < 0 || price.Output < 0 || (price.CachedInput != nil && *price.CachedInput < 0) {
+			return PriceCatalog{}, errors.New("price catalog contains negative price")
+		}
+	}
+	defaults := DefaultPriceCatalog()
+	if strings.TrimSpace(catalog.Currency) == "" {
+		catalog.Currency = defaults.Currency
+	}
+	if strings.EqualFold(catalog.Currency, defaults.Currency) {
+		catalog.Models = append(catalog.Models, defaults.Models...)
+	}
+	return catalog, nil
+}
+
+// Lookup 按顺序查找第一个匹配的价格：模型名相同，或以“配置名-”开头（兼容带日期后缀的版本号）。
+func (c PriceCatalog) Lookup(provider string, models ...string) (ModelPrice, bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	for _, price := range c.Models {
+		priceProvider := strings.ToLower(strings.TrimSpace(price.Provider))
+		if priceProvider != "" && priceProvider != provider {
+			continue
+		}
+		priceModel := strings.ToLower(strings.TrimSpace(price.Model))
+		if priceModel == "" {
+			return price, true
+		}
+		for _, model := range models {
+			model = strings.ToLower(strings.TrimSpace(model))
+			if model == priceModel || strings.HasPrefix(model, priceModel+"-") {
+				return price, true
+			}
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// Estimate 根据 token 用量估算费用，价格表中没有对应模型时返回 nil。
+func (c PriceCatalog) Estimate(provider string, usage *llm.Usage, models ...string) *llm.Cost {
+	if usage == nil {
+		return nil
+	}
+	price, ok := c.Lookup(provider, models...)
+	if !ok {
+		return nil
+	}
+	cached := usage.CachedTokens
+	if cached > usage.PromptTokens {
+		cached = usage.PromptTokens
+	}
+	cachedRate := price.Input
+	if price.CachedInput != nil {
+		cachedRate = *price.CachedInput
+	}
+	amount := (float64(usage.PromptTokens-cached)*price.Input +
+		float64(cached)*cachedRate +
+		float64(usage.CompletionTokens)*price.Output) / 1_000_000
+	return &llm.Cost{Amount: amount, Currency: c.Currency}
+}
+
+// EstimateCost 使用服务配置的价格表估算一次调用的费用。
+func (s *Service) EstimateCost(provider string, usage *llm.Usage, models ...string) *llm.Cost {
+	return s.prices.Estimate(provider, usage, models...)
+}
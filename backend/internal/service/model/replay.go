@@ -0,0 +1,155 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"electron-go-app/backend/internal/domain/llm"
+	domain "electron-go-app/backend/internal/domain/user"
+	"electron-go-app/backend/internal/infra/model/replay"
+)
+
+const (
+	// ProviderReplay 为录制/回放提供方，调用结果来自 cassette 文件。
+	ProviderReplay = "replay"
+	// DefaultReplayCassette 为全局录制/回放模式使用的默认 cassette 文件名。
+	DefaultReplayCassette = "default.json"
+)
+
+var (
+	ErrReplayDisabled  = errors.New("model replay is not configured")
+	ErrInvalidCassette = errors.New("invalid replay cassette name")
+)
+
+// ReplayConfig 描述录制/回放配置：Dir 为空时禁用 replay 提供方，Mode 非空时包装所有凭据的调用。
+type ReplayConfig struct {
+	Dir      string      // cassette 文件所在目录
+	Mode     replay.Mode // 全局模式，为空表示只对 provider=replay 的凭据生效
+	Cassette string      // 全局模式使用的 cassette 文件名
+}
+
+// replayOptions 为 replay 凭据在 extra_config 中的专用字段。
+type replayOptions struct {
+	cassette string
+	mode     string
+	upstream string
+}
+
+// newReplayProvider 构造 replay 凭据的客户端；配置了 upstream_provider 时复用同一凭据的 Key 与 BaseURL 录制真实调用。
+func (s *Service) newReplayProvider(credential *domain.UserModelCredential, req llm.Request) (llm.Provider, llm.Request, error) {
+	if s.replay.Dir == "" {
+		return nil, llm.Request{}, ErrReplayDisabled
+	}
+	options, upstreamCredential, err := splitReplayConfig(credential)
+	if err != nil {
+		return nil, llm.Request{}, err
+	}
+	mode, err := replay.ParseMode(options.mode)
+	if err != nil {
+		return nil, llm.Request{}, err
+	}
+	name := options.cassette
+	if name == "" {
+		name = credential.ModelKey
+	}
+	path, err := s.replayCassettePath(credential.UserID, name)
+	if err != nil {
+		return nil, llm.Request{}, err
+	}
+
+	opts := []replay.Option{replay.WithMode(mode)}
+	var prepared llm.Request
+	if options.upstream != "" {
+		if options.upstream == ProviderReplay {
+			return nil, llm.Request{}, ErrUnsupportedProvider
+		}
+		upstreamCredential.Provider = options.upstream
+		upstream, upstreamRequest, err := s.newBaseProvider(upstreamCredential, req)
+		if err != nil {
+			return nil, llm.Request{}, err
+		}
+		opts = append(opts, replay.WithUpstream(upstream))
+		prepared = upstreamRequest
+	} else {
+		prepared, err = prepareModelRequest(req, upstreamCredential, "")
+		if err != nil {
+			return nil, llm.Request{}, err
+		}
+	}
+	return replay.NewClient(path, opts...), prepared, nil
+}
+
+// wrapReplay 在全局录制/回放模式下为普通凭据套上 cassette，未开启时原样返回。
+func (s *Service) wrapReplay(provider llm.Provider) (llm.Provider, error) {
+	if s.replay.Mode == "" {
+		return provider, nil
+	}
+	if s.replay.Dir == "" {
+		return nil, ErrReplayDisabled
+	}
+	name := s.replay.Cassette
+	if name == "" {
+		name = DefaultReplayCassette
+	}
+	path, err := s.replayCassettePath(0, name)
+	if err != nil {
+		return nil, err
+	}
+	return replay.NewClient(path, replay.WithMode(s.replay.Mode), replay.WithUpstream(provider)), nil
+}
+
+// replayCassettePath 将 cassette 名称解析为目录下的文件路径，只接受单个文件名，避免用户配置写出目录之外。
+// userID 非 0 时落在 <Dir>/<userID>/ 子目录，用户之间无法读取彼此录制的请求与回复；0 表示全局模式共用的根目录。
+func (s *Service) replayCassettePath(userID uint, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || strings.Contains(name, "..") || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCassette, name)
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".json") {
+		name += ".json"
+	}
+	if userID == 0 {
+		return filepath.Join(s.replay.Dir, name), nil
+	}
+	return filepath.Join(s.replay.Dir, strconv.FormatUint(uint64(userID), 10), name), nil
+}
+
+// splitReplayConfig 从 extra_config 中摘出 replay 专用字段，返回的凭据副本不再包含这些字段，避免透传给上游。
+func splitReplayConfig(credential *domain.UserModelCredential) (replayOptions, *domain.UserModelCredential, error) {
+	clone := *credential
+	var options replayOptions
+	if strings.TrimSpace(credential.ExtraConfig) == "" {
+		return options, &clone, nil
+	}
+	extra := map[string]any{}
+	if err := json.Unmarshal([]byte(credential.ExtraConfig), &extra); err != nil {
+		return options, nil, fmt.Errorf("decode extra_config: %w", err)
+	}
+	for key, value := range extra {
+		text, _ := value.(string)
+		switch strings.ToLower(key) {
+		case "cassette":
+			options.cassette = strings.TrimSpace(text)
+		case "replay_mode":
+			options.mode = strings.TrimSpace(text)
+		case "upstream_provider":
+			options.upstream = normalizeProvider(text)
+		default:
+			continue
+		}
+		delete(extra, key)
+	}
+	clone.ExtraConfig = ""
+	if len(extra) > 0 {
+		raw, err := json.Marshal(extra)
+		if err != nil {
+			return options, nil, fmt.Errorf("encode extra_config: %w", err)
+		}
+		clone.ExtraConfig = string(raw)
+	}
+	return options, &clone, nil
+}
@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"electron-go-app/backend/internal/domain/llm"
 	domain "electron-go-app/backend/internal/domain/user"
 	"electron-go-app/backend/internal/infra/security"
 	"electron-go-app/backend/internal/repository"
@@ -28,50 +29,67 @@ var (
 	ErrStatusMismatchUpdate = errors.New("status update failed")
 	ErrCredentialDisabled   = errors.New("model credential disabled")
 	ErrUnsupportedProvider  = errors.New("unsupported model provider")
+	ErrBaseURLRequired      = errors.New("base_url is required for provider")
+	ErrModelUnavailable     = errors.New("model not available on provider")
 )
 
 // supportedProviders 维护允许接入的模型提供方列表。
 var supportedProviders = map[string]struct{}{
-	"deepseek":   {},
-	"volcengine": {},
+	"deepseek":          {},
+	"volcengine":        {},
+	"openai":            {},
+	"openai-compatible": {},
+	"ollama":            {},
+	"anthropic":         {},
+	ProviderReplay:      {},
 }
 
 // Credential 表示对外返回的模型凭据（脱敏）。
 type Credential struct {
-	ID                uint           `json:"id"`                            // 数据库主键
-	Provider          string         `json:"provider"`                      // 模型提供方标识，如 deepseek
-	ModelKey          string         `json:"model_key"`                     // 前端引用的模型键
-	DisplayName       string         `json:"display_name"`                  // 展示用名称
-	BaseURL           string         `json:"base_url"`                      // 自定义 BaseURL（可选）
-	ExtraConfig       map[string]any `json:"extra_config"`                  // 额外 JSON 配置
-	Status            string         `json:"status"`                        // 启用/禁用状态
-	LastVerifiedAt    *time.Time     `json:"last_verified_at"`              // 最近一次连通性校验时间
-	CreatedAt         time.Time      `json:"created_at"`                    // 创建时间
-	UpdatedAt         time.Time      `json:"updated_at"`                    // 更新时间
-	IsBuiltin         bool           `json:"is_builtin,omitempty"`          // 是否平台内置（只读）模型
-	DailyQuota        *int           `json:"daily_quota,omitempty"`         // 免费模型的每日额度
-	ActualModel       string         `json:"actual_model,omitempty"`        // 实际调用的底层模型键
-	RemainingQuota    *int           `json:"remaining_quota,omitempty"`     // 当前剩余额度
-	ResetAfterSeconds *int64         `json:"reset_after_seconds,omitempty"` // 距离额度重置的秒数
+	ID                uint              `json:"id"`                            // 数据库主键
+	Provider          string            `json:"provider"`                      // 模型提供方标识，如 deepseek
+	ModelKey          string            `json:"model_key"`                     // 前端引用的模型键
+	DisplayName       string            `json:"display_name"`                  // 展示用名称
+	BaseURL           string            `json:"base_url"`                      // 自定义 BaseURL（可选）
+	ExtraConfig       map[string]any    `json:"extra_config"`                  // 额外 JSON 配置
+	Status            string            `json:"status"`                        // 启用/禁用状态
+	LastVerifiedAt    *time.Time        `json:"last_verified_at"`              // 最近一次连通性校验时间
+	Circuit           *CircuitStatus    `json:"circuit,omitempty"`             // 熔断器状态，open/half_open 表示厂商降级
+	Health            *CredentialHealth `json:"health,omitempty"`              // 后台健康检查结果，尚未检查时为空
+	MonthlyBudget     *float64          `json:"monthly_budget"`                // 每月预算上限，为空表示不限制
+	MonthToDateCost   *float64          `json:"month_to_date_cost,omitempty"`  // 本月估算费用
+	Currency          string            `json:"currency,omitempty"`            // 费用与价格的币种
+	Price             *ModelPrice       `json:"price,omitempty"`               // 价格表中匹配到的单价（每百万 token）
+	CreatedAt         time.Time         `json:"created_at"`                    // 创建时间
+	UpdatedAt         time.Time         `json:"updated_at"`                    // 更新时间
+	IsBuiltin         bool              `json:"is_builtin,omitempty"`          // 是否平台内置（只读）模型
+	DailyQuota        *int              `json:"daily_quota,omitempty"`         // 免费模型的每日额度
+	ActualModel       string            `json:"actual_model,omitempty"`        // 实际调用的底层模型键
+	RemainingQuota    *int              `json:"remaining_quota,omitempty"`     // 当前剩余额度
+	ResetAfterSeconds *int64            `json:"reset_after_seconds,omitempty"` // 距离额度重置的秒数
 }
 
 // CreateInput 描述新增模型凭据所需的字段。
 type CreateInput struct {
-	Provider    string
-	ModelKey    string
-	DisplayName string
-	BaseURL     string
-	APIKey      string
-	ExtraConfig map[string]any
+	Provider      string
+	ModelKey      string
+	DisplayName   string
+	BaseURL       string
+	APIKey        string
+	ExtraConfig   map[string]any
+	MonthlyBudget *float64
+	ValidateModel bool // 为 true 时先确认 model_key 出现在提供方的模型列表中
 }
 
 // UpdateInput 定义可更新的凭据信息。
 type UpdateInput struct {
-	DisplayName *string
-	BaseURL     *string
-	APIKey      *string
-	ExtraConfig map[string]any
-	Status      *string
+	DisplayName   *string
+	BaseURL       *string
+	APIKey        *string
+	ExtraConfig   map[string]any
+	Status        *string
+	MonthlyBudget *float64 // 0 表示取消预算
+	ValidateModel bool     // 为 true 时按更新后的 BaseURL/API Key 重新确认 model_key 可用
 }
 
 // ResolveProviderByModelKey 返回指定模型 key 对应凭据的 provider。
@@ -86,15 +104,53 @@ func (s *Service) ResolveProviderByModelKey(ctx context.Context, userID uint, mo
 	return normalizeProvider(credential.Provider), nil
 }
 
+// CredentialFingerprint 返回模型 key 当前对应凭据的版本标识（凭据 ID 与更新时间），凭据被修改或重建后标识随之变化。
+func (s *Service) CredentialFingerprint(ctx context.Context, userID uint, modelKey string) (string, error) {
+	credential, err := s.repo.FindByModelKey(ctx, userID, modelKey)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrCredentialNotFound
+		}
+		return "", fmt.Errorf("find credential: %w", err)
+	}
+	return fmt.Sprintf("%d:%d", credential.ID, credential.UpdatedAt.UnixNano()), nil
+}
+
 // Service 聚合模型凭据仓储与用户仓储，用于跨层更新偏好设置。
 type Service struct {
-	repo  *repository.ModelCredentialRepository
-	users *repository.UserRepository
+	repo     *repository.ModelCredentialRepository
+	users    *repository.UserRepository
+	usage    *repository.ModelUsageRepository
+	retry    llm.RetryPolicy
+	breakers *circuitBreakers
+	prices   PriceCatalog
+	replay   ReplayConfig
+	catalogs *catalogCache
+	health   HealthConfig
 }
 
-// NewService 构造模型凭据服务。
+// Config 描述模型调用的可调参数。
+type Config struct {
+	Retry   llm.RetryPolicy // 单个凭据遇到限流或瞬时故障时的重试策略
+	Circuit CircuitConfig   // 单个凭据连续失败后的熔断策略
+	Prices  PriceCatalog    // 估算费用使用的价格表，为空时使用内置价格
+	Replay  ReplayConfig    // 录制/回放配置，用于离线测试与演示
+	// CatalogTTL 为模型目录的缓存时长，为 0 时使用 DefaultCatalogTTL。
+	CatalogTTL time.Duration
+	Health     HealthConfig // 后台凭据健康检查配置，Interval 为 0 时关闭
+}
+
+// NewService 构造模型凭据服务，使用默认重试与熔断策略，不记录用量流水。
 func NewService(repo *repository.ModelCredentialRepository, users *repository.UserRepository) *Service {
-	return &Service{repo: repo, users: users}
+	return NewServiceWithConfig(repo, users, nil, Config{Retry: llm.DefaultRetryPolicy(), Circuit: DefaultCircuitConfig(), Prices: DefaultPriceCatalog()})
+}
+
+// NewServiceWithConfig 构造模型凭据服务并注入自定义配置，usage 为空时不记录用量流水。
+func NewServiceWithConfig(repo *repository.ModelCredentialRepository, users *repository.UserRepository, usage *repository.ModelUsageRepository, cfg Config) *Service {
+	if len(cfg.Prices.Models) == 0 {
+		cfg.Prices = DefaultPriceCatalog()
+	}
+	return &Service{repo: repo, users: users, usage: usage, retry: cfg.Retry, breakers: newCircuitBreakers(cfg.Circuit), prices: cfg.Prices, replay: cfg.Replay, catalogs: newCatalogCache(cfg.CatalogTTL), health: cfg.Health}
 }
 
 // List 返回用户所有模型凭据（脱敏）。
@@ -103,12 +159,21 @@ func (s *Service) List(ctx context.Context, userID uint) ([]Credential, error) {
 	if err != nil {
 		return nil, fmt.Errorf("list credentials: %w", err)
 	}
+	monthCosts, err := s.usage.SumCostByCredentialSince(ctx, userID, startOfMonth(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("sum monthly cost: %w", err)
+	}
 	credentials := make([]Credential, 0, len(entities))
 	for _, entity := range entities {
-		cred, err := toCredential(entity)
+		cred, err := s.toCredential(entity)
 		if err != nil {
 			return nil, err
 		}
+		cred.Circuit = s.breakers.status(entity.ID)
+		if s.usage != nil {
+			spent := monthCosts[entity.ID]
+			cred.MonthToDateCost = &spent
+		}
 		credentials = append(credentials, cred)
 	}
 	return credentials, nil
@@ -127,17 +192,28 @@ func (s *Service) Create(ctx context.Context, userID uint, input CreateInput) (C
 	if err != nil {
 		return Credential{}, err
 	}
+	budget, err := normalizeBudget(input.MonthlyBudget)
+	if err != nil {
+		return Credential{}, err
+	}
 	provider := normalizeProvider(input.Provider)
 	modelKey := strings.TrimSpace(input.ModelKey)
 	entity := domain.UserModelCredential{
-		UserID:       userID,
-		Provider:     provider,
-		ModelKey:     modelKey,
-		DisplayName:  strings.TrimSpace(input.DisplayName),
-		BaseURL:      strings.TrimSpace(input.BaseURL),
-		APIKeyCipher: sealed,
-		ExtraConfig:  extraJSON,
-		Status:       "enabled",
+		UserID:        userID,
+		Provider:      provider,
+		ModelKey:      modelKey,
+		DisplayName:   strings.TrimSpace(input.DisplayName),
+		BaseURL:       strings.TrimSpace(input.BaseURL),
+		APIKeyCipher:  sealed,
+		ExtraConfig:   extraJSON,
+		Status:        "enabled",
+		MonthlyBudget: budget,
+	}
+	var models []CatalogModel
+	if input.ValidateModel {
+		if models, err = s.validateModelKey(ctx, &entity); err != nil {
+			return Credential{}, err
+		}
 	}
 	if err := s.repo.Create(ctx, &entity); err != nil {
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
@@ -145,7 +221,11 @@ func (s *Service) Create(ctx context.Context, userID uint, input CreateInput) (C
 		}
 		return Credential{}, fmt.Errorf("create credential: %w", err)
 	}
-	return toCredential(entity)
+	if models != nil {
+		// 校验时拿到的目录直接写入缓存，前端随后打开目录无需再次请求厂商接口。
+		s.catalogs.set(entity.ID, catalogEntry{models: models, fetchedAt: time.Now()})
+	}
+	return s.toCredential(entity)
 }
 
 // Update 修改凭据属性（含重新加密 APIKey）。
@@ -164,6 +244,9 @@ func (s *Service) Update(ctx context.Context, userID, id uint, input UpdateInput
 	}
 	if input.BaseURL != nil {
 		entity.BaseURL = strings.TrimSpace(*input.BaseURL)
+		if entity.BaseURL == "" && normalizeProvider(entity.Provider) == "openai-compatible" {
+			return Credential{}, ErrBaseURLRequired
+		}
 	}
 	if input.APIKey != nil {
 		sealed, err := encryptAPIKey(*input.APIKey)
@@ -171,6 +254,12 @@ func (s *Service) Update(ctx context.Context, userID, id uint, input UpdateInput
 			return Credential{}, err
 		}
 		entity.APIKeyCipher = sealed
+		// 更换 Key 后旧的健康结果不再可信，等待下一次检查重新评估。
+		entity.HealthStatus = ""
+		entity.HealthCheckedAt = nil
+		entity.HealthLatencyMs = 0
+		entity.HealthError = ""
+		entity.HealthFailures = 0
 	}
 	if input.ExtraConfig != nil {
 		extraJSON, err := encodeExtraConfig(input.ExtraConfig)
@@ -186,17 +275,32 @@ func (s *Service) Update(ctx context.Context, userID, id uint, input UpdateInput
 		}
 		entity.Status = nextStatus
 	}
+	if input.MonthlyBudget != nil {
+		budget, err := normalizeBudget(input.MonthlyBudget)
+		if err != nil {
+			return Credential{}, err
+		}
+		entity.MonthlyBudget = budget
+	}
+	if input.ValidateModel {
+		if _, err := s.validateModelKey(ctx, entity); err != nil {
+			return Credential{}, err
+		}
+	}
 
 	if err := s.repo.Update(ctx, entity); err != nil {
 		return Credential{}, fmt.Errorf("update credential: %w", err)
 	}
+	// 凭据被修改（如更换 API Key）后重新开始统计，避免沿用旧的熔断状态。
+	s.breakers.reset(entity.ID)
+	s.catalogs.invalidate(entity.ID)
 	if previousStatus != entity.Status && entity.Status == "disabled" {
 		// 禁用后即刻撤销用户偏好，避免前端拿到失效模型。
 		if err := s.clearPreferredModelIfMatched(ctx, entity.UserID, entity.ModelKey); err != nil {
 			return Credential{}, fmt.Errorf("clear preferred model: %w", err)
 		}
 	}
-	return toCredential(*entity)
+	return s.toCredential(*entity)
 }
 
 // Delete 移除凭据。
@@ -215,11 +319,17 @@ func (s *Service) Delete(ctx context.Context, userID, id uint) error {
 		}
 		return fmt.Errorf("delete credential: %w", err)
 	}
+	s.breakers.reset(entity.ID)
+	s.catalogs.invalidate(entity.ID)
 
 	// 删除与禁用共用同一偏好清理逻辑。
 	if err := s.clearPreferredModelIfMatched(ctx, entity.UserID, entity.ModelKey); err != nil {
 		return fmt.Errorf("clear preferred model: %w", err)
 	}
+	// 禁用的模型在运行时会被跳过，删除的模型则直接从回退链中剔除。
+	if err := s.removeFromFallbackChains(ctx, entity.UserID, entity.ModelKey); err != nil {
+		return fmt.Errorf("prune fallback chains: %w", err)
+	}
 	return nil
 }
 
@@ -232,6 +342,10 @@ func (s *Service) validateCreateInput(ctx context.Context, userID uint, input Cr
 	if !isSupportedProvider(provider) {
 		return ErrUnsupportedProvider
 	}
+	// 未配置 cassette 目录时不开放 replay 提供方。
+	if provider == ProviderReplay && s.replay.Dir == "" {
+		return ErrUnsupportedProvider
+	}
 	modelKey := strings.TrimSpace(input.ModelKey)
 	if modelKey == "" {
 		return errors.New("model_key is required")
@@ -239,9 +353,14 @@ func (s *Service) validateCreateInput(ctx context.Context, userID uint, input Cr
 	if strings.TrimSpace(input.DisplayName) == "" {
 		return errors.New("display_name is required")
 	}
-	if strings.TrimSpace(input.APIKey) == "" {
+	// 本地 Ollama 默认无需鉴权，仅在经过代理时填写 API Key；replay 仅在录制时复用 API Key。
+	if strings.TrimSpace(input.APIKey) == "" && provider != "ollama" && provider != ProviderReplay {
 		return errors.New("api_key is required")
 	}
+	// OpenAI 兼容服务没有统一入口，必须由用户显式填写 BaseURL。
+	if provider == "openai-compatible" && strings.TrimSpace(input.BaseURL) == "" {
+		return ErrBaseURLRequired
+	}
 	exists, err := s.repo.ExistsWithModelKey(ctx, userID, modelKey, nil)
 	if err != nil {
 		return fmt.Errorf("check duplicate: %w", err)
@@ -274,14 +393,14 @@ func encodeExtraConfig(extra map[string]any) (string, error) {
 }
 
 // toCredential 将数据库实体转换为对外返回的脱敏结构。
-func toCredential(entity domain.UserModelCredential) (Credential, error) {
+func (s *Service) toCredential(entity domain.UserModelCredential) (Credential, error) {
 	extra := map[string]any{}
 	if entity.ExtraConfig != "" {
 		if err := json.Unmarshal([]byte(entity.ExtraConfig), &extra); err != nil {
 			return Credential{}, fmt.Errorf("decode extra config: %w", err)
 		}
 	}
-	return Credential{
+	cred := Credential{
 		ID:             entity.ID,
 		Provider:       entity.Provider,
 		ModelKey:       entity.ModelKey,
@@ -292,7 +411,16 @@ func toCredential(entity domain.UserModelCredential) (Credential, error) {
 		LastVerifiedAt: entity.LastVerifiedAt,
 		CreatedAt:      entity.CreatedAt,
 		UpdatedAt:      entity.UpdatedAt,
-	}, nil
+		MonthlyBudget:  entity.MonthlyBudget,
+		Currency:       s.prices.Currency,
+		Health:         toCredentialHealth(entity),
+	}
+	// extra_config.model 指定了实际调用的模型时优先按它匹配价格。
+	configuredModel, _ := extra["model"].(string)
+	if price, ok := s.prices.Lookup(entity.Provider, configuredModel, entity.ModelKey); ok {
+		cred.Price = &price
+	}
+	return cred, nil
 }
 
 // normalizeProvider 统一 provider 大小写与空白处理，便于比较。
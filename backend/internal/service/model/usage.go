@@ -0,0 +1,219 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+	"electron-go-app/backend/internal/domain/modelusage"
+	domain "electron-go-app/backend/internal/domain/user"
+	appLogger "electron-go-app/backend/internal/infra/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// UsageOperationTestConnection 标记“测试连接”产生的调用。
+	UsageOperationTestConnection = "test_connection"
+	// DefaultUsageDays 为用量查询默认覆盖的天数（含今天）。
+	DefaultUsageDays = 30
+	// MaxUsageDays 为用量查询允许的最大天数。
+	MaxUsageDays = 90
+
+	usageRecordTimeout = 3 * time.Second
+)
+
+// ErrInvalidUsageRange 表示用量查询的天数超出允许范围。
+var ErrInvalidUsageRange = errors.New("invalid usage range")
+
+// UsageEvent 描述一次模型调用的用量，由凭据调用链或平台内置模型（免费额度、独立审核）上报。
+type UsageEvent struct {
+	UserID       uint
+	CredentialID *uint
+	Provider     string
+	ModelKey     string
+	Operation    string
+	Response     llm.Response
+	Latency      time.Duration
+	Err          error
+}
+
+// UsageTotals 汇总一段时间内的调用次数与 token 消耗。
+type UsageTotals struct {
+	Requests             int     `json:"requests"`
+	Failures             int     `json:"failures"`
+	PromptTokens         int64   `json:"prompt_tokens"`
+	CompletionTokens     int64   `json:"completion_tokens"`
+	ReasoningTokens      int64   `json:"reasoning_tokens"`
+	CachedTokens         int64   `json:"cached_tokens"`
+	TotalTokens          int64   `json:"total_tokens"`
+	AverageLatencyMillis float64 `json:"average_latency_ms"`
+	EstimatedCost        float64 `json:"estimated_cost"`
+	latencySum           int64
+}
+
+// DailyUsage 为按日聚合的用量，operations 按业务操作再拆分。
+type DailyUsage struct {
+	Date string `json:"date"`
+	UsageTotals
+	Operations map[string]UsageTotals `json:"operations"`
+}
+
+// CredentialUsage 为 GET /api/models/:id/usage 的返回结构。
+type CredentialUsage struct {
+	CredentialID uint         `json:"credential_id"`
+	Provider     string       `json:"provider"`
+	ModelKey     string       `json:"model_key"`
+	Currency     string       `json:"currency"`
+	From         string       `json:"from"`
+	To           string       `json:"to"`
+	Totals       UsageTotals  `json:"totals"`
+	Daily        []DailyUsage `json:"daily"`
+}
+
+// add 将一条流水累加到汇总中。
+func (t *UsageTotals) add(record modelusage.Record) {
+	t.Requests++
+	if !record.Success {
+		t.Failures++
+	}
+	t.PromptTokens += record.PromptTokens
+	t.CompletionTokens += record.CompletionTokens
+	t.ReasoningTokens += record.ReasoningTokens
+	t.CachedTokens += record.CachedTokens
+	t.TotalTokens += record.TotalTokens
+	if record.EstimatedCost != nil {
+		t.EstimatedCost += *record.EstimatedCost
+	}
+	t.latencySum += record.LatencyMillis
+	t.AverageLatencyMillis = float64(t.latencySum) / float64(t.Requests)
+}
+
+// RecordUsage 写入一条用量流水，写入失败只记录日志，不影响模型调用结果。
+func (s *Service) RecordUsage(ctx context.Context, event UsageEvent) {
+	if s.usage == nil || event.UserID == 0 {
+		return
+	}
+	operation := strings.TrimSpace(event.Operation)
+	if operation == "" {
+		operation = "unknown"
+	}
+	record := modelusage.Record{
+		UserID:        event.UserID,
+		CredentialID:  event.CredentialID,
+		Provider:      strings.TrimSpace(event.Provider),
+		ModelKey:      strings.TrimSpace(event.ModelKey),
+		Model:         strings.TrimSpace(event.Response.Model),
+		Operation:     operation,
+		LatencyMillis: event.Latency.Milliseconds(),
+		Success:       event.Err == nil,
+	}
+	if usage := event.Response.Usage; usage != nil {
+		record.PromptTokens = usage.PromptTokens
+		record.CompletionTokens = usage.CompletionTokens
+		record.ReasoningTokens = usage.ReasoningTokens
+		record.CachedTokens = usage.CachedTokens
+		record.TotalTokens = usage.TotalTokens
+		if record.TotalTokens == 0 {
+			record.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		}
+	}
+	cost := event.Response.Cost
+	if cost == nil {
+		cost = s.prices.Estimate(record.Provider, event.Response.Usage, record.Model, record.ModelKey)
+	}
+	if cost != nil {
+		amount := cost.Amount
+		record.EstimatedCost = &amount
+		record.Currency = cost.Currency
+	}
+	if event.Err != nil {
+		record.ErrorKind = string(llm.KindOf(event.Err))
+	}
+	// 调用上下文可能已超时或被取消，流水写入使用独立的短超时。
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usageRecordTimeout)
+	defer cancel()
+	if err := s.usage.Append(writeCtx, &record); err != nil {
+		appLogger.S().With("component", "model.service").Warnw("record model usage failed", "user_id", event.UserID, "model_key", record.ModelKey, "error", err)
+	}
+}
+
+// recordCredentialUsage 记录通过用户凭据发起的调用。
+func (s *Service) recordCredentialUsage(ctx context.Context, credential *domain.UserModelCredential, operation string, resp llm.Response, latency time.Duration, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	id := credential.ID
+	s.RecordUsage(ctx, UsageEvent{
+		UserID:       credential.UserID,
+		CredentialID: &id,
+		Provider:     normalizeProvider(credential.Provider),
+		ModelKey:     credential.ModelKey,
+		Operation:    operation,
+		Response:     resp,
+		Latency:      latency,
+		Err:          err,
+	})
+}
+
+// Usage 返回指定凭据最近 days 天（含今天）的按日用量汇总。
+func (s *Service) Usage(ctx context.Context, userID, id uint, days int) (CredentialUsage, error) {
+	if days == 0 {
+		days = DefaultUsageDays
+	}
+	if days < 1 || days > MaxUsageDays {
+		return CredentialUsage{}, ErrInvalidUsageRange
+	}
+	credential, err := s.repo.FindByID(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return CredentialUsage{}, ErrCredentialNotFound
+		}
+		return CredentialUsage{}, fmt.Errorf("find credential: %w", err)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := today.AddDate(0, 0, -(days - 1))
+	until := today.AddDate(0, 0, 1)
+
+	result := CredentialUsage{
+		CredentialID: credential.ID,
+		Provider:     normalizeProvider(credential.Provider),
+		ModelKey:     credential.ModelKey,
+		Currency:     s.prices.Currency,
+		From:         since.Format(time.DateOnly),
+		To:           today.Format(time.DateOnly),
+		Daily:        make([]DailyUsage, 0, days),
+	}
+	index := make(map[string]int, days)
+	for day := since; day.Before(until); day = day.AddDate(0, 0, 1) {
+		key := day.Format(time.DateOnly)
+		index[key] = len(result.Daily)
+		result.Daily = append(result.Daily, DailyUsage{Date: key, Operations: map[string]UsageTotals{}})
+	}
+	if s.usage == nil {
+		return result, nil
+	}
+
+	records, err := s.usage.ListByCredential(ctx, userID, credential.ID, since, until)
+	if err != nil {
+		return CredentialUsage{}, fmt.Errorf("list usage: %w", err)
+	}
+	for _, record := range records {
+		pos, ok := index[record.CreatedAt.In(now.Location()).Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		daily := &result.Daily[pos]
+		daily.add(record)
+		operation := daily.Operations[record.Operation]
+		operation.add(record)
+		daily.Operations[record.Operation] = operation
+		result.Totals.add(record)
+	}
+	return result, nil
+}
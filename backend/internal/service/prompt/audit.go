@@ -6,7 +6,8 @@ import (
 	"fmt"
 	"strings"
 
-	modeldomain "electron-go-app/backend/internal/infra/model/deepseek"
+	"electron-go-app/backend/internal/domain/llm"
+	"electron-go-app/backend/internal/infra/model/deepseek"
 )
 
 const defaultAuditModelKey = "deepseek-chat"
@@ -30,6 +31,14 @@ func (cfg AuditConfig) normalize() AuditConfig {
 	return cfg
 }
 
+// auditProviderName 返回独立审核模型的提供方标识，用于用量流水。
+func auditProviderName(provider string) string {
+	if trimmed := strings.ToLower(strings.TrimSpace(provider)); trimmed != "" {
+		return trimmed
+	}
+	return "deepseek"
+}
+
 // buildAuditInvoker 根据配置构造审核模型调用器，若未启用则返回空。
 func buildAuditInvoker(cfg AuditConfig) (ModelInvoker, string, error) {
 	cfg = cfg.normalize()
@@ -51,11 +60,11 @@ func buildAuditInvoker(cfg AuditConfig) (ModelInvoker, string, error) {
 		if cfg.APIKey == "" {
 			return nil, "", errors.New("审核模型未配置 API Key")
 		}
-		opts := []modeldomain.Option{}
+		opts := []deepseek.Option{}
 		if cfg.BaseURL != "" {
-			opts = append(opts, modeldomain.WithBaseURL(cfg.BaseURL))
+			opts = append(opts, deepseek.WithBaseURL(cfg.BaseURL))
 		}
-		client := modeldomain.NewClient(cfg.APIKey, opts...)
+		client := deepseek.NewClient(cfg.APIKey, opts...)
 		invoker := &deepSeekAuditInvoker{
 			client:       client,
 			defaultModel: cfg.ModelKey,
@@ -68,23 +77,23 @@ func buildAuditInvoker(cfg AuditConfig) (ModelInvoker, string, error) {
 
 // deepSeekAuditInvoker 直接调用 DeepSeek Chat Completion 作为审核模型。
 type deepSeekAuditInvoker struct {
-	client       *modeldomain.Client
+	client       llm.Provider
 	defaultModel string
 }
 
 // InvokeChatCompletion 调用 DeepSeek 审核模型，优先使用传入的 modelKey。
-func (d *deepSeekAuditInvoker) InvokeChatCompletion(ctx context.Context, _ uint, modelKey string, req modeldomain.ChatCompletionRequest) (modeldomain.ChatCompletionResponse, error) {
+func (d *deepSeekAuditInvoker) InvokeChatCompletion(ctx context.Context, _ uint, modelKey string, req llm.Request) (llm.Response, error) {
 	if d == nil || d.client == nil {
-		return modeldomain.ChatCompletionResponse{}, errors.New("审核模型客户端未初始化")
+		return llm.Response{}, errors.New("审核模型客户端未初始化")
 	}
 	key := strings.TrimSpace(modelKey)
 	if key == "" {
 		key = strings.TrimSpace(d.defaultModel)
 	}
 	if key == "" {
-		return modeldomain.ChatCompletionResponse{}, errors.New("审核模型缺少标识")
+		return llm.Response{}, errors.New("审核模型缺少标识")
 	}
 	request := req
 	request.Model = key
-	return d.client.ChatCompletion(ctx, request)
+	return d.client.Complete(ctx, request)
 }
@@ -0,0 +1,442 @@
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+const (
+	// MaxGenerateCandidates 限制单次生成的候选数量，避免一次请求放大过多模型调用。
+	MaxGenerateCandidates = 5
+	// CandidateRankHeuristic 按关键词覆盖度与篇幅为候选打分。
+	CandidateRankHeuristic = "heuristic"
+	// CandidateRankJudge 由生成模型对候选逐一打分，失败时退回启发式打分。
+	CandidateRankJudge = "judge"
+
+	// candidateTemperatureStep 为相邻候选之间的温度差，候选温度以配置温度为中心向两侧展开。
+	candidateTemperatureStep = 0.2
+	// candidateMinRunes 低于该长度的候选在启发式打分中会被扣分。
+	candidateMinRunes = 200
+	// usageOperationJudge 标记候选评审调用的用量流水，回退链沿用 generate 配置。
+	usageOperationJudge = "generate_judge"
+	// toolSubmitCandidateScores 为评审模型提交打分结果的函数名。
+	toolSubmitCandidateScores = "submit_candidate_scores"
+)
+
+var (
+	// ErrInvalidCandidateCount 表示候选数量超出允许范围。
+	ErrInvalidCandidateCount = fmt.Errorf("candidates must be between 1 and %d", MaxGenerateCandidates)
+	// ErrInvalidRankMode 表示候选排序方式无法识别。
+	ErrInvalidRankMode = errors.New("rank_by must be heuristic or judge")
+	// ErrCandidatesNotStreamable 表示流式生成不支持多候选。
+	ErrCandidatesNotStreamable = errors.New("multiple candidates are not supported in stream mode")
+	// ErrCandidatePromptEmpty 表示选定的候选正文为空。
+	ErrCandidatePromptEmpty = errors.New("prompt is empty")
+)
+
+// GenerateCandidate 描述多候选生成中的单个结果，Score 仅在排序后填充（0~1，越高越好）。
+type GenerateCandidate struct {
+	Index          int
+	Prompt         string
+	Model          string
+	ServedModelKey string
+	FallbackUsed   bool
+	Temperature    float64
+	Duration       time.Duration
+	Usage          *llm.Usage
+	Cost           *llm.Cost
+	Score          *float64
+	ScoreReason    string
+}
+
+// SelectCandidateInput 描述用户从多个候选中选定正文后写回工作区草稿的请求。
+type SelectCandidateInput struct {
+	UserID           uint
+	WorkspaceToken   string
+	WorkspaceVersion int64
+	Prompt           string
+}
+
+// candidateAttempt 记录单路并发调用与审核的结果。
+type candidateAttempt struct {
+	result   invokeResult
+	prompt   string
+	err      error
+	duration time.Duration
+}
+
+// normalizeCandidateOptions 校验候选数量与排序方式，返回规范化后的取值。
+func normalizeCandidateOptions(count int, rankBy string) (int, string, error) {
+	if count <= 0 {
+		count = 1
+	}
+	if count > MaxGenerateCandidates {
+		return 0, "", ErrInvalidCandidateCount
+	}
+	rankBy = strings.ToLower(strings.TrimSpace(rankBy))
+	switch rankBy {
+	case "", CandidateRankHeuristic, CandidateRankJudge:
+	default:
+		return 0, "", ErrInvalidRankMode
+	}
+	return count, rankBy, nil
+}
+
+// generateCandidates 以不同温度并发生成多个候选，逐一审核后按需排序并选出最佳候选；
+// 部分候选失败或未通过审核时仅丢弃该候选，全部失败才返回首个错误。
+func (s *Service) generateCandidates(ctx context.Context, input GenerateInput, modelKey string, req llm.Request, count int, rankBy string) (GenerateOutput, error) {
+	temperatures := candidateTemperatures(req.Temperature, count, s.generationBounds.Temperature)
+	attempts := make([]candidateAttempt, count)
+	var wg sync.WaitGroup
+	for idx := range attempts {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			attemptReq := req
+			attemptReq.Temperature = temperatures[idx]
+			started := time.Now()
+			attempts[idx] = s.generateCandidate(ctx, input, modelKey, attemptReq)
+			attempts[idx].duration = time.Since(started)
+		}(idx)
+	}
+	wg.Wait()
+
+	candidates := make([]GenerateCandidate, 0, count)
+	var firstErr error
+	for idx, attempt := range attempts {
+		if attempt.err != nil {
+			s.logger.Warnw("generate candidate dropped", "user_id", input.UserID, "candidate", idx, "error", attempt.err)
+			if firstErr == nil {
+				firstErr = attempt.err
+			}
+			continue
+		}
+		candidates = append(candidates, GenerateCandidate{
+			Index:          len(candidates),
+			Prompt:         attempt.prompt,
+			Model:          strings.TrimSpace(attempt.result.Response.Model),
+			ServedModelKey: attempt.result.ModelKey,
+			FallbackUsed:   attempt.result.FallbackUsed,
+			Temperature:    temperatures[idx],
+			Duration:       attempt.duration,
+			Usage:          attempt.result.Response.Usage,
+			Cost:           attempt.result.Response.Cost,
+		})
+	}
+	if len(candidates) == 0 {
+		return GenerateOutput{}, firstErr
+	}
+
+	usages := make([]*llm.Usage, 0, len(candidates)+1)
+	costs := make([]*llm.Cost, 0, len(candidates)+1)
+	for _, candidate := range candidates {
+		usages = append(usages, candidate.Usage)
+		costs = append(costs, candidate.Cost)
+	}
+	switch rankBy {
+	case CandidateRankHeuristic:
+		scoreCandidatesHeuristic(input, candidates)
+	case CandidateRankJudge:
+		judgeRes, err := s.judgeCandidates(ctx, input, modelKey, candidates)
+		if err != nil {
+			s.logger.Warnw("judge candidates failed, fallback to heuristic", "user_id", input.UserID, "error", err)
+			scoreCandidatesHeuristic(input, candidates)
+			rankBy = CandidateRankHeuristic
+		} else {
+			usages = append(usages, judgeRes.Response.Usage)
+			costs = append(costs, judgeRes.Response.Cost)
+		}
+	}
+
+	selected := selectCandidate(candidates)
+	best := candidates[selected]
+	return GenerateOutput{
+		Model:          best.Model,
+		Prompt:         best.Prompt,
+		Usage:          sumUsage(usages),
+		PositiveUsed:   input.PositiveKeywords,
+		NegativeUsed:   input.NegativeKeywords,
+		ServedModelKey: best.ServedModelKey,
+		FallbackUsed:   best.FallbackUsed,
+		Cost:           sumCost(costs),
+		Candidates:     candidates,
+		SelectedIndex:  selected,
+		RankedBy:       rankBy,
+	}, nil
+}
+
+// SelectCandidate 将用户选定的候选写回工作区草稿并刷新 TTL，工作区已过期时返回 ErrWorkspaceNotFound 而非重新创建；
+// 携带的工作区版本与当前版本不一致时返回 WorkspaceConflictError。返回写入后的版本号。
+func (s *Service) SelectCandidate(ctx context.Context, input SelectCandidateInput) (int64, error) {
+	if s.workspace == nil {
+		return 0, nil
+	}
+	token := strings.TrimSpace(input.WorkspaceToken)
+	body := strings.TrimSpace(input.Prompt)
+	if body == "" {
+		return 0, ErrCandidatePromptEmpty
+	}
+	storeCtx, cancel := s.workspaceContext(ctx)
+	defer cancel()
+	if _, err := s.liveWorkspace(storeCtx, input.UserID, token); err != nil {
+		return 0, err
+	}
+	version, err := s.workspace.UpdateDraftBody(storeCtx, input.UserID, token, input.WorkspaceVersion, body)
+	if err != nil {
+		return 0, s.workspaceConflict(storeCtx, input.UserID, token, err)
+	}
+	return version, s.workspace.Touch(storeCtx, input.UserID, token)
+}
+
+// generateCandidate 完成单个候选的生成与输出审核。
+func (s *Service) generateCandidate(ctx context.Context, input GenerateInput, modelKey string, req llm.Request) candidateAttempt {
+	res, err := s.invokeModelWithFallback(ctx, input.UserID, fallbackOperationGenerate, modelKey, req)
+	if err != nil {
+		return candidateAttempt{err: err}
+	}
+	promptText := extractPromptText(res.Response)
+	if promptText == "" {
+		return candidateAttempt{err: errors.New("model returned empty prompt")}
+	}
+	if err := s.auditContent(ctx, input.UserID, modelKey, promptText, auditStageGenerateOutput); err != nil {
+		return candidateAttempt{err: err}
+	}
+	return candidateAttempt{result: res, prompt: promptText}
+}
+
+// candidateTemperatures 以 base 为中心按固定步长展开候选温度，并限制在配置的上下限内。
+func candidateTemperatures(base float64, count int, bounds floatRange) []float64 {
+	out := make([]float64, count)
+	center := float64(count-1) / 2
+	for idx := range out {
+		value := base + candidateTemperatureStep*(float64(idx)-center)
+		if bounds.Max > 0 {
+			value = clampFloat(value, bounds.Min, bounds.Max)
+		}
+		out[idx] = math.Round(value*100) / 100
+	}
+	return out
+}
+
+// scoreCandidatesHeuristic 按正向关键词的加权覆盖率打分，出现负向关键词或篇幅过短时扣分。
+func scoreCandidatesHeuristic(input GenerateInput, candidates []GenerateCandidate) {
+	for idx := range candidates {
+		text := strings.ToLower(candidates[idx].Prompt)
+		var covered, total float64
+		for _, item := range input.PositiveKeywords {
+			word := strings.ToLower(strings.TrimSpace(item.Word))
+			if word == "" {
+				continue
+			}
+			weight := float64(item.Weight)
+			if weight <= 0 {
+				weight = 1
+			}
+			total += weight
+			if strings.Contains(text, word) {
+				covered += weight
+			}
+		}
+		score := 1.0
+		if total > 0 {
+			score = covered / total
+		}
+		negatives := 0
+		for _, item := range input.NegativeKeywords {
+			word := strings.ToLower(strings.TrimSpace(item.Word))
+			if word != "" && strings.Contains(text, word) {
+				negatives++
+			}
+		}
+		if len(input.NegativeKeywords) > 0 {
+			score -= 0.3 * float64(negatives) / float64(len(input.NegativeKeywords))
+		}
+		if utf8.RuneCountInString(candidates[idx].Prompt) < candidateMinRunes {
+			score -= 0.1
+		}
+		score = math.Round(clampFloat(score, 0, 1)*1000) / 1000
+		candidates[idx].Score = &score
+		candidates[idx].ScoreReason = fmt.Sprintf("关键词覆盖 %.0f%%，命中负向关键词 %d 个", ratioPercent(covered, total), negatives)
+	}
+}
+
+// ratioPercent 返回百分比，分母为零时视为全部覆盖。
+func ratioPercent(part, total float64) float64 {
+	if total <= 0 {
+		return 100
+	}
+	return part / total * 100
+}
+
+// candidateScore 为评审模型返回的单个候选打分。
+type candidateScore struct {
+	Index  int     `json:"index"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// judgeCandidates 让生成模型为全部候选打 0~10 分，结果归一到 0~1 写入候选。
+func (s *Service) judgeCandidates(ctx context.Context, input GenerateInput, modelKey string, candidates []GenerateCandidate) (invokeResult, error) {
+	req := buildJudgeRequest(input, candidates)
+	req.Model = modelKey
+	judgeCtx := llm.WithOperation(withoutResponseCache(ctx), usageOperationJudge)
+	res, err := s.invokeModelWithFallback(judgeCtx, input.UserID, fallbackOperationGenerate, modelKey, req)
+	if err != nil {
+		return invokeResult{}, err
+	}
+	content := structuredContent(res.Response, toolSubmitCandidateScores)
+	if content == "" {
+		return invokeResult{}, errors.New("judge returned empty content")
+	}
+	var payload struct {
+		Scores []candidateScore `json:"scores"`
+	}
+	if err := json.Unmarshal([]byte(content), &payload); err != nil {
+		return invokeResult{}, fmt.Errorf("decode judge response: %w", err)
+	}
+	if len(payload.Scores) == 0 {
+		return invokeResult{}, errors.New("judge returned no scores")
+	}
+	for idx := range candidates {
+		zero := 0.0
+		candidates[idx].Score = &zero
+	}
+	for _, item := range payload.Scores {
+		if item.Index < 0 || item.Index >= len(candidates) {
+			continue
+		}
+		score := math.Round(clampFloat(item.Score/10, 0, 1)*1000) / 1000
+		candidates[item.Index].Score = &score
+		candidates[item.Index].ScoreReason = strings.TrimSpace(item.Reason)
+	}
+	return res, nil
+}
+
+// buildJudgeRequest 构造评审候选的模型请求，强制通过函数调用返回打分。
+func buildJudgeRequest(input GenerateInput, candidates []GenerateCandidate) llm.Request {
+	lang := languageOrDefault(input.Language)
+	system := "你是一名严格的 Prompt 评审，需要比较多份候选 Prompt 的质量并逐一打分。"
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "目标语言：%s\n主题：%s\n", lang, input.Topic)
+	fmt.Fprintf(builder, "正向关键词：%s\n", joinKeywordWords(input.PositiveKeywords))
+	if len(input.NegativeKeywords) > 0 {
+		fmt.Fprintf(builder, "负向关键词：%s\n", joinKeywordWords(input.NegativeKeywords))
+	}
+	if instructions := strings.TrimSpace(input.Instructions); instructions != "" {
+		fmt.Fprintf(builder, "补充要求：%s\n", instructions)
+	}
+	fmt.Fprintf(builder, "请从主题贴合度、正向关键词覆盖、是否回避负向关键词以及结构清晰度四个方面，为每个候选给出 0~10 分并用一句话说明理由。\n")
+	for _, candidate := range candidates {
+		fmt.Fprintf(builder, "\n候选 %d：\n%s\n", candidate.Index, candidate.Prompt)
+	}
+	return withStructuredTool(llm.Request{
+		Messages: []llm.Message{
+			{Role: "system", Content: system},
+			{Role: "user", Content: builder.String()},
+		},
+		ResponseFormat: map[string]any{"type": "json_object"},
+	}, candidateScoresTool())
+}
+
+// candidateScoresTool 定义评审模型提交候选打分的函数。
+func candidateScoresTool() llm.Tool {
+	return llm.Tool{
+		Type: "function",
+		Function: llm.ToolFunction{
+			Name:        toolSubmitCandidateScores,
+			Description: "提交每个候选 Prompt 的评分",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"scores": map[string]any{
+						"type": "array",
+						"items": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"index":  map[string]any{"type": "integer", "description": "候选编号"},
+								"score":  map[string]any{"type": "number", "minimum": 0, "maximum": 10},
+								"reason": map[string]any{"type": "string", "description": "一句话理由"},
+							},
+							"required": []string{"index", "score"},
+						},
+					},
+				},
+				"required": []string{"scores"},
+			},
+		},
+	}
+}
+
+// selectCandidate 返回得分最高的候选下标，同分时取靠前的候选；未排序时选第一个。
+func selectCandidate(candidates []GenerateCandidate) int {
+	order := make([]int, len(candidates))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scoreOf(candidates[order[i]]) > scoreOf(candidates[order[j]])
+	})
+	return order[0]
+}
+
+// scoreOf 返回候选得分，未打分视为 0。
+func scoreOf(candidate GenerateCandidate) float64 {
+	if candidate.Score == nil {
+		return 0
+	}
+	return *candidate.Score
+}
+
+// sumUsage 汇总多次调用的 token 统计，全部为空时返回 nil。
+func sumUsage(items []*llm.Usage) *llm.Usage {
+	var total *llm.Usage
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if total == nil {
+			total = &llm.Usage{}
+		}
+		total.PromptTokens += item.PromptTokens
+		total.CompletionTokens += item.CompletionTokens
+		total.TotalTokens += item.TotalTokens
+		total.CachedTokens += item.CachedTokens
+		total.CacheCreationTokens += item.CacheCreationTokens
+		total.ReasoningTokens += item.ReasoningTokens
+		for key, value := range item.Extra {
+			if total.Extra == nil {
+				total.Extra = map[string]int64{}
+			}
+			total.Extra[key] += value
+		}
+	}
+	return total
+}
+
+// sumCost 汇总多次调用的估算费用；回退到不同币种的模型时无法直接相加，返回 nil。
+func sumCost(items []*llm.Cost) *llm.Cost {
+	var total *llm.Cost
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if total == nil {
+			total = &llm.Cost{Currency: item.Currency}
+		}
+		if !strings.EqualFold(total.Currency, item.Currency) {
+			return nil
+		}
+		total.Amount += item.Amount
+	}
+	return total
+}
@@ -0,0 +1,218 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+	userdomain "electron-go-app/backend/internal/domain/user"
+	"electron-go-app/backend/internal/infra/metrics"
+	modelsvc "electron-go-app/backend/internal/service/model"
+)
+
+// 回退链按操作区分，与用户设置中的 fallback_chains 键保持一致。
+const (
+	fallbackOperationInterpret = userdomain.FallbackOperationInterpret
+	fallbackOperationAugment   = userdomain.FallbackOperationAugment
+	fallbackOperationGenerate  = userdomain.FallbackOperationGenerate
+	fallbackOperationAudit     = userdomain.FallbackOperationAudit
+)
+
+// usageOperationIngest 标记成品 Prompt 解析的用量流水，回退链沿用 interpret 配置。
+const usageOperationIngest = "ingest"
+
+// FallbackChainResolver 为支持回退链的模型服务提供按操作读取用户配置的能力，未实现时仅调用主模型。
+type FallbackChainResolver interface {
+	FallbackChain(ctx context.Context, userID uint, operation string) ([]string, error)
+}
+
+// UsageRecorder 由支持用量流水的模型服务实现，用于补记平台内置模型（免费额度、独立审核模型）的调用。
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, event modelsvc.UsageEvent)
+}
+
+// CostEstimator 由支持价格表的模型服务实现，用于估算平台内置模型调用的费用。
+type CostEstimator interface {
+	EstimateCost(provider string, usage *llm.Usage, models ...string) *llm.Cost
+}
+
+// invokeResult 记录模型调用结果、实际服务的模型以及免费额度的使用情况。
+type invokeResult struct {
+	Response     llm.Response
+	ModelKey     string
+	FallbackUsed bool
+	Cached       bool // 响应来自缓存，未调用模型也未扣减免费额度
+	FreeTierUsed bool
+	FreeTierInfo *freeTierUsage
+}
+
+// invokeModelWithFallback 优先使用用户自定义凭据调用模型，失败时按回退链与免费额度依次兜底。
+func (s *Service) invokeModelWithFallback(ctx context.Context, userID uint, operation, modelKey string, req llm.Request) (invokeResult, error) {
+	return s.invokeModelStreamWithFallback(ctx, userID, operation, modelKey, req, nil)
+}
+
+// invokeModelStreamWithFallback 依次尝试 modelKey 与用户为该操作配置的回退链，onDelta 非空时以流式方式调用模型：
+//  1. 凭据缺失或禁用时，若该模型对应免费额度则改走 freeTier.invoke（审核操作除外）；
+//  2. 凭据不可用、熔断、限流、5xx、超时或免费额度耗尽时切换到下一个模型，每次尝试拥有独立的超时；
+//  3. 其它错误或流式输出已推送过增量时立即返回，避免前端收到拼接的重复内容。
+func (s *Service) invokeModelStreamWithFallback(ctx context.Context, userID uint, operation, modelKey string, req llm.Request, onDelta llm.StreamHandler) (invokeResult, error) {
+	if s.model == nil {
+		return invokeResult{}, fmt.Errorf("%w: 模型服务未初始化", ErrModelInvocationFailed)
+	}
+	emitted := false
+	handler := onDelta
+	if onDelta != nil {
+		handler = func(delta string) error {
+			emitted = true
+			return onDelta(delta)
+		}
+	}
+	candidates := s.fallbackCandidates(ctx, userID, operation, modelKey)
+	var lastErr error
+	for idx, candidate := range candidates {
+		if idx > 0 && parentDeadlineExceeded(ctx) {
+			break
+		}
+		res, err := s.invokeCandidate(ctx, userID, operation, candidate, req, handler)
+		if err == nil {
+			res.ModelKey = candidate
+			res.FallbackUsed = idx > 0
+			if res.FallbackUsed {
+				s.logger.Infow("model fallback served request", "user_id", userID, "operation", operation, "requested_model", modelKey, "served_model", candidate)
+			}
+			return res, nil
+		}
+		lastErr = err
+		if emitted || !shouldFallback(err) {
+			break
+		}
+		if idx < len(candidates)-1 {
+			s.logger.Warnw("model invocation failed, trying next fallback", "user_id", userID, "operation", operation, "model", candidate, "error", err)
+		}
+	}
+	if quotaErr := (*FreeTierQuotaExceededError)(nil); errors.As(lastErr, &quotaErr) {
+		return invokeResult{}, quotaErr
+	}
+	return invokeResult{}, fmt.Errorf("%w: %w", ErrModelInvocationFailed, lastErr)
+}
+
+// invokeCandidate 使用单个模型完成一次调用，凭据缺失时按需切换到免费额度。
+func (s *Service) invokeCandidate(ctx context.Context, userID uint, operation, modelKey string, req llm.Request, onDelta llm.StreamHandler) (invokeResult, error) {
+	attemptCtx, cancel := s.modelInvocationContext(ctx)
+	defer cancel()
+	// 调用方未显式标记时，以回退链操作作为用量流水的操作类型。
+	if llm.OperationFromContext(attemptCtx) == "" {
+		attemptCtx = llm.WithOperation(attemptCtx, operation)
+	}
+	request := req
+	request.Model = modelKey
+	cacheKey, cacheTTL := s.responseCache.keyFor(attemptCtx, userID, modelKey, request, onDelta)
+	if cacheKey != "" {
+		if resp, ok := s.responseCache.lookup(attemptCtx, cacheKey); ok {
+			return invokeResult{Response: resp, Cached: true}, nil
+		}
+	}
+	resp, err := callModel(attemptCtx, s.model, userID, modelKey, request, onDelta)
+	if err == nil {
+		if cacheKey != "" {
+			s.responseCache.save(attemptCtx, cacheKey, cacheTTL, resp)
+		}
+		return invokeResult{Response: resp}, nil
+	}
+	credentialMissing := errors.Is(err, modelsvc.ErrCredentialNotFound) || errors.Is(err, modelsvc.ErrCredentialDisabled)
+	if !credentialMissing {
+		metrics.RecordModelError(operation, llm.KindOf(err))
+	}
+	if !credentialMissing || operation == fallbackOperationAudit || s.freeTier == nil || !s.freeTier.matches(modelKey) {
+		return invokeResult{}, err
+	}
+	start := time.Now()
+	resp, usage, err := s.freeTier.invoke(attemptCtx, userID, request, onDelta)
+	if err != nil {
+		if !errors.Is(err, ErrFreeTierQuotaExceeded) {
+			metrics.RecordModelError(operation, llm.KindOf(err))
+			s.recordPlatformUsage(attemptCtx, userID, s.freeTier.provider, modelKey, resp, time.Since(start), err)
+		}
+		return invokeResult{}, err
+	}
+	if estimator, ok := s.model.(CostEstimator); ok && resp.Cost == nil {
+		resp.Cost = estimator.EstimateCost(s.freeTier.provider, resp.Usage, resp.Model, modelKey)
+	}
+	s.recordPlatformUsage(attemptCtx, userID, s.freeTier.provider, modelKey, resp, time.Since(start), nil)
+	if cacheKey != "" {
+		s.responseCache.save(attemptCtx, cacheKey, cacheTTL, resp)
+	}
+	return invokeResult{
+		Response:     resp,
+		FreeTierUsed: true,
+		FreeTierInfo: &usage,
+	}, nil
+}
+
+// recordPlatformUsage 为平台内置模型的调用补记用量流水，模型服务不支持时忽略。
+func (s *Service) recordPlatformUsage(ctx context.Context, userID uint, provider, modelKey string, resp llm.Response, latency time.Duration, err error) {
+	recorder, ok := s.model.(UsageRecorder)
+	if !ok || errors.Is(err, context.Canceled) {
+		return
+	}
+	recorder.RecordUsage(ctx, modelsvc.UsageEvent{
+		UserID:    userID,
+		Provider:  provider,
+		ModelKey:  modelKey,
+		Operation: llm.OperationFromContext(ctx),
+		Response:  resp,
+		Latency:   latency,
+		Err:       err,
+	})
+}
+
+// fallbackCandidates 返回去重后的候选模型列表：主模型在前，随后是用户配置的回退链。
+func (s *Service) fallbackCandidates(ctx context.Context, userID uint, operation, modelKey string) []string {
+	candidates := []string{strings.TrimSpace(modelKey)}
+	resolver, ok := s.model.(FallbackChainResolver)
+	if !ok {
+		return candidates
+	}
+	chain, err := resolver.FallbackChain(ctx, userID, operation)
+	if err != nil {
+		s.logger.Warnw("load fallback chain failed", "user_id", userID, "operation", operation, "error", err)
+		return candidates
+	}
+	seen := map[string]struct{}{strings.ToLower(candidates[0]): {}}
+	for _, key := range chain {
+		trimmed := strings.TrimSpace(key)
+		if trimmed == "" {
+			continue
+		}
+		if _, exists := seen[strings.ToLower(trimmed)]; exists {
+			continue
+		}
+		seen[strings.ToLower(trimmed)] = struct{}{}
+		candidates = append(candidates, trimmed)
+	}
+	return candidates
+}
+
+// shouldFallback 判断错误是否允许切换到回退链中的下一个模型。
+func shouldFallback(err error) bool {
+	switch {
+	case errors.Is(err, modelsvc.ErrCredentialNotFound), errors.Is(err, modelsvc.ErrCredentialDisabled):
+		return true
+	case errors.Is(err, ErrFreeTierQuotaExceeded):
+		return true
+	default:
+		return modelsvc.IsRetryableError(err)
+	}
+}
+
+// parentDeadlineExceeded 判断调用方自身设置的截止时间是否已到，已到时不再继续回退。
+func parentDeadlineExceeded(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && !time.Now().Before(deadline)
+}
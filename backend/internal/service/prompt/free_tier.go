@@ -7,7 +7,8 @@ import (
 	"strings"
 	"time"
 
-	modeldomain "electron-go-app/backend/internal/infra/model/deepseek"
+	"electron-go-app/backend/internal/domain/llm"
+	"electron-go-app/backend/internal/infra/model/deepseek"
 	"electron-go-app/backend/internal/infra/ratelimit"
 
 	"go.uber.org/zap"
@@ -149,11 +150,11 @@ func buildFreeTier(cfg FreeTierConfig, limiter ratelimit.Limiter, logger *zap.Su
 	var invoker ModelInvoker
 	switch strings.ToLower(cfg.Provider) {
 	case "deepseek":
-		opts := []modeldomain.Option{}
+		opts := []deepseek.Option{}
 		if cfg.BaseURL != "" {
-			opts = append(opts, modeldomain.WithBaseURL(cfg.BaseURL))
+			opts = append(opts, deepseek.WithBaseURL(cfg.BaseURL))
 		}
-		client := modeldomain.NewClient(cfg.APIKey, opts...)
+		client := deepseek.NewClient(cfg.APIKey, opts...)
 		invoker = &staticDeepSeekInvoker{
 			client:       client,
 			defaultModel: cfg.ActualModel,
@@ -218,23 +219,23 @@ func (f *freeTier) info() *FreeTierInfo {
 	}
 }
 
-// invoke 调用免费额度模型，同时扣减额度。
-func (f *freeTier) invoke(ctx context.Context, userID uint, req modeldomain.ChatCompletionRequest) (modeldomain.ChatCompletionResponse, freeTierUsage, error) {
+// invoke 调用免费额度模型，同时扣减额度；onDelta 非空时以流式方式调用。
+func (f *freeTier) invoke(ctx context.Context, userID uint, req llm.Request, onDelta llm.StreamHandler) (llm.Response, freeTierUsage, error) {
 	if f == nil || !f.enabled {
-		return modeldomain.ChatCompletionResponse{}, freeTierUsage{}, errors.New("free tier disabled")
+		return llm.Response{}, freeTierUsage{}, errors.New("free tier disabled")
 	}
 	usage, err := f.consume(ctx, userID)
 	if err != nil {
-		return modeldomain.ChatCompletionResponse{}, freeTierUsage{}, err
+		return llm.Response{}, freeTierUsage{}, err
 	}
 	request := req
 	request.Model = strings.TrimSpace(request.Model)
 	if request.Model == "" || strings.EqualFold(request.Model, f.alias) {
 		request.Model = f.actualModel
 	}
-	resp, err := f.invoker.InvokeChatCompletion(ctx, userID, request.Model, request)
+	resp, err := callModel(ctx, f.invoker, userID, request.Model, request, onDelta)
 	if err != nil {
-		return modeldomain.ChatCompletionResponse{}, usage, err
+		return llm.Response{}, usage, err
 	}
 	return resp, usage, nil
 }
@@ -302,14 +303,32 @@ func (f *freeTier) snapshot(ctx context.Context, userID uint) (freeTierUsage, ti
 
 // staticDeepSeekInvoker 使用固定凭据直接调用 DeepSeek。
 type staticDeepSeekInvoker struct {
-	client       *modeldomain.Client
+	client       llm.Provider
 	defaultModel string
 }
 
 // InvokeChatCompletion 通过 DeepSeek 客户端发送对话请求。
-func (s *staticDeepSeekInvoker) InvokeChatCompletion(ctx context.Context, _ uint, modelKey string, req modeldomain.ChatCompletionRequest) (modeldomain.ChatCompletionResponse, error) {
+func (s *staticDeepSeekInvoker) InvokeChatCompletion(ctx context.Context, _ uint, modelKey string, req llm.Request) (llm.Response, error) {
+	request, err := s.prepare(modelKey, req)
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return s.client.Complete(ctx, request)
+}
+
+// InvokeChatCompletionStream 通过 DeepSeek 客户端以流式方式发送对话请求。
+func (s *staticDeepSeekInvoker) InvokeChatCompletionStream(ctx context.Context, _ uint, modelKey string, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	request, err := s.prepare(modelKey, req)
+	if err != nil {
+		return llm.Response{}, err
+	}
+	return s.client.CompleteStream(ctx, request, onDelta)
+}
+
+// prepare 校验客户端并补齐模型标识。
+func (s *staticDeepSeekInvoker) prepare(modelKey string, req llm.Request) (llm.Request, error) {
 	if s == nil || s.client == nil {
-		return modeldomain.ChatCompletionResponse{}, errors.New("免费额度模型客户端未初始化")
+		return llm.Request{}, errors.New("免费额度模型客户端未初始化")
 	}
 	request := req
 	request.Model = strings.TrimSpace(request.Model)
@@ -320,7 +339,7 @@ func (s *staticDeepSeekInvoker) InvokeChatCompletion(ctx context.Context, _ uint
 		request.Model = strings.TrimSpace(s.defaultModel)
 	}
 	if request.Model == "" {
-		return modeldomain.ChatCompletionResponse{}, errors.New("免费额度模型缺少标识")
+		return llm.Request{}, errors.New("免费额度模型缺少标识")
 	}
-	return s.client.ChatCompletion(ctx, request)
+	return request, nil
 }
@@ -0,0 +1,188 @@
+package prompt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+	"electron-go-app/backend/internal/infra/metrics"
+	modelsvc "electron-go-app/backend/internal/service/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultResponseCacheInterpretTTL 为解析描述结果的默认缓存时长。
+	DefaultResponseCacheInterpretTTL = 10 * time.Minute
+	// DefaultResponseCacheAugmentTTL 为补充关键词结果的默认缓存时长。
+	DefaultResponseCacheAugmentTTL = 10 * time.Minute
+	// DefaultResponseCacheIngestTTL 为解析成品 Prompt 结果的默认缓存时长。
+	DefaultResponseCacheIngestTTL = 30 * time.Minute
+
+	responseCacheTimeout = 2 * time.Second
+)
+
+// ResponseCache 抽象模型响应缓存的存储，在线模式使用 Redis，本地模式使用 SQLite。
+type ResponseCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// CredentialFingerprinter 由模型服务实现，返回 model_key 当前对应凭据的版本标识；凭据改指其它模型或端点后标识随之变化，旧缓存不再命中。
+type CredentialFingerprinter interface {
+	CredentialFingerprint(ctx context.Context, userID uint, modelKey string) (string, error)
+}
+
+// ResponseCacheConfig 描述模型响应缓存的开关、存储与各操作的缓存时长。
+type ResponseCacheConfig struct {
+	Enabled      bool
+	InterpretTTL time.Duration
+	AugmentTTL   time.Duration
+	IngestTTL    time.Duration
+	Store        ResponseCache
+}
+
+// responseCache 缓存 interpret/augment/ingest 的模型响应，命中时不再调用模型也不扣减免费额度。
+type responseCache struct {
+	store        ResponseCache
+	ttls         map[string]time.Duration
+	fingerprints CredentialFingerprinter
+	logger       *zap.SugaredLogger
+}
+
+// responseCacheBypassKey 标记本次请求跳过响应缓存。
+type responseCacheBypassKey struct{}
+
+// newResponseCache 根据配置构造响应缓存，未启用或缺少存储时返回 nil；模型服务支持凭据标识时将其纳入缓存 key。
+func newResponseCache(cfg ResponseCacheConfig, model ModelInvoker, logger *zap.SugaredLogger) *responseCache {
+	if !cfg.Enabled || cfg.Store == nil {
+		return nil
+	}
+	if cfg.InterpretTTL <= 0 {
+		cfg.InterpretTTL = DefaultResponseCacheInterpretTTL
+	}
+	if cfg.AugmentTTL <= 0 {
+		cfg.AugmentTTL = DefaultResponseCacheAugmentTTL
+	}
+	if cfg.IngestTTL <= 0 {
+		cfg.IngestTTL = DefaultResponseCacheIngestTTL
+	}
+	fingerprints, _ := model.(CredentialFingerprinter)
+	return &responseCache{
+		store:        cfg.Store,
+		fingerprints: fingerprints,
+		ttls: map[string]time.Duration{
+			fallbackOperationInterpret: cfg.InterpretTTL,
+			fallbackOperationAugment:   cfg.AugmentTTL,
+			usageOperationIngest:       cfg.IngestTTL,
+		},
+		logger: logger.With("component", "prompt.response_cache"),
+	}
+}
+
+// withoutResponseCache 标记本次调用跳过响应缓存，既不读取也不写入。
+func withoutResponseCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, responseCacheBypassKey{}, true)
+}
+
+// responseCacheBypassed 判断调用方是否要求跳过响应缓存。
+func responseCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(responseCacheBypassKey{}).(bool)
+	return bypass
+}
+
+// keyFor 返回本次调用的缓存 key 与时长；未启用、调用方跳过、流式调用或操作不可缓存时返回空串。
+func (c *responseCache) keyFor(ctx context.Context, userID uint, modelKey string, req llm.Request, onDelta llm.StreamHandler) (string, time.Duration) {
+	if c == nil || onDelta != nil || responseCacheBypassed(ctx) {
+		return "", 0
+	}
+	ttl := c.ttls[llm.OperationFromContext(ctx)]
+	if ttl <= 0 {
+		return "", 0
+	}
+	fingerprint := ""
+	if c.fingerprints != nil {
+		var err error
+		fingerprint, err = c.fingerprints.CredentialFingerprint(ctx, userID, modelKey)
+		if err != nil && !errors.Is(err, modelsvc.ErrCredentialNotFound) {
+			c.logger.Warnw("resolve credential fingerprint failed", "user_id", userID, "model_key", modelKey, "error", err)
+			return "", 0
+		}
+	}
+	key, err := responseCacheKey(userID, modelKey, fingerprint, req)
+	if err != nil {
+		c.logger.Warnw("build response cache key failed", "user_id", userID, "model_key", modelKey, "error", err)
+		return "", 0
+	}
+	return key, ttl
+}
+
+// lookup 读取缓存的响应，读取失败按未命中处理。
+func (c *responseCache) lookup(ctx context.Context, key string) (llm.Response, bool) {
+	operation := llm.OperationFromContext(ctx)
+	readCtx, cancel := context.WithTimeout(ctx, responseCacheTimeout)
+	defer cancel()
+	raw, ok, err := c.store.Get(readCtx, key)
+	if err != nil {
+		c.logger.Warnw("read response cache failed", "operation", operation, "error", err)
+		ok = false
+	}
+	var resp llm.Response
+	if ok {
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			c.logger.Warnw("decode cached response failed", "operation", operation, "error", err)
+			ok = false
+		}
+	}
+	if ok {
+		metrics.RecordResponseCache(operation, "hit")
+		// 命中缓存不产生新的模型费用。
+		resp.Cost = nil
+		return resp, true
+	}
+	metrics.RecordResponseCache(operation, "miss")
+	return llm.Response{}, false
+}
+
+// save 写入响应，失败只记录日志。
+func (c *responseCache) save(ctx context.Context, key string, ttl time.Duration, resp llm.Response) {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		c.logger.Warnw("encode response for cache failed", "error", err)
+		return
+	}
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), responseCacheTimeout)
+	defer cancel()
+	if err := c.store.Set(writeCtx, key, raw, ttl); err != nil {
+		c.logger.Warnw("write response cache failed", "operation", llm.OperationFromContext(ctx), "error", err)
+	}
+}
+
+// responseCacheKey 对用户、模型、凭据标识与规范化后的请求求 SHA-256，用户维度隔离可避免跨账号复用凭据结果，
+// 凭据标识保证同一 model_key 改指其它模型或端点后不会读到旧配置的响应。
+func responseCacheKey(userID uint, modelKey, fingerprint string, req llm.Request) (string, error) {
+	normalized := req
+	normalized.Model = strings.ToLower(strings.TrimSpace(modelKey))
+	normalized.Messages = make([]llm.Message, len(req.Messages))
+	for idx, message := range req.Messages {
+		message.Role = strings.ToLower(strings.TrimSpace(message.Role))
+		message.Content = strings.TrimSpace(message.Content)
+		normalized.Messages[idx] = message
+	}
+	payload, err := json.Marshal(struct {
+		UserID     uint           `json:"user_id"`
+		Credential string         `json:"credential,omitempty"`
+		Request    llm.Request    `json:"request"`
+		Extra      map[string]any `json:"extra,omitempty"`
+	}{UserID: userID, Credential: fingerprint, Request: normalized, Extra: req.ExtraFields})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
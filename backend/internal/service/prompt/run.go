@@ -0,0 +1,278 @@
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+	"electron-go-app/backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// usageOperationRun 标记试运行产生的模型调用，便于在用量统计中区分。
+const usageOperationRun = "run"
+
+// ErrPromptVariablesMissing 表示试运行时仍有必填变量未提供取值。
+var ErrPromptVariablesMissing = errors.New("prompt variables missing")
+
+// PromptVariablesMissingError 携带缺失取值的必填变量名，便于前端逐项提示。
+type PromptVariablesMissingError struct {
+	Missing []string
+}
+
+// Error 返回用户可读的中文提示。
+func (e *PromptVariablesMissingError) Error() string {
+	return fmt.Sprintf("缺少必填变量：%s", strings.Join(e.Missing, "、"))
+}
+
+// Is 允许通过 errors.Is 判断是否为变量缺失。
+func (e *PromptVariablesMissingError) Is(target error) bool {
+	return target == ErrPromptVariablesMissing
+}
+
+// RunPromptInput 描述一次 Prompt 试运行：VersionNo 为 0 时运行当前正文，ModelKey 与 GenerationProfile 为空时沿用所运行正文（当前 Prompt 或指定版本）保存的配置。
+type RunPromptInput struct {
+	UserID            uint
+	PromptID          uint
+	VersionNo         int
+	ModelKey          string
+	Values            map[string]any
+	GenerationProfile *promptdomain.GenerationProfile
+}
+
+// PromptRunUsage 汇总试运行的 token 用量。
+type PromptRunUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// PromptRunDetail 为试运行记录的对外视图。
+type PromptRunDetail struct {
+	ID                uint                           `json:"id"`
+	PromptID          uint                           `json:"prompt_id"`
+	VersionNo         int                            `json:"version"`
+	ModelKey          string                         `json:"model_key"`
+	Model             string                         `json:"model,omitempty"`
+	Values            map[string]any                 `json:"values"`
+	GenerationProfile promptdomain.GenerationProfile `json:"generation_profile"`
+	Input             string                         `json:"input"`
+	Output            string                         `json:"output"`
+	Usage             PromptRunUsage                 `json:"usage"`
+	Cost              *llm.Cost                      `json:"cost,omitempty"`
+	LatencyMillis     int64                          `json:"latency_ms"`
+	Status            string                         `json:"status"`
+	Error             string                         `json:"error,omitempty"`
+	CreatedAt         time.Time                      `json:"created_at"`
+}
+
+// ListPromptRunsInput 描述试运行记录的分页查询条件，VersionNo 为空时返回全部版本。
+type ListPromptRunsInput struct {
+	UserID    uint
+	PromptID  uint
+	VersionNo *int
+	Page      int
+	PageSize  int
+}
+
+// ListPromptRunsOutput 返回试运行记录与分页信息。
+type ListPromptRunsOutput struct {
+	Items    []PromptRunDetail
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// RunPrompt 渲染 Prompt 并发送给指定模型，记录输入变量、模型、生成配置、输出、用量与耗时。
+// 必填变量缺失时直接返回 PromptVariablesMissingError，不会调用模型；模型调用失败同样会留下失败记录。
+func (s *Service) RunPrompt(ctx context.Context, input RunPromptInput) (PromptRunDetail, error) {
+	if input.UserID == 0 || input.PromptID == 0 {
+		return PromptRunDetail{}, errors.New("user id and prompt id are required")
+	}
+	if s.model == nil {
+		return PromptRunDetail{}, fmt.Errorf("%w: 模型服务未初始化", ErrModelInvocationFailed)
+	}
+	entity, err := s.prompts.FindByID(ctx, input.UserID, input.PromptID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return PromptRunDetail{}, ErrPromptNotFound
+		}
+		return PromptRunDetail{}, err
+	}
+	body, rawVariables, rawProfile, defaultModel := entity.Body, entity.Variables, entity.GenerationProfile, entity.Model
+	if input.VersionNo > 0 {
+		version, err := s.prompts.FindVersion(ctx, input.PromptID, input.VersionNo)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return PromptRunDetail{}, ErrPromptVersionNotFound
+			}
+			return PromptRunDetail{}, err
+		}
+		body, rawVariables, rawProfile, defaultModel = version.Body, version.Variables, version.GenerationProfile, version.Model
+	}
+	rendered := renderPromptBody(body, reconcilePromptVariables(body, decodeStoredVariables(rawVariables)), input.Values)
+	if len(rendered.Missing) > 0 {
+		return PromptRunDetail{}, &PromptVariablesMissingError{Missing: rendered.Missing}
+	}
+	if strings.TrimSpace(rendered.Body) == "" {
+		return PromptRunDetail{}, errors.New("prompt body is empty")
+	}
+	modelKey := strings.TrimSpace(input.ModelKey)
+	if modelKey == "" {
+		modelKey = strings.TrimSpace(defaultModel)
+	}
+	if modelKey == "" {
+		return PromptRunDetail{}, errors.New("model key is required")
+	}
+	profile := s.decodeGenerationProfile(rawProfile)
+	if input.GenerationProfile != nil {
+		profile = s.normalizeGenerationProfile(input.GenerationProfile)
+	}
+
+	invokeCtx, cancel := s.modelInvocationContext(ctx)
+	defer cancel()
+	invokeCtx = llm.WithOperation(invokeCtx, usageOperationRun)
+	start := time.Now()
+	resp, invokeErr := s.model.InvokeChatCompletion(invokeCtx, input.UserID, modelKey, llm.Request{
+		Model:       modelKey,
+		Messages:    []llm.Message{{Role: "user", Content: rendered.Body}},
+		Temperature: profile.Temperature,
+		MaxTokens:   profile.MaxOutputTokens,
+		TopP:        profile.TopP,
+	})
+	latency := time.Since(start)
+
+	record := promptdomain.PromptRun{
+		PromptID:          input.PromptID,
+		VersionNo:         input.VersionNo,
+		UserID:            input.UserID,
+		ModelKey:          modelKey,
+		Values:            encodeRunValues(input.Values),
+		GenerationProfile: s.encodeGenerationProfile(profile),
+		Input:             rendered.Body,
+		LatencyMillis:     latency.Milliseconds(),
+		Status:            promptdomain.PromptRunStatusSucceeded,
+	}
+	if invokeErr != nil {
+		record.Status = promptdomain.PromptRunStatusFailed
+		record.Error = invokeErr.Error()
+	} else {
+		record.Model = strings.TrimSpace(resp.Model)
+		record.Output = resp.Text()
+		if resp.Usage != nil {
+			record.PromptTokens = resp.Usage.PromptTokens
+			record.CompletionTokens = resp.Usage.CompletionTokens
+			record.TotalTokens = resp.Usage.TotalTokens
+		}
+		if resp.Cost != nil {
+			amount := resp.Cost.Amount
+			record.EstimatedCost = &amount
+			record.Currency = resp.Cost.Currency
+		}
+	}
+	if s.runs != nil {
+		if err := s.runs.Create(ctx, &record); err != nil {
+			s.logger.Warnw("persist prompt run failed", "user_id", input.UserID, "prompt_id", input.PromptID, "error", err)
+		}
+	}
+	if invokeErr != nil {
+		return PromptRunDetail{}, fmt.Errorf("%w: %w", ErrModelInvocationFailed, invokeErr)
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	return s.toPromptRunDetail(record), nil
+}
+
+// ListPromptRuns 按时间倒序分页返回 Prompt 的试运行记录，可按版本筛选。
+func (s *Service) ListPromptRuns(ctx context.Context, input ListPromptRunsInput) (ListPromptRunsOutput, error) {
+	if input.UserID == 0 || input.PromptID == 0 {
+		return ListPromptRunsOutput{}, errors.New("user id and prompt id are required")
+	}
+	if _, err := s.prompts.FindByID(ctx, input.UserID, input.PromptID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ListPromptRunsOutput{}, ErrPromptNotFound
+		}
+		return ListPromptRunsOutput{}, err
+	}
+	page := input.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := input.PageSize
+	if pageSize <= 0 {
+		pageSize = s.listDefaultPageSize
+	}
+	if pageSize > s.listMaxPageSize {
+		pageSize = s.listMaxPageSize
+	}
+	output := ListPromptRunsOutput{Items: []PromptRunDetail{}, Page: page, PageSize: pageSize}
+	if s.runs == nil {
+		return output, nil
+	}
+	records, total, err := s.runs.ListByPrompt(ctx, input.PromptID, repository.PromptRunListFilter{
+		VersionNo: input.VersionNo,
+		Limit:     pageSize,
+		Offset:    (page - 1) * pageSize,
+	})
+	if err != nil {
+		return ListPromptRunsOutput{}, err
+	}
+	for _, record := range records {
+		output.Items = append(output.Items, s.toPromptRunDetail(record))
+	}
+	output.Total = total
+	return output, nil
+}
+
+// toPromptRunDetail 将试运行记录转换为对外视图，并解析保存的变量值与生成配置。
+func (s *Service) toPromptRunDetail(record promptdomain.PromptRun) PromptRunDetail {
+	values := map[string]any{}
+	if raw := strings.TrimSpace(record.Values); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			values = map[string]any{}
+		}
+	}
+	detail := PromptRunDetail{
+		ID:                record.ID,
+		PromptID:          record.PromptID,
+		VersionNo:         record.VersionNo,
+		ModelKey:          record.ModelKey,
+		Model:             record.Model,
+		Values:            values,
+		GenerationProfile: s.decodeGenerationProfile(record.GenerationProfile),
+		Input:             record.Input,
+		Output:            record.Output,
+		Usage: PromptRunUsage{
+			PromptTokens:     record.PromptTokens,
+			CompletionTokens: record.CompletionTokens,
+			TotalTokens:      record.TotalTokens,
+		},
+		LatencyMillis: record.LatencyMillis,
+		Status:        record.Status,
+		Error:         record.Error,
+		CreatedAt:     record.CreatedAt,
+	}
+	if record.EstimatedCost != nil {
+		detail.Cost = &llm.Cost{Amount: *record.EstimatedCost, Currency: record.Currency}
+	}
+	return detail
+}
+
+// encodeRunValues 将试运行的变量取值编码为 JSON，没有取值时返回空串。
+func encodeRunValues(values map[string]any) string {
+	if len(values) == 0 {
+		return ""
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
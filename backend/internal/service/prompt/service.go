@@ -14,14 +14,14 @@ import (
 	"time"
 	"unicode"
 
+	"electron-go-app/backend/internal/domain/llm"
 	promptdomain "electron-go-app/backend/internal/domain/prompt"
 	"electron-go-app/backend/internal/infra/metrics"
-	modeldomain "electron-go-app/backend/internal/infra/model/deepseek"
 	"electron-go-app/backend/internal/infra/ratelimit"
 	"electron-go-app/backend/internal/repository"
 	adminmetrics "electron-go-app/backend/internal/service/adminmetrics"
-	modelsvc "electron-go-app/backend/internal/service/model"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -29,27 +29,55 @@ import (
 
 // ModelInvoker 抽象模型服务，便于在单元测试中注入假实现。
 type ModelInvoker interface {
-	InvokeChatCompletion(ctx context.Context, userID uint, modelKey string, req modeldomain.ChatCompletionRequest) (modeldomain.ChatCompletionResponse, error)
+	InvokeChatCompletion(ctx context.Context, userID uint, modelKey string, req llm.Request) (llm.Response, error)
 }
 
-// WorkspaceStore 抽象 Redis 工作区的读写接口。
+// StreamingModelInvoker 为支持流式输出的模型服务提供增量回调能力，未实现时生成接口会退化为一次性推送。
+type StreamingModelInvoker interface {
+	InvokeChatCompletionStream(ctx context.Context, userID uint, modelKey string, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error)
+}
+
+// WorkspaceStore 抽象 Redis 工作区的读写接口。内容写入会使版本号加一并返回新版本；
+// expectedVersion 不为 WorkspaceAnyVersion 时先比对版本，不一致返回 ErrWorkspaceVersionConflict 且不写入。
 type WorkspaceStore interface {
 	CreateOrReplace(ctx context.Context, userID uint, snapshot promptdomain.WorkspaceSnapshot) (string, error)
-	MergeKeywords(ctx context.Context, userID uint, token string, keywords []promptdomain.WorkspaceKeyword) error
-	RemoveKeyword(ctx context.Context, userID uint, token, polarity, word string) error
-	UpdateDraftBody(ctx context.Context, userID uint, token, body string) error
-	SetAttributes(ctx context.Context, userID uint, token string, attrs map[string]string) error
+	MergeKeywords(ctx context.Context, userID uint, token string, expectedVersion int64, keywords []promptdomain.WorkspaceKeyword) (int64, error)
+	ReplaceKeywords(ctx context.Context, userID uint, token string, expectedVersion int64, positive, negative []promptdomain.WorkspaceKeyword) (int64, error)
+	RemoveKeyword(ctx context.Context, userID uint, token string, expectedVersion int64, polarity, word string) (int64, error)
+	UpdateDraftBody(ctx context.Context, userID uint, token string, expectedVersion int64, body string) (int64, error)
+	UpdateDraft(ctx context.Context, userID uint, token string, expectedVersion int64, body string, attrs map[string]string) (int64, error)
+	SetAttributes(ctx context.Context, userID uint, token string, expectedVersion int64, attrs map[string]string) (int64, error)
+	Undo(ctx context.Context, userID uint, token string, expectedVersion int64) (int64, error)
+	Redo(ctx context.Context, userID uint, token string, expectedVersion int64) (int64, error)
 	Touch(ctx context.Context, userID uint, token string) error
 	Snapshot(ctx context.Context, userID uint, token string) (promptdomain.WorkspaceSnapshot, error)
+	List(ctx context.Context, userID uint) ([]promptdomain.WorkspaceSummary, error)
 	Delete(ctx context.Context, userID uint, token string) error
 	SetPromptMeta(ctx context.Context, userID uint, token string, promptID uint, status string) error
 	GetPromptMeta(ctx context.Context, userID uint, token string) (uint, string, error)
 }
 
-// PersistenceQueue 描述异步落库队列的最小能力集合。
+// PersistenceQueue 描述异步落库队列的最小能力集合：取出的任务需显式 Ack 或 Retry，未确认的任务可被 Reclaim 回收。
 type PersistenceQueue interface {
 	Enqueue(ctx context.Context, task promptdomain.PersistenceTask) (string, error)
 	BlockingPop(ctx context.Context, timeout time.Duration) (promptdomain.PersistenceTask, error)
+	Ack(ctx context.Context, task promptdomain.PersistenceTask) error
+	Retry(ctx context.Context, task promptdomain.PersistenceTask, cause error) (bool, error)
+	Reclaim(ctx context.Context) (int, error)
+}
+
+// TaskStatusStore 记录异步落库任务的处理状态，任务不存在或已过期时 Get 返回 redis.Nil。
+type TaskStatusStore interface {
+	Save(ctx context.Context, status promptdomain.PersistenceTaskStatus) error
+	Get(ctx context.Context, taskID string) (promptdomain.PersistenceTaskStatus, error)
+}
+
+// PromptRunStore 持久化 Prompt 试运行记录，未配置时试运行结果不落库。
+type PromptRunStore interface {
+	Create(ctx context.Context, run *promptdomain.PromptRun) error
+	ListByPrompt(ctx context.Context, promptID uint, filter repository.PromptRunListFilter) ([]promptdomain.PromptRun, int64, error)
+	DeleteByPrompt(ctx context.Context, promptID uint) error
+	DeleteByUser(ctx context.Context, userID uint) error
 }
 
 // Service 汇总 Prompt 工作台所需的核心能力，包括：
@@ -63,8 +91,11 @@ type Service struct {
 	model               ModelInvoker
 	auditModel          ModelInvoker
 	auditModelKey       string
+	auditProvider       string
 	workspace           WorkspaceStore
 	queue               PersistenceQueue
+	taskStatus          TaskStatusStore
+	runs                PromptRunStore
 	logger              *zap.SugaredLogger
 	keywordLimit        int
 	keywordMaxLength    int
@@ -77,6 +108,7 @@ type Service struct {
 	versionKeepLimit    int
 	importBatchSize     int
 	freeTier            *freeTier
+	responseCache       *responseCache
 	generationDefault   promptdomain.GenerationProfile
 	generationBounds    generationBounds
 	adminMetrics        *adminmetrics.Service
@@ -85,9 +117,11 @@ type Service struct {
 }
 
 const (
-	defaultQueuePollInterval     = 2 * time.Second
-	defaultModelInvokeTimeout    = 35 * time.Second
-	defaultWorkspaceWriteTimeout = 5 * time.Second
+	defaultQueuePollInterval      = 2 * time.Second
+	defaultQueueReclaimInterval   = time.Minute
+	defaultModelInvokeTimeout     = 35 * time.Second
+	defaultWorkspaceWriteTimeout  = 5 * time.Second
+	defaultTaskStatusPollInterval = 500 * time.Millisecond
 )
 
 // DefaultKeywordLimit 限制正/负向关键词数量的默认值。
@@ -218,6 +252,8 @@ var (
 	ErrSharePayloadInvalid = errors.New("share payload invalid")
 	// ErrSharePayloadTooLarge 表示分享串超出配置的长度上限。
 	ErrSharePayloadTooLarge = errors.New("share payload too large")
+	// ErrSaveTaskNotFound 表示异步保存任务不存在、已过期或不属于当前用户。
+	ErrSaveTaskNotFound = errors.New("save task not found")
 )
 
 // Config 汇总 Prompt 服务的可配置参数。
@@ -236,6 +272,9 @@ type Config struct {
 	FreeTier            FreeTierConfig
 	Generation          GenerationConfig
 	Share               ShareConfig
+	ResponseCache       ResponseCacheConfig
+	TaskStatus          TaskStatusStore
+	Runs                PromptRunStore
 }
 
 // GenerationConfig 描述 Prompt 生成参数的可配置范围与默认值。
@@ -377,8 +416,11 @@ func NewServiceWithConfig(prompts *repository.PromptRepository, keywords *reposi
 		model:               model,
 		auditModel:          auditInvoker,
 		auditModelKey:       strings.TrimSpace(auditModelKey),
+		auditProvider:       auditProviderName(cfg.Audit.Provider),
 		workspace:           workspace,
 		queue:               queue,
+		taskStatus:          cfg.TaskStatus,
+		runs:                cfg.Runs,
 		logger:              logger,
 		keywordLimit:        cfg.KeywordLimit,
 		keywordMaxLength:    cfg.KeywordMaxLength,
@@ -393,6 +435,7 @@ func NewServiceWithConfig(prompts *repository.PromptRepository, keywords *reposi
 		sharePrefix:         sharePrefix,
 		shareMaxEncodedLen:  shareMaxLen,
 		freeTier:            freeTier,
+		responseCache:       newResponseCache(cfg.ResponseCache, model, logger),
 		generationDefault: promptdomain.GenerationProfile{
 			StepwiseReasoning: genCfg.DefaultStepwise,
 			Temperature:       tempDefault,
@@ -571,6 +614,7 @@ func (s *Service) GetPromptVersionDetail(ctx context.Context, input GetVersionDe
 		PositiveKeywords: positive,
 		NegativeKeywords: negative,
 		Generation:       s.decodeGenerationProfile(version.GenerationProfile),
+		Variables:        reconcilePromptVariables(version.Body, decodeStoredVariables(version.Variables)),
 		CreatedAt:        version.CreatedAt,
 	}, nil
 }
@@ -603,6 +647,7 @@ type promptExportRecord struct {
 	UpdatedAt         time.Time                        `json:"updated_at"`
 	LatestVersionNo   int                              `json:"latest_version_no"`
 	GenerationProfile promptdomain.GenerationProfile   `json:"generation_profile"`
+	Variables         []promptdomain.PromptVariable    `json:"variables,omitempty"`
 }
 
 type promptExportEnvelope struct {
@@ -706,6 +751,7 @@ func (s *Service) ExportPrompts(ctx context.Context, input ExportPromptsInput) (
 			UpdatedAt:         record.UpdatedAt,
 			LatestVersionNo:   record.LatestVersionNo,
 			GenerationProfile: s.decodeGenerationProfile(record.GenerationProfile),
+			Variables:         reconcilePromptVariables(record.Body, decodeStoredVariables(record.Variables)),
 		})
 	}
 
@@ -757,6 +803,11 @@ func (s *Service) ImportPrompts(ctx context.Context, input ImportPromptsInput) (
 		if err := s.prompts.DeleteByUser(ctx, input.UserID); err != nil {
 			return result, fmt.Errorf("clear prompts before import: %w", err)
 		}
+		if s.runs != nil {
+			if err := s.runs.DeleteByUser(ctx, input.UserID); err != nil {
+				s.logger.Warnw("clear prompt runs before import failed", "user_id", input.UserID, "error", err)
+			}
+		}
 	}
 	batchSize := s.importBatchSize
 	if batchSize <= 0 {
@@ -882,6 +933,7 @@ func (s *Service) ImportSharedPrompt(ctx context.Context, input ImportSharedProm
 		Tags:             tags,
 		PositiveKeywords: positive,
 		NegativeKeywords: negative,
+		Variables:        record.Variables,
 	}
 	saveInput.GenerationProfile = &normalizedProfile
 	saveResult, err := s.persistPrompt(ctx, saveInput, promptdomain.PromptStatusDraft, "")
@@ -942,6 +994,7 @@ func (s *Service) importPromptRecord(ctx context.Context, userID uint, record pr
 		Tags:             record.Tags,
 		PositiveKeywords: positiveItems,
 		NegativeKeywords: negativeItems,
+		Variables:        record.Variables,
 	}
 	profile := record.GenerationProfile
 	input.GenerationProfile = &profile
@@ -1029,6 +1082,7 @@ func (s *Service) syncImportedMetadata(ctx context.Context, promptID uint, recor
 		NegativeKeywords:  string(negativeBytes),
 		Model:             record.Model,
 		GenerationProfile: s.encodeGenerationProfile(record.GenerationProfile),
+		Variables:         encodePromptVariables(reconcilePromptVariables(record.Body, record.Variables)),
 	}
 	if !record.UpdatedAt.IsZero() {
 		version.CreatedAt = record.UpdatedAt
@@ -1070,6 +1124,7 @@ func (s *Service) GetPrompt(ctx context.Context, input GetPromptInput) (PromptDe
 		UpdatedAt:        entity.UpdatedAt,
 		PublishedAt:      entity.PublishedAt,
 		Generation:       profile,
+		Variables:        reconcilePromptVariables(entity.Body, decodeStoredVariables(entity.Variables)),
 	}
 
 	if s.workspace != nil {
@@ -1083,6 +1138,7 @@ func (s *Service) GetPrompt(ctx context.Context, input GetPromptInput) (PromptDe
 			PromptID:  entity.ID,
 			Status:    entity.Status,
 			UpdatedAt: time.Now(),
+			Version:   1,
 		}
 		attrs := make(map[string]string)
 		if tags := detail.Tags; len(tags) > 0 {
@@ -1107,6 +1163,7 @@ func (s *Service) GetPrompt(ctx context.Context, input GetPromptInput) (PromptDe
 			)
 		} else {
 			detail.WorkspaceToken = token
+			detail.WorkspaceVersion = snapshot.Version
 		}
 	}
 	return detail, nil
@@ -1120,6 +1177,11 @@ func (s *Service) DeletePrompt(ctx context.Context, input DeletePromptInput) err
 		}
 		return err
 	}
+	if s.runs != nil {
+		if err := s.runs.DeleteByPrompt(ctx, input.PromptID); err != nil {
+			s.logger.Warnw("delete prompt runs failed", "user_id", input.UserID, "prompt_id", input.PromptID, "error", err)
+		}
+	}
 	return nil
 }
 
@@ -1201,8 +1263,9 @@ func (s *Service) changePromptLike(ctx context.Context, input UpdateLikeInput, l
 	}, nil
 }
 
-// StartPersistenceWorker 启动后台协程消费 Redis 队列并写入 MySQL。
-// 使用 BLPop 轮询 Redis List，将排队的保存任务转为同步落库操作；当未启用 Redis/队列时保持旧行为。
+// StartPersistenceWorker 启动后台协程消费落库队列并写入数据库。
+// 启动时先回收崩溃 worker 遗留的任务，之后逐个取出任务同步落库：成功后 Ack，失败则交给队列按退避重试，
+// 用尽次数后进入死信；运行期间定期回收长时间未确认的任务。当未启用队列时保持旧行为。
 func (s *Service) StartPersistenceWorker(ctx context.Context, pollTimeout time.Duration) {
 	if s.queue == nil || s.workspace == nil {
 		s.logger.Infow("persistence worker disabled (queue or workspace missing)")
@@ -1212,10 +1275,22 @@ func (s *Service) StartPersistenceWorker(ctx context.Context, pollTimeout time.D
 		pollTimeout = defaultQueuePollInterval
 	}
 	go func() {
+		var lastReclaim time.Time
 		for {
 			if ctx.Err() != nil {
 				return
 			}
+			if time.Since(lastReclaim) >= defaultQueueReclaimInterval {
+				lastReclaim = time.Now()
+				if reclaimed, err := s.queue.Reclaim(ctx); err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					s.logger.Warnw("reclaim persistence tasks failed", "error", err)
+				} else if reclaimed > 0 {
+					s.logger.Infow("reclaimed persistence tasks", "count", reclaimed)
+				}
+			}
 			task, err := s.queue.BlockingPop(ctx, pollTimeout)
 			if err != nil {
 				if errors.Is(err, context.Canceled) || errors.Is(err, redis.Nil) {
@@ -1227,13 +1302,156 @@ func (s *Service) StartPersistenceWorker(ctx context.Context, pollTimeout time.D
 				s.logger.Warnw("dequeue persistence task failed", "error", err)
 				continue
 			}
-			if err := s.processPersistenceTask(ctx, task); err != nil {
-				s.logger.Errorw("process persistence task failed", "task_id", task.TaskID, "prompt_id", task.PromptID, "user_id", task.UserID, "error", err)
-			}
+			s.handlePersistenceTask(ctx, task)
 		}
 	}()
 }
 
+// handlePersistenceTask 处理单个落库任务并根据结果确认或重试，确认失败时任务会在回收后重新处理。
+func (s *Service) handlePersistenceTask(ctx context.Context, task promptdomain.PersistenceTask) {
+	// 队列操作不随 worker 退出而取消，避免处理完成的任务因 ctx 取消而无法确认。
+	queueCtx := context.WithoutCancel(ctx)
+	s.recordTaskStatus(queueCtx, task, promptdomain.PersistenceTaskStateRunning, task.Attempts, task.LastError, SaveOutput{})
+	result, processErr := s.processPersistenceTask(ctx, task)
+	if processErr == nil {
+		if err := s.queue.Ack(queueCtx, task); err != nil {
+			s.logger.Warnw("ack persistence task failed", "task_id", task.TaskID, "error", err)
+		}
+		s.recordTaskStatus(queueCtx, task, promptdomain.PersistenceTaskStateSucceeded, task.Attempts, "", result)
+		return
+	}
+	dead, err := s.queue.Retry(queueCtx, task, processErr)
+	if err != nil {
+		s.logger.Errorw("reschedule persistence task failed", "task_id", task.TaskID, "error", err, "cause", processErr)
+		return
+	}
+	state := promptdomain.PersistenceTaskStatePending
+	if dead {
+		state = promptdomain.PersistenceTaskStateFailed
+	}
+	s.recordTaskStatus(queueCtx, task, state, task.Attempts+1, processErr.Error(), SaveOutput{})
+	if dead {
+		s.logger.Errorw("persistence task moved to dead letter", "task_id", task.TaskID, "prompt_id", task.PromptID, "user_id", task.UserID, "attempts", task.Attempts+1, "error", processErr)
+		return
+	}
+	s.logger.Warnw("process persistence task failed, will retry", "task_id", task.TaskID, "prompt_id", task.PromptID, "user_id", task.UserID, "attempts", task.Attempts+1, "error", processErr)
+}
+
+// enqueueSave 将保存请求写入落库队列并登记 pending 状态，由后台 worker 完成落库。
+// 状态先于任务写入，避免 worker 更新的 running 状态被覆盖。
+func (s *Service) enqueueSave(ctx context.Context, input SaveInput, status, action, workspaceToken string) (SaveOutput, error) {
+	now := time.Now()
+	task := promptdomain.PersistenceTask{
+		TaskID:            uuid.NewString(),
+		UserID:            input.UserID,
+		PromptID:          input.PromptID,
+		WorkspaceToken:    workspaceToken,
+		Publish:           input.Publish,
+		Topic:             input.Topic,
+		Body:              input.Body,
+		Instructions:      input.Instructions,
+		Model:             input.Model,
+		Status:            status,
+		Tags:              input.Tags,
+		RequestedAt:       now,
+		Action:            action,
+		GenerationProfile: s.encodeGenerationProfile(*input.GenerationProfile),
+		Variables:         input.Variables,
+	}
+	if err := s.taskStatus.Save(ctx, promptdomain.PersistenceTaskStatus{
+		TaskID:         task.TaskID,
+		UserID:         task.UserID,
+		State:          promptdomain.PersistenceTaskStatePending,
+		WorkspaceToken: workspaceToken,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}); err != nil {
+		return SaveOutput{}, fmt.Errorf("record save task: %w", err)
+	}
+	taskID, err := s.queue.Enqueue(ctx, task)
+	if err != nil {
+		return SaveOutput{}, fmt.Errorf("enqueue save task: %w", err)
+	}
+	return SaveOutput{
+		PromptID: input.PromptID,
+		Status:   status,
+		TaskID:   taskID,
+		Token:    workspaceToken,
+	}, nil
+}
+
+// recordTaskStatus 根据 worker 的处理进度更新任务状态，未配置状态存储时跳过，写入失败只记录日志。
+func (s *Service) recordTaskStatus(ctx context.Context, task promptdomain.PersistenceTask, state string, attempts int, lastError string, result SaveOutput) {
+	if s.taskStatus == nil || strings.TrimSpace(task.TaskID) == "" {
+		return
+	}
+	status := promptdomain.PersistenceTaskStatus{
+		TaskID:         task.TaskID,
+		UserID:         task.UserID,
+		State:          state,
+		Attempts:       attempts,
+		Error:          lastError,
+		PromptID:       result.PromptID,
+		Version:        result.Version,
+		Status:         result.Status,
+		WorkspaceToken: task.WorkspaceToken,
+		CreatedAt:      task.RequestedAt,
+		UpdatedAt:      time.Now(),
+	}
+	if status.PromptID == 0 {
+		status.PromptID = task.PromptID
+	}
+	if err := s.taskStatus.Save(ctx, status); err != nil {
+		s.logger.Warnw("record persistence task status failed", "task_id", task.TaskID, "state", state, "error", err)
+	}
+}
+
+// GetSaveTask 查询异步保存任务的处理状态，仅任务所属用户可见。
+func (s *Service) GetSaveTask(ctx context.Context, userID uint, taskID string) (promptdomain.PersistenceTaskStatus, error) {
+	taskID = strings.TrimSpace(taskID)
+	if s.taskStatus == nil || taskID == "" {
+		return promptdomain.PersistenceTaskStatus{}, ErrSaveTaskNotFound
+	}
+	status, err := s.taskStatus.Get(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return promptdomain.PersistenceTaskStatus{}, ErrSaveTaskNotFound
+		}
+		return promptdomain.PersistenceTaskStatus{}, fmt.Errorf("load save task: %w", err)
+	}
+	if status.UserID != userID {
+		return promptdomain.PersistenceTaskStatus{}, ErrSaveTaskNotFound
+	}
+	return status, nil
+}
+
+// WatchSaveTask 轮询任务状态并在每次变化时回调 onUpdate，任务进入终态、回调返回错误或 ctx 取消时结束。
+func (s *Service) WatchSaveTask(ctx context.Context, userID uint, taskID string, onUpdate func(promptdomain.PersistenceTaskStatus) error) error {
+	ticker := time.NewTicker(defaultTaskStatusPollInterval)
+	defer ticker.Stop()
+	var last promptdomain.PersistenceTaskStatus
+	for {
+		status, err := s.GetSaveTask(ctx, userID, taskID)
+		if err != nil {
+			return err
+		}
+		if status.State != last.State || status.Attempts != last.Attempts || !status.UpdatedAt.Equal(last.UpdatedAt) {
+			if err := onUpdate(status); err != nil {
+				return err
+			}
+			last = status
+		}
+		if status.Finished() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 // modelInvocationContext 在调用外部模型时拆解 HTTP 请求上下文，并为长耗时请求设置安全超时。
 // Gin 在响应写入后会取消 request.Context()，直接复用会导致还在进行中的模型调用被中断。
 // 这里改用 context.WithoutCancel 继承 Value/Deadline，再包裹一个 35s 超时，确保模型请求能顺利完成。
@@ -1355,10 +1573,13 @@ type PromptDetail struct {
 	IsLiked          bool
 	LikeCount        uint
 	WorkspaceToken   string
+	WorkspaceVersion int64 // 工作区初始版本号，后续编辑时回传用于冲突检测
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
 	PublishedAt      *time.Time
 	Generation       promptdomain.GenerationProfile
+	Variables        []promptdomain.PromptVariable
+	Cached           bool // 仅 IngestPrompt 填充：模型解析结果是否来自响应缓存
 }
 
 // PromptVersionDetail 包含历史版本的完整内容。
@@ -1370,6 +1591,7 @@ type PromptVersionDetail struct {
 	PositiveKeywords []KeywordItem
 	NegativeKeywords []KeywordItem
 	Generation       promptdomain.GenerationProfile
+	Variables        []promptdomain.PromptVariable
 	CreatedAt        time.Time
 }
 
@@ -1404,6 +1626,7 @@ type InterpretInput struct {
 	Description string
 	ModelKey    string
 	Language    string
+	BypassCache bool // 跳过响应缓存，强制重新调用模型
 }
 
 // InterpretOutput 返回结构化的 Topic 与关键词列表。
@@ -1413,16 +1636,21 @@ type InterpretOutput struct {
 	NegativeKeywords []KeywordItem
 	Confidence       float64
 	WorkspaceToken   string
+	WorkspaceVersion int64 // 工作区当前版本号，后续编辑时回传用于冲突检测
 	Instructions     string
 	Tags             []string
+	ServedModelKey   string // 实际完成调用的模型 key，可能来自回退链
+	FallbackUsed     bool   // 是否因主模型失败而使用了回退模型
+	Cached           bool   // 模型响应是否来自缓存（未扣减免费额度）
 }
 
 // IngestPromptInput 描述解析成品 Prompt 所需的参数。
 type IngestPromptInput struct {
-	UserID   uint
-	Body     string
-	ModelKey string
-	Language string
+	UserID      uint
+	Body        string
+	ModelKey    string
+	Language    string
+	BypassCache bool
 }
 
 // AugmentInput 描述补充关键词的请求参数。
@@ -1436,12 +1664,18 @@ type AugmentInput struct {
 	Language          string
 	RequestedPositive int
 	RequestedNegative int
+	BypassCache       bool
 }
 
 // AugmentOutput 返回模型补充后的关键词列表（仅新增部分）。
 type AugmentOutput struct {
-	Positive []KeywordItem
-	Negative []KeywordItem
+	Positive       []KeywordItem
+	Negative       []KeywordItem
+	ServedModelKey string
+	FallbackUsed   bool
+	Cached         bool
+	// WorkspaceVersion 为写入工作区后的版本号，未写入时为 0。
+	WorkspaceVersion int64
 }
 
 // ManualKeywordInput 描述手动新增关键词时的参数。
@@ -1455,22 +1689,32 @@ type ManualKeywordInput struct {
 	PromptID       uint
 	WorkspaceToken string // interpret 返回的 Redis 工作区 token，用于把手动关键词写入缓存。
 	Weight         int
+	// WorkspaceVersion 是前端所见的工作区版本，与当前版本不一致即返回冲突；为 WorkspaceAnyVersion 时不校验。
+	WorkspaceVersion int64
+}
+
+// ManualKeywordOutput 返回新增的关键词及写入后的工作区版本号。
+type ManualKeywordOutput struct {
+	KeywordItem
+	WorkspaceVersion int64 `json:"workspace_version,omitempty"`
 }
 
 // RemoveKeywordInput 描述移除临时工作区关键词所需的参数。
 type RemoveKeywordInput struct {
-	UserID         uint
-	Word           string
-	Polarity       string
-	WorkspaceToken string
+	UserID           uint
+	Word             string
+	Polarity         string
+	WorkspaceToken   string
+	WorkspaceVersion int64
 }
 
 // SyncWorkspaceInput 用于将前端排序/权重调整同步到 Redis 工作区。
 type SyncWorkspaceInput struct {
-	UserID         uint
-	WorkspaceToken string
-	Positive       []KeywordItem
-	Negative       []KeywordItem
+	UserID           uint
+	WorkspaceToken   string
+	WorkspaceVersion int64
+	Positive         []KeywordItem
+	Negative         []KeywordItem
 }
 
 // GenerateInput 描述生成 Prompt 正文所需的上下文。
@@ -1492,16 +1736,26 @@ type GenerateInput struct {
 	PromptID          uint
 	IncludeKeywordRef bool
 	GenerationProfile *promptdomain.GenerationProfile
+	Candidates        int    // 候选数量，0 或 1 表示只生成一份
+	RankBy            string // 候选排序方式：空串不排序，heuristic 按关键词覆盖度，judge 由模型打分
 }
 
-// GenerateOutput 返回生成的 Prompt、模型信息与耗时。
+// GenerateOutput 返回生成的 Prompt、模型信息与耗时；多候选时 Prompt 为选中的候选，Usage 与 Cost 为全部调用的合计。
 type GenerateOutput struct {
-	Model        string
-	Prompt       string
-	Duration     time.Duration
-	Usage        *modeldomain.ChatCompletionUsage
-	PositiveUsed []KeywordItem
-	NegativeUsed []KeywordItem
+	Model          string
+	Prompt         string
+	Duration       time.Duration
+	Usage          *llm.Usage
+	PositiveUsed   []KeywordItem
+	NegativeUsed   []KeywordItem
+	ServedModelKey string
+	FallbackUsed   bool
+	Cost           *llm.Cost
+	Candidates     []GenerateCandidate
+	SelectedIndex  int
+	RankedBy       string
+	// WorkspaceVersion 为生成结果写回工作区后的版本号，未写入时为 0。
+	WorkspaceVersion int64
 }
 
 // auditContent 使用用户选择的模型对文本进行内容审核，审核不通过时返回 ErrContentRejected。
@@ -1523,11 +1777,25 @@ func (s *Service) auditContent(ctx context.Context, userID uint, modelKey string
 	}
 	req := buildAuditRequest(stage, trimmedText)
 	req.Model = invokeModelKey
-	modelCtx, cancel := s.modelInvocationContext(ctx)
-	defer cancel()
-	resp, err := invoker.InvokeChatCompletion(modelCtx, userID, invokeModelKey, req)
-	if err != nil {
-		return fmt.Errorf("content audit failed: %w", err)
+	var resp llm.Response
+	if s.auditModel != nil {
+		modelCtx, cancel := s.modelInvocationContext(ctx)
+		defer cancel()
+		modelCtx = llm.WithOperation(modelCtx, fallbackOperationAudit)
+		start := time.Now()
+		auditResp, err := invoker.InvokeChatCompletion(modelCtx, userID, invokeModelKey, req)
+		s.recordPlatformUsage(modelCtx, userID, s.auditProvider, invokeModelKey, auditResp, time.Since(start), err)
+		if err != nil {
+			return fmt.Errorf("content audit failed: %w", err)
+		}
+		resp = auditResp
+	} else {
+		// 未配置独立审核模型时沿用用户模型，并按审核操作的回退链切换（不消耗免费额度）。
+		invokeRes, err := s.invokeModelWithFallback(ctx, userID, fallbackOperationAudit, invokeModelKey, req)
+		if err != nil {
+			return fmt.Errorf("content audit failed: %w", err)
+		}
+		resp = invokeRes.Response
 	}
 	verdict, err := parseAuditPayload(resp)
 	if err != nil {
@@ -1545,46 +1813,25 @@ func (s *Service) AuditCommentContent(ctx context.Context, userID uint, content
 	return s.auditContent(ctx, userID, "", content, auditStageCommentBody)
 }
 
-// invokeResult 记录模型调用结果以及免费额度的使用情况。
-type invokeResult struct {
-	Response     modeldomain.ChatCompletionResponse
-	FreeTierUsed bool
-	FreeTierInfo *freeTierUsage
-}
-
-// invokeModelWithFallback 优先使用用户自定义凭据调用模型，若缺失则回退到免费额度。
-//  1. 先调用原来的 model.InvokeChatCompletion（也就是 ModelService.InvokeChatCompletion），这和过去的逻辑完全一样。
-//  2. 如果返回 modelsvc.ErrCredentialNotFound 或 ErrCredentialDisabled，并且我们启用了 free tier，就改走 freeTier.invoke(...)。
-//  3. 其它错误保持原状向上抛，让 Handler 决定应该提示网络错误还是内容审核失败。
-func (s *Service) invokeModelWithFallback(ctx context.Context, userID uint, modelKey string, req modeldomain.ChatCompletionRequest) (invokeResult, error) {
-	if s.model == nil {
-		return invokeResult{}, fmt.Errorf("%w: 模型服务未初始化", ErrModelInvocationFailed)
+// callModel 在 onDelta 为空时直接调用 InvokeChatCompletion；否则优先使用流式接口，
+// 调用器不支持流式时退化为一次性调用并把完整正文作为单个增量推送。
+func callModel(ctx context.Context, invoker ModelInvoker, userID uint, modelKey string, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	if onDelta == nil {
+		return invoker.InvokeChatCompletion(ctx, userID, modelKey, req)
 	}
-	resp, err := s.model.InvokeChatCompletion(ctx, userID, modelKey, req)
-	if err == nil {
-		return invokeResult{Response: resp}, nil
-	}
-
-	if s.freeTier == nil || !s.freeTier.matches(modelKey) {
-		return invokeResult{}, fmt.Errorf("%w: %w", ErrModelInvocationFailed, err)
+	if streamer, ok := invoker.(StreamingModelInvoker); ok {
+		return streamer.InvokeChatCompletionStream(ctx, userID, modelKey, req, onDelta)
 	}
-	if !errors.Is(err, modelsvc.ErrCredentialNotFound) && !errors.Is(err, modelsvc.ErrCredentialDisabled) {
-		return invokeResult{}, fmt.Errorf("%w: %w", ErrModelInvocationFailed, err)
+	resp, err := invoker.InvokeChatCompletion(ctx, userID, modelKey, req)
+	if err != nil {
+		return llm.Response{}, err
 	}
-
-	resp, usage, fallbackErr := s.freeTier.invoke(ctx, userID, req)
-	if fallbackErr != nil {
-		if quotaErr := (*FreeTierQuotaExceededError)(nil); errors.As(fallbackErr, &quotaErr) {
-			return invokeResult{}, quotaErr
+	if text := extractPromptText(resp); text != "" {
+		if err := onDelta(text); err != nil {
+			return llm.Response{}, err
 		}
-		return invokeResult{}, fmt.Errorf("%w: %w", ErrModelInvocationFailed, fallbackErr)
 	}
-
-	return invokeResult{
-		Response:     resp,
-		FreeTierUsed: true,
-		FreeTierInfo: &usage,
-	}, nil
+	return resp, nil
 }
 
 // SaveInput 描述保存草稿或发布 Prompt 的参数。
@@ -1603,6 +1850,8 @@ type SaveInput struct {
 	WorkspaceToken           string
 	EnforcePublishValidation bool
 	GenerationProfile        *promptdomain.GenerationProfile
+	Variables                []promptdomain.PromptVariable // 显式的变量定义，为 nil 时沿用已保存的定义；正文中新出现的占位符会自动补充
+	Async                    bool                          // 为 true 且工作区与落库队列可用时改为入队异步落库，结果通过任务状态查询
 }
 
 // SaveOutput 返回保存后的 Prompt 元数据。
@@ -1612,6 +1861,8 @@ type SaveOutput struct {
 	Version  int    `json:"version"`
 	TaskID   string `json:"task_id,omitempty"`
 	Token    string `json:"workspace_token,omitempty"`
+	// WorkspaceVersion 为保存后回写工作区的版本号，异步保存时为 0。
+	WorkspaceVersion int64 `json:"workspace_version,omitempty"`
 }
 
 // Interpret 调用大模型解析自然语言描述，并将结果写入关键词表以便复用。
@@ -1633,10 +1884,10 @@ func (s *Service) Interpret(ctx context.Context, input InterpretInput) (Interpre
 	}
 	req := buildInterpretationRequest(description, input.Language)
 	req.Model = modelKey
-	// 防止模型超时
-	modelCtx, cancel := s.modelInvocationContext(ctx)
-	defer cancel()
-	invokeRes, err := s.invokeModelWithFallback(modelCtx, input.UserID, modelKey, req)
+	if input.BypassCache {
+		ctx = withoutResponseCache(ctx)
+	}
+	invokeRes, err := s.invokeModelWithFallback(ctx, input.UserID, fallbackOperationInterpret, modelKey, req)
 	if err != nil {
 		return InterpretOutput{}, err
 	}
@@ -1655,10 +1906,13 @@ func (s *Service) Interpret(ctx context.Context, input InterpretInput) (Interpre
 		}
 	}
 	output := InterpretOutput{
-		Topic:        payload.Topic,
-		Confidence:   payload.Confidence,
-		Instructions: payload.Instructions,
-		Tags:         cleanedTags,
+		Topic:          payload.Topic,
+		Confidence:     payload.Confidence,
+		Instructions:   payload.Instructions,
+		Tags:           cleanedTags,
+		ServedModelKey: invokeRes.ModelKey,
+		FallbackUsed:   invokeRes.FallbackUsed,
+		Cached:         invokeRes.Cached,
 	}
 	if output.Topic == "" {
 		return InterpretOutput{}, errors.New("model did not return topic")
@@ -1717,6 +1971,7 @@ func (s *Service) Interpret(ctx context.Context, input InterpretInput) (Interpre
 			s.logger.Warnw("store workspace snapshot failed", "user_id", input.UserID, "topic", payload.Topic, "error", err)
 		} else {
 			output.WorkspaceToken = token
+			output.WorkspaceVersion = workspaceSnapshot.Version
 		}
 	} else {
 		// 无 Redis 时保持旧行为，直接写入 MySQL 字典。
@@ -1767,9 +2022,11 @@ func (s *Service) IngestPrompt(ctx context.Context, input IngestPromptInput) (Pr
 	}
 	req := buildPromptIngestRequest(body, input.Language, positiveLimit, negativeLimit, tagLimit)
 	req.Model = modelKey
-	modelCtx, cancel := s.modelInvocationContext(ctx)
-	defer cancel()
-	invokeRes, err := s.invokeModelWithFallback(modelCtx, input.UserID, modelKey, req)
+	invokeCtx := llm.WithOperation(ctx, usageOperationIngest)
+	if input.BypassCache {
+		invokeCtx = withoutResponseCache(invokeCtx)
+	}
+	invokeRes, err := s.invokeModelWithFallback(invokeCtx, input.UserID, fallbackOperationInterpret, modelKey, req)
 	if err != nil {
 		return PromptDetail{}, err
 	}
@@ -1822,6 +2079,7 @@ func (s *Service) IngestPrompt(ctx context.Context, input IngestPromptInput) (Pr
 			Description: body,
 			ModelKey:    modelKey,
 			Language:    input.Language,
+			BypassCache: input.BypassCache,
 		}); ierr == nil {
 			if strings.TrimSpace(payload.Topic) == "" {
 				payload.Topic = fallback.Topic
@@ -1868,6 +2126,7 @@ func (s *Service) IngestPrompt(ctx context.Context, input IngestPromptInput) (Pr
 	if err != nil {
 		return PromptDetail{}, err
 	}
+	detail.Cached = invokeRes.Cached
 	return detail, nil
 }
 
@@ -1898,9 +2157,11 @@ func (s *Service) AugmentKeywords(ctx context.Context, input AugmentInput) (Augm
 
 	req := buildAugmentRequest(input)
 	req.Model = modelKey
-	modelCtx, cancel := s.modelInvocationContext(ctx)
-	defer cancel()
-	invokeRes, err := s.invokeModelWithFallback(modelCtx, input.UserID, modelKey, req)
+	invokeCtx := ctx
+	if input.BypassCache {
+		invokeCtx = withoutResponseCache(ctx)
+	}
+	invokeRes, err := s.invokeModelWithFallback(invokeCtx, input.UserID, fallbackOperationAugment, modelKey, req)
 	if err != nil {
 		return AugmentOutput{}, err
 	}
@@ -1919,6 +2180,9 @@ func (s *Service) AugmentKeywords(ctx context.Context, input AugmentInput) (Augm
 		workspaceNew     []promptdomain.WorkspaceKeyword
 		workspaceEnabled = s.workspace != nil && strings.TrimSpace(input.WorkspaceToken) != ""
 	)
+	output.ServedModelKey = invokeRes.ModelKey
+	output.FallbackUsed = invokeRes.FallbackUsed
+	output.Cached = invokeRes.Cached
 	for idx, entry := range payload.Positive {
 		if positiveCapacity <= 0 {
 			break
@@ -1980,26 +2244,29 @@ func (s *Service) AugmentKeywords(ctx context.Context, input AugmentInput) (Augm
 	if workspaceEnabled && len(workspaceNew) > 0 {
 		storeCtx, cancel := s.workspaceContext(ctx)
 		defer cancel()
-		if err := s.workspace.MergeKeywords(storeCtx, input.UserID, input.WorkspaceToken, workspaceNew); err != nil {
+		if version, err := s.workspace.MergeKeywords(storeCtx, input.UserID, input.WorkspaceToken, promptdomain.WorkspaceAnyVersion, workspaceNew); err != nil {
 			s.logger.Warnw("merge workspace keywords failed", "user_id", input.UserID, "token", input.WorkspaceToken, "error", err)
-		} else if err := s.workspace.Touch(storeCtx, input.UserID, input.WorkspaceToken); err != nil {
-			s.logger.Warnw("touch workspace failed", "user_id", input.UserID, "token", input.WorkspaceToken, "error", err)
+		} else {
+			output.WorkspaceVersion = version
+			if err := s.workspace.Touch(storeCtx, input.UserID, input.WorkspaceToken); err != nil {
+				s.logger.Warnw("touch workspace failed", "user_id", input.UserID, "token", input.WorkspaceToken, "error", err)
+			}
 		}
 	}
 	return output, nil
 }
 
-// AddManualKeyword 将用户手动输入的关键词写入数据库，并返回最终条目。
-func (s *Service) AddManualKeyword(ctx context.Context, input ManualKeywordInput) (KeywordItem, error) {
+// AddManualKeyword 将用户手动输入的关键词写入数据库，并返回最终条目；写入工作区时校验版本号，冲突时返回 WorkspaceConflictError。
+func (s *Service) AddManualKeyword(ctx context.Context, input ManualKeywordInput) (ManualKeywordOutput, error) {
 	word := s.clampKeywordWord(input.Word)
 	if word == "" {
-		return KeywordItem{}, errors.New("keyword is empty")
+		return ManualKeywordOutput{}, errors.New("keyword is empty")
 	}
 	if input.UserID == 0 {
-		return KeywordItem{}, errors.New("user id is required")
+		return ManualKeywordOutput{}, errors.New("user id is required")
 	}
 	if strings.TrimSpace(input.Topic) == "" {
-		return KeywordItem{}, errors.New("topic is required")
+		return ManualKeywordOutput{}, errors.New("topic is required")
 	}
 	polarity := normalizePolarity(input.Polarity)
 	source := input.Source
@@ -2023,19 +2290,19 @@ func (s *Service) AddManualKeyword(ctx context.Context, input ManualKeywordInput
 			positiveCount = workspaceKeywordCount(snapshot.Positive)
 			negativeCount = workspaceKeywordCount(snapshot.Negative)
 			if workspaceHasKeyword(snapshot.Positive, promptdomain.KeywordPolarityPositive, word) && polarity == promptdomain.KeywordPolarityPositive {
-				return KeywordItem{}, ErrDuplicateKeyword
+				return ManualKeywordOutput{}, ErrDuplicateKeyword
 			}
 			if workspaceHasKeyword(snapshot.Negative, promptdomain.KeywordPolarityNegative, word) && polarity == promptdomain.KeywordPolarityNegative {
-				return KeywordItem{}, ErrDuplicateKeyword
+				return ManualKeywordOutput{}, ErrDuplicateKeyword
 			}
 		} else if !errors.Is(snapErr, redis.Nil) {
 			s.logger.Warnw("load workspace snapshot for manual keyword failed", "user_id", input.UserID, "token", token, "error", snapErr)
 		}
 		if polarity == promptdomain.KeywordPolarityPositive && positiveCount >= s.keywordLimit {
-			return KeywordItem{}, ErrPositiveKeywordLimit
+			return ManualKeywordOutput{}, ErrPositiveKeywordLimit
 		}
 		if polarity == promptdomain.KeywordPolarityNegative && negativeCount >= s.keywordLimit {
-			return KeywordItem{}, ErrNegativeKeywordLimit
+			return ManualKeywordOutput{}, ErrNegativeKeywordLimit
 		}
 		workspaceKeyword := promptdomain.WorkspaceKeyword{
 			Word:     word,
@@ -2044,17 +2311,24 @@ func (s *Service) AddManualKeyword(ctx context.Context, input ManualKeywordInput
 			Weight:   weight,
 			Score:    float64(time.Now().UnixNano()),
 		}
-		if err := s.workspace.MergeKeywords(storeCtx, input.UserID, token, []promptdomain.WorkspaceKeyword{workspaceKeyword}); err != nil {
+		version, err := s.workspace.MergeKeywords(storeCtx, input.UserID, token, input.WorkspaceVersion, []promptdomain.WorkspaceKeyword{workspaceKeyword})
+		if err != nil {
+			if errors.Is(err, promptdomain.ErrWorkspaceVersionConflict) {
+				return ManualKeywordOutput{}, s.workspaceConflict(storeCtx, input.UserID, token, err)
+			}
 			s.logger.Warnw("merge manual keyword to workspace failed", "user_id", input.UserID, "token", token, "word", word, "error", err)
 		} else if err := s.workspace.Touch(storeCtx, input.UserID, token); err != nil {
 			s.logger.Warnw("touch workspace failed", "user_id", input.UserID, "token", token, "error", err)
 		}
-		return KeywordItem{
-			KeywordID: 0,
-			Word:      word,
-			Source:    source,
-			Polarity:  polarity,
-			Weight:    weight,
+		return ManualKeywordOutput{
+			KeywordItem: KeywordItem{
+				KeywordID: 0,
+				Word:      word,
+				Source:    source,
+				Polarity:  polarity,
+				Weight:    weight,
+			},
+			WorkspaceVersion: version,
 		}, nil
 	}
 
@@ -2069,7 +2343,7 @@ func (s *Service) AddManualKeyword(ctx context.Context, input ManualKeywordInput
 	}
 	stored, err := s.keywords.Upsert(ctx, entity)
 	if err != nil {
-		return KeywordItem{}, err
+		return ManualKeywordOutput{}, err
 	}
 
 	item := KeywordItem{
@@ -2084,70 +2358,79 @@ func (s *Service) AddManualKeyword(ctx context.Context, input ManualKeywordInput
 			s.logger.Warnw("attach manual keyword failed", "promptID", input.PromptID, "keywordID", stored.ID, "error", err)
 		}
 	}
-	return item, nil
+	return ManualKeywordOutput{KeywordItem: item}, nil
 }
 
-// RemoveWorkspaceKeyword 从临时工作区中移除单个关键词，保持 Redis 与前端状态同步。
-func (s *Service) RemoveWorkspaceKeyword(ctx context.Context, input RemoveKeywordInput) error {
+// RemoveWorkspaceKeyword 从临时工作区中移除单个关键词，保持 Redis 与前端状态同步，返回写入后的工作区版本号。
+func (s *Service) RemoveWorkspaceKeyword(ctx context.Context, input RemoveKeywordInput) (int64, error) {
 	if s.workspace == nil {
-		return nil
+		return 0, nil
 	}
 	token := strings.TrimSpace(input.WorkspaceToken)
 	if token == "" {
-		return nil
+		return 0, nil
 	}
 	word := strings.TrimSpace(input.Word)
 	if word == "" {
-		return errors.New("keyword is empty")
+		return 0, errors.New("keyword is empty")
 	}
 	storeCtx, cancel := s.workspaceContext(ctx)
 	defer cancel()
-	if err := s.workspace.RemoveKeyword(storeCtx, input.UserID, token, normalizePolarity(input.Polarity), word); err != nil {
-		return err
+	version, err := s.workspace.RemoveKeyword(storeCtx, input.UserID, token, input.WorkspaceVersion, normalizePolarity(input.Polarity), word)
+	if err != nil {
+		return 0, s.workspaceConflict(storeCtx, input.UserID, token, err)
 	}
-	return nil
+	return version, nil
 }
 
 // SyncWorkspaceKeywords 是前端拖拽关键词、调整权重后同步 Redis 工作区快照的接口。保持 Redis 状态与 UI 一致。流程如下：
-// 1. 前端把最新的正/负向关键词列表（包含顺序与权重）连同 workspace_token 发给后端。
-// 2. Service 先用 workspace.Snapshot 取回当前 Redis 快照：里面包括草稿正文、现有关键词等。
+// 1. 前端把最新的正/负向关键词列表（包含顺序与权重）连同 workspace_token 与所见的 workspace_version 发给后端。
+// 2. Service 先用 workspace.Snapshot 确认工作区仍然存在。
 // 3. 用 workspaceKeywordsFromOrdered 把前端传回的数组转换成 Redis 存储结构（我们会重新分配 score、清洗来源/权重）。
-// 4. 更新 snapshot 的 Positive/Negative 列表、更新时间和版本号。
-// 5. 调用 workspace.CreateOrReplace，这一步内部使用 MULTI/EXEC 管道一次性覆盖 Hash 和 ZSet，保证排序与权重同步更新。
-// 6. 如果 token 失效或 Redis 有问题就返回错误，让前端重新拉最新快照。
-func (s *Service) SyncWorkspaceKeywords(ctx context.Context, input SyncWorkspaceInput) error {
+// 4. 调用 workspace.ReplaceKeywords，在 WATCH 事务中校验版本号并一次性覆盖两个 ZSet，版本号加一。
+// 5. 如果 token 失效或 Redis 有问题就返回错误，版本冲突时返回携带最新快照的 WorkspaceConflictError，让前端合并或重新拉取。
+func (s *Service) SyncWorkspaceKeywords(ctx context.Context, input SyncWorkspaceInput) (int64, error) {
 	if s.workspace == nil {
-		return nil
+		return 0, nil
 	}
 	token := strings.TrimSpace(input.WorkspaceToken)
 	if token == "" {
-		return errors.New("workspace token is empty")
+		return 0, errors.New("workspace token is empty")
 	}
 	if err := enforceKeywordLimit(s.keywordLimit, input.Positive, input.Negative); err != nil {
-		return err
+		return 0, err
 	}
 	storeCtx, cancel := s.workspaceContext(ctx)
 	defer cancel()
-	snapshot, err := s.workspace.Snapshot(storeCtx, input.UserID, token)
-	if err != nil {
+	if _, err := s.workspace.Snapshot(storeCtx, input.UserID, token); err != nil {
 		if errors.Is(err, redis.Nil) {
-			return fmt.Errorf("workspace not found")
+			return 0, fmt.Errorf("workspace not found")
 		}
-		return err
+		return 0, err
 	}
-	snapshot.Positive = s.workspaceKeywordsFromOrdered(input.Positive)
-	snapshot.Negative = s.workspaceKeywordsFromOrdered(input.Negative)
-	snapshot.UpdatedAt = time.Now()
-	snapshot.Version++
-	snapshot.Token = token
-	if _, err := s.workspace.CreateOrReplace(storeCtx, input.UserID, snapshot); err != nil {
-		return err
+	version, err := s.workspace.ReplaceKeywords(storeCtx, input.UserID, token, input.WorkspaceVersion,
+		s.workspaceKeywordsFromOrdered(input.Positive),
+		s.workspaceKeywordsFromOrdered(input.Negative),
+	)
+	if err != nil {
+		return 0, s.workspaceConflict(storeCtx, input.UserID, token, err)
 	}
-	return nil
+	return version, nil
 }
 
 // GeneratePrompt 调用模型生成 Prompt，并返回正文与耗时。
-func (s *Service) GeneratePrompt(ctx context.Context, input GenerateInput) (output GenerateOutput, err error) {
+func (s *Service) GeneratePrompt(ctx context.Context, input GenerateInput) (GenerateOutput, error) {
+	return s.generatePrompt(ctx, input, nil)
+}
+
+// GeneratePromptStream 以流式方式生成 Prompt：模型每输出一段文本即回调 onDelta，
+// 全部接收完成后再对拼装好的正文执行审核与工作区回写，返回值与 GeneratePrompt 一致。
+func (s *Service) GeneratePromptStream(ctx context.Context, input GenerateInput, onDelta llm.StreamHandler) (GenerateOutput, error) {
+	return s.generatePrompt(ctx, input, onDelta)
+}
+
+// generatePrompt 是 GeneratePrompt 与 GeneratePromptStream 的共享实现，onDelta 为空时走一次性调用。
+func (s *Service) generatePrompt(ctx context.Context, input GenerateInput, onDelta llm.StreamHandler) (output GenerateOutput, err error) {
 	start := time.Now()
 	defer func() {
 		modelLabel := strings.TrimSpace(input.ModelKey)
@@ -2157,13 +2440,17 @@ func (s *Service) GeneratePrompt(ctx context.Context, input GenerateInput) (outp
 		elapsed := time.Since(start)
 		metrics.ObservePromptGenerate(classifyGenerateError(err), modelLabel, elapsed, output.Usage)
 		if s.adminMetrics != nil {
-			s.adminMetrics.RecordActivity(adminmetrics.ActivityEvent{
+			event := adminmetrics.ActivityEvent{
 				UserID:    input.UserID,
 				Kind:      adminmetrics.ActivityGenerate,
 				Success:   err == nil,
 				Duration:  elapsed,
 				Timestamp: time.Now(),
-			})
+			}
+			if output.Cost != nil {
+				event.Cost = output.Cost.Amount
+			}
+			s.adminMetrics.RecordActivity(event)
 		}
 	}()
 
@@ -2187,12 +2474,28 @@ func (s *Service) GeneratePrompt(ctx context.Context, input GenerateInput) (outp
 	if err = enforceKeywordLimit(s.keywordLimit, input.PositiveKeywords, input.NegativeKeywords); err != nil {
 		return
 	}
+	candidateCount, rankBy, optErr := normalizeCandidateOptions(input.Candidates, input.RankBy)
+	if optErr != nil {
+		err = optErr
+		return
+	}
+	if candidateCount > 1 && onDelta != nil {
+		err = ErrCandidatesNotStreamable
+		return
+	}
 	profile := s.normalizeGenerationProfile(input.GenerationProfile)
 	req := buildGenerateRequest(input, profile)
 	req.Model = modelKey
-	modelCtx, cancel := s.modelInvocationContext(ctx)
-	defer cancel()
-	invokeRes, invokeErr := s.invokeModelWithFallback(modelCtx, input.UserID, modelKey, req)
+	if candidateCount > 1 {
+		output, err = s.generateCandidates(ctx, input, modelKey, req, candidateCount, rankBy)
+		if err != nil {
+			return
+		}
+		output.Duration = time.Since(start)
+		output.WorkspaceVersion = s.writeGeneratedDraft(ctx, input, output.Prompt, profile)
+		return
+	}
+	invokeRes, invokeErr := s.invokeModelStreamWithFallback(ctx, input.UserID, fallbackOperationGenerate, modelKey, req, onDelta)
 	if invokeErr != nil {
 		err = invokeErr
 		return
@@ -2206,32 +2509,45 @@ func (s *Service) GeneratePrompt(ctx context.Context, input GenerateInput) (outp
 	if err = s.auditContent(ctx, input.UserID, modelKey, promptText, auditStageGenerateOutput); err != nil {
 		return
 	}
-	if s.workspace != nil && strings.TrimSpace(input.WorkspaceToken) != "" {
-		// 写回最新草稿并刷新 TTL，防止用户在生成后继续调整时工作区被 Redis 过期策略清理。
-		storeCtx, cancelStore := s.workspaceContext(ctx)
-		defer cancelStore()
-		token := strings.TrimSpace(input.WorkspaceToken)
-		if updateErr := s.workspace.UpdateDraftBody(storeCtx, input.UserID, token, promptText); updateErr != nil {
-			s.logger.Warnw("update workspace draft failed", "user_id", input.UserID, "token", input.WorkspaceToken, "error", updateErr)
-		} else if touchErr := s.workspace.Touch(storeCtx, input.UserID, token); touchErr != nil {
-			s.logger.Warnw("touch workspace failed", "user_id", input.UserID, "token", input.WorkspaceToken, "error", touchErr)
-		} else if attrErr := s.workspace.SetAttributes(storeCtx, input.UserID, token, map[string]string{
-			workspaceAttrGenerationProfile: s.encodeGenerationProfile(profile),
-		}); attrErr != nil {
-			s.logger.Warnw("set workspace generation profile failed", "user_id", input.UserID, "token", input.WorkspaceToken, "error", attrErr)
-		}
-	}
+	workspaceVersion := s.writeGeneratedDraft(ctx, input, promptText, profile)
 	output = GenerateOutput{
-		Model:        strings.TrimSpace(invokeRes.Response.Model),
-		Prompt:       promptText,
-		Duration:     duration,
-		Usage:        invokeRes.Response.Usage,
-		PositiveUsed: input.PositiveKeywords,
-		NegativeUsed: input.NegativeKeywords,
+		Model:            strings.TrimSpace(invokeRes.Response.Model),
+		Prompt:           promptText,
+		Duration:         duration,
+		Usage:            invokeRes.Response.Usage,
+		PositiveUsed:     input.PositiveKeywords,
+		NegativeUsed:     input.NegativeKeywords,
+		ServedModelKey:   invokeRes.ModelKey,
+		FallbackUsed:     invokeRes.FallbackUsed,
+		Cost:             invokeRes.Response.Cost,
+		WorkspaceVersion: workspaceVersion,
 	}
 	return
 }
 
+// writeGeneratedDraft 将生成结果写回工作区草稿并刷新 TTL，防止用户在生成后继续调整时工作区被 Redis 过期策略清理。
+// 生成是用户显式触发的覆盖动作，不校验版本号；返回写入后的版本号，写入失败时返回 0。
+func (s *Service) writeGeneratedDraft(ctx context.Context, input GenerateInput, promptText string, profile promptdomain.GenerationProfile) int64 {
+	token := strings.TrimSpace(input.WorkspaceToken)
+	if s.workspace == nil || token == "" {
+		return 0
+	}
+	storeCtx, cancelStore := s.workspaceContext(ctx)
+	defer cancelStore()
+	// 正文与生成配置在同一次写入中落地，撤销一次即可回到生成前的状态。
+	version, updateErr := s.workspace.UpdateDraft(storeCtx, input.UserID, token, promptdomain.WorkspaceAnyVersion, promptText, map[string]string{
+		workspaceAttrGenerationProfile: s.encodeGenerationProfile(profile),
+	})
+	if updateErr != nil {
+		s.logger.Warnw("update workspace draft failed", "user_id", input.UserID, "token", input.WorkspaceToken, "error", updateErr)
+		return 0
+	}
+	if touchErr := s.workspace.Touch(storeCtx, input.UserID, token); touchErr != nil {
+		s.logger.Warnw("touch workspace failed", "user_id", input.UserID, "token", input.WorkspaceToken, "error", touchErr)
+	}
+	return version
+}
+
 // classifyGenerateError 将生成接口返回的错误归类为指标标签，方便监控统计。
 func classifyGenerateError(err error) string {
 	if err == nil {
@@ -2242,13 +2558,19 @@ func classifyGenerateError(err error) string {
 		return "content_rejected"
 	case errors.Is(err, ErrPositiveKeywordLimit), errors.Is(err, ErrNegativeKeywordLimit):
 		return "keyword_limit"
-	case errors.Is(err, ErrModelInvocationFailed):
-		return "model_error"
+	case errors.Is(err, ErrFreeTierQuotaExceeded):
+		return "free_tier_quota"
 	case errors.Is(err, context.DeadlineExceeded):
 		return "timeout"
-	default:
-		return "error"
 	}
+	// 模型错误按统一的错误类型细分（rate_limited、auth_failed 等），无法归类时沿用 model_error。
+	if kind := llm.KindOf(err); kind != llm.ErrorKindUnknown {
+		return string(kind)
+	}
+	if errors.Is(err, ErrModelInvocationFailed) {
+		return "model_error"
+	}
+	return "error"
 }
 
 // Save 保存或发布 Prompt：
@@ -2358,6 +2680,11 @@ func (s *Service) Save(ctx context.Context, input SaveInput) (output SaveOutput,
 		action = promptdomain.TaskActionUpdate
 	}
 
+	if input.Async && workspaceEnabled && s.queue != nil && s.taskStatus != nil {
+		output, err = s.enqueueSave(ctx, input, status, action, workspaceToken)
+		return
+	}
+
 	output, err = s.persistPrompt(ctx, input, status, action)
 	if err != nil {
 		return
@@ -2370,8 +2697,10 @@ func (s *Service) Save(ctx context.Context, input SaveInput) (output SaveOutput,
 			workspaceAttrInstructions:      input.Instructions,
 			workspaceAttrGenerationProfile: s.encodeGenerationProfile(normalizedProfile),
 		}
-		if setErr := s.workspace.SetAttributes(metaCtx, input.UserID, workspaceToken, attrs); setErr != nil {
+		if version, setErr := s.workspace.SetAttributes(metaCtx, input.UserID, workspaceToken, promptdomain.WorkspaceAnyVersion, attrs); setErr != nil {
 			s.logger.Warnw("set workspace tags failed", "user_id", input.UserID, "token", workspaceToken, "error", setErr)
+		} else {
+			output.WorkspaceVersion = version
 		}
 		if metaErr := s.workspace.SetPromptMeta(metaCtx, input.UserID, workspaceToken, output.PromptID, status); metaErr != nil {
 			s.logger.Warnw("set workspace meta failed", "user_id", input.UserID, "token", workspaceToken, "error", metaErr)
@@ -2591,20 +2920,20 @@ func (s *Service) persistKeywords(ctx context.Context, userID uint, topic string
 	}
 }
 
-// processPersistenceTask 消费异步队列任务，将 Redis 工作区内容（含标签）持久化回数据库。
+// processPersistenceTask 消费异步队列任务，将 Redis 工作区内容（含标签）持久化回数据库，返回落库结果。
 // 把快照和任务里的字段合成一个完整的 SaveInput，补齐标签去重、关键词列表之类的细节，然后再调用 persistPrompt
-func (s *Service) processPersistenceTask(ctx context.Context, task promptdomain.PersistenceTask) error {
+func (s *Service) processPersistenceTask(ctx context.Context, task promptdomain.PersistenceTask) (SaveOutput, error) {
 	if s.workspace == nil {
-		return errors.New("workspace store not configured")
+		return SaveOutput{}, errors.New("workspace store not configured")
 	}
 	storeCtx, cancel := s.workspaceContext(ctx)
 	snapshot, err := s.workspace.Snapshot(storeCtx, task.UserID, task.WorkspaceToken)
 	cancel()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return fmt.Errorf("workspace not found for task %s", task.TaskID)
+			return SaveOutput{}, fmt.Errorf("workspace not found for task %s", task.TaskID)
 		}
-		return fmt.Errorf("load workspace snapshot: %w", err)
+		return SaveOutput{}, fmt.Errorf("load workspace snapshot: %w", err)
 	}
 	input := SaveInput{
 		UserID:                   task.UserID,
@@ -2619,6 +2948,7 @@ func (s *Service) processPersistenceTask(ctx context.Context, task promptdomain.
 		Tags:                     task.Tags,
 		Publish:                  task.Publish,
 		EnforcePublishValidation: true,
+		Variables:                task.Variables,
 	}
 	if input.GenerationProfile == nil && strings.TrimSpace(task.GenerationProfile) != "" {
 		profile := s.decodeGenerationProfile(task.GenerationProfile)
@@ -2648,18 +2978,18 @@ func (s *Service) processPersistenceTask(ctx context.Context, task promptdomain.
 	}
 	status := normalizeStatus(task.Status, task.Publish)
 	if err := enforceKeywordLimit(s.keywordLimit, input.PositiveKeywords, input.NegativeKeywords); err != nil {
-		return fmt.Errorf("keyword limit: %w", err)
+		return SaveOutput{}, fmt.Errorf("keyword limit: %w", err)
 	}
 	cleanedTags, err := s.normalizeTags(input.Tags)
 	if err != nil {
-		return fmt.Errorf("tag limit: %w", err)
+		return SaveOutput{}, fmt.Errorf("tag limit: %w", err)
 	}
 	input.Tags = cleanedTags
 	normalizedProfile := s.normalizeGenerationProfile(input.GenerationProfile)
 	input.GenerationProfile = &normalizedProfile
 	result, err := s.persistPrompt(ctx, input, status, action)
 	if err != nil {
-		return fmt.Errorf("persist prompt: %w", err)
+		return SaveOutput{}, fmt.Errorf("persist prompt: %w", err)
 	}
 	metaCtx, cancelMeta := s.workspaceContext(ctx)
 	attrs := map[string]string{
@@ -2667,7 +2997,7 @@ func (s *Service) processPersistenceTask(ctx context.Context, task promptdomain.
 		workspaceAttrInstructions:      input.Instructions,
 		workspaceAttrGenerationProfile: s.encodeGenerationProfile(normalizedProfile),
 	}
-	if err := s.workspace.SetAttributes(metaCtx, task.UserID, task.WorkspaceToken, attrs); err != nil {
+	if _, err := s.workspace.SetAttributes(metaCtx, task.UserID, task.WorkspaceToken, promptdomain.WorkspaceAnyVersion, attrs); err != nil {
 		s.logger.Warnw("set workspace tags failed", "task_id", task.TaskID, "token", task.WorkspaceToken, "error", err)
 	}
 	if err := s.workspace.SetPromptMeta(metaCtx, task.UserID, task.WorkspaceToken, result.PromptID, status); err != nil {
@@ -2675,7 +3005,7 @@ func (s *Service) processPersistenceTask(ctx context.Context, task promptdomain.
 	}
 	cancelMeta()
 	s.logger.Infow("prompt persisted", "task_id", task.TaskID, "prompt_id", result.PromptID, "user_id", task.UserID, "publish", task.Publish)
-	return nil
+	return result, nil
 }
 
 // persistPrompt 根据动作类型创建或更新 Prompt 主记录，同时保持关键词与版本一致。
@@ -2695,6 +3025,12 @@ func (s *Service) persistPrompt(ctx context.Context, input SaveInput, status, ac
 	input.Tags = cleanedTags
 	profile := s.normalizeGenerationProfile(input.GenerationProfile)
 	input.GenerationProfile = &profile
+	if input.Variables == nil && input.PromptID != 0 {
+		if existing, err := s.prompts.FindByID(ctx, input.UserID, input.PromptID); err == nil {
+			input.Variables = decodeStoredVariables(existing.Variables)
+		}
+	}
+	input.Variables = reconcilePromptVariables(input.Body, input.Variables)
 	// 只有当这次保存的最终状态是 published，并且调用方显式要求执行发布校验（EnforcePublishValidation == true）时，才会去跑
 	// validatePublishInput。validatePublishInput 会检查发布必须具备的字段，例如主题、正文、补充要求、模型、正/负向关键词、标签等。一旦缺少，就返回错误，
 	// 阻止这次发布
@@ -2746,6 +3082,7 @@ func (s *Service) createPromptRecord(ctx context.Context, input SaveInput, profi
 		Tags:              string(encodedTags),
 		LatestVersionNo:   0,
 		GenerationProfile: s.encodeGenerationProfile(profile),
+		Variables:         encodePromptVariables(input.Variables),
 	}
 	if status == promptdomain.PromptStatusPublished {
 		now := time.Now()
@@ -2787,7 +3124,7 @@ func (s *Service) updatePromptRecord(ctx context.Context, input SaveInput, profi
 			if existing, findErr := s.prompts.FindByUserAndTopic(ctx, input.UserID, sanitizedTopic); findErr == nil {
 				entity = existing
 			} else {
-				return SaveOutput{}, fmt.Errorf("prompt not found")
+				return SaveOutput{}, ErrPromptNotFound
 			}
 		} else {
 			return SaveOutput{}, err
@@ -2814,6 +3151,7 @@ func (s *Service) updatePromptRecord(ctx context.Context, input SaveInput, profi
 	entity.Status = status
 	entity.Tags = string(encodedTags)
 	entity.GenerationProfile = s.encodeGenerationProfile(profile)
+	entity.Variables = encodePromptVariables(input.Variables)
 	if status == promptdomain.PromptStatusPublished {
 		currentVersion := entity.LatestVersionNo
 		if entity.ID != 0 {
@@ -2883,7 +3221,7 @@ func (s *Service) upsertPromptKeywords(ctx context.Context, userID uint, topic s
 	return relations, nil
 }
 
-// validatePublishInput 会在发布前检查必要字段，缺失时返回可读的错误提示。
+// validatePublishInput 会在发布前检查必要字段与模板变量定义，缺失或无效时返回可读的错误提示。
 func (s *Service) validatePublishInput(input SaveInput) error {
 	missing := make([]string, 0, 6)
 	if strings.TrimSpace(input.Topic) == "" {
@@ -2907,10 +3245,10 @@ func (s *Service) validatePublishInput(input SaveInput) error {
 	if len(input.Tags) == 0 {
 		missing = append(missing, "标签")
 	}
-	if len(missing) == 0 {
-		return nil
+	if len(missing) > 0 {
+		return fmt.Errorf("发布失败：缺少%s", strings.Join(missing, "、"))
 	}
-	return fmt.Errorf("发布失败：缺少%s", strings.Join(missing, "、"))
+	return validatePromptVariables(input.Variables)
 }
 
 // recordPromptVersion 写入 Prompt 的历史版本，便于后续回滚。
@@ -2924,6 +3262,7 @@ func (s *Service) recordPromptVersion(ctx context.Context, prompt *promptdomain.
 		NegativeKeywords:  prompt.NegativeKeywords,
 		Model:             prompt.Model,
 		GenerationProfile: prompt.GenerationProfile,
+		Variables:         prompt.Variables,
 	}
 	return s.prompts.CreateVersion(ctx, version)
 }
@@ -2958,12 +3297,12 @@ type auditPayload struct {
 	Reason  string `json:"reason"`
 }
 
-// parseAuditPayload 解析内容审核模型返回的 JSON 结果，判断是否放行。
-func parseAuditPayload(resp modeldomain.ChatCompletionResponse) (auditPayload, error) {
+// parseAuditPayload 解析内容审核模型返回的 JSON 结果（优先取函数调用参数），判断是否放行。
+func parseAuditPayload(resp llm.Response) (auditPayload, error) {
 	if len(resp.Choices) == 0 {
 		return auditPayload{}, errors.New("content audit returned no choices")
 	}
-	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+	content := structuredContent(resp, toolSubmitAuditVerdict)
 	if content == "" {
 		return auditPayload{}, errors.New("content audit returned empty message")
 	}
@@ -2974,11 +3313,11 @@ func parseAuditPayload(resp modeldomain.ChatCompletionResponse) (auditPayload, e
 	return payload, nil
 }
 
-func parseInterpretationPayload(resp modeldomain.ChatCompletionResponse) (interpretationPayload, error) {
+func parseInterpretationPayload(resp llm.Response) (interpretationPayload, error) {
 	if len(resp.Choices) == 0 {
 		return interpretationPayload{}, errors.New("model returned no choices")
 	}
-	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+	content := structuredContent(resp, toolSubmitInterpretation)
 	if content == "" {
 		return interpretationPayload{}, errors.New("model returned empty content")
 	}
@@ -3002,11 +3341,11 @@ type augmentPayload struct {
 	Negative []keywordPayload `json:"negative_keywords"`
 }
 
-func parseAugmentPayload(resp modeldomain.ChatCompletionResponse) (augmentPayload, error) {
+func parseAugmentPayload(resp llm.Response) (augmentPayload, error) {
 	if len(resp.Choices) == 0 {
 		return augmentPayload{}, errors.New("model returned no choices")
 	}
-	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+	content := structuredContent(resp, toolSubmitKeywords)
 	if content == "" {
 		return augmentPayload{}, errors.New("model returned empty content")
 	}
@@ -3143,11 +3482,8 @@ func clampWeight(value int) int {
 	return value
 }
 
-func extractPromptText(resp modeldomain.ChatCompletionResponse) string {
-	if len(resp.Choices) == 0 {
-		return ""
-	}
-	return strings.TrimSpace(resp.Choices[0].Message.Content)
+func extractPromptText(resp llm.Response) string {
+	return strings.TrimSpace(resp.Text())
 }
 
 func (s *Service) marshalKeywordItems(items []KeywordItem) ([]byte, error) {
@@ -3320,7 +3656,7 @@ func (s *Service) clampTagValue(tag string) string {
 }
 
 // buildAuditRequest 根据业务阶段构造内容审核提示词，要求模型返回允许与否。
-func buildAuditRequest(stage auditStage, content string) modeldomain.ChatCompletionRequest {
+func buildAuditRequest(stage auditStage, content string) llm.Request {
 	stageHint := "解析前的用户输入"
 	switch stage {
 	case auditStageGenerateOutput:
@@ -3331,17 +3667,17 @@ func buildAuditRequest(stage auditStage, content string) modeldomain.ChatComplet
 	system := "你是一名内容审核助手，需要识别文本中是否包含黄赌毒、暴力、仇恨、违法或其他违反政策的内容。输出必须严格遵循 JSON 结构。"
 	builder := &strings.Builder{}
 	fmt.Fprintf(builder, "审核阶段：%s\n待审核内容：\n%s\n\n请按照以下格式返回：{\"allowed\":true/false,\"reason\":\"若不允许，请说明原因\"}。", stageHint, content)
-	return modeldomain.ChatCompletionRequest{
-		Messages: []modeldomain.ChatMessage{
+	return withStructuredTool(llm.Request{
+		Messages: []llm.Message{
 			{Role: "system", Content: system},
 			{Role: "user", Content: builder.String()},
 		},
 		ResponseFormat: map[string]any{"type": "json_object"},
-	}
+	}, auditVerdictTool())
 }
 
 // buildInterpretationRequest 拼装解析自然语言描述所需的模型请求。
-func buildInterpretationRequest(description, language string) modeldomain.ChatCompletionRequest {
+func buildInterpretationRequest(description, language string) llm.Request {
 	lang := languageOrDefault(language)
 	system := "你是一名 Prompt 主题解析助手，负责从用户的自然语言意图中提炼主题、补充要求以及关键词。请始终返回结构化 JSON。"
 	user := fmt.Sprintf(
@@ -3350,18 +3686,18 @@ func buildInterpretationRequest(description, language string) modeldomain.ChatCo
 			"描述：%s",
 		lang, description,
 	)
-	return modeldomain.ChatCompletionRequest{
-		Model: modeldomain.ChatCompletionRequest{}.Model,
-		Messages: []modeldomain.ChatMessage{
+	return withStructuredTool(llm.Request{
+		Model: llm.Request{}.Model,
+		Messages: []llm.Message{
 			{Role: "system", Content: system},
 			{Role: "user", Content: user},
 		},
 		ResponseFormat: map[string]any{"type": "json_object"},
-	}
+	}, interpretationTool())
 }
 
 // buildPromptIngestRequest 构建解析成品 Prompt 所需的模型请求体，提炼基础属性。
-func buildPromptIngestRequest(body, language string, positiveLimit, negativeLimit, tagLimit int) modeldomain.ChatCompletionRequest {
+func buildPromptIngestRequest(body, language string, positiveLimit, negativeLimit, tagLimit int) llm.Request {
 	lang := languageOrDefault(language)
 	if positiveLimit <= 0 {
 		positiveLimit = DefaultKeywordLimit
@@ -3379,17 +3715,17 @@ func buildPromptIngestRequest(body, language string, positiveLimit, negativeLimi
 	fmt.Fprintf(builder, "2. 负向关键词不超过 %d 个，可为空；给出的每个关键词都要附带 0~%d 的整数权重。\n", negativeLimit, maxKeywordWeight)
 	fmt.Fprintf(builder, "3. 标签不超过 %d 个，覆盖目标对象、场景或行业；补充要求 `instructions` 必须使用 1~2 句中文概述该 Prompt 的核心限制或注意事项。\n", tagLimit)
 	fmt.Fprintf(builder, "4. 如正文包含步骤、角色或语气偏好，请将其概括进 `instructions`；`confidence` 必须为 0~1 区间的小数。\nPrompt 正文：\n%s", body)
-	return modeldomain.ChatCompletionRequest{
-		Messages: []modeldomain.ChatMessage{
+	return withStructuredTool(llm.Request{
+		Messages: []llm.Message{
 			{Role: "system", Content: system},
 			{Role: "user", Content: builder.String()},
 		},
 		ResponseFormat: map[string]any{"type": "json_object"},
-	}
+	}, interpretationTool())
 }
 
 // buildAugmentRequest 构建模型补充关键词的提示词上下文。
-func buildAugmentRequest(input AugmentInput) modeldomain.ChatCompletionRequest {
+func buildAugmentRequest(input AugmentInput) llm.Request {
 	lang := languageOrDefault(input.Language)
 	system := "你是一名关键词扩写助手，需要补充与主题相关的关键词，并避免重复已有词汇。"
 	builder := &strings.Builder{}
@@ -3401,17 +3737,17 @@ func buildAugmentRequest(input AugmentInput) modeldomain.ChatCompletionRequest {
 		defaultIfZero(input.RequestedPositive, 5),
 		defaultIfZero(input.RequestedNegative, 3),
 	)
-	return modeldomain.ChatCompletionRequest{
-		Messages: []modeldomain.ChatMessage{
+	return withStructuredTool(llm.Request{
+		Messages: []llm.Message{
 			{Role: "system", Content: system},
 			{Role: "user", Content: builder.String()},
 		},
 		ResponseFormat: map[string]any{"type": "json_object"},
-	}
+	}, keywordsTool())
 }
 
 // buildGenerateRequest 依据主题与关键词生成最终 Prompt 的模型请求体。
-func buildGenerateRequest(input GenerateInput, profile promptdomain.GenerationProfile) modeldomain.ChatCompletionRequest {
+func buildGenerateRequest(input GenerateInput, profile promptdomain.GenerationProfile) llm.Request {
 	lang := languageOrDefault(input.Language)
 	system := "你是一名 Prompt 工程师，需要根据给定主题与关键词生成高质量的提示词，帮助大模型完成任务。"
 	builder := &strings.Builder{}
@@ -3440,9 +3776,9 @@ func buildGenerateRequest(input GenerateInput, profile promptdomain.GenerationPr
 	if profile.StepwiseReasoning {
 		fmt.Fprintf(builder, "\n请先用简洁的步骤梳理你的思考过程，再给出最终优化后的 Prompt。最终输出仍需仅包含完整的 Prompt 正文。")
 	}
-	return modeldomain.ChatCompletionRequest{
+	return llm.Request{
 		Model:       strings.TrimSpace(input.ModelKey),
-		Messages:    []modeldomain.ChatMessage{{Role: "system", Content: system}, {Role: "user", Content: builder.String()}},
+		Messages:    []llm.Message{{Role: "system", Content: system}, {Role: "user", Content: builder.String()}},
 		Temperature: profile.Temperature,
 		MaxTokens:   profile.MaxOutputTokens,
 		TopP:        profile.TopP,
@@ -3692,6 +4028,7 @@ func (s *Service) buildShareRecord(entity *promptdomain.Prompt) promptExportReco
 		UpdatedAt:         entity.UpdatedAt,
 		LatestVersionNo:   entity.LatestVersionNo,
 		GenerationProfile: s.decodeGenerationProfile(entity.GenerationProfile),
+		Variables:         reconcilePromptVariables(entity.Body, decodeStoredVariables(entity.Variables)),
 	}
 }
 
@@ -0,0 +1,116 @@
+package prompt
+
+import (
+	"strings"
+
+	"electron-go-app/backend/internal/domain/llm"
+)
+
+// 结构化步骤使用的函数名，模型通过调用这些函数返回 JSON 参数。
+const (
+	toolSubmitInterpretation = "submit_interpretation"
+	toolSubmitKeywords       = "submit_keywords"
+	toolSubmitAuditVerdict   = "submit_audit_verdict"
+)
+
+// keywordArraySchema 描述带权重的关键词数组。
+func keywordArraySchema(description string) map[string]any {
+	return map[string]any{
+		"type":        "array",
+		"description": description,
+		"items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"word":   map[string]any{"type": "string", "description": "关键词"},
+				"weight": map[string]any{"type": "integer", "minimum": 0, "maximum": maxKeywordWeight, "description": "与主题的相关度"},
+			},
+			"required": []string{"word", "weight"},
+		},
+	}
+}
+
+// interpretationTool 定义解析描述与成品 Prompt 时返回结构化结果的函数。
+func interpretationTool() llm.Tool {
+	return llm.Tool{
+		Type: "function",
+		Function: llm.ToolFunction{
+			Name:        toolSubmitInterpretation,
+			Description: "提交解析得到的主题、补充要求、关键词与标签",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"topic":             map[string]any{"type": "string", "description": "主题名称"},
+					"instructions":      map[string]any{"type": "string", "description": "补充要求"},
+					"positive_keywords": keywordArraySchema("正向关键词"),
+					"negative_keywords": keywordArraySchema("负向关键词"),
+					"tags":              map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "检索标签"},
+					"confidence":        map[string]any{"type": "number", "minimum": 0, "maximum": 1, "description": "解析置信度"},
+				},
+				"required": []string{"topic", "positive_keywords", "negative_keywords"},
+			},
+		},
+	}
+}
+
+// keywordsTool 定义补充关键词时返回结构化结果的函数。
+func keywordsTool() llm.Tool {
+	return llm.Tool{
+		Type: "function",
+		Function: llm.ToolFunction{
+			Name:        toolSubmitKeywords,
+			Description: "提交补充的正向与负向关键词",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"positive_keywords": keywordArraySchema("补充的正向关键词"),
+					"negative_keywords": keywordArraySchema("补充的负向关键词"),
+				},
+				"required": []string{"positive_keywords", "negative_keywords"},
+			},
+		},
+	}
+}
+
+// auditVerdictTool 定义内容审核时返回结论的函数。
+func auditVerdictTool() llm.Tool {
+	return llm.Tool{
+		Type: "function",
+		Function: llm.ToolFunction{
+			Name:        toolSubmitAuditVerdict,
+			Description: "提交内容审核结论",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"allowed": map[string]any{"type": "boolean", "description": "内容是否允许发布"},
+					"reason":  map[string]any{"type": "string", "description": "不允许时的原因"},
+				},
+				"required": []string{"allowed"},
+			},
+		},
+	}
+}
+
+// withStructuredTool 为请求挂载单个函数并强制模型调用；保留 response_format，
+// 不支持函数调用的厂商（Ollama、Anthropic 或推理模型）仍会按 JSON 正文返回。
+func withStructuredTool(req llm.Request, tool llm.Tool) llm.Request {
+	req.Tools = []llm.Tool{tool}
+	req.ToolChoice = map[string]any{
+		"type":     "function",
+		"function": map[string]any{"name": tool.Function.Name},
+	}
+	return req
+}
+
+// structuredContent 优先返回首个候选中指定函数调用的参数，模型未走函数调用时退回正文内容。
+func structuredContent(resp llm.Response, toolName string) string {
+	if len(resp.Choices) == 0 {
+		return ""
+	}
+	message := resp.Choices[0].Message
+	for _, call := range message.ToolCalls {
+		if call.Function.Name == toolName && strings.TrimSpace(call.Function.Arguments) != "" {
+			return strings.TrimSpace(call.Function.Arguments)
+		}
+	}
+	return strings.TrimSpace(message.Content)
+}
@@ -0,0 +1,235 @@
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+
+	"gorm.io/gorm"
+)
+
+// RenderPromptInput 描述渲染 Prompt 模板所需的参数，VersionNo 为 0 时渲染当前正文。
+type RenderPromptInput struct {
+	UserID    uint
+	PromptID  uint
+	VersionNo int
+	Values    map[string]any
+}
+
+// RenderPromptOutput 返回替换变量后的正文，以及缺失的必填变量与未在定义中出现的变量。
+type RenderPromptOutput struct {
+	Body      string                        `json:"body"`
+	Variables []promptdomain.PromptVariable `json:"variables"`
+	Missing   []string                      `json:"missing"`
+	Unknown   []string                      `json:"unknown"`
+}
+
+// RenderPrompt 使用传入的变量值渲染 Prompt 正文：未传值的变量使用默认值，必填且无默认值的变量保留占位符并记入 Missing。
+func (s *Service) RenderPrompt(ctx context.Context, input RenderPromptInput) (RenderPromptOutput, error) {
+	if input.UserID == 0 || input.PromptID == 0 {
+		return RenderPromptOutput{}, errors.New("user id and prompt id are required")
+	}
+	entity, err := s.prompts.FindByID(ctx, input.UserID, input.PromptID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return RenderPromptOutput{}, ErrPromptNotFound
+		}
+		return RenderPromptOutput{}, err
+	}
+	body, rawVariables := entity.Body, entity.Variables
+	if input.VersionNo > 0 {
+		version, err := s.prompts.FindVersion(ctx, input.PromptID, input.VersionNo)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return RenderPromptOutput{}, ErrPromptVersionNotFound
+			}
+			return RenderPromptOutput{}, err
+		}
+		body, rawVariables = version.Body, version.Variables
+	}
+	variables := reconcilePromptVariables(body, decodeStoredVariables(rawVariables))
+	return renderPromptBody(body, variables, input.Values), nil
+}
+
+// renderPromptBody 按变量定义替换正文中的占位符，非必填且无默认值的变量替换为空串。
+func renderPromptBody(body string, variables []promptdomain.PromptVariable, values map[string]any) RenderPromptOutput {
+	defined := make(map[string]promptdomain.PromptVariable, len(variables))
+	for _, variable := range variables {
+		defined[variable.Name] = variable
+	}
+	resolved := make(map[string]string, len(variables))
+	missing := make([]string, 0)
+	for _, variable := range variables {
+		if raw, ok := values[variable.Name]; ok && raw != nil {
+			resolved[variable.Name] = formatVariableValue(raw)
+			continue
+		}
+		if variable.Default != "" {
+			resolved[variable.Name] = variable.Default
+			continue
+		}
+		if variable.Required {
+			missing = append(missing, variable.Name)
+			continue
+		}
+		resolved[variable.Name] = ""
+	}
+	unknown := make([]string, 0)
+	for name := range values {
+		if _, ok := defined[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	rendered := promptdomain.PromptVariablePattern.ReplaceAllStringFunc(body, func(placeholder string) string {
+		name := promptdomain.PromptVariablePattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := resolved[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+	return RenderPromptOutput{Body: rendered, Variables: variables, Missing: missing, Unknown: unknown}
+}
+
+// formatVariableValue 将请求中的变量值转换为文本，数字与布尔值按 JSON 字面量输出。
+func formatVariableValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
+
+// reconcilePromptVariables 以正文为准整理变量定义：保留正文仍在引用的显式定义，为新出现的占位符补充 string 类型定义，
+// 移除正文中已不存在的变量，结果按占位符首次出现的顺序排列。
+func reconcilePromptVariables(body string, declared []promptdomain.PromptVariable) []promptdomain.PromptVariable {
+	names := promptdomain.DetectPromptVariables(body)
+	if len(names) == 0 {
+		return []promptdomain.PromptVariable{}
+	}
+	byName := make(map[string]promptdomain.PromptVariable, len(declared))
+	for _, variable := range declared {
+		name := strings.TrimSpace(variable.Name)
+		if _, exists := byName[name]; name == "" || exists {
+			continue
+		}
+		byName[name] = normalizePromptVariable(variable)
+	}
+	result := make([]promptdomain.PromptVariable, 0, len(names))
+	for _, name := range names {
+		variable, ok := byName[name]
+		if !ok {
+			variable = promptdomain.PromptVariable{Type: promptdomain.PromptVariableTypeString}
+		}
+		variable.Name = name
+		result = append(result, variable)
+	}
+	return result
+}
+
+// normalizePromptVariable 清洗单个变量定义：类型转为小写，未指定类型时按是否提供可选值推断为 enum 或 string。
+func normalizePromptVariable(variable promptdomain.PromptVariable) promptdomain.PromptVariable {
+	variable.Type = strings.ToLower(strings.TrimSpace(variable.Type))
+	variable.Default = strings.TrimSpace(variable.Default)
+	variable.Description = strings.TrimSpace(variable.Description)
+	enum := make([]string, 0, len(variable.Enum))
+	seen := make(map[string]struct{}, len(variable.Enum))
+	for _, option := range variable.Enum {
+		option = strings.TrimSpace(option)
+		if _, exists := seen[option]; option == "" || exists {
+			continue
+		}
+		seen[option] = struct{}{}
+		enum = append(enum, option)
+	}
+	variable.Enum = nil
+	if len(enum) > 0 {
+		variable.Enum = enum
+	}
+	if variable.Type == "" {
+		variable.Type = promptdomain.PromptVariableTypeString
+		if len(variable.Enum) > 0 {
+			variable.Type = promptdomain.PromptVariableTypeEnum
+		}
+	}
+	return variable
+}
+
+// validatePromptVariables 检查变量类型是否受支持、enum 是否提供可选值以及默认值是否与类型匹配。
+func validatePromptVariables(variables []promptdomain.PromptVariable) error {
+	problems := make([]string, 0)
+	for _, variable := range variables {
+		switch variable.Type {
+		case promptdomain.PromptVariableTypeString, promptdomain.PromptVariableTypeNumber, promptdomain.PromptVariableTypeBoolean:
+		case promptdomain.PromptVariableTypeEnum:
+			if len(variable.Enum) == 0 {
+				problems = append(problems, fmt.Sprintf("变量 %s 缺少可选值", variable.Name))
+				continue
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("变量 %s 的类型 %s 不受支持", variable.Name, variable.Type))
+			continue
+		}
+		if variable.Default == "" {
+			continue
+		}
+		valid := true
+		switch variable.Type {
+		case promptdomain.PromptVariableTypeNumber:
+			_, err := strconv.ParseFloat(variable.Default, 64)
+			valid = err == nil
+		case promptdomain.PromptVariableTypeBoolean:
+			_, err := strconv.ParseBool(variable.Default)
+			valid = err == nil
+		}
+		if valid && len(variable.Enum) > 0 {
+			valid = slices.Contains(variable.Enum, variable.Default)
+		}
+		if !valid {
+			problems = append(problems, fmt.Sprintf("变量 %s 的默认值 %s 无效", variable.Name, variable.Default))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("发布失败：%s", strings.Join(problems, "；"))
+}
+
+// encodePromptVariables 将变量定义编码为 JSON 字符串，没有变量时返回空串。
+func encodePromptVariables(variables []promptdomain.PromptVariable) string {
+	if len(variables) == 0 {
+		return ""
+	}
+	encoded, err := json.Marshal(variables)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// decodeStoredVariables 解析数据库中保存的变量定义，格式错误时视为没有显式定义。
+func decodeStoredVariables(raw string) []promptdomain.PromptVariable {
+	variables, err := promptdomain.DecodePromptVariables(raw)
+	if err != nil {
+		return nil
+	}
+	return variables
+}
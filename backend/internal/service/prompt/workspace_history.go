@@ -0,0 +1,63 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+)
+
+var (
+	// ErrNothingToUndo 表示工作区没有可撤销的操作。
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo 表示工作区没有可重做的操作。
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// WorkspaceHistoryInput 描述撤销/重做请求，WorkspaceVersion 需与工作区当前版本一致。
+type WorkspaceHistoryInput struct {
+	UserID           uint
+	WorkspaceToken   string
+	WorkspaceVersion int64
+}
+
+// UndoWorkspace 撤销工作区最近一次关键词、草稿正文或属性修改，返回恢复后的工作区快照。
+func (s *Service) UndoWorkspace(ctx context.Context, input WorkspaceHistoryInput) (promptdomain.WorkspaceSnapshot, error) {
+	return s.travelWorkspace(ctx, input, true)
+}
+
+// RedoWorkspace 重做最近一次被撤销的修改，返回恢复后的工作区快照。
+func (s *Service) RedoWorkspace(ctx context.Context, input WorkspaceHistoryInput) (promptdomain.WorkspaceSnapshot, error) {
+	return s.travelWorkspace(ctx, input, false)
+}
+
+// travelWorkspace 是 UndoWorkspace 与 RedoWorkspace 的共享实现：工作区已过期时返回 ErrWorkspaceNotFound 而非重新创建，
+// 版本不一致时返回 WorkspaceConflictError，成功后刷新 TTL 并读取最新快照。
+func (s *Service) travelWorkspace(ctx context.Context, input WorkspaceHistoryInput, undo bool) (promptdomain.WorkspaceSnapshot, error) {
+	token := strings.TrimSpace(input.WorkspaceToken)
+	storeCtx, cancel := s.workspaceContext(ctx)
+	defer cancel()
+	if _, err := s.liveWorkspace(storeCtx, input.UserID, token); err != nil {
+		return promptdomain.WorkspaceSnapshot{}, err
+	}
+	travel, empty := s.workspace.Undo, ErrNothingToUndo
+	if !undo {
+		travel, empty = s.workspace.Redo, ErrNothingToRedo
+	}
+	if _, err := travel(storeCtx, input.UserID, token, input.WorkspaceVersion); err != nil {
+		if errors.Is(err, promptdomain.ErrWorkspaceHistoryEmpty) {
+			return promptdomain.WorkspaceSnapshot{}, empty
+		}
+		return promptdomain.WorkspaceSnapshot{}, s.workspaceConflict(storeCtx, input.UserID, token, err)
+	}
+	if err := s.workspace.Touch(storeCtx, input.UserID, token); err != nil {
+		s.logger.Warnw("touch workspace after history travel failed", "user_id", input.UserID, "token", token, "error", err)
+	}
+	snapshot, err := s.workspace.Snapshot(storeCtx, input.UserID, token)
+	if err != nil {
+		return promptdomain.WorkspaceSnapshot{}, fmt.Errorf("load workspace: %w", err)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,60 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrWorkspaceNotFound 表示工作区不存在或已过期。
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// ListWorkspaces 返回用户仍在有效期内的工作区摘要，按更新时间倒序；未启用工作区时返回空列表。
+func (s *Service) ListWorkspaces(ctx context.Context, userID uint) ([]promptdomain.WorkspaceSummary, error) {
+	if s.workspace == nil {
+		return nil, nil
+	}
+	storeCtx, cancel := s.workspaceContext(ctx)
+	defer cancel()
+	return s.workspace.List(storeCtx, userID)
+}
+
+// DiscardWorkspace 主动丢弃工作区及其撤销/重做历史，工作区不存在时返回 ErrWorkspaceNotFound。
+func (s *Service) DiscardWorkspace(ctx context.Context, userID uint, token string) error {
+	token = strings.TrimSpace(token)
+	storeCtx, cancel := s.workspaceContext(ctx)
+	defer cancel()
+	if _, err := s.liveWorkspace(storeCtx, userID, token); err != nil {
+		return err
+	}
+	return s.workspace.Delete(storeCtx, userID, token)
+}
+
+// PromoteWorkspace 将工作区内容保存为 Prompt：已关联 Prompt 时更新该 Prompt 并沿用工作区记录的状态，否则新建草稿。
+// 工作区保留并回写 prompt_id，便于继续编辑。
+func (s *Service) PromoteWorkspace(ctx context.Context, userID uint, token string) (SaveOutput, error) {
+	token = strings.TrimSpace(token)
+	storeCtx, cancel := s.workspaceContext(ctx)
+	_, err := s.liveWorkspace(storeCtx, userID, token)
+	cancel()
+	if err != nil {
+		return SaveOutput{}, err
+	}
+	return s.Save(ctx, SaveInput{UserID: userID, WorkspaceToken: token})
+}
+
+// liveWorkspace 读取仍在有效期内的工作区，未启用工作区或工作区不存在时返回 ErrWorkspaceNotFound。
+func (s *Service) liveWorkspace(ctx context.Context, userID uint, token string) (promptdomain.WorkspaceSnapshot, error) {
+	if s.workspace == nil || token == "" {
+		return promptdomain.WorkspaceSnapshot{}, ErrWorkspaceNotFound
+	}
+	snapshot, err := s.workspace.Snapshot(ctx, userID, token)
+	if errors.Is(err, redis.Nil) {
+		return promptdomain.WorkspaceSnapshot{}, ErrWorkspaceNotFound
+	}
+	return snapshot, err
+}
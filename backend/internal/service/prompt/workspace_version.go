@@ -0,0 +1,40 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+)
+
+// ErrWorkspaceConflict 表示工作区已被其它窗口修改，本次编辑未写入。
+var ErrWorkspaceConflict = errors.New("workspace modified by another session")
+
+// WorkspaceConflictError 携带冲突发生时工作区的最新快照，便于前端合并或重新加载；工作区已失效时 Snapshot 为空。
+type WorkspaceConflictError struct {
+	Snapshot *promptdomain.WorkspaceSnapshot
+}
+
+// Error 返回用户可读的中文提示。
+func (e *WorkspaceConflictError) Error() string {
+	return "工作区已在其它窗口被修改，请合并或刷新后重试。"
+}
+
+// Is 允许通过 errors.Is 判断是否为工作区版本冲突。
+func (e *WorkspaceConflictError) Is(target error) bool {
+	return target == ErrWorkspaceConflict || target == promptdomain.ErrWorkspaceVersionConflict
+}
+
+// workspaceConflict 将存储层的版本冲突转换为携带最新快照的 WorkspaceConflictError，其它错误原样返回。
+func (s *Service) workspaceConflict(ctx context.Context, userID uint, token string, err error) error {
+	if !errors.Is(err, promptdomain.ErrWorkspaceVersionConflict) {
+		return err
+	}
+	conflict := &WorkspaceConflictError{}
+	if snapshot, snapErr := s.workspace.Snapshot(ctx, userID, token); snapErr == nil {
+		conflict.Snapshot = &snapshot
+	} else {
+		s.logger.Warnw("load workspace snapshot for conflict failed", "user_id", userID, "token", token, "error", snapErr)
+	}
+	return conflict
+}
@@ -377,6 +377,7 @@ type SubmitInput struct {
 	PositiveKeywords string
 	NegativeKeywords string
 	Tags             string
+	Variables        string // 模板变量定义 JSON，为空且指定了原 Prompt 时沿用原 Prompt 的定义
 	Model            string
 	Language         string
 }
@@ -404,6 +405,12 @@ func (s *Service) Submit(ctx context.Context, input SubmitInput) (*promptdomain.
 		if prompt.Status != promptdomain.PromptStatusPublished {
 			return nil, errors.New("仅发布后的 Prompt 可以投稿到公共库")
 		}
+		if strings.TrimSpace(input.Variables) == "" {
+			input.Variables = prompt.Variables
+		}
+	}
+	if _, err := promptdomain.DecodePromptVariables(input.Variables); err != nil {
+		return nil, errors.New("变量定义格式不正确")
 	}
 	topic := strings.TrimSpace(input.Topic)
 	lang := strings.TrimSpace(input.Language)
@@ -423,6 +430,7 @@ func (s *Service) Submit(ctx context.Context, input SubmitInput) (*promptdomain.
 		existing.PositiveKeywords = input.PositiveKeywords
 		existing.NegativeKeywords = input.NegativeKeywords
 		existing.Tags = input.Tags
+		existing.Variables = strings.TrimSpace(input.Variables)
 		existing.Model = strings.TrimSpace(input.Model)
 		existing.Language = lang
 		existing.Status = promptdomain.PublicPromptStatusPending
@@ -448,6 +456,7 @@ func (s *Service) Submit(ctx context.Context, input SubmitInput) (*promptdomain.
 		PositiveKeywords: input.PositiveKeywords,
 		NegativeKeywords: input.NegativeKeywords,
 		Tags:             input.Tags,
+		Variables:        strings.TrimSpace(input.Variables),
 		Model:            strings.TrimSpace(input.Model),
 		Language:         lang,
 		Status:           promptdomain.PublicPromptStatusPending,
@@ -1152,6 +1161,7 @@ func (s *Service) Download(ctx context.Context, input DownloadInput) (*promptdom
 			Model:            entity.Model,
 			Status:           promptdomain.PromptStatusDraft,
 			Tags:             entity.Tags,
+			Variables:        entity.Variables,
 			CreatedAt:        now,
 			UpdatedAt:        now,
 			LatestVersionNo:  1,
@@ -79,6 +79,11 @@ func (s *Service) UpdateSettings(ctx context.Context, userID uint, settings doma
 	if err := s.ensurePreferredModel(ctx, userID, settings.PreferredModel); err != nil {
 		return Profile{}, err
 	}
+	chains, err := s.normalizeFallbackChains(ctx, userID, settings.FallbackChains)
+	if err != nil {
+		return Profile{}, err
+	}
+	settings.FallbackChains = chains
 	raw, err := domain.SettingsJSON(settings)
 	if err != nil {
 		return Profile{}, fmt.Errorf("encode settings: %w", err)
@@ -206,3 +211,74 @@ func (s *Service) ensurePreferredModel(ctx context.Context, userID uint, modelKe
 	}
 	return nil
 }
+
+// ErrFallbackChainInvalid 表示回退链的操作名或长度不合法。
+var ErrFallbackChainInvalid = errors.New("fallback chain invalid")
+
+// ErrFallbackModelNotFound 表示回退链引用了不存在的模型。
+var ErrFallbackModelNotFound = errors.New("fallback model not found")
+
+// ErrFallbackModelDisabled 表示回退链引用了已禁用的模型。
+var ErrFallbackModelDisabled = errors.New("fallback model disabled")
+
+// normalizeFallbackChains 清洗用户提交的回退链：去除空白与重复项，并逐个校验模型可用。
+func (s *Service) normalizeFallbackChains(ctx context.Context, userID uint, chains map[string][]string) (map[string][]string, error) {
+	if len(chains) == 0 {
+		return nil, nil
+	}
+	defaultKey := domain.DefaultSettings().PreferredModel
+	normalized := make(map[string][]string, len(chains))
+	for operation, chain := range chains {
+		op := strings.ToLower(strings.TrimSpace(operation))
+		if !domain.IsFallbackOperation(op) {
+			return nil, fmt.Errorf("%w: unknown operation %q", ErrFallbackChainInvalid, operation)
+		}
+		seen := make(map[string]struct{}, len(chain))
+		cleaned := make([]string, 0, len(chain))
+		for _, key := range chain {
+			trimmed := strings.TrimSpace(key)
+			if trimmed == "" {
+				continue
+			}
+			if _, ok := seen[strings.ToLower(trimmed)]; ok {
+				continue
+			}
+			seen[strings.ToLower(trimmed)] = struct{}{}
+			if trimmed != defaultKey {
+				if err := s.ensureFallbackModel(ctx, userID, trimmed); err != nil {
+					return nil, err
+				}
+			}
+			cleaned = append(cleaned, trimmed)
+		}
+		if len(cleaned) > domain.MaxFallbackChainLength {
+			return nil, fmt.Errorf("%w: %s exceeds %d models", ErrFallbackChainInvalid, op, domain.MaxFallbackChainLength)
+		}
+		if len(cleaned) == 0 {
+			continue
+		}
+		normalized[op] = cleaned
+	}
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+	return normalized, nil
+}
+
+// ensureFallbackModel 校验回退链中的模型属于当前用户且处于启用状态。
+func (s *Service) ensureFallbackModel(ctx context.Context, userID uint, modelKey string) error {
+	if s.models == nil {
+		return nil
+	}
+	credential, err := s.models.FindByModelKey(ctx, userID, modelKey)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %s", ErrFallbackModelNotFound, modelKey)
+		}
+		return fmt.Errorf("find fallback model: %w", err)
+	}
+	if !strings.EqualFold(credential.Status, "enabled") {
+		return fmt.Errorf("%w: %s", ErrFallbackModelDisabled, modelKey)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"electron-go-app/backend/internal/domain/llm"
+	modelsvc "electron-go-app/backend/internal/service/model"
+)
+
+func TestInvokeAnthropicChatCompletion(t *testing.T) {
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("x-api-key"); got != "sk-ant-test" {
+			t.Fatalf("unexpected x-api-key header: %q", got)
+		}
+		if got := r.Header.Get("anthropic-version"); got != "2023-06-01" {
+			t.Fatalf("unexpected anthropic-version header: %q", got)
+		}
+		if got := r.Header.Get("anthropic-beta"); got != "prompt-caching-2024-07-31" {
+			t.Fatalf("unexpected anthropic-beta header: %q", got)
+		}
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if payload["model"] != "claude-3-5-haiku-latest" {
+			t.Fatalf("unexpected model: %v", payload["model"])
+		}
+		system, _ := payload["system"].(string)
+		if !strings.HasPrefix(system, "you are a helper") {
+			t.Fatalf("expected system prompt to be hoisted, got %q", system)
+		}
+		messages, _ := payload["messages"].([]any)
+		if len(messages) != 2 {
+			t.Fatalf("expected consecutive user messages to be merged, got %v", messages)
+		}
+		first, _ := messages[0].(map[string]any)
+		if first["role"] != "user" || first["content"] != "hello\n\nworld" {
+			t.Fatalf("unexpected first message: %v", first)
+		}
+		if payload["max_tokens"] != float64(512) || payload["temperature"] != 0.3 {
+			t.Fatalf("unexpected sampling params: %v", payload)
+		}
+		if stops, _ := payload["stop_sequences"].([]any); len(stops) != 1 || stops[0] != "END" {
+			t.Fatalf("unexpected stop sequences: %v", payload["stop_sequences"])
+		}
+		if payload["top_k"] != float64(40) {
+			t.Fatalf("expected extra config passthrough, got %v", payload["top_k"])
+		}
+		if _, ok := payload["anthropic_beta"]; ok {
+			t.Fatalf("anthropic_beta should be sent as header only")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":    "msg_01",
+			"type":  "message",
+			"role":  "assistant",
+			"model": "claude-3-5-haiku-latest",
+			"content": []any{
+				map[string]any{"type": "thinking", "thinking": "considering"},
+				map[string]any{"type": "text", "text": "hi "},
+				map[string]any{"type": "text", "text": "there"},
+			},
+			"stop_reason": "max_tokens",
+			"usage": map[string]any{
+				"input_tokens":            10,
+				"output_tokens":           5,
+				"cache_read_input_tokens": 4,
+			},
+		})
+	}))
+	defer server.Close()
+
+	svc, _, _, userID := newTestModelService(t)
+	_, err := svc.Create(context.Background(), userID, modelsvc.CreateInput{
+		Provider:    "anthropic",
+		ModelKey:    "claude-3-5-haiku-latest",
+		DisplayName: "Claude Haiku",
+		BaseURL:     server.URL + "/v1",
+		APIKey:      "sk-ant-test",
+		ExtraConfig: map[string]any{
+			"top_k":          40,
+			"anthropic_beta": "prompt-caching-2024-07-31",
+		},
+	})
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+
+	resp, err := svc.InvokeChatCompletion(context.Background(), userID, "claude-3-5-haiku-latest", llm.Request{
+		Messages: []llm.Message{
+			{Role: "system", Content: "you are a helper"},
+			{Role: "user", Content: "hello"},
+			{Role: "user", Content: "world"},
+			{Role: "assistant", Content: "ok"},
+		},
+		MaxTokens:      512,
+		Temperature:    0.3,
+		Stop:           []string{"END"},
+		ResponseFormat: map[string]any{"type": "json_object"},
+	})
+	if err != nil {
+		t.Fatalf("invoke anthropic: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "hi there" {
+		t.Fatalf("unexpected choices: %+v", resp.Choices)
+	}
+	if resp.Choices[0].FinishReason != "length" {
+		t.Fatalf("expected finish reason length, got %q", resp.Choices[0].FinishReason)
+	}
+	if resp.Usage == nil || resp.Usage.PromptTokens != 14 || resp.Usage.CompletionTokens != 5 || resp.Usage.TotalTokens != 19 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+	if resp.Usage.CachedTokens != 4 {
+		t.Fatalf("unexpected cached tokens: %d", resp.Usage.CachedTokens)
+	}
+	if resp.Choices[0].Reasoning == nil || resp.Choices[0].Reasoning.Content != "considering" {
+		t.Fatalf("expected thinking block to surface as reasoning, got %+v", resp.Choices[0].Reasoning)
+	}
+}
+
+func TestInvokeAnthropicChatCompletionStream(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_02","type":"message","role":"assistant","model":"claude-3-5-sonnet-latest","content":[],"usage":{"input_tokens":8,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}`,
+		`{"type":"message_stop"}`,
+	}
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if payload["stream"] != true {
+			t.Fatalf("expected stream=true, got %v", payload["stream"])
+		}
+		if payload["max_tokens"] != float64(4096) {
+			t.Fatalf("expected default max_tokens, got %v", payload["max_tokens"])
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			var head struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(event), &head)
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", head.Type, event)
+		}
+	}))
+	defer server.Close()
+
+	svc, _, _, userID := newTestModelService(t)
+	_, err := svc.Create(context.Background(), userID, modelsvc.CreateInput{
+		Provider:    "anthropic",
+		ModelKey:    "claude-3-5-sonnet-latest",
+		DisplayName: "Claude Sonnet",
+		BaseURL:     server.URL,
+		APIKey:      "sk-ant-test",
+	})
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+
+	var deltas []string
+	resp, err := svc.InvokeChatCompletionStream(context.Background(), userID, "claude-3-5-sonnet-latest", llm.Request{
+		Messages: []llm.Message{{Role: "user", Content: "ping"}},
+	}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("invoke anthropic stream: %v", err)
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Fatalf("unexpected deltas: %v", deltas)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "Hello" || resp.Choices[0].FinishReason != "stop" {
+		t.Fatalf("unexpected choices: %+v", resp.Choices)
+	}
+	if resp.Usage == nil || resp.Usage.PromptTokens != 8 || resp.Usage.CompletionTokens != 3 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+}
@@ -9,9 +9,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"syscall"
 	"testing"
+	"time"
 
+	"electron-go-app/backend/internal/domain/llm"
 	"electron-go-app/backend/internal/infra/model/deepseek"
 	modelsvc "electron-go-app/backend/internal/service/model"
 )
@@ -110,6 +113,114 @@ func TestDeepSeekClientChatCompletion(t *testing.T) {
 	}
 }
 
+func TestDeepSeekClientChatCompletionStream(t *testing.T) {
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if payload["stream"] != true {
+			t.Fatalf("expected stream=true, got %v", payload["stream"])
+		}
+		options, _ := payload["stream_options"].(map[string]any)
+		if options["include_usage"] != true {
+			t.Fatalf("expected include_usage, got %v", payload["stream_options"])
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"stream-1","model":"deepseek-chat","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+			`{"id":"stream-1","model":"deepseek-chat","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`,
+			`{"id":"stream-1","model":"deepseek-chat","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`,
+		}
+		for _, chunk := range chunks {
+			_, _ = w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	client := deepseek.NewClient("test-key", deepseek.WithBaseURL(server.URL))
+	var deltas []string
+	resp, err := client.ChatCompletionStream(context.Background(), deepseek.ChatCompletionRequest{
+		Model:    "deepseek-chat",
+		Messages: []deepseek.ChatMessage{{Role: "user", Content: "Hi"}},
+	}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream returned error: %v", err)
+	}
+	if len(deltas) != 2 || deltas[0] != "Hel" || deltas[1] != "lo" {
+		t.Fatalf("unexpected deltas: %v", deltas)
+	}
+	if resp.ID != "stream-1" || len(resp.Choices) != 1 {
+		t.Fatalf("unexpected assembled response: %+v", resp)
+	}
+	if resp.Choices[0].Message.Content != "Hello" || resp.Choices[0].FinishReason != "stop" {
+		t.Fatalf("unexpected assembled choice: %+v", resp.Choices[0])
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 5 {
+		t.Fatalf("expected usage from final chunk, got %+v", resp.Usage)
+	}
+}
+
+// TestDeepSeekProviderStreamToolCalls 验证 assistant 工具调用与 tool 结果消息会写入请求体，流式返回的 tool_calls 片段按 index 拼接。
+func TestDeepSeekProviderStreamToolCalls(t *testing.T) {
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Messages []deepseek.ChatMessage `json:"messages"`
+			Tools    []llm.Tool             `json:"tools"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if len(payload.Tools) != 1 || payload.Tools[0].Function.Name != "lookup" {
+			t.Fatalf("unexpected tools: %+v", payload.Tools)
+		}
+		if len(payload.Messages) != 3 || len(payload.Messages[1].ToolCalls) != 1 || payload.Messages[1].ToolCalls[0].ID != "call_0" {
+			t.Fatalf("expected assistant tool call in request, got %+v", payload.Messages)
+		}
+		if payload.Messages[2].ToolCallID != "call_0" {
+			t.Fatalf("expected tool_call_id on tool message, got %+v", payload.Messages[2])
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"stream-tool","model":"deepseek-chat","choices":[{"index":0,"delta":{"role":"assistant","content":"","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"lookup","arguments":""}}]}}]}`,
+			`{"id":"stream-tool","model":"deepseek-chat","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"q\":"}}]}}]}`,
+			`{"id":"stream-tool","model":"deepseek-chat","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"go\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		}
+		for _, chunk := range chunks {
+			_, _ = w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	client := deepseek.NewClient("test-key", deepseek.WithBaseURL(server.URL))
+	resp, err := client.CompleteStream(context.Background(), llm.Request{
+		Model: "deepseek-chat",
+		Messages: []llm.Message{
+			{Role: "user", Content: "查一下 go"},
+			{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "call_0", Type: "function", Function: llm.ToolCallFunction{Name: "lookup", Arguments: `{"q":"golang"}`}}}},
+			{Role: "tool", ToolCallID: "call_0", Content: "no result"},
+		},
+		Tools: []llm.Tool{{Type: "function", Function: llm.ToolFunction{Name: "lookup", Parameters: map[string]any{"type": "object"}}}},
+	}, func(string) error { return nil })
+	if err != nil {
+		t.Fatalf("CompleteStream returned error: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].FinishReason != "tool_calls" {
+		t.Fatalf("unexpected choices: %+v", resp.Choices)
+	}
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Function.Name != "lookup" || calls[0].Function.Arguments != `{"q":"go"}` {
+		t.Fatalf("unexpected assembled tool calls: %+v", calls)
+	}
+}
+
 func TestDeepSeekClientAPIError(t *testing.T) {
 	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
@@ -148,6 +259,127 @@ func TestDeepSeekClientAPIError(t *testing.T) {
 	}
 }
 
+func TestDeepSeekClientRetriesRateLimitedRequest(t *testing.T) {
+	var calls atomic.Int32
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{"message": "rate limit reached", "type": "rate_limit_error"},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "retry-id",
+			"model":   "deepseek-chat",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "ok"}, "finish_reason": "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	client := deepseek.NewClient("sk-test",
+		deepseek.WithBaseURL(server.URL),
+		deepseek.WithRetryPolicy(llm.RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second}),
+	)
+	resp, err := client.ChatCompletion(context.Background(), deepseek.ChatCompletionRequest{
+		Model:    "deepseek-chat",
+		Messages: []deepseek.ChatMessage{{Role: "user", Content: "test"}},
+	})
+	if err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content != "ok" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 calls, got %d", got)
+	}
+}
+
+func TestDeepSeekClientDoesNotRetryNonRetryableError(t *testing.T) {
+	var calls atomic.Int32
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"error": map[string]any{
+				"message": "This model's maximum context length is 65536 tokens",
+				"type":    "invalid_request_error",
+				"code":    "context_length_exceeded",
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := deepseek.NewClient("sk-test",
+		deepseek.WithBaseURL(server.URL),
+		deepseek.WithRetryPolicy(llm.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}),
+	)
+	_, err := client.ChatCompletion(context.Background(), deepseek.ChatCompletionRequest{
+		Model:    "deepseek-chat",
+		Messages: []deepseek.ChatMessage{{Role: "user", Content: "test"}},
+	})
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if kind := llm.KindOf(err); kind != llm.ErrorKindContextTooLong {
+		t.Fatalf("expected context_too_long, got %s", kind)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected a single call, got %d", got)
+	}
+}
+
+func TestLLMClassifyStatus(t *testing.T) {
+	cases := []struct {
+		status  int
+		code    string
+		message string
+		want    llm.ErrorKind
+	}{
+		{http.StatusUnauthorized, "invalid_key", "invalid api key", llm.ErrorKindAuthFailed},
+		{http.StatusPaymentRequired, "", "Insufficient Balance", llm.ErrorKindQuotaExhausted},
+		{http.StatusTooManyRequests, "insufficient_quota", "You exceeded your current quota", llm.ErrorKindQuotaExhausted},
+		{http.StatusTooManyRequests, "", "rate limit reached", llm.ErrorKindRateLimited},
+		{http.StatusBadRequest, "", "prompt is too long", llm.ErrorKindContextTooLong},
+		{http.StatusBadRequest, "SensitiveContentDetected", "", llm.ErrorKindContentFiltered},
+		{http.StatusServiceUnavailable, "", "overloaded", llm.ErrorKindTransient},
+		{http.StatusBadRequest, "", "bad request", llm.ErrorKindUnknown},
+	}
+	for _, tc := range cases {
+		if got := llm.ClassifyStatus(tc.status, tc.code, tc.message); got != tc.want {
+			t.Fatalf("ClassifyStatus(%d, %q, %q) = %s, want %s", tc.status, tc.code, tc.message, got, tc.want)
+		}
+	}
+}
+
+func TestLLMRetryPolicyDelay(t *testing.T) {
+	policy := llm.RetryPolicy{MaxRetries: 2, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	transient := &deepseek.APIError{StatusCode: http.StatusBadGateway}
+	for retry := 1; retry <= 2; retry++ {
+		delay, ok := policy.Delay(retry, transient)
+		upper := policy.BaseDelay << (retry - 1)
+		if !ok || delay < upper/2 || delay > upper {
+			t.Fatalf("retry %d: expected jittered delay in [%s, %s], got %s (ok=%v)", retry, upper/2, upper, delay, ok)
+		}
+	}
+	if _, ok := policy.Delay(3, transient); ok {
+		t.Fatalf("expected retries to be exhausted")
+	}
+	if _, ok := policy.Delay(1, &deepseek.APIError{StatusCode: http.StatusUnauthorized}); ok {
+		t.Fatalf("expected auth failure not to be retried")
+	}
+	hinted := &deepseek.APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 300 * time.Millisecond}
+	if delay, ok := policy.Delay(1, hinted); !ok || delay != 300*time.Millisecond {
+		t.Fatalf("expected Retry-After to be honoured, got %s (ok=%v)", delay, ok)
+	}
+	tooLong := &deepseek.APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute}
+	if _, ok := policy.Delay(1, tooLong); ok {
+		t.Fatalf("expected Retry-After beyond MaxDelay to stop retrying")
+	}
+}
+
 func TestInvokeDeepSeekChatCompletion(t *testing.T) {
 	key := make([]byte, 32)
 	for i := range key {
@@ -214,8 +446,8 @@ func TestInvokeDeepSeekChatCompletion(t *testing.T) {
 		t.Fatalf("create credential: %v", err)
 	}
 
-	resp, err := svc.InvokeDeepSeekChatCompletion(context.Background(), userID, "deepseek-chat", deepseek.ChatCompletionRequest{
-		Messages: []deepseek.ChatMessage{
+	resp, err := svc.InvokeDeepSeekChatCompletion(context.Background(), userID, "deepseek-chat", llm.Request{
+		Messages: []llm.Message{
 			{Role: "user", Content: "Hi"},
 		},
 	})
@@ -230,6 +462,79 @@ func TestInvokeDeepSeekChatCompletion(t *testing.T) {
 	}
 }
 
+func TestInvokeDeepSeekReasoningAndCacheUsage(t *testing.T) {
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if _, exists := payload["stream"]; exists {
+			t.Fatalf("stream in extra_config should be ignored, got %v", payload["stream"])
+		}
+		if stops, _ := payload["stop"].([]any); len(stops) != 1 || stops[0] != "###" {
+			t.Fatalf("expected stop from extra_config, got %v", payload["stop"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":           "reasoner-test",
+			"model":        "deepseek-reasoner",
+			"service_tier": "default",
+			"choices": []any{
+				map[string]any{
+					"index": 0,
+					"message": map[string]any{
+						"role":              "assistant",
+						"content":           "42",
+						"reasoning_content": "think step by step",
+					},
+					"finish_reason": "stop",
+				},
+			},
+			"usage": map[string]any{
+				"prompt_tokens":             30,
+				"completion_tokens":         20,
+				"total_tokens":              50,
+				"prompt_cache_hit_tokens":   12,
+				"prompt_cache_miss_tokens":  18,
+				"completion_tokens_details": map[string]any{"reasoning_tokens": 15},
+			},
+		})
+	}))
+	defer server.Close()
+
+	svc, _, _, userID := newTestModelService(t)
+	if _, err := svc.Create(context.Background(), userID, modelsvc.CreateInput{
+		Provider:    "deepseek",
+		ModelKey:    "deepseek-reasoner",
+		DisplayName: "DeepSeek Reasoner",
+		BaseURL:     server.URL,
+		APIKey:      "sk-secret",
+		ExtraConfig: map[string]any{"stream": true, "stop": "###"},
+	}); err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+
+	resp, err := svc.InvokeChatCompletion(context.Background(), userID, "deepseek-reasoner", llm.Request{
+		Messages: []llm.Message{{Role: "user", Content: "answer"}},
+	})
+	if err != nil {
+		t.Fatalf("invoke deepseek: %v", err)
+	}
+	if resp.Text() != "42" {
+		t.Fatalf("unexpected content: %q", resp.Text())
+	}
+	if resp.Choices[0].Reasoning == nil || resp.Choices[0].Reasoning.Content != "think step by step" {
+		t.Fatalf("expected reasoning content, got %+v", resp.Choices[0].Reasoning)
+	}
+	if resp.ServiceTier != "default" || resp.SystemFingerprint != "" {
+		t.Fatalf("unexpected service tier/fingerprint: %q/%q", resp.ServiceTier, resp.SystemFingerprint)
+	}
+	if resp.Usage == nil || resp.Usage.CachedTokens != 12 || resp.Usage.ReasoningTokens != 15 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
 func TestServiceTestConnection(t *testing.T) {
 	key := make([]byte, 32)
 	for i := range key {
@@ -280,8 +585,8 @@ func TestServiceTestConnection(t *testing.T) {
 		t.Fatalf("create credential: %v", err)
 	}
 
-	resp, err := svc.TestConnection(context.Background(), userID, credential.ID, deepseek.ChatCompletionRequest{
-		Messages: []deepseek.ChatMessage{{Role: "user", Content: "ping"}},
+	resp, err := svc.TestConnection(context.Background(), userID, credential.ID, llm.Request{
+		Messages: []llm.Message{{Role: "user", Content: "ping"}},
 	})
 	if err != nil {
 		t.Fatalf("test connection: %v", err)
@@ -333,8 +638,8 @@ func TestServiceTestConnectionDisabled(t *testing.T) {
 		t.Fatalf("disable credential: %v", err)
 	}
 
-	_, err = svc.TestConnection(context.Background(), userID, credential.ID, deepseek.ChatCompletionRequest{
-		Messages: []deepseek.ChatMessage{{Role: "user", Content: "ping"}},
+	_, err = svc.TestConnection(context.Background(), userID, credential.ID, llm.Request{
+		Messages: []llm.Message{{Role: "user", Content: "ping"}},
 	})
 	if !errors.Is(err, modelsvc.ErrCredentialDisabled) {
 		t.Fatalf("expected ErrCredentialDisabled, got %v", err)
@@ -400,8 +705,8 @@ func TestInvokeVolcengineChatCompletion(t *testing.T) {
 		t.Fatalf("create volcengine credential: %v", err)
 	}
 
-	resp, err := svc.InvokeDeepSeekChatCompletion(context.Background(), userID, cred.ModelKey, deepseek.ChatCompletionRequest{
-		Messages: []deepseek.ChatMessage{{Role: "user", Content: "你好"}},
+	resp, err := svc.InvokeDeepSeekChatCompletion(context.Background(), userID, cred.ModelKey, llm.Request{
+		Messages: []llm.Message{{Role: "user", Content: "你好"}},
 	})
 	if err != nil {
 		t.Fatalf("invoke volcengine: %v", err)
@@ -410,8 +715,8 @@ func TestInvokeVolcengineChatCompletion(t *testing.T) {
 		t.Fatalf("unexpected volcengine response: %+v", resp.Choices)
 	}
 
-	resp2, err := svc.TestConnection(context.Background(), userID, cred.ID, deepseek.ChatCompletionRequest{
-		Messages: []deepseek.ChatMessage{{Role: "user", Content: "test"}},
+	resp2, err := svc.TestConnection(context.Background(), userID, cred.ID, llm.Request{
+		Messages: []llm.Message{{Role: "user", Content: "test"}},
 	})
 	if err != nil {
 		t.Fatalf("test connection for volcengine: %v", err)
@@ -463,8 +768,8 @@ func TestInvokeDeepSeekWithDisabledCredential(t *testing.T) {
 		t.Fatalf("disable credential: %v", err)
 	}
 
-	_, err = svc.InvokeDeepSeekChatCompletion(context.Background(), userID, "deepseek-chat", deepseek.ChatCompletionRequest{
-		Messages: []deepseek.ChatMessage{
+	_, err = svc.InvokeDeepSeekChatCompletion(context.Background(), userID, "deepseek-chat", llm.Request{
+		Messages: []llm.Message{
 			{Role: "user", Content: "test"},
 		},
 	})
@@ -472,3 +777,106 @@ func TestInvokeDeepSeekWithDisabledCredential(t *testing.T) {
 		t.Fatalf("expected ErrCredentialDisabled, got %v", err)
 	}
 }
+
+func TestInvokeOpenAICompatibleChatCompletion(t *testing.T) {
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-openai" {
+			t.Fatalf("unexpected authorization header: %s", got)
+		}
+		if got := r.Header.Get("OpenAI-Organization"); got != "org-demo" {
+			t.Fatalf("expected organization header, got %q", got)
+		}
+		if got := r.Header.Get("OpenAI-Project"); got != "proj-demo" {
+			t.Fatalf("expected project header, got %q", got)
+		}
+		if got := r.Header.Get("X-Gateway-Tenant"); got != "team-a" {
+			t.Fatalf("expected custom header, got %q", got)
+		}
+
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		for _, key := range []string{"organization", "project", "headers"} {
+			if _, exists := payload[key]; exists {
+				t.Fatalf("expected %s to be stripped from body", key)
+			}
+		}
+		if payload["model"] != "gpt-4o-mini" {
+			t.Fatalf("expected model gpt-4o-mini, got %v", payload["model"])
+		}
+		if payload["temperature"] != 0.2 {
+			t.Fatalf("expected caller temperature to win, got %v", payload["temperature"])
+		}
+		if payload["max_tokens"] != float64(512) {
+			t.Fatalf("expected max_tokens from extra config, got %v", payload["max_tokens"])
+		}
+		if payload["seed"] != float64(7) {
+			t.Fatalf("expected unknown extra field to pass through, got %v", payload["seed"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":     "openai-test",
+			"object": "chat.completion",
+			"model":  "gpt-4o-mini",
+			"choices": []any{
+				map[string]any{
+					"index":         0,
+					"message":       map[string]any{"role": "assistant", "content": "pong"},
+					"finish_reason": "stop",
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	svc, _, _, userID := newTestModelService(t)
+
+	_, err := svc.Create(context.Background(), userID, modelsvc.CreateInput{
+		Provider:    "openai-compatible",
+		ModelKey:    "gpt-4o-mini",
+		DisplayName: "Gateway",
+		BaseURL:     server.URL + "/v1",
+		APIKey:      "sk-openai",
+		ExtraConfig: map[string]any{
+			"organization": "org-demo",
+			"project":      "proj-demo",
+			"headers":      map[string]any{"X-Gateway-Tenant": "team-a"},
+			"temperature":  1,
+			"max_tokens":   512,
+			"seed":         7,
+		},
+	})
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+
+	resp, err := svc.InvokeChatCompletion(context.Background(), userID, "gpt-4o-mini", llm.Request{
+		Messages:    []llm.Message{{Role: "user", Content: "ping"}},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		t.Fatalf("invoke openai-compatible: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "pong" {
+		t.Fatalf("unexpected response content: %+v", resp.Choices)
+	}
+}
+
+func TestCreateOpenAICompatibleRequiresBaseURL(t *testing.T) {
+	svc, _, _, userID := newTestModelService(t)
+
+	_, err := svc.Create(context.Background(), userID, modelsvc.CreateInput{
+		Provider:    "openai-compatible",
+		ModelKey:    "local-llm",
+		DisplayName: "Local",
+		APIKey:      "sk-local",
+	})
+	if !errors.Is(err, modelsvc.ErrBaseURLRequired) {
+		t.Fatalf("expected ErrBaseURLRequired, got %v", err)
+	}
+}
@@ -8,13 +8,22 @@ package unit
 
 import (
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"electron-go-app/backend/internal/domain/llm"
+	"electron-go-app/backend/internal/domain/modelusage"
 	domain "electron-go-app/backend/internal/domain/user"
 	"electron-go-app/backend/internal/infra/security"
 	"electron-go-app/backend/internal/repository"
@@ -511,3 +520,725 @@ func TestModelServiceRejectsInvalidStatus(t *testing.T) {
 		t.Fatalf("expected invalid status error, got %v", err)
 	}
 }
+
+func TestModelServiceCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"message": "overloaded"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "breaker",
+			"model":   "deepseek-chat",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "ok"}, "finish_reason": "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	_, db, userRepo, userID := newTestModelService(t)
+	svc := modelsvc.NewServiceWithConfig(repository.NewModelCredentialRepository(db), userRepo, nil, modelsvc.Config{
+		Retry:   llm.RetryPolicy{MaxRetries: 0},
+		Circuit: modelsvc.CircuitConfig{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond},
+	})
+	ctx := context.Background()
+	if _, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:    "deepseek",
+		ModelKey:    "deepseek-chat",
+		DisplayName: "DeepSeek",
+		BaseURL:     server.URL,
+		APIKey:      "sk-test",
+	}); err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+	req := llm.Request{Messages: []llm.Message{{Role: "user", Content: "ping"}}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.InvokeChatCompletion(ctx, userID, "deepseek-chat", req); err == nil || errors.Is(err, modelsvc.ErrCircuitOpen) {
+			t.Fatalf("attempt %d: expected provider error, got %v", i+1, err)
+		}
+	}
+	_, err := svc.InvokeChatCompletion(ctx, userID, "deepseek-chat", req)
+	if !errors.Is(err, modelsvc.ErrCircuitOpen) {
+		t.Fatalf("expected circuit open error, got %v", err)
+	}
+	if !modelsvc.IsRetryableError(err) {
+		t.Fatalf("expected circuit open error to allow fallback")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected open circuit to skip provider, got %d calls", got)
+	}
+	creds, err := svc.List(ctx, userID)
+	if err != nil {
+		t.Fatalf("list credentials: %v", err)
+	}
+	if creds[0].Circuit == nil || creds[0].Circuit.State != modelsvc.CircuitStateOpen || creds[0].Circuit.ConsecutiveFailures != 2 {
+		t.Fatalf("unexpected circuit status: %+v", creds[0].Circuit)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	if _, err := svc.InvokeChatCompletion(ctx, userID, "deepseek-chat", req); err != nil {
+		t.Fatalf("expected half-open probe to succeed, got %v", err)
+	}
+	creds, err = svc.List(ctx, userID)
+	if err != nil {
+		t.Fatalf("list credentials: %v", err)
+	}
+	if creds[0].Circuit.State != modelsvc.CircuitStateClosed {
+		t.Fatalf("expected circuit to close after probe, got %s", creds[0].Circuit.State)
+	}
+}
+
+// TestModelServiceHealthCheck 验证后台健康检查会探测启用中的凭据，记录耗时与错误，并把 401 标记为 revoked。
+func TestModelServiceHealthCheck(t *testing.T) {
+	var calls atomic.Int32
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Authorization") == "Bearer sk-revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"message": "invalid api key"}})
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["max_tokens"] != float64(1) {
+			t.Errorf("expected minimal ping request, got %v", body)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "health",
+			"model":   "deepseek-chat",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "p"}, "finish_reason": "length"}},
+		})
+	}))
+	defer server.Close()
+
+	_, db, userRepo, userID := newTestModelService(t)
+	repo := repository.NewModelCredentialRepository(db)
+	svc := modelsvc.NewServiceWithConfig(repo, userRepo, nil, modelsvc.Config{
+		Retry:   llm.RetryPolicy{MaxRetries: 0},
+		Circuit: modelsvc.DefaultCircuitConfig(),
+		Health:  modelsvc.HealthConfig{Timeout: time.Second, BatchSize: 1},
+	})
+	ctx := context.Background()
+	create := func(modelKey, apiKey string) modelsvc.Credential {
+		cred, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+			Provider:    "deepseek",
+			ModelKey:    modelKey,
+			DisplayName: modelKey,
+			BaseURL:     server.URL,
+			APIKey:      apiKey,
+		})
+		if err != nil {
+			t.Fatalf("create credential %s: %v", modelKey, err)
+		}
+		return cred
+	}
+	healthy := create("deepseek-chat", "sk-valid")
+	revoked := create("deepseek-revoked", "sk-revoked")
+	disabled := create("deepseek-disabled", "sk-valid")
+	status := "disabled"
+	if _, err := svc.Update(ctx, userID, disabled.ID, modelsvc.UpdateInput{Status: &status}); err != nil {
+		t.Fatalf("disable credential: %v", err)
+	}
+
+	checked, err := svc.CheckCredentialsHealth(ctx)
+	if err != nil {
+		t.Fatalf("check health: %v", err)
+	}
+	if checked != 2 || calls.Load() != 2 {
+		t.Fatalf("expected only enabled credentials to be checked, got checked=%d calls=%d", checked, calls.Load())
+	}
+	if _, err := svc.CheckCredentialsHealth(ctx); err != nil {
+		t.Fatalf("check health again: %v", err)
+	}
+
+	creds, err := svc.List(ctx, userID)
+	if err != nil {
+		t.Fatalf("list credentials: %v", err)
+	}
+	byID := map[uint]modelsvc.Credential{}
+	for _, cred := range creds {
+		byID[cred.ID] = cred
+	}
+	if got := byID[healthy.ID]; got.Health == nil || got.Health.Status != modelsvc.HealthStatusHealthy || got.Health.LastError != "" || got.LastVerifiedAt == nil {
+		t.Fatalf("unexpected healthy credential: %+v health=%+v", got, got.Health)
+	}
+	got := byID[revoked.ID]
+	if got.Health == nil || got.Health.Status != modelsvc.HealthStatusRevoked || got.Health.ConsecutiveFailures != 2 || !strings.Contains(got.Health.LastError, "invalid api key") {
+		t.Fatalf("unexpected revoked credential health: %+v", got.Health)
+	}
+	if got.LastVerifiedAt != nil || byID[disabled.ID].Health != nil {
+		t.Fatalf("expected failed or disabled credentials to stay unverified")
+	}
+
+	stale, err := svc.CredentialFingerprint(ctx, userID, "deepseek-revoked")
+	if err != nil {
+		t.Fatalf("credential fingerprint: %v", err)
+	}
+	newKey := "sk-valid"
+	updated, err := svc.Update(ctx, userID, revoked.ID, modelsvc.UpdateInput{APIKey: &newKey})
+	if err != nil {
+		t.Fatalf("rotate api key: %v", err)
+	}
+	if updated.Health != nil {
+		t.Fatalf("expected health to reset after api key change, got %+v", updated.Health)
+	}
+	// 更换 Key 会改变凭据标识，使响应缓存失效；健康检查只写健康字段，不影响标识。
+	before, err := svc.CredentialFingerprint(ctx, userID, "deepseek-revoked")
+	if err != nil || before == stale {
+		t.Fatalf("expected credential update to change the fingerprint, got %q -> %q err=%v", stale, before, err)
+	}
+	if _, err := svc.CheckCredentialsHealth(ctx); err != nil {
+		t.Fatalf("check health after rotation: %v", err)
+	}
+	if after, err := svc.CredentialFingerprint(ctx, userID, "deepseek-revoked"); err != nil || after != before {
+		t.Fatalf("expected health checks to keep the credential fingerprint, got %q -> %q err=%v", before, after, err)
+	}
+	if _, err := svc.CredentialFingerprint(ctx, userID, "missing"); !errors.Is(err, modelsvc.ErrCredentialNotFound) {
+		t.Fatalf("expected missing credential error, got %v", err)
+	}
+}
+
+// TestModelServiceHealthCheckSkipsRotatedCredential 验证探测期间用户更换 Key 时，旧 Key 的失败结果不会把新凭据标记为 revoked。
+func TestModelServiceHealthCheckSkipsRotatedCredential(t *testing.T) {
+	var rotate func()
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer sk-old" {
+			rotate()
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"message": "invalid api key"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "health",
+			"model":   "deepseek-chat",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "p"}, "finish_reason": "length"}},
+		})
+	}))
+	defer server.Close()
+
+	_, db, userRepo, userID := newTestModelService(t)
+	svc := modelsvc.NewServiceWithConfig(repository.NewModelCredentialRepository(db), userRepo, nil, modelsvc.Config{
+		Retry:   llm.RetryPolicy{MaxRetries: 0},
+		Circuit: modelsvc.DefaultCircuitConfig(),
+		Health:  modelsvc.HealthConfig{Timeout: time.Second, BatchSize: 10},
+	})
+	ctx := context.Background()
+	cred, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:    "deepseek",
+		ModelKey:    "deepseek-chat",
+		DisplayName: "deepseek-chat",
+		BaseURL:     server.URL,
+		APIKey:      "sk-old",
+	})
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+	rotate = func() {
+		newKey := "sk-new"
+		if _, err := svc.Update(ctx, userID, cred.ID, modelsvc.UpdateInput{APIKey: &newKey}); err != nil {
+			t.Errorf("rotate api key: %v", err)
+		}
+	}
+
+	checked, err := svc.CheckCredentialsHealth(ctx)
+	if err != nil {
+		t.Fatalf("check health: %v", err)
+	}
+	if checked != 0 {
+		t.Fatalf("expected stale probe result to be discarded, got checked=%d", checked)
+	}
+	creds, err := svc.List(ctx, userID)
+	if err != nil || len(creds) != 1 {
+		t.Fatalf("list credentials: %+v err=%v", creds, err)
+	}
+	if creds[0].Health != nil {
+		t.Fatalf("expected rotated credential health to stay unset, got %+v", creds[0].Health)
+	}
+
+	checked, err = svc.CheckCredentialsHealth(ctx)
+	if err != nil || checked != 1 {
+		t.Fatalf("expected new key to be checked, got checked=%d err=%v", checked, err)
+	}
+	creds, err = svc.List(ctx, userID)
+	if err != nil || creds[0].Health == nil || creds[0].Health.Status != modelsvc.HealthStatusHealthy {
+		t.Fatalf("expected new key to be healthy, got %+v err=%v", creds, err)
+	}
+}
+
+// TestModelServiceHealthCheckerRunsOnStart 验证后台健康检查启动后立即探测一轮，而不是等待一个完整周期。
+func TestModelServiceHealthCheckerRunsOnStart(t *testing.T) {
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "health",
+			"model":   "deepseek-chat",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "p"}, "finish_reason": "length"}},
+		})
+	}))
+	defer server.Close()
+
+	_, db, userRepo, userID := newTestModelService(t)
+	svc := modelsvc.NewServiceWithConfig(repository.NewModelCredentialRepository(db), userRepo, nil, modelsvc.Config{
+		Retry:   llm.RetryPolicy{MaxRetries: 0},
+		Circuit: modelsvc.DefaultCircuitConfig(),
+		Health:  modelsvc.HealthConfig{Interval: time.Hour, Timeout: time.Second},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:    "deepseek",
+		ModelKey:    "deepseek-chat",
+		DisplayName: "deepseek-chat",
+		BaseURL:     server.URL,
+		APIKey:      "sk-valid",
+	}); err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+
+	svc.StartHealthChecker(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		creds, err := svc.List(ctx, userID)
+		if err != nil {
+			t.Fatalf("list credentials: %v", err)
+		}
+		if len(creds) == 1 && creds[0].Health != nil {
+			if creds[0].Health.Status != modelsvc.HealthStatusHealthy {
+				t.Fatalf("unexpected health after startup probe: %+v", creds[0].Health)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a health probe right after start, health still unknown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestModelServiceUsageLedger(t *testing.T) {
+	var fail atomic.Bool
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"message": "prompt is too long"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "usage",
+			"model":   "deepseek-chat",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "ok"}, "finish_reason": "stop"}},
+			"usage": map[string]any{
+				"prompt_tokens":             100,
+				"completion_tokens":         40,
+				"total_tokens":              140,
+				"prompt_cache_hit_tokens":   30,
+				"completion_tokens_details": map[string]any{"reasoning_tokens": 10},
+			},
+		})
+	}))
+	defer server.Close()
+
+	_, db, userRepo, userID := newTestModelService(t)
+	if err := db.AutoMigrate(&modelusage.Record{}); err != nil {
+		t.Fatalf("auto migrate usage: %v", err)
+	}
+	svc := modelsvc.NewServiceWithConfig(repository.NewModelCredentialRepository(db), userRepo, repository.NewModelUsageRepository(db), modelsvc.Config{
+		Retry: llm.RetryPolicy{MaxRetries: 0},
+	})
+	ctx := context.Background()
+	cred, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:    "deepseek",
+		ModelKey:    "deepseek-chat",
+		DisplayName: "DeepSeek",
+		BaseURL:     server.URL,
+		APIKey:      "sk-test",
+	})
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+	req := llm.Request{Messages: []llm.Message{{Role: "user", Content: "ping"}}}
+
+	if _, err := svc.InvokeChatCompletion(llm.WithOperation(ctx, "generate"), userID, "deepseek-chat", req); err != nil {
+		t.Fatalf("invoke generate: %v", err)
+	}
+	if _, err := svc.InvokeChatCompletion(llm.WithOperation(ctx, "interpret"), userID, "deepseek-chat", req); err != nil {
+		t.Fatalf("invoke interpret: %v", err)
+	}
+	fail.Store(true)
+	if _, err := svc.InvokeChatCompletion(llm.WithOperation(ctx, "generate"), userID, "deepseek-chat", req); err == nil {
+		t.Fatalf("expected provider error")
+	}
+
+	var records []modelusage.Record
+	if err := db.Order("id").Find(&records).Error; err != nil {
+		t.Fatalf("list records: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 ledger records, got %d", len(records))
+	}
+	first := records[0]
+	if first.CredentialID == nil || *first.CredentialID != cred.ID || first.Provider != "deepseek" || first.Operation != "generate" || !first.Success {
+		t.Fatalf("unexpected first record: %+v", first)
+	}
+	if first.PromptTokens != 100 || first.CompletionTokens != 40 || first.CachedTokens != 30 || first.ReasoningTokens != 10 || first.TotalTokens != 140 {
+		t.Fatalf("unexpected token counts: %+v", first)
+	}
+	if records[2].Success || records[2].ErrorKind != "context_too_long" {
+		t.Fatalf("unexpected failure record: %+v", records[2])
+	}
+
+	usage, err := svc.Usage(ctx, userID, cred.ID, 7)
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if len(usage.Daily) != 7 {
+		t.Fatalf("expected 7 daily buckets, got %d", len(usage.Daily))
+	}
+	if usage.Totals.Requests != 3 || usage.Totals.Failures != 1 || usage.Totals.TotalTokens != 280 {
+		t.Fatalf("unexpected totals: %+v", usage.Totals)
+	}
+	today := usage.Daily[len(usage.Daily)-1]
+	if today.Operations["generate"].Requests != 2 || today.Operations["interpret"].TotalTokens != 140 {
+		t.Fatalf("unexpected operation breakdown: %+v", today.Operations)
+	}
+
+	if _, err := svc.Usage(ctx, userID, cred.ID, modelsvc.MaxUsageDays+1); !errors.Is(err, modelsvc.ErrInvalidUsageRange) {
+		t.Fatalf("expected invalid range error, got %v", err)
+	}
+	if _, err := svc.Usage(ctx, userID, cred.ID+100, 7); !errors.Is(err, modelsvc.ErrCredentialNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestPriceCatalogEstimate(t *testing.T) {
+	catalog := modelsvc.DefaultPriceCatalog()
+	usage := &llm.Usage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000, CachedTokens: 400_000}
+
+	cost := catalog.Estimate("deepseek", usage, "deepseek-chat")
+	if cost == nil || cost.Currency != "USD" {
+		t.Fatalf("expected usd cost, got %+v", cost)
+	}
+	// 600k 普通输入 * 0.27 + 400k 缓存输入 * 0.07 + 1M 输出 * 1.10
+	if want := 0.6*0.27 + 0.4*0.07 + 1.10; math.Abs(cost.Amount-want) > 1e-9 {
+		t.Fatalf("expected %.6f, got %.6f", want, cost.Amount)
+	}
+
+	price, ok := catalog.Lookup("openai", "gpt-4o-mini-2024-07-18")
+	if !ok || price.Input != 0.15 {
+		t.Fatalf("expected gpt-4o-mini price by prefix, got %+v ok=%v", price, ok)
+	}
+	if _, ok := catalog.Lookup("deepseek", "unknown-model"); ok {
+		t.Fatalf("expected no price for unknown model")
+	}
+	if cost := catalog.Estimate("deepseek", nil, "deepseek-chat"); cost != nil {
+		t.Fatalf("expected nil cost without usage, got %+v", cost)
+	}
+}
+
+func TestModelServiceMonthlyBudget(t *testing.T) {
+	var calls atomic.Int32
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "budget",
+			"model":   "deepseek-chat",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "ok"}, "finish_reason": "stop"}},
+			"usage":   map[string]any{"prompt_tokens": 100000, "completion_tokens": 50000, "total_tokens": 150000},
+		})
+	}))
+	defer server.Close()
+
+	_, db, userRepo, userID := newTestModelService(t)
+	if err := db.AutoMigrate(&modelusage.Record{}); err != nil {
+		t.Fatalf("auto migrate usage: %v", err)
+	}
+	svc := modelsvc.NewServiceWithConfig(repository.NewModelCredentialRepository(db), userRepo, repository.NewModelUsageRepository(db), modelsvc.Config{
+		Retry: llm.RetryPolicy{MaxRetries: 0},
+	})
+	ctx := context.Background()
+
+	negative := -1.0
+	if _, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:      "deepseek",
+		ModelKey:      "deepseek-chat",
+		DisplayName:   "DeepSeek",
+		BaseURL:       server.URL,
+		APIKey:        "sk-test",
+		MonthlyBudget: &negative,
+	}); !errors.Is(err, modelsvc.ErrInvalidBudget) {
+		t.Fatalf("expected invalid budget error, got %v", err)
+	}
+
+	// 每次调用约 0.027 + 0.055 = 0.082 美元，两次后超过 0.1 的预算。
+	budget := 0.1
+	cred, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:      "deepseek",
+		ModelKey:      "deepseek-chat",
+		DisplayName:   "DeepSeek",
+		BaseURL:       server.URL,
+		APIKey:        "sk-test",
+		MonthlyBudget: &budget,
+	})
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+	req := llm.Request{Messages: []llm.Message{{Role: "user", Content: "ping"}}}
+
+	resp, err := svc.InvokeChatCompletion(ctx, userID, "deepseek-chat", req)
+	if err != nil {
+		t.Fatalf("first invoke: %v", err)
+	}
+	if resp.Cost == nil || math.Abs(resp.Cost.Amount-0.082) > 1e-9 || resp.Cost.Currency != "USD" {
+		t.Fatalf("unexpected cost: %+v", resp.Cost)
+	}
+	if _, err := svc.InvokeChatCompletion(ctx, userID, "deepseek-chat", req); err != nil {
+		t.Fatalf("second invoke: %v", err)
+	}
+	_, err = svc.InvokeChatCompletion(ctx, userID, "deepseek-chat", req)
+	if !errors.Is(err, modelsvc.ErrBudgetExceeded) {
+		t.Fatalf("expected budget exceeded, got %v", err)
+	}
+	if !modelsvc.IsRetryableError(err) {
+		t.Fatalf("expected budget error to allow fallback")
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected provider not to be called after budget exceeded, got %d calls", calls.Load())
+	}
+
+	list, err := svc.List(ctx, userID)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var listed *modelsvc.Credential
+	for i := range list {
+		if list[i].ID == cred.ID {
+			listed = &list[i]
+		}
+	}
+	if listed == nil || listed.MonthToDateCost == nil || math.Abs(*listed.MonthToDateCost-0.164) > 1e-9 {
+		t.Fatalf("unexpected month to date cost: %+v", listed)
+	}
+	if listed.Price == nil || listed.Price.Output != 1.10 || listed.Currency != "USD" {
+		t.Fatalf("unexpected price: %+v", listed.Price)
+	}
+
+	// 预算置 0 表示取消限制。
+	zero := 0.0
+	if _, err := svc.Update(ctx, userID, cred.ID, modelsvc.UpdateInput{MonthlyBudget: &zero}); err != nil {
+		t.Fatalf("clear budget: %v", err)
+	}
+	if _, err := svc.InvokeChatCompletion(ctx, userID, "deepseek-chat", req); err != nil {
+		t.Fatalf("invoke after clearing budget: %v", err)
+	}
+}
+
+func TestModelServiceRotateMasterKey(t *testing.T) {
+	svc, db, _, userID := newTestModelService(t)
+	ctx := context.Background()
+
+	// 其它用例可能改写过环境变量，先让密钥与当前环境保持一致。
+	oldKey := os.Getenv("MODEL_CREDENTIAL_MASTER_KEY")
+	if err := security.ReloadKeys(); err != nil {
+		t.Fatalf("reload keys: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Setenv("MODEL_CREDENTIAL_MASTER_KEY", oldKey)
+		os.Unsetenv("MODEL_CREDENTIAL_LEGACY_KEYS")
+		_ = security.ReloadKeys()
+	})
+	oldRaw, err := base64.StdEncoding.DecodeString(oldKey)
+	if err != nil {
+		t.Fatalf("decode master key: %v", err)
+	}
+
+	versioned, err := svc.Create(ctx, userID, modelsvc.CreateInput{Provider: "deepseek", ModelKey: "versioned", DisplayName: "Versioned", APIKey: "sk-versioned"})
+	if err != nil {
+		t.Fatalf("create versioned: %v", err)
+	}
+	legacy, err := svc.Create(ctx, userID, modelsvc.CreateInput{Provider: "deepseek", ModelKey: "legacy", DisplayName: "Legacy", APIKey: "sk-placeholder"})
+	if err != nil {
+		t.Fatalf("create legacy: %v", err)
+	}
+	broken, err := svc.Create(ctx, userID, modelsvc.CreateInput{Provider: "deepseek", ModelKey: "broken", DisplayName: "Broken", APIKey: "sk-placeholder"})
+	if err != nil {
+		t.Fatalf("create broken: %v", err)
+	}
+	// 模拟引入 key ID 之前写入的 nonce|ciphertext 旧格式。
+	block, err := aes.NewCipher(oldRaw)
+	if err != nil {
+		t.Fatalf("new cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("new gcm: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	legacyCipher := gcm.Seal(append([]byte{}, nonce...), nonce, []byte("sk-legacy"), nil)
+	if err := db.Model(&domain.UserModelCredential{}).Where("id = ?", legacy.ID).Update("api_key_cipher", legacyCipher).Error; err != nil {
+		t.Fatalf("write legacy cipher: %v", err)
+	}
+	if err := db.Model(&domain.UserModelCredential{}).Where("id = ?", broken.ID).Update("api_key_cipher", []byte("not a ciphertext at all")).Error; err != nil {
+		t.Fatalf("write broken cipher: %v", err)
+	}
+
+	newRaw := make([]byte, 32)
+	for i := range newRaw {
+		newRaw[i] = byte(200 - i)
+	}
+	os.Setenv("MODEL_CREDENTIAL_MASTER_KEY", base64.StdEncoding.EncodeToString(newRaw))
+	os.Setenv("MODEL_CREDENTIAL_LEGACY_KEYS", oldKey)
+	if err := security.ReloadKeys(); err != nil {
+		t.Fatalf("reload keys: %v", err)
+	}
+
+	dry, err := svc.RotateMasterKey(ctx, modelsvc.KeyRotationOptions{BatchSize: 2, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if dry.Scanned != 3 || dry.Rotated != 2 || len(dry.Failed) != 1 || dry.Failed[0] != broken.ID {
+		t.Fatalf("unexpected dry run report: %+v", dry)
+	}
+	var stored domain.UserModelCredential
+	if err := db.First(&stored, legacy.ID).Error; err != nil {
+		t.Fatalf("load legacy: %v", err)
+	}
+	if string(stored.APIKeyCipher) != string(legacyCipher) {
+		t.Fatalf("dry run must not rewrite ciphertext")
+	}
+
+	report, err := svc.RotateMasterKey(ctx, modelsvc.KeyRotationOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if report.PrimaryKeyID != security.KeyID(newRaw) || report.Rotated != 2 || report.UpToDate != 0 || len(report.Failed) != 1 {
+		t.Fatalf("unexpected rotation report: %+v", report)
+	}
+	again, err := svc.RotateMasterKey(ctx, modelsvc.KeyRotationOptions{})
+	if err != nil {
+		t.Fatalf("rotate again: %v", err)
+	}
+	if again.Rotated != 0 || again.UpToDate != 2 {
+		t.Fatalf("expected rotation to be idempotent: %+v", again)
+	}
+
+	// 移除旧密钥后，轮换过的凭据仍可解密。
+	os.Unsetenv("MODEL_CREDENTIAL_LEGACY_KEYS")
+	if err := security.ReloadKeys(); err != nil {
+		t.Fatalf("reload keys: %v", err)
+	}
+	expected := map[uint]string{versioned.ID: "sk-versioned", legacy.ID: "sk-legacy"}
+	for id, want := range expected {
+		var entity domain.UserModelCredential
+		if err := db.First(&entity, id).Error; err != nil {
+			t.Fatalf("load credential %d: %v", id, err)
+		}
+		plain, err := security.Decrypt(entity.APIKeyCipher)
+		if err != nil {
+			t.Fatalf("decrypt credential %d: %v", id, err)
+		}
+		if string(plain) != want {
+			t.Fatalf("expected %q, got %q", want, plain)
+		}
+		if stale, _ := security.NeedsRotation(entity.APIKeyCipher); stale {
+			t.Fatalf("credential %d should be encrypted with the new primary key", id)
+		}
+	}
+
+	os.Setenv("MODEL_CREDENTIAL_MASTER_KEY", oldKey)
+	if err := security.ReloadKeys(); err != nil {
+		t.Fatalf("reload keys: %v", err)
+	}
+	if err := db.First(&stored, legacy.ID).Error; err != nil {
+		t.Fatalf("load legacy: %v", err)
+	}
+	if _, err := security.Decrypt(stored.APIKeyCipher); !errors.Is(err, security.ErrUnknownKeyID) {
+		t.Fatalf("expected unknown key id error, got %v", err)
+	}
+}
+
+func TestModelServiceCatalog(t *testing.T) {
+	var hits atomic.Int32
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing/models" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodGet || r.URL.Path != "/models" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Fatalf("unexpected authorization header: %q", got)
+		}
+		hits.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"object": "list",
+			"data": []any{
+				map[string]any{"id": "qwen2.5-7b-instruct", "object": "model", "owned_by": "vllm", "max_model_len": 32768},
+				map[string]any{"id": "deepseek-chat", "object": "model", "owned_by": "deepseek"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	svc, _, _, userID := newTestModelService(t)
+	ctx := context.Background()
+	if _, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:      "openai-compatible",
+		ModelKey:      "deepseek-chta",
+		DisplayName:   "Typo",
+		BaseURL:       server.URL,
+		APIKey:        "sk-test",
+		ValidateModel: true,
+	}); !errors.Is(err, modelsvc.ErrModelUnavailable) {
+		t.Fatalf("expected model unavailable error, got %v", err)
+	}
+	cred, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:      "openai-compatible",
+		ModelKey:      "deepseek-chat",
+		DisplayName:   "Gateway",
+		BaseURL:       server.URL,
+		APIKey:        "sk-test",
+		ValidateModel: true,
+	})
+	if err != nil {
+		t.Fatalf("create with validation: %v", err)
+	}
+
+	catalog, err := svc.Catalog(ctx, userID, cred.ID, false)
+	if err != nil {
+		t.Fatalf("catalog: %v", err)
+	}
+	if !catalog.Cached || hits.Load() != 2 {
+		t.Fatalf("expected catalog fetched during validation to be cached, cached=%v hits=%d", catalog.Cached, hits.Load())
+	}
+	if len(catalog.Models) != 2 || catalog.Models[0].ID != "deepseek-chat" {
+		t.Fatalf("unexpected models: %+v", catalog.Models)
+	}
+	known := catalog.Models[0]
+	if known.ContextWindow != 128000 || len(known.Capabilities) == 0 {
+		t.Fatalf("expected known model metadata, got %+v", known)
+	}
+	if catalog.Models[1].ContextWindow != 32768 || catalog.Models[1].OwnedBy != "vllm" {
+		t.Fatalf("expected provider reported context window, got %+v", catalog.Models[1])
+	}
+
+	refreshed, err := svc.Catalog(ctx, userID, cred.ID, true)
+	if err != nil {
+		t.Fatalf("refresh catalog: %v", err)
+	}
+	if refreshed.Cached || hits.Load() != 3 {
+		t.Fatalf("expected refresh to bypass cache, cached=%v hits=%d", refreshed.Cached, hits.Load())
+	}
+
+	missingURL := server.URL + "/missing"
+	if _, err := svc.Update(ctx, userID, cred.ID, modelsvc.UpdateInput{BaseURL: &missingURL, ValidateModel: true}); !errors.Is(err, modelsvc.ErrCatalogUnsupported) {
+		t.Fatalf("expected catalog unsupported error, got %v", err)
+	}
+	if _, err := svc.Catalog(ctx, userID, cred.ID+100, false); !errors.Is(err, modelsvc.ErrCredentialNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"electron-go-app/backend/internal/domain/llm"
+	modelsvc "electron-go-app/backend/internal/service/model"
+)
+
+func TestInvokeOllamaChatCompletion(t *testing.T) {
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Fatalf("expected no authorization header, got %q", got)
+		}
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if payload["model"] != "qwen2.5:7b" {
+			t.Fatalf("expected model qwen2.5:7b, got %v", payload["model"])
+		}
+		if payload["stream"] != false {
+			t.Fatalf("expected stream=false, got %v", payload["stream"])
+		}
+		if payload["format"] != "json" {
+			t.Fatalf("expected json format, got %v", payload["format"])
+		}
+		if payload["keep_alive"] != "5m" {
+			t.Fatalf("expected keep_alive to stay top-level, got %v", payload["keep_alive"])
+		}
+		options, _ := payload["options"].(map[string]any)
+		if options["temperature"] != 0.4 || options["top_p"] != 0.8 || options["num_predict"] != float64(256) {
+			t.Fatalf("unexpected sampling options: %v", options)
+		}
+		if options["num_ctx"] != float64(8192) {
+			t.Fatalf("expected extra config to land in options, got %v", options)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"model":             "qwen2.5:7b",
+			"created_at":        "2025-10-20T08:00:00Z",
+			"message":           map[string]any{"role": "assistant", "content": "{\"ok\":true}"},
+			"done":              true,
+			"done_reason":       "stop",
+			"prompt_eval_count": 12,
+			"eval_count":        4,
+		})
+	}))
+	defer server.Close()
+
+	svc, _, _, userID := newTestModelService(t)
+	_, err := svc.Create(context.Background(), userID, modelsvc.CreateInput{
+		Provider:    "ollama",
+		ModelKey:    "qwen2.5:7b",
+		DisplayName: "Local Qwen",
+		BaseURL:     server.URL,
+		ExtraConfig: map[string]any{
+			"keep_alive": "5m",
+			"num_ctx":    8192,
+		},
+	})
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+
+	resp, err := svc.InvokeChatCompletion(context.Background(), userID, "qwen2.5:7b", llm.Request{
+		Messages:       []llm.Message{{Role: "user", Content: "ping"}},
+		Temperature:    0.4,
+		TopP:           0.8,
+		MaxTokens:      256,
+		ResponseFormat: map[string]any{"type": "json_object"},
+	})
+	if err != nil {
+		t.Fatalf("invoke ollama: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "{\"ok\":true}" {
+		t.Fatalf("unexpected choices: %+v", resp.Choices)
+	}
+	if resp.Usage == nil || resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 4 || resp.Usage.TotalTokens != 16 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestOllamaTestConnectionRequiresPulledModel(t *testing.T) {
+	chatCalled := false
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"models": []any{map[string]any{"name": "llama3.1:latest", "model": "llama3.1:latest"}},
+			})
+		case "/api/chat":
+			chatCalled = true
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"model":   "llama3.1:latest",
+				"message": map[string]any{"role": "assistant", "content": "pong"},
+				"done":    true,
+			})
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	svc, _, _, userID := newTestModelService(t)
+	missing, err := svc.Create(context.Background(), userID, modelsvc.CreateInput{
+		Provider:    "ollama",
+		ModelKey:    "mistral",
+		DisplayName: "Mistral",
+		BaseURL:     server.URL,
+	})
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+	_, err = svc.TestConnection(context.Background(), userID, missing.ID, llm.Request{
+		Messages: []llm.Message{{Role: "user", Content: "ping"}},
+	})
+	if !errors.Is(err, modelsvc.ErrModelUnavailable) {
+		t.Fatalf("expected ErrModelUnavailable, got %v", err)
+	}
+	if chatCalled {
+		t.Fatalf("chat should not be called when the model is missing")
+	}
+
+	installed, err := svc.Create(context.Background(), userID, modelsvc.CreateInput{
+		Provider:    "ollama",
+		ModelKey:    "llama3.1",
+		DisplayName: "Llama",
+		BaseURL:     server.URL + "/api",
+	})
+	if err != nil {
+		t.Fatalf("create credential: %v", err)
+	}
+	resp, err := svc.TestConnection(context.Background(), userID, installed.ID, llm.Request{
+		Messages: []llm.Message{{Role: "user", Content: "ping"}},
+	})
+	if err != nil {
+		t.Fatalf("test connection: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "pong" {
+		t.Fatalf("unexpected choices: %+v", resp.Choices)
+	}
+}
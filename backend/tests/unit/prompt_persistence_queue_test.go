@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+	promptinfra "electron-go-app/backend/internal/infra/prompt"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// TestPersistenceQueueAckRetryDeadLetter 验证 Redis 落库队列在 Ack 前保留任务、失败后按退避重试、用尽次数后转入死信，并能回收崩溃 worker 的任务。
+func TestPersistenceQueueAckRetryDeadLetter(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("start miniredis: %v", err)
+	}
+	defer server.Close()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	policy := promptdomain.PersistenceRetryPolicy{MaxAttempts: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	queue := promptinfra.NewPersistenceQueue(client, "test:queue",
+		promptinfra.WithQueueRetryPolicy(policy),
+		promptinfra.WithQueueConsumer("worker-a"),
+	)
+	if _, err := queue.Reclaim(ctx); err != nil {
+		t.Fatalf("initial reclaim: %v", err)
+	}
+
+	taskID, err := queue.Enqueue(ctx, promptdomain.PersistenceTask{UserID: 1, Topic: "publish", Publish: true})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	task, err := queue.BlockingPop(ctx, 100*time.Millisecond)
+	if err != nil || task.TaskID != taskID || task.Receipt == "" {
+		t.Fatalf("expected task with receipt, got %+v err=%v", task, err)
+	}
+	if dead, err := queue.Retry(ctx, task, errors.New("db down")); err != nil || dead {
+		t.Fatalf("expected retry to be scheduled, dead=%v err=%v", dead, err)
+	}
+	if _, err := queue.BlockingPop(ctx, 10*time.Millisecond); !errors.Is(err, redis.Nil) {
+		t.Fatalf("expected retry to wait for backoff, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	retried, err := queue.BlockingPop(ctx, 100*time.Millisecond)
+	if err != nil || retried.TaskID != taskID || retried.Attempts != 1 || retried.LastError != "db down" {
+		t.Fatalf("expected retried task, got %+v err=%v", retried, err)
+	}
+	if dead, err := queue.Retry(ctx, retried, errors.New("db down again")); err != nil || !dead {
+		t.Fatalf("expected task to be dead-lettered, dead=%v err=%v", dead, err)
+	}
+	deadLetters, err := client.LRange(ctx, "test:queue:dead", 0, -1).Result()
+	if err != nil || len(deadLetters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %v err=%v", deadLetters, err)
+	}
+	var deadTask promptdomain.PersistenceTask
+	if err := json.Unmarshal([]byte(deadLetters[0]), &deadTask); err != nil || deadTask.TaskID != taskID || deadTask.Attempts != 2 || !deadTask.Publish {
+		t.Fatalf("unexpected dead letter payload: %+v err=%v", deadTask, err)
+	}
+
+	// worker-a 取出任务后崩溃，worker-b 启动时回收空闲超时的 pending 任务。
+	if _, err := queue.Enqueue(ctx, promptdomain.PersistenceTask{UserID: 1, Topic: "crashed"}); err != nil {
+		t.Fatalf("enqueue crashed: %v", err)
+	}
+	if _, err := queue.BlockingPop(ctx, 100*time.Millisecond); err != nil {
+		t.Fatalf("pop crashed: %v", err)
+	}
+	other := promptinfra.NewPersistenceQueue(client, "test:queue",
+		promptinfra.WithQueueConsumer("worker-b"),
+		promptinfra.WithQueueClaimIdle(time.Millisecond),
+	)
+	time.Sleep(5 * time.Millisecond)
+	if reclaimed, err := other.Reclaim(ctx); err != nil || reclaimed != 1 {
+		t.Fatalf("expected 1 reclaimed task, got %d err=%v", reclaimed, err)
+	}
+	recovered, err := other.BlockingPop(ctx, 100*time.Millisecond)
+	if err != nil || recovered.Topic != "crashed" || recovered.Attempts != 1 || recovered.LastError != promptdomain.ErrPersistenceTaskStalled.Error() {
+		t.Fatalf("expected reclaimed task with a counted attempt, got %+v err=%v", recovered, err)
+	}
+	if err := other.Ack(ctx, recovered); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	pending, err := client.XPending(ctx, "test:queue:stream", "persistence-workers").Result()
+	if err != nil || pending.Count != 0 {
+		t.Fatalf("expected no pending tasks after ack, got %+v err=%v", pending, err)
+	}
+
+	// 反复卡住 worker 的任务每次回收都计入处理次数，用尽后转入死信，不再回到 Stream。
+	stalling := promptinfra.NewPersistenceQueue(client, "test:queue",
+		promptinfra.WithQueueRetryPolicy(policy),
+		promptinfra.WithQueueConsumer("worker-c"),
+		promptinfra.WithQueueClaimIdle(time.Millisecond),
+	)
+	if _, err := stalling.Enqueue(ctx, promptdomain.PersistenceTask{UserID: 1, Topic: "stuck"}); err != nil {
+		t.Fatalf("enqueue stuck: %v", err)
+	}
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		stuck, err := stalling.BlockingPop(ctx, 100*time.Millisecond)
+		if err != nil || stuck.Topic != "stuck" || stuck.Attempts != attempt-1 {
+			t.Fatalf("expected stuck task on attempt %d, got %+v err=%v", attempt, stuck, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if reclaimed, err := stalling.Reclaim(ctx); err != nil || reclaimed != 1 {
+			t.Fatalf("expected stuck task to be reclaimed, got %d err=%v", reclaimed, err)
+		}
+	}
+	if _, err := stalling.BlockingPop(ctx, 10*time.Millisecond); !errors.Is(err, redis.Nil) {
+		t.Fatalf("expected exhausted stuck task to leave the stream, got %v", err)
+	}
+	deadLetters, err = client.LRange(ctx, "test:queue:dead", 0, -1).Result()
+	if err != nil || len(deadLetters) != 2 {
+		t.Fatalf("expected stuck task in dead letters, got %v err=%v", deadLetters, err)
+	}
+	if err := json.Unmarshal([]byte(deadLetters[1]), &deadTask); err != nil || deadTask.Topic != "stuck" || deadTask.Attempts != policy.MaxAttempts {
+		t.Fatalf("unexpected stuck dead letter: %+v err=%v", deadTask, err)
+	}
+}
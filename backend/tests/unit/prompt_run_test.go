@@ -0,0 +1,191 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"electron-go-app/backend/internal/domain/llm"
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+	"electron-go-app/backend/internal/repository"
+	promptsvc "electron-go-app/backend/internal/service/prompt"
+
+	"gorm.io/gorm"
+)
+
+// setupPromptRunService 构造带试运行记录仓储的 Prompt 服务，复用 setupPromptService 的 SQLite 库。
+func setupPromptRunService(t *testing.T) (*promptsvc.Service, *fakeModelInvoker, *gorm.DB) {
+	t.Helper()
+	_, promptRepo, keywordRepo, db, _ := setupPromptService(t)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	if err := db.AutoMigrate(&promptdomain.PromptRun{}); err != nil {
+		t.Fatalf("auto migrate prompt runs: %v", err)
+	}
+	modelStub := &fakeModelInvoker{}
+	service, err := promptsvc.NewServiceWithConfig(promptRepo, keywordRepo, modelStub, nil, nil, nil, nil, nil, promptsvc.Config{
+		KeywordLimit:        promptsvc.DefaultKeywordLimit,
+		KeywordMaxLength:    promptsvc.DefaultKeywordMaxLength,
+		TagLimit:            promptsvc.DefaultTagLimit,
+		TagMaxLength:        promptsvc.DefaultTagMaxLength,
+		DefaultListPageSize: 20,
+		MaxListPageSize:     100,
+		VersionRetention:    promptsvc.DefaultVersionRetentionLimit,
+		Runs:                repository.NewPromptRunRepository(db),
+	})
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+	return service, modelStub, db
+}
+
+// TestPromptServiceRunPrompt 验证试运行会渲染变量后调用模型、记录用量，缺失必填变量时拒绝调用，并可按版本查询运行记录。
+func TestPromptServiceRunPrompt(t *testing.T) {
+	service, modelStub, db := setupPromptRunService(t)
+	ctx := context.Background()
+
+	saved, err := service.Save(ctx, promptsvc.SaveInput{
+		UserID:    1,
+		Topic:     "翻译助手",
+		Body:      "把下面的内容翻译成{{language}}：{{text}}",
+		Model:     "deepseek-chat",
+		Status:    promptdomain.PromptStatusDraft,
+		Variables: []promptdomain.PromptVariable{{Name: "text", Required: true}, {Name: "language", Default: "英文"}},
+	})
+	if err != nil {
+		t.Fatalf("save prompt: %v", err)
+	}
+
+	_, err = service.RunPrompt(ctx, promptsvc.RunPromptInput{UserID: 1, PromptID: saved.PromptID})
+	var missingErr *promptsvc.PromptVariablesMissingError
+	if !errors.As(err, &missingErr) || len(missingErr.Missing) != 1 || missingErr.Missing[0] != "text" {
+		t.Fatalf("expected missing text variable, got %v", err)
+	}
+	if len(modelStub.requests) != 0 {
+		t.Fatalf("model should not be invoked when variables are missing")
+	}
+
+	modelStub.responses = []llm.Response{{
+		Model:   "deepseek-chat-v3",
+		Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "Hello"}}},
+		Usage:   &llm.Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15},
+		Cost:    &llm.Cost{Amount: 0.002, Currency: "CNY"},
+	}}
+	run, err := service.RunPrompt(ctx, promptsvc.RunPromptInput{
+		UserID:            1,
+		PromptID:          saved.PromptID,
+		Values:            map[string]any{"text": "你好"},
+		GenerationProfile: &promptdomain.GenerationProfile{Temperature: 0.2, MaxOutputTokens: 256},
+	})
+	if err != nil {
+		t.Fatalf("run prompt: %v", err)
+	}
+	if run.Output != "Hello" || run.Input != "把下面的内容翻译成英文：你好" || run.ModelKey != "deepseek-chat" || run.Model != "deepseek-chat-v3" {
+		t.Fatalf("unexpected run result: %+v", run)
+	}
+	if run.Usage.TotalTokens != 15 || run.Cost == nil || run.Cost.Amount != 0.002 || run.Status != promptdomain.PromptRunStatusSucceeded {
+		t.Fatalf("expected usage and cost recorded, got %+v", run)
+	}
+	if req := modelStub.requests[0]; len(req.Messages) != 1 || req.Messages[0].Content != run.Input || req.Temperature != 0.2 || req.MaxTokens != 256 {
+		t.Fatalf("unexpected model request: %+v", req)
+	}
+
+	modelStub.err = errors.New("upstream unavailable")
+	if _, err := service.RunPrompt(ctx, promptsvc.RunPromptInput{
+		UserID:   1,
+		PromptID: saved.PromptID,
+		ModelKey: "gpt-4o",
+		Values:   map[string]any{"text": "再见", "language": "日文"},
+	}); !errors.Is(err, promptsvc.ErrModelInvocationFailed) {
+		t.Fatalf("expected model invocation failure, got %v", err)
+	}
+
+	runs, err := service.ListPromptRuns(ctx, promptsvc.ListPromptRunsInput{UserID: 1, PromptID: saved.PromptID})
+	if err != nil {
+		t.Fatalf("list prompt runs: %v", err)
+	}
+	if runs.Total != 2 || len(runs.Items) != 2 {
+		t.Fatalf("expected two recorded runs, got %+v", runs)
+	}
+	failed := runs.Items[0]
+	if failed.Status != promptdomain.PromptRunStatusFailed || failed.ModelKey != "gpt-4o" || failed.Values["language"] != "日文" || failed.Error == "" {
+		t.Fatalf("expected latest failed run first, got %+v", failed)
+	}
+	if runs.Items[1].GenerationProfile.MaxOutputTokens != 256 || runs.Items[1].Usage.PromptTokens != 12 {
+		t.Fatalf("expected stored profile and usage, got %+v", runs.Items[1])
+	}
+
+	versionOne := 1
+	filtered, err := service.ListPromptRuns(ctx, promptsvc.ListPromptRunsInput{UserID: 1, PromptID: saved.PromptID, VersionNo: &versionOne})
+	if err != nil || filtered.Total != 0 {
+		t.Fatalf("expected no runs for version 1, got %+v err=%v", filtered, err)
+	}
+	if _, err := service.ListPromptRuns(ctx, promptsvc.ListPromptRunsInput{UserID: 2, PromptID: saved.PromptID}); !errors.Is(err, promptsvc.ErrPromptNotFound) {
+		t.Fatalf("expected other users to be rejected, got %v", err)
+	}
+	if _, err := service.RunPrompt(ctx, promptsvc.RunPromptInput{UserID: 1, PromptID: saved.PromptID, VersionNo: 3}); !errors.Is(err, promptsvc.ErrPromptVersionNotFound) {
+		t.Fatalf("expected missing version error, got %v", err)
+	}
+
+	if err := service.DeletePrompt(ctx, promptsvc.DeletePromptInput{UserID: 1, PromptID: saved.PromptID}); err != nil {
+		t.Fatalf("delete prompt: %v", err)
+	}
+	var remaining int64
+	if err := db.Model(&promptdomain.PromptRun{}).Count(&remaining).Error; err != nil || remaining != 0 {
+		t.Fatalf("expected runs removed with prompt, got %d err=%v", remaining, err)
+	}
+}
+
+// TestPromptServiceRunPromptVersionModel 验证运行历史版本且未指定模型时使用该版本保存的模型，而不是 Prompt 当前的模型。
+func TestPromptServiceRunPromptVersionModel(t *testing.T) {
+	service, modelStub, _ := setupPromptRunService(t)
+	ctx := context.Background()
+
+	publish := promptsvc.SaveInput{
+		UserID:                   1,
+		Topic:                    "摘要助手",
+		Body:                     "请总结：{{text}}",
+		Instructions:             "三句话以内",
+		Model:                    "deepseek-chat",
+		Publish:                  true,
+		PositiveKeywords:         []promptsvc.KeywordItem{{Word: "要点"}},
+		NegativeKeywords:         []promptsvc.KeywordItem{{Word: "冗长"}},
+		Tags:                     []string{"摘要"},
+		EnforcePublishValidation: true,
+	}
+	published, err := service.Save(ctx, publish)
+	if err != nil || published.Version != 1 {
+		t.Fatalf("publish prompt: %+v err=%v", published, err)
+	}
+	update := publish
+	update.PromptID = published.PromptID
+	update.Body = "请用要点列出：{{text}}"
+	update.Model = "gpt-4o"
+	update.Publish = false
+	update.Status = promptdomain.PromptStatusDraft
+	update.EnforcePublishValidation = false
+	if _, err := service.Save(ctx, update); err != nil {
+		t.Fatalf("update prompt model: %v", err)
+	}
+
+	modelStub.responses = []llm.Response{
+		{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "v1"}}}},
+		{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "current"}}}},
+	}
+	versioned, err := service.RunPrompt(ctx, promptsvc.RunPromptInput{UserID: 1, PromptID: published.PromptID, VersionNo: 1, Values: map[string]any{"text": "会议记录"}})
+	if err != nil {
+		t.Fatalf("run version 1: %v", err)
+	}
+	if versioned.ModelKey != "deepseek-chat" || modelStub.requests[0].Model != "deepseek-chat" || versioned.Input != "请总结：会议记录" {
+		t.Fatalf("expected version 1 to run on its own model, got %+v", versioned)
+	}
+	current, err := service.RunPrompt(ctx, promptsvc.RunPromptInput{UserID: 1, PromptID: published.PromptID, Values: map[string]any{"text": "会议记录"}})
+	if err != nil {
+		t.Fatalf("run current body: %v", err)
+	}
+	if current.ModelKey != "gpt-4o" || current.Input != "请用要点列出：会议记录" {
+		t.Fatalf("expected current body to run on the current model, got %+v", current)
+	}
+}
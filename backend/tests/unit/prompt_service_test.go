@@ -8,11 +8,15 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"electron-go-app/backend/internal/domain/llm"
+	"electron-go-app/backend/internal/domain/modelcache"
 	promptdomain "electron-go-app/backend/internal/domain/prompt"
-	deepseek "electron-go-app/backend/internal/infra/model/deepseek"
+	"electron-go-app/backend/internal/infra/model/deepseek"
+	"electron-go-app/backend/internal/infra/ratelimit"
 	"electron-go-app/backend/internal/repository"
 	modelsvc "electron-go-app/backend/internal/service/model"
 	promptsvc "electron-go-app/backend/internal/service/prompt"
@@ -23,15 +27,15 @@ import (
 
 // fakeModelInvoker 用于在单元测试中模拟大模型响应。
 type fakeModelInvoker struct {
-	responses []deepseek.ChatCompletionResponse
+	responses []llm.Response
 	err       error
-	requests  []deepseek.ChatCompletionRequest
+	requests  []llm.Request
 }
 
-func (f *fakeModelInvoker) InvokeChatCompletion(_ context.Context, _ uint, _ string, req deepseek.ChatCompletionRequest) (deepseek.ChatCompletionResponse, error) {
+func (f *fakeModelInvoker) InvokeChatCompletion(_ context.Context, _ uint, _ string, req llm.Request) (llm.Response, error) {
 	f.requests = append(f.requests, req)
 	if len(f.responses) == 0 {
-		return deepseek.ChatCompletionResponse{}, f.err
+		return llm.Response{}, f.err
 	}
 	resp := f.responses[0]
 	f.responses = f.responses[1:]
@@ -46,12 +50,28 @@ func (f *fakeWorkspaceStore) CreateOrReplace(context.Context, uint, promptdomain
 	return "", nil
 }
 
-func (f *fakeWorkspaceStore) MergeKeywords(context.Context, uint, string, []promptdomain.WorkspaceKeyword) error {
-	return nil
+func (f *fakeWorkspaceStore) MergeKeywords(context.Context, uint, string, int64, []promptdomain.WorkspaceKeyword) (int64, error) {
+	return 0, nil
 }
 
-func (f *fakeWorkspaceStore) UpdateDraftBody(context.Context, uint, string, string) error {
-	return nil
+func (f *fakeWorkspaceStore) ReplaceKeywords(context.Context, uint, string, int64, []promptdomain.WorkspaceKeyword, []promptdomain.WorkspaceKeyword) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeWorkspaceStore) UpdateDraftBody(context.Context, uint, string, int64, string) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeWorkspaceStore) UpdateDraft(context.Context, uint, string, int64, string, map[string]string) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeWorkspaceStore) Undo(context.Context, uint, string, int64) (int64, error) {
+	return 0, promptdomain.ErrWorkspaceHistoryEmpty
+}
+
+func (f *fakeWorkspaceStore) Redo(context.Context, uint, string, int64) (int64, error) {
+	return 0, promptdomain.ErrWorkspaceHistoryEmpty
 }
 
 func (f *fakeWorkspaceStore) Touch(context.Context, uint, string) error {
@@ -62,6 +82,10 @@ func (f *fakeWorkspaceStore) Snapshot(context.Context, uint, string) (promptdoma
 	return f.snapshot, nil
 }
 
+func (f *fakeWorkspaceStore) List(context.Context, uint) ([]promptdomain.WorkspaceSummary, error) {
+	return nil, nil
+}
+
 func (f *fakeWorkspaceStore) Delete(context.Context, uint, string) error {
 	return nil
 }
@@ -74,12 +98,12 @@ func (f *fakeWorkspaceStore) GetPromptMeta(context.Context, uint, string) (uint,
 	return 0, "", nil
 }
 
-func (f *fakeWorkspaceStore) RemoveKeyword(context.Context, uint, string, string, string) error {
-	return nil
+func (f *fakeWorkspaceStore) RemoveKeyword(context.Context, uint, string, int64, string, string) (int64, error) {
+	return 0, nil
 }
 
-func (f *fakeWorkspaceStore) SetAttributes(context.Context, uint, string, map[string]string) error {
-	return nil
+func (f *fakeWorkspaceStore) SetAttributes(context.Context, uint, string, int64, map[string]string) (int64, error) {
+	return 0, nil
 }
 
 func setupPromptService(t *testing.T) (*promptsvc.Service, *repository.PromptRepository, *repository.KeywordRepository, *gorm.DB, *fakeModelInvoker) {
@@ -136,7 +160,7 @@ func setupPromptServiceWithConfig(t *testing.T, cfg promptsvc.Config) (*promptsv
 	return service, promptRepo, keywordRepo, db, modelStub
 }
 
-func buildAuditResponse(t *testing.T, allowed bool, reason string) deepseek.ChatCompletionResponse {
+func buildAuditResponse(t *testing.T, allowed bool, reason string) llm.Response {
 	t.Helper()
 	payload := map[string]any{
 		"allowed": allowed,
@@ -146,10 +170,10 @@ func buildAuditResponse(t *testing.T, allowed bool, reason string) deepseek.Chat
 	if err != nil {
 		t.Fatalf("marshal audit payload: %v", err)
 	}
-	return deepseek.ChatCompletionResponse{
+	return llm.Response{
 		Model: "audit-model",
-		Choices: []deepseek.ChatCompletionChoice{
-			{Message: deepseek.ChatMessage{Role: "assistant", Content: string(raw)}},
+		Choices: []llm.Choice{
+			{Message: llm.Message{Role: "assistant", Content: string(raw)}},
 		},
 	}
 }
@@ -179,12 +203,12 @@ func TestPromptServiceInterpret(t *testing.T) {
 		"tags":         []string{"React", "面试", "React", "备考"},
 	}
 	content, _ := json.Marshal(payload)
-	modelStub.responses = []deepseek.ChatCompletionResponse{
+	modelStub.responses = []llm.Response{
 		buildAuditResponse(t, true, ""),
 		{
 			Model: "deepseek-chat",
-			Choices: []deepseek.ChatCompletionChoice{
-				{Message: deepseek.ChatMessage{Role: "assistant", Content: string(content)}},
+			Choices: []llm.Choice{
+				{Message: llm.Message{Role: "assistant", Content: string(content)}},
 			},
 		},
 	}
@@ -252,12 +276,12 @@ func TestPromptServiceInterpretInstructionsArray(t *testing.T) {
 		},
 	}
 	content, _ := json.Marshal(payload)
-	modelStub.responses = []deepseek.ChatCompletionResponse{
+	modelStub.responses = []llm.Response{
 		buildAuditResponse(t, true, ""),
 		{
 			Model: "deepseek-chat",
-			Choices: []deepseek.ChatCompletionChoice{
-				{Message: deepseek.ChatMessage{Role: "assistant", Content: string(content)}},
+			Choices: []llm.Choice{
+				{Message: llm.Message{Role: "assistant", Content: string(content)}},
 			},
 		},
 	}
@@ -290,6 +314,59 @@ func TestPromptServiceInterpretInstructionsArray(t *testing.T) {
 	}
 }
 
+// TestPromptServiceInterpretToolCall 验证解析与审核强制走函数调用，并从 tool_calls 参数中读取结构化结果。
+func TestPromptServiceInterpretToolCall(t *testing.T) {
+	service, _, _, db, modelStub := setupPromptService(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	toolResponse := func(name, arguments string) llm.Response {
+		return llm.Response{
+			Model: "deepseek-chat",
+			Choices: []llm.Choice{{
+				Message: llm.Message{
+					Role:      "assistant",
+					ToolCalls: []llm.ToolCall{{ID: "call_1", Type: "function", Function: llm.ToolCallFunction{Name: name, Arguments: arguments}}},
+				},
+				FinishReason: "tool_calls",
+			}},
+		}
+	}
+	modelStub.responses = []llm.Response{
+		toolResponse("submit_audit_verdict", `{"allowed":true}`),
+		toolResponse("submit_interpretation", `{"topic":"Go 并发","positive_keywords":[{"word":"goroutine","weight":5}],"negative_keywords":[],"instructions":"附带示例","confidence":0.9}`),
+	}
+
+	result, err := service.Interpret(context.Background(), promptsvc.InterpretInput{
+		UserID:      3,
+		Description: "讲解 Go 并发模型",
+		ModelKey:    "deepseek-chat",
+		Language:    "中文",
+	})
+	if err != nil {
+		t.Fatalf("Interpret returned error: %v", err)
+	}
+	if result.Topic != "Go 并发" || len(result.PositiveKeywords) != 1 || result.Instructions != "附带示例" {
+		t.Fatalf("unexpected interpretation: %+v", result)
+	}
+	if len(modelStub.requests) != 2 {
+		t.Fatalf("expected 2 model requests, got %d", len(modelStub.requests))
+	}
+	for idx, name := range []string{"submit_audit_verdict", "submit_interpretation"} {
+		req := modelStub.requests[idx]
+		if len(req.Tools) != 1 || req.Tools[0].Function.Name != name {
+			t.Fatalf("request %d: expected tool %s, got %+v", idx, name, req.Tools)
+		}
+		choice, _ := req.ToolChoice.(map[string]any)
+		function, _ := choice["function"].(map[string]any)
+		if function["name"] != name {
+			t.Fatalf("request %d: expected forced tool_choice %s, got %+v", idx, name, req.ToolChoice)
+		}
+	}
+}
+
 // TestPromptServiceExportPrompts 验证导出接口会生成本地文件并包含完整 Prompt 记录。
 func TestPromptServiceExportPrompts(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -763,11 +840,11 @@ func TestPromptServiceAugmentKeywords(t *testing.T) {
 		},
 	}
 	content, _ := json.Marshal(payload)
-	modelStub.responses = []deepseek.ChatCompletionResponse{
+	modelStub.responses = []llm.Response{
 		{
 			Model: "deepseek-chat",
-			Choices: []deepseek.ChatCompletionChoice{
-				{Message: deepseek.ChatMessage{Role: "assistant", Content: string(content)}},
+			Choices: []llm.Choice{
+				{Message: llm.Message{Role: "assistant", Content: string(content)}},
 			},
 		},
 	}
@@ -804,13 +881,13 @@ func TestPromptServiceGeneratePrompt(t *testing.T) {
 		sqlDB.Close()
 	}()
 
-	modelStub.responses = []deepseek.ChatCompletionResponse{
+	modelStub.responses = []llm.Response{
 		{
 			Model: "deepseek-chat",
-			Choices: []deepseek.ChatCompletionChoice{
-				{Message: deepseek.ChatMessage{Role: "assistant", Content: "这是准备 React 技术面试的 Prompt 正文"}},
+			Choices: []llm.Choice{
+				{Message: llm.Message{Role: "assistant", Content: "这是准备 React 技术面试的 Prompt 正文"}},
 			},
-			Usage: &deepseek.ChatCompletionUsage{
+			Usage: &llm.Usage{
 				PromptTokens:     10,
 				CompletionTokens: 20,
 				TotalTokens:      30,
@@ -852,7 +929,7 @@ func TestPromptServiceInterpretAuditReject(t *testing.T) {
 		sqlDB.Close()
 	}()
 
-	modelStub.responses = []deepseek.ChatCompletionResponse{
+	modelStub.responses = []llm.Response{
 		buildAuditResponse(t, false, "描述包含违规内容"),
 	}
 
@@ -873,6 +950,135 @@ func TestPromptServiceInterpretAuditReject(t *testing.T) {
 	}
 }
 
+// fakeCandidateModelInvoker 可被并发调用，按请求挂载的函数区分审核、评审与生成，生成结果随温度变化。
+type fakeCandidateModelInvoker struct {
+	mu       sync.Mutex
+	requests []llm.Request
+	scores   string
+}
+
+func (f *fakeCandidateModelInvoker) InvokeChatCompletion(_ context.Context, _ uint, _ string, req llm.Request) (llm.Response, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+	usage := &llm.Usage{PromptTokens: 6, CompletionTokens: 4, TotalTokens: 10}
+	toolCall := func(name, arguments string) llm.Response {
+		return llm.Response{
+			Model: "deepseek-chat",
+			Usage: usage,
+			Choices: []llm.Choice{{Message: llm.Message{
+				Role:      "assistant",
+				ToolCalls: []llm.ToolCall{{ID: "call", Type: "function", Function: llm.ToolCallFunction{Name: name, Arguments: arguments}}},
+			}}},
+		}
+	}
+	if len(req.Tools) > 0 {
+		switch req.Tools[0].Function.Name {
+		case "submit_audit_verdict":
+			return toolCall("submit_audit_verdict", `{"allowed":true}`), nil
+		case "submit_candidate_scores":
+			return toolCall("submit_candidate_scores", f.scores), nil
+		}
+	}
+	content := "围绕 React 准备面试题。"
+	if req.Temperature > 0.8 {
+		content = "围绕 React 与 Hooks 准备面试题，覆盖状态管理与性能优化，并给出示例答案。"
+	}
+	return llm.Response{
+		Model:   "deepseek-chat",
+		Usage:   usage,
+		Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: content}}},
+	}, nil
+}
+
+// TestPromptServiceGenerateCandidates 验证多候选以不同温度并发生成，可按启发式或评审模型排序，并把选中的候选作为正文返回。
+func TestPromptServiceGenerateCandidates(t *testing.T) {
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	invoker := &fakeCandidateModelInvoker{scores: `{"scores":[{"index":0,"score":9,"reason":"最简洁"},{"index":1,"score":5},{"index":2,"score":6}]}`}
+	service, err := promptsvc.NewServiceWithConfig(
+		repository.NewPromptRepository(db),
+		repository.NewKeywordRepository(db),
+		invoker,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		promptsvc.Config{KeywordLimit: promptsvc.DefaultKeywordLimit},
+	)
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+	input := promptsvc.GenerateInput{
+		UserID:   1,
+		Topic:    "React 面试",
+		ModelKey: "deepseek-chat",
+		PositiveKeywords: []promptsvc.KeywordItem{
+			{Word: "React", Weight: 5},
+			{Word: "Hooks", Weight: 4},
+		},
+		Candidates: 3,
+		RankBy:     promptsvc.CandidateRankHeuristic,
+	}
+
+	out, err := service.GeneratePrompt(context.Background(), input)
+	if err != nil {
+		t.Fatalf("GeneratePrompt error: %v", err)
+	}
+	if len(out.Candidates) != 3 {
+		t.Fatalf("expected 3 candidates, got %d", len(out.Candidates))
+	}
+	temperatures := []float64{out.Candidates[0].Temperature, out.Candidates[1].Temperature, out.Candidates[2].Temperature}
+	if !reflect.DeepEqual(temperatures, []float64{0.5, 0.7, 0.9}) {
+		t.Fatalf("unexpected candidate temperatures: %v", temperatures)
+	}
+	if out.SelectedIndex != 2 || out.RankedBy != promptsvc.CandidateRankHeuristic {
+		t.Fatalf("expected heuristic to pick the candidate covering all keywords, got index %d ranked by %q", out.SelectedIndex, out.RankedBy)
+	}
+	if out.Prompt != out.Candidates[2].Prompt || !strings.Contains(out.Prompt, "Hooks") {
+		t.Fatalf("unexpected selected prompt: %s", out.Prompt)
+	}
+	if out.Candidates[2].Score == nil || *out.Candidates[2].Score <= *out.Candidates[0].Score {
+		t.Fatalf("expected higher score for full coverage candidate: %+v", out.Candidates)
+	}
+	if out.Usage == nil || out.Usage.TotalTokens != 30 {
+		t.Fatalf("expected usage summed across candidates, got %+v", out.Usage)
+	}
+
+	input.RankBy = promptsvc.CandidateRankJudge
+	out, err = service.GeneratePrompt(context.Background(), input)
+	if err != nil {
+		t.Fatalf("GeneratePrompt with judge error: %v", err)
+	}
+	if out.SelectedIndex != 0 || out.RankedBy != promptsvc.CandidateRankJudge {
+		t.Fatalf("expected judge to pick candidate 0, got index %d ranked by %q", out.SelectedIndex, out.RankedBy)
+	}
+	if out.Candidates[0].Score == nil || *out.Candidates[0].Score != 0.9 || out.Candidates[0].ScoreReason != "最简洁" {
+		t.Fatalf("unexpected judge score: %+v", out.Candidates[0])
+	}
+	if out.Usage == nil || out.Usage.TotalTokens != 40 {
+		t.Fatalf("expected usage to include judge call, got %+v", out.Usage)
+	}
+
+	input.Candidates = promptsvc.MaxGenerateCandidates + 1
+	if _, err := service.GeneratePrompt(context.Background(), input); !errors.Is(err, promptsvc.ErrInvalidCandidateCount) {
+		t.Fatalf("expected ErrInvalidCandidateCount, got %v", err)
+	}
+	input.Candidates = 2
+	if _, err := service.GeneratePromptStream(context.Background(), input, func(string) error { return nil }); !errors.Is(err, promptsvc.ErrCandidatesNotStreamable) {
+		t.Fatalf("expected ErrCandidatesNotStreamable, got %v", err)
+	}
+}
+
 // TestPromptServiceGeneratePromptAuditReject 验证生成后的 Prompt 审核未通过会返回错误。
 func TestPromptServiceGeneratePromptAuditReject(t *testing.T) {
 	service, _, _, db, modelStub := setupPromptService(t)
@@ -881,11 +1087,11 @@ func TestPromptServiceGeneratePromptAuditReject(t *testing.T) {
 		sqlDB.Close()
 	}()
 
-	modelStub.responses = []deepseek.ChatCompletionResponse{
+	modelStub.responses = []llm.Response{
 		{
 			Model: "deepseek-chat",
-			Choices: []deepseek.ChatCompletionChoice{
-				{Message: deepseek.ChatMessage{Role: "assistant", Content: "这是违规 Prompt"}},
+			Choices: []llm.Choice{
+				{Message: llm.Message{Role: "assistant", Content: "这是违规 Prompt"}},
 			},
 		},
 		buildAuditResponse(t, false, "生成内容涉及违禁信息"),
@@ -908,6 +1114,132 @@ func TestPromptServiceGeneratePromptAuditReject(t *testing.T) {
 	}
 }
 
+// fakeStreamingModelInvoker 在 fakeModelInvoker 基础上按字符拆分正文，模拟流式增量推送。
+type fakeStreamingModelInvoker struct {
+	*fakeModelInvoker
+	streamCalls int
+}
+
+func (f *fakeStreamingModelInvoker) InvokeChatCompletionStream(ctx context.Context, userID uint, modelKey string, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	f.streamCalls++
+	resp, err := f.InvokeChatCompletion(ctx, userID, modelKey, req)
+	if err != nil {
+		return resp, err
+	}
+	for _, r := range resp.Choices[0].Message.Content {
+		if err := onDelta(string(r)); err != nil {
+			return llm.Response{}, err
+		}
+	}
+	return resp, nil
+}
+
+// TestPromptServiceGeneratePromptStream 验证流式生成会逐段推送增量，并在拼装后的正文上执行审核。
+func TestPromptServiceGeneratePromptStream(t *testing.T) {
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	streamStub := &fakeStreamingModelInvoker{fakeModelInvoker: &fakeModelInvoker{}}
+	service, err := promptsvc.NewServiceWithConfig(
+		repository.NewPromptRepository(db),
+		repository.NewKeywordRepository(db),
+		streamStub,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		promptsvc.Config{KeywordLimit: promptsvc.DefaultKeywordLimit},
+	)
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+
+	streamStub.responses = []llm.Response{
+		{
+			Model: "deepseek-chat",
+			Choices: []llm.Choice{
+				{Message: llm.Message{Role: "assistant", Content: "流式生成的正文"}},
+			},
+			Usage: &llm.Usage{PromptTokens: 5, CompletionTokens: 7, TotalTokens: 12},
+		},
+		buildAuditResponse(t, true, ""),
+	}
+
+	var deltas []string
+	out, err := service.GeneratePromptStream(context.Background(), promptsvc.GenerateInput{
+		UserID:           1,
+		Topic:            "流式主题",
+		ModelKey:         "deepseek-chat",
+		PositiveKeywords: []promptsvc.KeywordItem{{Word: "流式"}},
+	}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GeneratePromptStream error: %v", err)
+	}
+	if streamStub.streamCalls != 1 {
+		t.Fatalf("expected generation to use streaming invoker once, got %d", streamStub.streamCalls)
+	}
+	if len(deltas) != len([]rune("流式生成的正文")) || strings.Join(deltas, "") != out.Prompt {
+		t.Fatalf("unexpected deltas %v for prompt %q", deltas, out.Prompt)
+	}
+	if out.Usage == nil || out.Usage.TotalTokens != 12 {
+		t.Fatalf("unexpected usage: %+v", out.Usage)
+	}
+	if len(streamStub.requests) != 2 {
+		t.Fatalf("expected generate + audit requests, got %d", len(streamStub.requests))
+	}
+	auditReq := streamStub.requests[1]
+	if !strings.Contains(auditReq.Messages[len(auditReq.Messages)-1].Content, "流式生成的正文") {
+		t.Fatalf("expected audit to run on assembled text, got %+v", auditReq.Messages)
+	}
+}
+
+// TestPromptServiceGeneratePromptStreamFallback 验证调用器不支持流式时会整体推送一次正文。
+func TestPromptServiceGeneratePromptStreamFallback(t *testing.T) {
+	service, _, _, db, modelStub := setupPromptService(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	modelStub.responses = []llm.Response{
+		{
+			Model: "deepseek-chat",
+			Choices: []llm.Choice{
+				{Message: llm.Message{Role: "assistant", Content: "一次性正文"}},
+			},
+		},
+		buildAuditResponse(t, true, ""),
+	}
+
+	var deltas []string
+	out, err := service.GeneratePromptStream(context.Background(), promptsvc.GenerateInput{
+		UserID:           1,
+		Topic:            "回退主题",
+		ModelKey:         "deepseek-chat",
+		PositiveKeywords: []promptsvc.KeywordItem{{Word: "回退"}},
+	}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GeneratePromptStream error: %v", err)
+	}
+	if len(deltas) != 1 || deltas[0] != out.Prompt {
+		t.Fatalf("expected single delta with full prompt, got %v", deltas)
+	}
+}
+
 // TestPromptServiceSave 验证保存草稿与发布版本的行为。
 func TestPromptServiceSave(t *testing.T) {
 	service, promptRepo, _, db, _ := setupPromptService(t)
@@ -1439,11 +1771,11 @@ func TestGeneratePromptUsesFreeTierWhenCredentialMissing(t *testing.T) {
 	keywordRepo := repository.NewKeywordRepository(db)
 
 	primaryInvoker := &fakeModelInvoker{err: modelsvc.ErrCredentialNotFound}
-	fallbackInvoker := &fakeModelInvoker{responses: []deepseek.ChatCompletionResponse{
+	fallbackInvoker := &fakeModelInvoker{responses: []llm.Response{
 		{
 			Model: "deepseek-chat",
-			Choices: []deepseek.ChatCompletionChoice{
-				{Message: deepseek.ChatMessage{Role: "assistant", Content: "测试 Prompt 正文"}},
+			Choices: []llm.Choice{
+				{Message: llm.Message{Role: "assistant", Content: "测试 Prompt 正文"}},
 			},
 		},
 	}}
@@ -1452,11 +1784,11 @@ func TestGeneratePromptUsesFreeTierWhenCredentialMissing(t *testing.T) {
 		"reason":  "",
 	}
 	auditRaw, _ := json.Marshal(auditPayload)
-	auditInvoker := &fakeModelInvoker{responses: []deepseek.ChatCompletionResponse{
+	auditInvoker := &fakeModelInvoker{responses: []llm.Response{
 		{
 			Model: "audit-model",
-			Choices: []deepseek.ChatCompletionChoice{
-				{Message: deepseek.ChatMessage{Role: "assistant", Content: string(auditRaw)}},
+			Choices: []llm.Choice{
+				{Message: llm.Message{Role: "assistant", Content: string(auditRaw)}},
 			},
 		},
 	}}
@@ -1511,3 +1843,284 @@ func TestGeneratePromptUsesFreeTierWhenCredentialMissing(t *testing.T) {
 		t.Fatalf("expected fallback request model deepseek-chat, got %s", fallbackInvoker.requests[0].Model)
 	}
 }
+
+// fakeChainModelInvoker 按模型 key 返回预设结果，并实现 FallbackChainResolver 以模拟用户配置的回退链。
+type fakeChainModelInvoker struct {
+	chains    map[string][]string
+	errs      map[string]error
+	responses map[string]llm.Response
+	calls     []string
+}
+
+func (f *fakeChainModelInvoker) InvokeChatCompletion(_ context.Context, _ uint, modelKey string, _ llm.Request) (llm.Response, error) {
+	f.calls = append(f.calls, modelKey)
+	if err, ok := f.errs[modelKey]; ok {
+		return llm.Response{}, err
+	}
+	if resp, ok := f.responses[modelKey]; ok {
+		return resp, nil
+	}
+	return llm.Response{}, modelsvc.ErrCredentialNotFound
+}
+
+func (f *fakeChainModelInvoker) FallbackChain(_ context.Context, _ uint, operation string) ([]string, error) {
+	if chain, ok := f.chains[operation]; ok {
+		return chain, nil
+	}
+	return f.chains["default"], nil
+}
+
+// TestGeneratePromptFallsBackAlongChain 验证主模型限流时会沿回退链切换，并返回实际服务的模型。
+func TestGeneratePromptFallsBackAlongChain(t *testing.T) {
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	invoker := &fakeChainModelInvoker{
+		chains: map[string][]string{
+			"generate": {"missing", "doubao", "doubao"},
+			"default":  {"unused"},
+		},
+		errs: map[string]error{
+			"primary": &deepseek.APIError{StatusCode: 429, Message: "rate limited"},
+		},
+		responses: map[string]llm.Response{
+			"doubao": {
+				Model:   "doubao-pro",
+				Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "回退模型生成的正文"}}},
+			},
+		},
+	}
+	auditInvoker := &fakeModelInvoker{responses: []llm.Response{buildAuditResponse(t, true, "")}}
+	service, err := promptsvc.NewServiceWithConfig(
+		repository.NewPromptRepository(db),
+		repository.NewKeywordRepository(db),
+		invoker,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		promptsvc.Config{
+			KeywordLimit: promptsvc.DefaultKeywordLimit,
+			Audit:        promptsvc.AuditConfig{Enabled: true, ModelKey: "audit-model", Invoker: auditInvoker},
+		},
+	)
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+
+	out, err := service.GeneratePrompt(context.Background(), promptsvc.GenerateInput{
+		UserID:           1,
+		Topic:            "回退主题",
+		ModelKey:         "primary",
+		PositiveKeywords: []promptsvc.KeywordItem{{Word: "回退"}},
+	})
+	if err != nil {
+		t.Fatalf("GeneratePrompt error: %v", err)
+	}
+	if out.Prompt != "回退模型生成的正文" {
+		t.Fatalf("unexpected prompt: %s", out.Prompt)
+	}
+	if out.ServedModelKey != "doubao" || !out.FallbackUsed {
+		t.Fatalf("expected doubao to serve via fallback, got %q fallback=%v", out.ServedModelKey, out.FallbackUsed)
+	}
+	if !reflect.DeepEqual(invoker.calls, []string{"primary", "missing", "doubao"}) {
+		t.Fatalf("unexpected invocation order: %v", invoker.calls)
+	}
+}
+
+// TestGeneratePromptFallbackStopsOnNonRetryableError 验证非可重试错误不会触发回退。
+func TestGeneratePromptFallbackStopsOnNonRetryableError(t *testing.T) {
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	invoker := &fakeChainModelInvoker{
+		chains: map[string][]string{"default": {"doubao"}},
+		errs: map[string]error{
+			"primary": &deepseek.APIError{StatusCode: 400, Message: "bad request"},
+		},
+	}
+	service, err := promptsvc.NewServiceWithConfig(
+		repository.NewPromptRepository(db),
+		repository.NewKeywordRepository(db),
+		invoker,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		promptsvc.Config{KeywordLimit: promptsvc.DefaultKeywordLimit},
+	)
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+
+	_, err = service.GeneratePrompt(context.Background(), promptsvc.GenerateInput{
+		UserID:           1,
+		Topic:            "不回退",
+		ModelKey:         "primary",
+		PositiveKeywords: []promptsvc.KeywordItem{{Word: "错误"}},
+	})
+	if !errors.Is(err, promptsvc.ErrModelInvocationFailed) {
+		t.Fatalf("expected ErrModelInvocationFailed, got %v", err)
+	}
+	if !reflect.DeepEqual(invoker.calls, []string{"primary"}) {
+		t.Fatalf("expected no fallback on 400, got %v", invoker.calls)
+	}
+}
+
+func TestPromptServiceAugmentResponseCache(t *testing.T) {
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("extract sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+	if err := db.AutoMigrate(&promptdomain.Prompt{}, &promptdomain.Keyword{}, &promptdomain.PromptKeyword{}, &modelcache.Entry{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+
+	content, _ := json.Marshal(map[string]any{
+		"topic":             "React 面试",
+		"positive_keywords": []map[string]any{{"word": "React", "weight": 5}},
+		"negative_keywords": []map[string]any{},
+	})
+	freeInvoker := &fakeModelInvoker{responses: []llm.Response{
+		{Model: "deepseek-chat", Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: string(content)}}}},
+		{Model: "deepseek-chat", Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: string(content)}}}},
+	}}
+	cfg := promptsvc.Config{
+		FreeTier: promptsvc.FreeTierConfig{
+			Enabled:     true,
+			ActualModel: "deepseek-chat",
+			DailyQuota:  1,
+			Window:      time.Hour,
+			Invoker:     freeInvoker,
+		},
+		ResponseCache: promptsvc.ResponseCacheConfig{
+			Enabled: true,
+			Store:   repository.NewModelResponseCacheRepository(db),
+		},
+	}
+	primary := &fakeModelInvoker{err: modelsvc.ErrCredentialNotFound}
+	service, err := promptsvc.NewServiceWithConfig(repository.NewPromptRepository(db), repository.NewKeywordRepository(db), primary, nil, nil, nil, ratelimit.NewMemoryLimiter(), nil, cfg)
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+	input := promptsvc.AugmentInput{UserID: 1, Topic: "React 面试", ModelKey: "deepseek"}
+
+	first, err := service.AugmentKeywords(context.Background(), input)
+	if err != nil {
+		t.Fatalf("first augment: %v", err)
+	}
+	if first.Cached {
+		t.Fatalf("expected first response not cached")
+	}
+
+	// 额度仅有 1 次，第二次命中缓存，不会调用模型也不会触发额度耗尽。
+	second, err := service.AugmentKeywords(context.Background(), input)
+	if err != nil {
+		t.Fatalf("second augment: %v", err)
+	}
+	if !second.Cached || len(second.Positive) != 1 || second.Positive[0].Word != "React" {
+		t.Fatalf("expected cached keywords, got %+v", second)
+	}
+	if len(freeInvoker.requests) != 1 {
+		t.Fatalf("expected free tier invoked once, got %d", len(freeInvoker.requests))
+	}
+
+	// 其它用户不共享缓存。
+	other := input
+	other.UserID = 2
+	if res, err := service.AugmentKeywords(context.Background(), other); err != nil || res.Cached {
+		t.Fatalf("expected other user to miss cache, got cached=%v err=%v", res.Cached, err)
+	}
+
+	input.BypassCache = true
+	if _, err := service.AugmentKeywords(context.Background(), input); !errors.Is(err, promptsvc.ErrFreeTierQuotaExceeded) {
+		t.Fatalf("expected bypass to hit the model and exhaust quota, got %v", err)
+	}
+}
+
+// fingerprintModelInvoker 在 fakeModelInvoker 基础上暴露凭据版本标识，模拟用户修改凭据后标识变化。
+type fingerprintModelInvoker struct {
+	fakeModelInvoker
+	fingerprint string
+}
+
+func (f *fingerprintModelInvoker) CredentialFingerprint(context.Context, uint, string) (string, error) {
+	return f.fingerprint, nil
+}
+
+// TestPromptServiceResponseCacheCredentialFingerprint 验证同一 model_key 的凭据被修改后不再命中旧凭据的缓存响应。
+func TestPromptServiceResponseCacheCredentialFingerprint(t *testing.T) {
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("extract sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+	if err := db.AutoMigrate(&promptdomain.Prompt{}, &promptdomain.Keyword{}, &promptdomain.PromptKeyword{}, &modelcache.Entry{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+
+	reply := func(word string) llm.Response {
+		content, _ := json.Marshal(map[string]any{
+			"topic":             "React 面试",
+			"positive_keywords": []map[string]any{{"word": word, "weight": 5}},
+			"negative_keywords": []map[string]any{},
+		})
+		return llm.Response{Model: "deepseek-chat", Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: string(content)}}}}
+	}
+	model := &fingerprintModelInvoker{fingerprint: "1:100"}
+	model.responses = []llm.Response{reply("React"), reply("Vue")}
+	service, err := promptsvc.NewServiceWithConfig(repository.NewPromptRepository(db), repository.NewKeywordRepository(db), model, nil, nil, nil, nil, nil, promptsvc.Config{
+		ResponseCache: promptsvc.ResponseCacheConfig{Enabled: true, Store: repository.NewModelResponseCacheRepository(db)},
+	})
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+	input := promptsvc.AugmentInput{UserID: 1, Topic: "React 面试", ModelKey: "deepseek"}
+
+	if _, err := service.AugmentKeywords(context.Background(), input); err != nil {
+		t.Fatalf("first augment: %v", err)
+	}
+	cached, err := service.AugmentKeywords(context.Background(), input)
+	if err != nil || !cached.Cached {
+		t.Fatalf("expected unchanged credential to hit cache, got cached=%v err=%v", cached.Cached, err)
+	}
+
+	// 用户把 model_key 改指其它模型后，凭据标识变化，必须重新调用模型。
+	model.fingerprint = "1:200"
+	fresh, err := service.AugmentKeywords(context.Background(), input)
+	if err != nil {
+		t.Fatalf("augment after credential update: %v", err)
+	}
+	if fresh.Cached || len(fresh.Positive) != 1 || fresh.Positive[0].Word != "Vue" || len(model.requests) != 2 {
+		t.Fatalf("expected credential update to bypass stale cache, got %+v requests=%d", fresh, len(model.requests))
+	}
+}
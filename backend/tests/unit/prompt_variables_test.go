@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+	promptsvc "electron-go-app/backend/internal/service/prompt"
+)
+
+// TestPromptServiceTemplateVariables 验证保存时自动识别模板变量、发布时校验默认值、渲染时报告缺失与未定义变量，以及分享串携带变量定义。
+func TestPromptServiceTemplateVariables(t *testing.T) {
+	service, _, _, db, _ := setupPromptService(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+	ctx := context.Background()
+
+	body := "为{{audience}}介绍{{ product_name }}，语气{{tone}}，面向{{audience}}"
+	draft, err := service.Save(ctx, promptsvc.SaveInput{
+		UserID: 1,
+		Topic:  "产品介绍",
+		Body:   body,
+		Model:  "deepseek-chat",
+		Status: promptdomain.PromptStatusDraft,
+		Variables: []promptdomain.PromptVariable{
+			{Name: "tone", Enum: []string{"正式", "轻松"}, Default: "正式"},
+			{Name: "product_name", Required: true, Description: "产品名称"},
+			{Name: "unused"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("save draft: %v", err)
+	}
+	detail, err := service.GetPrompt(ctx, promptsvc.GetPromptInput{UserID: 1, PromptID: draft.PromptID})
+	if err != nil {
+		t.Fatalf("get prompt: %v", err)
+	}
+	if len(detail.Variables) != 3 || detail.Variables[0].Name != "audience" || detail.Variables[1].Name != "product_name" || detail.Variables[2].Name != "tone" {
+		t.Fatalf("expected detected variables in body order, got %+v", detail.Variables)
+	}
+	if detail.Variables[0].Type != promptdomain.PromptVariableTypeString || !detail.Variables[1].Required || detail.Variables[2].Type != promptdomain.PromptVariableTypeEnum {
+		t.Fatalf("unexpected variable definitions: %+v", detail.Variables)
+	}
+
+	publish := promptsvc.SaveInput{
+		UserID:                   1,
+		PromptID:                 draft.PromptID,
+		Topic:                    "产品介绍",
+		Body:                     body,
+		Instructions:             "突出卖点",
+		Model:                    "deepseek-chat",
+		Publish:                  true,
+		PositiveKeywords:         []promptsvc.KeywordItem{{Word: "卖点"}},
+		NegativeKeywords:         []promptsvc.KeywordItem{{Word: "夸大"}},
+		Tags:                     []string{"营销"},
+		EnforcePublishValidation: true,
+		Variables:                []promptdomain.PromptVariable{{Name: "tone", Type: promptdomain.PromptVariableTypeEnum, Enum: []string{"正式"}, Default: "随意"}},
+	}
+	if _, err := service.Save(ctx, publish); err == nil || !strings.Contains(err.Error(), "变量 tone") {
+		t.Fatalf("expected invalid default to block publish, got %v", err)
+	}
+	publish.Variables = nil
+	published, err := service.Save(ctx, publish)
+	if err != nil || published.Version != 1 {
+		t.Fatalf("expected publish with stored variables, got %+v err=%v", published, err)
+	}
+
+	rendered, err := service.RenderPrompt(ctx, promptsvc.RenderPromptInput{
+		UserID:   1,
+		PromptID: draft.PromptID,
+		Values:   map[string]any{"audience": "工程师", "extra": 1.5},
+	})
+	if err != nil {
+		t.Fatalf("render prompt: %v", err)
+	}
+	if rendered.Body != "为工程师介绍{{ product_name }}，语气正式，面向工程师" {
+		t.Fatalf("unexpected rendered body: %q", rendered.Body)
+	}
+	if len(rendered.Missing) != 1 || rendered.Missing[0] != "product_name" || len(rendered.Unknown) != 1 || rendered.Unknown[0] != "extra" {
+		t.Fatalf("expected missing product_name and unknown extra, got missing=%v unknown=%v", rendered.Missing, rendered.Unknown)
+	}
+	versioned, err := service.RenderPrompt(ctx, promptsvc.RenderPromptInput{
+		UserID:    1,
+		PromptID:  draft.PromptID,
+		VersionNo: 1,
+		Values:    map[string]any{"audience": "学生", "product_name": "笔记本", "tone": "轻松"},
+	})
+	if err != nil || versioned.Body != "为学生介绍笔记本，语气轻松，面向学生" || len(versioned.Missing) != 0 {
+		t.Fatalf("unexpected version render: %+v err=%v", versioned, err)
+	}
+
+	share, err := service.SharePrompt(ctx, promptsvc.SharePromptInput{UserID: 1, PromptID: draft.PromptID})
+	if err != nil {
+		t.Fatalf("share prompt: %v", err)
+	}
+	imported, err := service.ImportSharedPrompt(ctx, promptsvc.ImportSharedPromptInput{UserID: 2, Payload: share.Payload})
+	if err != nil {
+		t.Fatalf("import shared prompt: %v", err)
+	}
+	copied, err := service.GetPrompt(ctx, promptsvc.GetPromptInput{UserID: 2, PromptID: imported.PromptID})
+	if err != nil {
+		t.Fatalf("get imported prompt: %v", err)
+	}
+	if len(copied.Variables) != 3 || copied.Variables[1].Description != "产品名称" || len(copied.Variables[2].Enum) != 2 {
+		t.Fatalf("expected shared prompt to carry variable schema, got %+v", copied.Variables)
+	}
+}
@@ -0,0 +1,515 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"electron-go-app/backend/internal/domain/llm"
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+	"electron-go-app/backend/internal/repository"
+	promptsvc "electron-go-app/backend/internal/service/prompt"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newLocalWorkspaceDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&promptdomain.WorkspaceRecord{}, &promptdomain.WorkspaceKeywordRecord{}, &promptdomain.WorkspaceHistoryRecord{}, &promptdomain.PersistenceTaskRecord{}, &promptdomain.PersistenceTaskStatusRecord{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	return db
+}
+
+// TestPromptWorkspaceRepositoryLifecycle 验证本地工作区与 Redis 实现语义一致：关键词按 score 排序、合并覆盖、属性打补丁以及过期后视为不存在。
+func TestPromptWorkspaceRepositoryLifecycle(t *testing.T) {
+	db := newLocalWorkspaceDB(t)
+	store := repository.NewPromptWorkspaceRepository(db, time.Hour)
+	ctx := context.Background()
+
+	token, err := store.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{
+		Topic:   "React 面试",
+		Version: 3,
+		Positive: []promptdomain.WorkspaceKeyword{
+			{Word: "Hooks", Polarity: promptdomain.KeywordPolarityPositive, Weight: 4, Score: 2},
+			{Word: "React", Polarity: promptdomain.KeywordPolarityPositive, Weight: 5, Score: 1},
+		},
+		Negative:   []promptdomain.WorkspaceKeyword{{Word: "jQuery", Polarity: promptdomain.KeywordPolarityNegative, Score: 1}},
+		Attributes: map[string]string{"tags": "前端"},
+	})
+	if err != nil || token == "" {
+		t.Fatalf("create workspace: token=%q err=%v", token, err)
+	}
+	if _, err := store.MergeKeywords(ctx, 1, token, promptdomain.WorkspaceAnyVersion, []promptdomain.WorkspaceKeyword{
+		{Word: "hooks", Polarity: promptdomain.KeywordPolarityPositive, Weight: 2, Source: "manual", Score: 0.5},
+	}); err != nil {
+		t.Fatalf("merge keywords: %v", err)
+	}
+	if _, err := store.RemoveKeyword(ctx, 1, token, promptdomain.WorkspaceAnyVersion, promptdomain.KeywordPolarityNegative, "JQUERY"); err != nil {
+		t.Fatalf("remove keyword: %v", err)
+	}
+	if _, err := store.SetAttributes(ctx, 1, token, promptdomain.WorkspaceAnyVersion, map[string]string{"tags": "", "instructions": "附示例"}); err != nil {
+		t.Fatalf("set attributes: %v", err)
+	}
+	if _, err := store.UpdateDraftBody(ctx, 1, token, promptdomain.WorkspaceAnyVersion, "草稿正文"); err != nil {
+		t.Fatalf("update draft: %v", err)
+	}
+	if err := store.SetPromptMeta(ctx, 1, token, 42, "draft"); err != nil {
+		t.Fatalf("set prompt meta: %v", err)
+	}
+
+	snapshot, err := store.Snapshot(ctx, 1, token)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	// 创建时版本为 3，随后的四次内容写入各加一，SetPromptMeta 不改变版本。
+	if snapshot.Topic != "React 面试" || snapshot.Version != 7 || snapshot.DraftBody != "草稿正文" || snapshot.PromptID != 42 || snapshot.Status != "draft" {
+		t.Fatalf("unexpected snapshot metadata: %+v", snapshot)
+	}
+	if len(snapshot.Positive) != 2 || snapshot.Positive[0].Word != "hooks" || snapshot.Positive[0].Weight != 2 || snapshot.Positive[1].Word != "React" {
+		t.Fatalf("unexpected positive keywords: %+v", snapshot.Positive)
+	}
+	if len(snapshot.Negative) != 0 {
+		t.Fatalf("expected negative keyword removed, got %+v", snapshot.Negative)
+	}
+	if len(snapshot.Attributes) != 1 || snapshot.Attributes["instructions"] != "附示例" {
+		t.Fatalf("unexpected attributes: %+v", snapshot.Attributes)
+	}
+	if _, err := store.Snapshot(ctx, 2, token); !errors.Is(err, redis.Nil) {
+		t.Fatalf("expected other user's lookup to miss, got %v", err)
+	}
+
+	short := repository.NewPromptWorkspaceRepository(db, 30*time.Millisecond)
+	expiring, err := short.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{Topic: "临时", Attributes: map[string]string{"tags": "旧"}})
+	if err != nil {
+		t.Fatalf("create expiring workspace: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := short.Touch(ctx, 1, expiring); err != nil {
+		t.Fatalf("touch expired workspace: %v", err)
+	}
+	if _, err := short.Snapshot(ctx, 1, expiring); !errors.Is(err, redis.Nil) {
+		t.Fatalf("expected expired workspace to be gone, got %v", err)
+	}
+	if _, err := short.UpdateDraftBody(ctx, 1, expiring, promptdomain.WorkspaceAnyVersion, "新正文"); err != nil {
+		t.Fatalf("write expired workspace: %v", err)
+	}
+	recreated, err := short.Snapshot(ctx, 1, expiring)
+	if err != nil || recreated.Topic != "" || recreated.Attributes != nil || recreated.DraftBody != "新正文" {
+		t.Fatalf("expected write to recreate an empty workspace, got %+v err=%v", recreated, err)
+	}
+}
+
+// TestPersistenceTaskRepositoryQueue 验证本地落库队列先进先出、空队列超时返回 redis.Nil、能被新任务唤醒，以及重试、死信与重启回收。
+func TestPersistenceTaskRepositoryQueue(t *testing.T) {
+	db := newLocalWorkspaceDB(t)
+	policy := promptdomain.PersistenceRetryPolicy{MaxAttempts: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	queue := repository.NewPersistenceTaskRepository(db, policy)
+	ctx := context.Background()
+
+	first, err := queue.Enqueue(ctx, promptdomain.PersistenceTask{UserID: 1, Topic: "first"})
+	if err != nil {
+		t.Fatalf("enqueue first: %v", err)
+	}
+	if _, err := queue.Enqueue(ctx, promptdomain.PersistenceTask{UserID: 1, Topic: "second"}); err != nil {
+		t.Fatalf("enqueue second: %v", err)
+	}
+	task, err := queue.BlockingPop(ctx, time.Second)
+	if err != nil || task.TaskID != first || task.Topic != "first" {
+		t.Fatalf("expected first task, got %+v err=%v", task, err)
+	}
+	if err := queue.Ack(ctx, task); err != nil {
+		t.Fatalf("ack first: %v", err)
+	}
+	second, err := queue.BlockingPop(ctx, time.Second)
+	if err != nil || second.Topic != "second" {
+		t.Fatalf("expected second task, got %+v err=%v", second, err)
+	}
+	if dead, err := queue.Retry(ctx, second, errors.New("db down")); err != nil || dead {
+		t.Fatalf("expected retry to reschedule, dead=%v err=%v", dead, err)
+	}
+	// 重试任务在退避结束后由轮询取出，等待时间需覆盖一次轮询间隔。
+	retried, err := queue.BlockingPop(ctx, 3*time.Second)
+	if err != nil || retried.TaskID != second.TaskID || retried.Attempts != 1 || retried.LastError != "db down" {
+		t.Fatalf("expected retried task with attempt count, got %+v err=%v", retried, err)
+	}
+	if dead, err := queue.Retry(ctx, retried, errors.New("db down")); err != nil || !dead {
+		t.Fatalf("expected task to be dead-lettered, dead=%v err=%v", dead, err)
+	}
+	if _, err := queue.BlockingPop(ctx, 20*time.Millisecond); !errors.Is(err, redis.Nil) {
+		t.Fatalf("expected redis.Nil on empty queue, got %v", err)
+	}
+
+	// 模拟进程崩溃：取出后未确认，新进程启动时回收。
+	if _, err := queue.Enqueue(ctx, promptdomain.PersistenceTask{UserID: 1, Topic: "crashed"}); err != nil {
+		t.Fatalf("enqueue crashed: %v", err)
+	}
+	if _, err := queue.BlockingPop(ctx, time.Second); err != nil {
+		t.Fatalf("pop crashed: %v", err)
+	}
+	restarted := repository.NewPersistenceTaskRepository(db, policy)
+	if reclaimed, err := restarted.Reclaim(ctx); err != nil || reclaimed != 1 {
+		t.Fatalf("expected 1 reclaimed task, got %d err=%v", reclaimed, err)
+	}
+	if task, err = restarted.BlockingPop(ctx, time.Second); err != nil || task.Topic != "crashed" || task.Attempts != 1 || task.LastError != promptdomain.ErrPersistenceTaskStalled.Error() {
+		t.Fatalf("expected reclaimed task with a counted attempt, got %+v err=%v", task, err)
+	}
+	if err := restarted.Ack(ctx, task); err != nil {
+		t.Fatalf("ack reclaimed: %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = restarted.Enqueue(context.Background(), promptdomain.PersistenceTask{UserID: 1, Topic: "late"})
+	}()
+	if task, err = restarted.BlockingPop(ctx, 0); err != nil || task.Topic != "late" {
+		t.Fatalf("expected blocking pop to wake up on enqueue, got %+v err=%v", task, err)
+	}
+
+	// 反复卡住 worker 的任务每次回收都计入处理次数，用尽后转为 dead，不再被取出。
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		time.Sleep(5 * time.Millisecond)
+		restarted = repository.NewPersistenceTaskRepository(db, policy)
+		if reclaimed, err := restarted.Reclaim(ctx); err != nil || reclaimed != 1 {
+			t.Fatalf("expected stalled task to be reclaimed on attempt %d, got %d err=%v", attempt, reclaimed, err)
+		}
+		if attempt < policy.MaxAttempts {
+			if task, err = restarted.BlockingPop(ctx, time.Second); err != nil || task.Topic != "late" || task.Attempts != attempt {
+				t.Fatalf("expected stalled task to be requeued, got %+v err=%v", task, err)
+			}
+		}
+	}
+	if _, err := restarted.BlockingPop(ctx, 20*time.Millisecond); !errors.Is(err, redis.Nil) {
+		t.Fatalf("expected exhausted stalled task to be dead, got %v", err)
+	}
+	var stalled promptdomain.PersistenceTaskRecord
+	if err := db.Where("task_id = ?", task.TaskID).Take(&stalled).Error; err != nil || stalled.State != promptdomain.PersistenceTaskDead {
+		t.Fatalf("expected stalled task to be dead-lettered, got %+v err=%v", stalled, err)
+	}
+}
+
+// TestPromptServiceAsyncSaveTaskStatus 验证异步保存返回任务 ID，worker 落库时依次更新任务状态，且任务只对所属用户可见。
+func TestPromptServiceAsyncSaveTaskStatus(t *testing.T) {
+	db := newLocalWorkspaceDB(t)
+	if err := db.AutoMigrate(&promptdomain.Prompt{}, &promptdomain.Keyword{}, &promptdomain.PromptKeyword{}, &promptdomain.PromptLike{}, &promptdomain.PromptVersion{}); err != nil {
+		t.Fatalf("auto migrate prompts: %v", err)
+	}
+	workspace := repository.NewPromptWorkspaceRepository(db, time.Hour)
+	queue := repository.NewPersistenceTaskRepository(db, promptdomain.DefaultPersistenceRetryPolicy())
+	service, err := promptsvc.NewServiceWithConfig(
+		repository.NewPromptRepository(db),
+		repository.NewKeywordRepository(db),
+		&fakeModelInvoker{},
+		workspace,
+		queue,
+		nil,
+		nil,
+		nil,
+		promptsvc.Config{TaskStatus: repository.NewPersistenceTaskStatusRepository(db, time.Hour)},
+	)
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := workspace.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{
+		Topic:     "异步保存",
+		DraftBody: "工作区正文",
+		Positive:  []promptdomain.WorkspaceKeyword{{Word: "异步", Polarity: promptdomain.KeywordPolarityPositive, Weight: 3}},
+	})
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+	out, err := service.Save(ctx, promptsvc.SaveInput{UserID: 1, WorkspaceToken: token, Async: true})
+	if err != nil || out.TaskID == "" || out.Token != token {
+		t.Fatalf("expected async save to return task id, got %+v err=%v", out, err)
+	}
+	status, err := service.GetSaveTask(ctx, 1, out.TaskID)
+	if err != nil || status.State != promptdomain.PersistenceTaskStatePending {
+		t.Fatalf("expected pending task, got %+v err=%v", status, err)
+	}
+	if _, err := service.GetSaveTask(ctx, 2, out.TaskID); !errors.Is(err, promptsvc.ErrSaveTaskNotFound) {
+		t.Fatalf("expected other user's lookup to miss, got %v", err)
+	}
+
+	service.StartPersistenceWorker(ctx, 50*time.Millisecond)
+	var states []string
+	if err := service.WatchSaveTask(ctx, 1, out.TaskID, func(update promptdomain.PersistenceTaskStatus) error {
+		states = append(states, update.State)
+		status = update
+		return nil
+	}); err != nil {
+		t.Fatalf("watch save task: %v", err)
+	}
+	if status.State != promptdomain.PersistenceTaskStateSucceeded || status.PromptID == 0 || status.Status != promptdomain.PromptStatusDraft {
+		t.Fatalf("expected succeeded task with prompt id, got %+v (states %v)", status, states)
+	}
+	var stored promptdomain.Prompt
+	if err := db.First(&stored, status.PromptID).Error; err != nil || stored.Body != "工作区正文" {
+		t.Fatalf("expected prompt persisted from workspace, got %+v err=%v", stored, err)
+	}
+}
+
+// TestPromptServiceWorkspaceConflict 验证本地工作区携带过期版本编辑时，服务返回带最新快照的冲突错误且不修改数据。
+func TestPromptServiceWorkspaceConflict(t *testing.T) {
+	db := newLocalWorkspaceDB(t)
+	workspace := repository.NewPromptWorkspaceRepository(db, time.Hour)
+	service, err := promptsvc.NewServiceWithConfig(nil, nil, &fakeModelInvoker{}, workspace, nil, nil, nil, nil, promptsvc.Config{})
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+	ctx := context.Background()
+	token, err := workspace.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{
+		Topic:    "并发编辑",
+		Version:  1,
+		Positive: []promptdomain.WorkspaceKeyword{{Word: "React", Polarity: promptdomain.KeywordPolarityPositive, Score: 1}},
+	})
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+
+	if _, err := service.SelectCandidate(ctx, promptsvc.SelectCandidateInput{UserID: 1, WorkspaceToken: "expired", WorkspaceVersion: 1, Prompt: "新正文"}); !errors.Is(err, promptsvc.ErrWorkspaceNotFound) {
+		t.Fatalf("expected select on missing workspace to report not found, got %v", err)
+	}
+	version, err := service.SelectCandidate(ctx, promptsvc.SelectCandidateInput{UserID: 1, WorkspaceToken: token, WorkspaceVersion: 1, Prompt: "新正文"})
+	if err != nil || version != 2 {
+		t.Fatalf("expected select to bump version to 2, got %d err=%v", version, err)
+	}
+	_, err = service.RemoveWorkspaceKeyword(ctx, promptsvc.RemoveKeywordInput{
+		UserID:           1,
+		Word:             "React",
+		Polarity:         promptdomain.KeywordPolarityPositive,
+		WorkspaceToken:   token,
+		WorkspaceVersion: 1,
+	})
+	var conflict *promptsvc.WorkspaceConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, promptsvc.ErrWorkspaceConflict) {
+		t.Fatalf("expected workspace conflict, got %v", err)
+	}
+	if conflict.Snapshot == nil || conflict.Snapshot.Version != 2 || conflict.Snapshot.DraftBody != "新正文" || len(conflict.Snapshot.Positive) != 1 {
+		t.Fatalf("expected conflict to carry current snapshot, got %+v", conflict.Snapshot)
+	}
+	if version, err = service.SyncWorkspaceKeywords(ctx, promptsvc.SyncWorkspaceInput{UserID: 1, WorkspaceToken: token, WorkspaceVersion: 2}); err != nil || version != 3 {
+		t.Fatalf("expected sync with current version to succeed, got %d err=%v", version, err)
+	}
+}
+
+// TestPromptServiceWorkspaceUndoRedo 验证本地工作区的撤销/重做：恢复关键词与草稿正文，栈为空时返回明确错误，删除工作区时一并清理历史。
+func TestPromptServiceWorkspaceUndoRedo(t *testing.T) {
+	db := newLocalWorkspaceDB(t)
+	workspace := repository.NewPromptWorkspaceRepository(db, time.Hour)
+	service, err := promptsvc.NewServiceWithConfig(nil, nil, &fakeModelInvoker{}, workspace, nil, nil, nil, nil, promptsvc.Config{})
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+	ctx := context.Background()
+	token, err := workspace.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{
+		Topic:     "撤销重做",
+		Version:   1,
+		DraftBody: "初稿",
+		Positive:  []promptdomain.WorkspaceKeyword{{Word: "React", Polarity: promptdomain.KeywordPolarityPositive, Score: 1}},
+	})
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+	input := promptsvc.WorkspaceHistoryInput{UserID: 1, WorkspaceToken: token, WorkspaceVersion: promptdomain.WorkspaceAnyVersion}
+	if _, err := service.UndoWorkspace(ctx, input); !errors.Is(err, promptsvc.ErrNothingToUndo) {
+		t.Fatalf("expected nothing to undo, got %v", err)
+	}
+
+	if _, err := service.SyncWorkspaceKeywords(ctx, promptsvc.SyncWorkspaceInput{UserID: 1, WorkspaceToken: token, WorkspaceVersion: 1}); err != nil {
+		t.Fatalf("sync keywords: %v", err)
+	}
+	if _, err := service.SelectCandidate(ctx, promptsvc.SelectCandidateInput{UserID: 1, WorkspaceToken: token, WorkspaceVersion: 2, Prompt: "二稿"}); err != nil {
+		t.Fatalf("select candidate: %v", err)
+	}
+
+	snapshot, err := service.UndoWorkspace(ctx, promptsvc.WorkspaceHistoryInput{UserID: 1, WorkspaceToken: token, WorkspaceVersion: 3})
+	if err != nil || snapshot.DraftBody != "初稿" || snapshot.Version != 4 || len(snapshot.Positive) != 0 {
+		t.Fatalf("expected draft undo, got %+v err=%v", snapshot, err)
+	}
+	if _, err := service.UndoWorkspace(ctx, promptsvc.WorkspaceHistoryInput{UserID: 1, WorkspaceToken: token, WorkspaceVersion: 3}); !errors.Is(err, promptsvc.ErrWorkspaceConflict) {
+		t.Fatalf("expected stale undo to conflict, got %v", err)
+	}
+	snapshot, err = service.UndoWorkspace(ctx, input)
+	if err != nil || len(snapshot.Positive) != 1 || snapshot.Positive[0].Word != "React" {
+		t.Fatalf("expected keyword undo, got %+v err=%v", snapshot, err)
+	}
+	if _, err := service.UndoWorkspace(ctx, input); !errors.Is(err, promptsvc.ErrNothingToUndo) {
+		t.Fatalf("expected undo stack to be exhausted, got %v", err)
+	}
+	snapshot, err = service.RedoWorkspace(ctx, input)
+	if err != nil || len(snapshot.Positive) != 0 || snapshot.DraftBody != "初稿" {
+		t.Fatalf("expected keyword redo, got %+v err=%v", snapshot, err)
+	}
+	snapshot, err = service.RedoWorkspace(ctx, input)
+	if err != nil || snapshot.DraftBody != "二稿" {
+		t.Fatalf("expected draft redo, got %+v err=%v", snapshot, err)
+	}
+	if _, err := service.RedoWorkspace(ctx, input); !errors.Is(err, promptsvc.ErrNothingToRedo) {
+		t.Fatalf("expected nothing to redo, got %v", err)
+	}
+
+	for i := 0; i < promptdomain.WorkspaceHistoryLimit+3; i++ {
+		if _, err := workspace.UpdateDraftBody(ctx, 1, token, promptdomain.WorkspaceAnyVersion, "循环"); err != nil {
+			t.Fatalf("update draft %d: %v", i, err)
+		}
+	}
+	var count int64
+	if err := db.Model(&promptdomain.WorkspaceHistoryRecord{}).Where("token = ?", token).Count(&count).Error; err != nil || count != promptdomain.WorkspaceHistoryLimit {
+		t.Fatalf("expected history to be bounded, got %d err=%v", count, err)
+	}
+	if err := workspace.Delete(ctx, 1, token); err != nil {
+		t.Fatalf("delete workspace: %v", err)
+	}
+	if err := db.Model(&promptdomain.WorkspaceHistoryRecord{}).Where("token = ?", token).Count(&count).Error; err != nil || count != 0 {
+		t.Fatalf("expected history to be deleted with workspace, got %d err=%v", count, err)
+	}
+}
+
+// TestPromptServiceGenerateUndoRestoresDraft 验证生成结果的正文与生成配置作为一条历史写入工作区，撤销一次即可回到生成前的状态。
+func TestPromptServiceGenerateUndoRestoresDraft(t *testing.T) {
+	db := newLocalWorkspaceDB(t)
+	workspace := repository.NewPromptWorkspaceRepository(db, time.Hour)
+	modelStub := &fakeModelInvoker{responses: []llm.Response{
+		{Model: "deepseek-chat", Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "生成后的正文"}}}},
+		buildAuditResponse(t, true, ""),
+	}}
+	service, err := promptsvc.NewServiceWithConfig(nil, nil, modelStub, workspace, nil, nil, nil, nil, promptsvc.Config{})
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+	ctx := context.Background()
+	token, err := workspace.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{
+		Topic:      "生成撤销",
+		Version:    1,
+		DraftBody:  "生成前的正文",
+		Attributes: map[string]string{"generation_profile": `{"temperature":0.3}`},
+	})
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+	before, err := workspace.Snapshot(ctx, 1, token)
+	if err != nil {
+		t.Fatalf("load snapshot: %v", err)
+	}
+
+	out, err := service.GeneratePrompt(ctx, promptsvc.GenerateInput{
+		UserID:            1,
+		Topic:             "生成撤销",
+		ModelKey:          "deepseek-chat",
+		PositiveKeywords:  []promptsvc.KeywordItem{{Word: "React"}},
+		WorkspaceToken:    token,
+		GenerationProfile: &promptdomain.GenerationProfile{Temperature: 0.9, MaxOutputTokens: 512},
+	})
+	if err != nil {
+		t.Fatalf("generate prompt: %v", err)
+	}
+	if out.WorkspaceVersion != 2 {
+		t.Fatalf("expected one workspace write per generation, got version %d", out.WorkspaceVersion)
+	}
+	generated, err := workspace.Snapshot(ctx, 1, token)
+	if err != nil || generated.DraftBody != "生成后的正文" || generated.Attributes["generation_profile"] == before.Attributes["generation_profile"] {
+		t.Fatalf("expected generated body and profile, got %+v err=%v", generated, err)
+	}
+
+	restored, err := service.UndoWorkspace(ctx, promptsvc.WorkspaceHistoryInput{UserID: 1, WorkspaceToken: token, WorkspaceVersion: out.WorkspaceVersion})
+	if err != nil {
+		t.Fatalf("undo generation: %v", err)
+	}
+	if restored.DraftBody != before.DraftBody || restored.Attributes["generation_profile"] != before.Attributes["generation_profile"] {
+		t.Fatalf("expected single undo to restore pre-generate state, got body=%q attrs=%v", restored.DraftBody, restored.Attributes)
+	}
+	if _, err := service.UndoWorkspace(ctx, promptsvc.WorkspaceHistoryInput{UserID: 1, WorkspaceToken: token, WorkspaceVersion: promptdomain.WorkspaceAnyVersion}); !errors.Is(err, promptsvc.ErrNothingToUndo) {
+		t.Fatalf("expected generation to leave a single history entry, got %v", err)
+	}
+}
+
+// TestPromptServiceListAndPromoteWorkspaces 验证工作区列表只返回本人未过期的工作区，并支持转存为草稿与丢弃。
+func TestPromptServiceListAndPromoteWorkspaces(t *testing.T) {
+	db := newLocalWorkspaceDB(t)
+	if err := db.AutoMigrate(&promptdomain.Prompt{}, &promptdomain.Keyword{}, &promptdomain.PromptKeyword{}, &promptdomain.PromptLike{}, &promptdomain.PromptVersion{}); err != nil {
+		t.Fatalf("auto migrate prompts: %v", err)
+	}
+	workspace := repository.NewPromptWorkspaceRepository(db, time.Hour)
+	service, err := promptsvc.NewServiceWithConfig(
+		repository.NewPromptRepository(db),
+		repository.NewKeywordRepository(db),
+		&fakeModelInvoker{},
+		workspace,
+		nil,
+		nil,
+		nil,
+		nil,
+		promptsvc.Config{},
+	)
+	if err != nil {
+		t.Fatalf("init prompt service: %v", err)
+	}
+	ctx := context.Background()
+
+	older, err := workspace.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{Topic: "旧工作区", DraftBody: "旧正文", UpdatedAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("create older workspace: %v", err)
+	}
+	newer, err := workspace.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{
+		Topic:     "新工作区",
+		DraftBody: strings.Repeat("长", promptdomain.WorkspaceDraftPreviewRunes+10),
+		Positive:  []promptdomain.WorkspaceKeyword{{Word: "草稿", Polarity: promptdomain.KeywordPolarityPositive, Weight: 3}},
+	})
+	if err != nil {
+		t.Fatalf("create newer workspace: %v", err)
+	}
+	if _, err := workspace.CreateOrReplace(ctx, 2, promptdomain.WorkspaceSnapshot{Topic: "他人工作区"}); err != nil {
+		t.Fatalf("create other workspace: %v", err)
+	}
+
+	items, err := service.ListWorkspaces(ctx, 1)
+	if err != nil || len(items) != 2 || items[0].Token != newer || items[1].Token != older {
+		t.Fatalf("expected own workspaces newest first, got %+v err=%v", items, err)
+	}
+	if preview := []rune(items[0].DraftPreview); len(preview) != promptdomain.WorkspaceDraftPreviewRunes+1 || items[1].DraftPreview != "旧正文" {
+		t.Fatalf("expected truncated draft preview, got %q / %q", items[0].DraftPreview, items[1].DraftPreview)
+	}
+
+	out, err := service.PromoteWorkspace(ctx, 1, newer)
+	if err != nil || out.PromptID == 0 || out.Status != promptdomain.PromptStatusDraft {
+		t.Fatalf("expected workspace promoted to draft, got %+v err=%v", out, err)
+	}
+	items, err = service.ListWorkspaces(ctx, 1)
+	if err != nil || len(items) != 2 || items[0].Token != newer || items[0].PromptID != out.PromptID {
+		t.Fatalf("expected promoted workspace to keep prompt id, got %+v err=%v", items, err)
+	}
+	if _, err := service.PromoteWorkspace(ctx, 2, newer); !errors.Is(err, promptsvc.ErrWorkspaceNotFound) {
+		t.Fatalf("expected other user's promote to miss, got %v", err)
+	}
+
+	if err := service.DiscardWorkspace(ctx, 1, older); err != nil {
+		t.Fatalf("discard workspace: %v", err)
+	}
+	if err := service.DiscardWorkspace(ctx, 1, older); !errors.Is(err, promptsvc.ErrWorkspaceNotFound) {
+		t.Fatalf("expected discarded workspace to be gone, got %v", err)
+	}
+	items, err = service.ListWorkspaces(ctx, 1)
+	if err != nil || len(items) != 1 || items[0].Token != newer {
+		t.Fatalf("expected only promoted workspace to remain, got %+v err=%v", items, err)
+	}
+}
@@ -0,0 +1,221 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	promptdomain "electron-go-app/backend/internal/domain/prompt"
+	promptinfra "electron-go-app/backend/internal/infra/prompt"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// TestWorkspaceStoreVersionConflict 验证 Redis 工作区写入会递增版本号，携带过期版本的写入返回冲突且不修改数据，属性无变化时版本保持不变。
+func TestWorkspaceStoreVersionConflict(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("start miniredis: %v", err)
+	}
+	defer server.Close()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	store := promptinfra.NewWorkspaceStore(client)
+	token, err := store.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{
+		Topic:    "并发编辑",
+		Version:  1,
+		Positive: []promptdomain.WorkspaceKeyword{{Word: "React", Polarity: promptdomain.KeywordPolarityPositive, Score: 1}},
+	})
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+
+	version, err := store.MergeKeywords(ctx, 1, token, 1, []promptdomain.WorkspaceKeyword{
+		{Word: "Hooks", Polarity: promptdomain.KeywordPolarityPositive, Score: 2},
+	})
+	if err != nil || version != 2 {
+		t.Fatalf("expected merge to bump version to 2, got %d err=%v", version, err)
+	}
+	// 另一个窗口仍持有版本 1，删除关键词应被拒绝。
+	if _, err := store.RemoveKeyword(ctx, 1, token, 1, promptdomain.KeywordPolarityPositive, "React"); !errors.Is(err, promptdomain.ErrWorkspaceVersionConflict) {
+		t.Fatalf("expected version conflict, got %v", err)
+	}
+	if _, err := store.ReplaceKeywords(ctx, 1, token, 1, nil, nil); !errors.Is(err, promptdomain.ErrWorkspaceVersionConflict) {
+		t.Fatalf("expected replace conflict, got %v", err)
+	}
+	snapshot, err := store.Snapshot(ctx, 1, token)
+	if err != nil || snapshot.Version != 2 || len(snapshot.Positive) != 2 {
+		t.Fatalf("expected rejected writes to leave workspace untouched, got %+v err=%v", snapshot, err)
+	}
+
+	if version, err = store.UpdateDraftBody(ctx, 1, token, promptdomain.WorkspaceAnyVersion, "草稿"); err != nil || version != 3 {
+		t.Fatalf("expected unchecked draft write to bump version to 3, got %d err=%v", version, err)
+	}
+	if version, err = store.SetAttributes(ctx, 1, token, 3, map[string]string{"tags": "前端"}); err != nil || version != 4 {
+		t.Fatalf("expected attribute write to bump version to 4, got %d err=%v", version, err)
+	}
+	if version, err = store.SetAttributes(ctx, 1, token, promptdomain.WorkspaceAnyVersion, map[string]string{"tags": "前端"}); err != nil || version != 4 {
+		t.Fatalf("expected unchanged attributes to keep version 4, got %d err=%v", version, err)
+	}
+	if version, err = store.ReplaceKeywords(ctx, 1, token, 4, []promptdomain.WorkspaceKeyword{
+		{Word: "Hooks", Polarity: promptdomain.KeywordPolarityPositive, Score: 1},
+	}, nil); err != nil || version != 5 {
+		t.Fatalf("expected replace to bump version to 5, got %d err=%v", version, err)
+	}
+	snapshot, err = store.Snapshot(ctx, 1, token)
+	if err != nil || snapshot.Version != 5 || snapshot.DraftBody != "草稿" || len(snapshot.Positive) != 1 || snapshot.Positive[0].Word != "Hooks" {
+		t.Fatalf("unexpected final snapshot: %+v err=%v", snapshot, err)
+	}
+}
+
+// TestWorkspaceStoreUndoRedo 验证 Redis 工作区的撤销/重做栈：撤销恢复关键词、草稿与属性，新的修改清空重做栈，历史与工作区共享 TTL。
+func TestWorkspaceStoreUndoRedo(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("start miniredis: %v", err)
+	}
+	defer server.Close()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	store := promptinfra.NewWorkspaceStore(client, promptinfra.WithWorkspaceTTL(time.Minute))
+	token, err := store.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{
+		Topic:     "撤销重做",
+		Version:   1,
+		DraftBody: "初稿",
+		Positive:  []promptdomain.WorkspaceKeyword{{Word: "React", Polarity: promptdomain.KeywordPolarityPositive, Score: 1}},
+	})
+	if err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+	if _, err := store.Undo(ctx, 1, token, promptdomain.WorkspaceAnyVersion); !errors.Is(err, promptdomain.ErrWorkspaceHistoryEmpty) {
+		t.Fatalf("expected empty history on fresh workspace, got %v", err)
+	}
+
+	if _, err := store.RemoveKeyword(ctx, 1, token, promptdomain.WorkspaceAnyVersion, promptdomain.KeywordPolarityPositive, "React"); err != nil {
+		t.Fatalf("remove keyword: %v", err)
+	}
+	if _, err := store.UpdateDraftBody(ctx, 1, token, promptdomain.WorkspaceAnyVersion, "二稿"); err != nil {
+		t.Fatalf("update draft: %v", err)
+	}
+	if _, err := store.SetAttributes(ctx, 1, token, promptdomain.WorkspaceAnyVersion, map[string]string{"tags": "前端"}); err != nil {
+		t.Fatalf("set attributes: %v", err)
+	}
+	if ttl := server.TTL("prompt:workspace:1:" + token + ":undo"); ttl != time.Minute {
+		t.Fatalf("expected undo stack to share workspace ttl, got %v", ttl)
+	}
+
+	// 撤销属性修改后再撤销正文修改。
+	version, err := store.Undo(ctx, 1, token, 4)
+	if err != nil || version != 5 {
+		t.Fatalf("expected undo to bump version to 5, got %d err=%v", version, err)
+	}
+	if _, err := store.Undo(ctx, 1, token, 4); !errors.Is(err, promptdomain.ErrWorkspaceVersionConflict) {
+		t.Fatalf("expected stale undo to conflict, got %v", err)
+	}
+	if _, err := store.Undo(ctx, 1, token, version); err != nil {
+		t.Fatalf("second undo: %v", err)
+	}
+	snapshot, err := store.Snapshot(ctx, 1, token)
+	if err != nil || snapshot.DraftBody != "初稿" || len(snapshot.Attributes) != 0 || len(snapshot.Positive) != 0 {
+		t.Fatalf("expected draft and attributes to be restored, got %+v err=%v", snapshot, err)
+	}
+	if _, err := store.Undo(ctx, 1, token, promptdomain.WorkspaceAnyVersion); err != nil {
+		t.Fatalf("third undo: %v", err)
+	}
+	snapshot, err = store.Snapshot(ctx, 1, token)
+	if err != nil || len(snapshot.Positive) != 1 || snapshot.Positive[0].Word != "React" || snapshot.Positive[0].Score != 1 {
+		t.Fatalf("expected removed keyword to be restored with its score, got %+v err=%v", snapshot, err)
+	}
+
+	if _, err := store.Redo(ctx, 1, token, promptdomain.WorkspaceAnyVersion); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	snapshot, err = store.Snapshot(ctx, 1, token)
+	if err != nil || len(snapshot.Positive) != 0 || snapshot.DraftBody != "初稿" {
+		t.Fatalf("expected redo to remove keyword again, got %+v err=%v", snapshot, err)
+	}
+	// 新的修改会清空重做栈。
+	if _, err := store.UpdateDraftBody(ctx, 1, token, promptdomain.WorkspaceAnyVersion, "三稿"); err != nil {
+		t.Fatalf("update draft: %v", err)
+	}
+	if _, err := store.Redo(ctx, 1, token, promptdomain.WorkspaceAnyVersion); !errors.Is(err, promptdomain.ErrWorkspaceHistoryEmpty) {
+		t.Fatalf("expected redo stack to be cleared, got %v", err)
+	}
+
+	// 正文与属性一并写入时只产生一条历史，撤销一次即同时恢复两者。
+	if _, err := store.UpdateDraft(ctx, 1, token, promptdomain.WorkspaceAnyVersion, "四稿", map[string]string{"generation_profile": `{"temperature":0.9}`}); err != nil {
+		t.Fatalf("update draft with attributes: %v", err)
+	}
+	if _, err := store.Undo(ctx, 1, token, promptdomain.WorkspaceAnyVersion); err != nil {
+		t.Fatalf("undo combined update: %v", err)
+	}
+	snapshot, err = store.Snapshot(ctx, 1, token)
+	if err != nil || snapshot.DraftBody != "三稿" || len(snapshot.Attributes) != 0 {
+		t.Fatalf("expected combined update to be undone at once, got %+v err=%v", snapshot, err)
+	}
+
+	for i := 0; i < promptdomain.WorkspaceHistoryLimit+5; i++ {
+		if _, err := store.UpdateDraftBody(ctx, 1, token, promptdomain.WorkspaceAnyVersion, "循环"); err != nil {
+			t.Fatalf("update draft %d: %v", i, err)
+		}
+	}
+	if length, err := client.LLen(ctx, "prompt:workspace:1:"+token+":undo").Result(); err != nil || length != promptdomain.WorkspaceHistoryLimit {
+		t.Fatalf("expected undo stack to be bounded, got %d err=%v", length, err)
+	}
+}
+
+// TestWorkspaceStoreListIndex 验证 Redis 工作区索引按更新时间倒序列出本人的工作区，并清理已过期或已删除的 token。
+func TestWorkspaceStoreListIndex(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("start miniredis: %v", err)
+	}
+	defer server.Close()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	store := promptinfra.NewWorkspaceStore(client, promptinfra.WithWorkspaceTTL(time.Hour))
+	older, err := store.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{Topic: "旧工作区", DraftBody: "旧正文", UpdatedAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("create older workspace: %v", err)
+	}
+	newer, err := store.CreateOrReplace(ctx, 1, promptdomain.WorkspaceSnapshot{Topic: "新工作区", PromptID: 7, Version: 3})
+	if err != nil {
+		t.Fatalf("create newer workspace: %v", err)
+	}
+	if _, err := store.CreateOrReplace(ctx, 2, promptdomain.WorkspaceSnapshot{Topic: "他人工作区"}); err != nil {
+		t.Fatalf("create other workspace: %v", err)
+	}
+
+	items, err := store.List(ctx, 1)
+	if err != nil || len(items) != 2 || items[0].Token != newer || items[1].Token != older {
+		t.Fatalf("expected own workspaces newest first, got %+v err=%v", items, err)
+	}
+	if items[0].PromptID != 7 || items[0].Version != 3 || items[1].DraftPreview != "旧正文" || items[0].ExpiresAt.Before(time.Now()) {
+		t.Fatalf("unexpected summaries: %+v", items)
+	}
+
+	// 模拟工作区 key 先于索引过期，以及通过 Delete 主动丢弃。
+	if err := client.Del(ctx, "prompt:workspace:1:"+older).Err(); err != nil {
+		t.Fatalf("expire older workspace: %v", err)
+	}
+	if items, err = store.List(ctx, 1); err != nil || len(items) != 1 || items[0].Token != newer {
+		t.Fatalf("expected expired workspace to be skipped, got %+v err=%v", items, err)
+	}
+	if members, err := client.ZRange(ctx, "prompt:workspace:index:1", 0, -1).Result(); err != nil || len(members) != 1 {
+		t.Fatalf("expected stale token to be pruned from index, got %v err=%v", members, err)
+	}
+	if err := store.Delete(ctx, 1, newer); err != nil {
+		t.Fatalf("delete workspace: %v", err)
+	}
+	if items, err = store.List(ctx, 1); err != nil || len(items) != 0 {
+		t.Fatalf("expected no workspaces after delete, got %+v err=%v", items, err)
+	}
+}
@@ -0,0 +1,244 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"electron-go-app/backend/internal/domain/llm"
+	domain "electron-go-app/backend/internal/domain/user"
+	"electron-go-app/backend/internal/infra/model/replay"
+	"electron-go-app/backend/internal/repository"
+	modelsvc "electron-go-app/backend/internal/service/model"
+)
+
+// countingProvider 是录制测试用的上游模型，记录被调用的次数。
+type countingProvider struct {
+	calls atomic.Int32
+	text  string
+}
+
+func (p *countingProvider) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	p.calls.Add(1)
+	return llm.Response{
+		ID:      "upstream",
+		Model:   req.Model,
+		Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: p.text}, FinishReason: "stop"}},
+		Usage:   &llm.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		Cost:    &llm.Cost{Amount: 1, Currency: "USD"},
+	}, nil
+}
+
+func (p *countingProvider) CompleteStream(ctx context.Context, req llm.Request, onDelta llm.StreamHandler) (llm.Response, error) {
+	if err := onDelta(p.text); err != nil {
+		return llm.Response{}, err
+	}
+	return p.Complete(ctx, req)
+}
+
+func TestReplayClientRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "demo.json")
+	upstream := &countingProvider{text: "recorded answer"}
+	req := llm.Request{
+		Model:       "deepseek-chat",
+		Messages:    []llm.Message{{Role: "user", Content: "hello"}},
+		ExtraFields: map[string]any{"seed": float64(7)},
+	}
+	ctx := context.Background()
+
+	recorder := replay.NewClient(path, replay.WithMode(replay.ModeRecord), replay.WithUpstream(upstream))
+	if _, err := recorder.Complete(ctx, req); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cassette: %v", err)
+	}
+	var file struct {
+		Version      int                  `json:"version"`
+		Interactions []replay.Interaction `json:"interactions"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		t.Fatalf("decode cassette: %v", err)
+	}
+	key, err := replay.RequestKey(req)
+	if err != nil {
+		t.Fatalf("request key: %v", err)
+	}
+	if file.Version != 1 || len(file.Interactions) != 1 || file.Interactions[0].Key != key {
+		t.Fatalf("unexpected cassette content: %s", raw)
+	}
+	if file.Interactions[0].Response.Cost != nil {
+		t.Fatalf("expected cost to be stripped from cassette")
+	}
+
+	player := replay.NewClient(path)
+	resp, err := player.Complete(ctx, req)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if resp.Text() != "recorded answer" || resp.Usage == nil || resp.Usage.TotalTokens != 5 {
+		t.Fatalf("unexpected replayed response: %+v", resp)
+	}
+	var streamed string
+	if _, err := player.CompleteStream(ctx, req, func(delta string) error {
+		streamed += delta
+		return nil
+	}); err != nil {
+		t.Fatalf("replay stream: %v", err)
+	}
+	if streamed != "recorded answer" {
+		t.Fatalf("expected streamed replay text, got %q", streamed)
+	}
+
+	changed := req
+	changed.ExtraFields = map[string]any{"seed": float64(8)}
+	if _, err := player.Complete(ctx, changed); !errors.Is(err, replay.ErrCassetteMiss) {
+		t.Fatalf("expected cassette miss, got %v", err)
+	}
+
+	auto := replay.NewClient(path, replay.WithMode(replay.ModeAuto), replay.WithUpstream(upstream))
+	if _, err := auto.Complete(ctx, req); err != nil {
+		t.Fatalf("auto hit: %v", err)
+	}
+	if _, err := auto.Complete(ctx, changed); err != nil {
+		t.Fatalf("auto record: %v", err)
+	}
+	if calls := upstream.calls.Load(); calls != 2 {
+		t.Fatalf("expected upstream to be called twice, got %d", calls)
+	}
+	if _, err := player.Complete(ctx, changed); err != nil {
+		t.Fatalf("expected auto mode recording to be replayable: %v", err)
+	}
+
+	if _, err := replay.ParseMode("rewind"); !errors.Is(err, replay.ErrInvalidMode) {
+		t.Fatalf("expected invalid mode error, got %v", err)
+	}
+}
+
+func TestModelServiceReplayProvider(t *testing.T) {
+	var hits atomic.Int32
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if _, ok := payload["replay_mode"]; ok {
+			t.Fatalf("replay options must not be forwarded upstream: %v", payload)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "live",
+			"model":   "deepseek-chat",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "live answer"}, "finish_reason": "stop"}},
+			"usage":   map[string]any{"prompt_tokens": 5, "completion_tokens": 2, "total_tokens": 7},
+		})
+	}))
+
+	svc, db, userRepo, userID := newTestModelService(t)
+	ctx := context.Background()
+	if _, err := svc.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:    "replay",
+		ModelKey:    "replay-demo",
+		DisplayName: "Replay",
+		APIKey:      "unused",
+	}); !errors.Is(err, modelsvc.ErrUnsupportedProvider) {
+		t.Fatalf("expected replay provider to require a cassette dir, got %v", err)
+	}
+
+	dir := t.TempDir()
+	recorder := modelsvc.NewServiceWithConfig(repository.NewModelCredentialRepository(db), userRepo, nil, modelsvc.Config{
+		Retry:  llm.RetryPolicy{MaxRetries: 0},
+		Replay: modelsvc.ReplayConfig{Dir: dir},
+	})
+	if _, err := recorder.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:    "replay",
+		ModelKey:    "deepseek-chat",
+		DisplayName: "DeepSeek (recorded)",
+		BaseURL:     server.URL,
+		APIKey:      "sk-test",
+		ExtraConfig: map[string]any{"upstream_provider": "deepseek", "replay_mode": "auto", "cassette": "flow"},
+	}); err != nil {
+		t.Fatalf("create replay credential: %v", err)
+	}
+	req := llm.Request{Messages: []llm.Message{{Role: "user", Content: "ping"}}}
+	for i := 0; i < 2; i++ {
+		resp, err := recorder.InvokeChatCompletion(ctx, userID, "deepseek-chat", req)
+		if err != nil {
+			t.Fatalf("invoke #%d: %v", i, err)
+		}
+		if resp.Text() != "live answer" {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected the second call to be replayed, upstream hits=%d", got)
+	}
+	userDir := filepath.Join(dir, strconv.FormatUint(uint64(userID), 10))
+	if _, err := os.Stat(filepath.Join(userDir, "flow.json")); err != nil {
+		t.Fatalf("expected cassette file under the user's directory: %v", err)
+	}
+	server.Close()
+
+	// 全局回放模式下普通凭据同样从 cassette 读取，上游已下线也能得到相同结果。
+	player := modelsvc.NewServiceWithConfig(repository.NewModelCredentialRepository(db), userRepo, nil, modelsvc.Config{
+		Retry:  llm.RetryPolicy{MaxRetries: 0},
+		Replay: modelsvc.ReplayConfig{Dir: userDir, Mode: replay.ModeReplay, Cassette: "flow.json"},
+	})
+	if _, err := player.Create(ctx, userID, modelsvc.CreateInput{
+		Provider:    "deepseek",
+		ModelKey:    "deepseek-offline",
+		DisplayName: "DeepSeek offline",
+		BaseURL:     server.URL,
+		APIKey:      "sk-test",
+	}); err != nil {
+		t.Fatalf("create deepseek credential: %v", err)
+	}
+	offline := req
+	offline.Model = "deepseek-chat"
+	resp, err := player.InvokeChatCompletion(ctx, userID, "deepseek-offline", offline)
+	if err != nil {
+		t.Fatalf("global replay: %v", err)
+	}
+	if resp.Text() != "live answer" || resp.Usage == nil || resp.Usage.TotalTokens != 7 {
+		t.Fatalf("unexpected replayed response: %+v", resp)
+	}
+	offline.Messages = []llm.Message{{Role: "user", Content: "unrecorded"}}
+	if _, err := player.InvokeChatCompletion(ctx, userID, "deepseek-offline", offline); !errors.Is(err, replay.ErrCassetteMiss) {
+		t.Fatalf("expected cassette miss, got %v", err)
+	}
+
+	// 其他用户即使使用同名 cassette 也只会读取自己的目录，无法回放他人录制的内容，也不能借助相对路径跳出目录。
+	other := domain.User{Username: "other", Email: "other@example.com", Settings: "{}"}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatalf("create other user: %v", err)
+	}
+	for idx, cassette := range []string{"flow", "..", "..flow", "../" + strconv.FormatUint(uint64(userID), 10) + "/flow"} {
+		modelKey := "stolen-" + strconv.Itoa(idx)
+		if _, err := recorder.Create(ctx, other.ID, modelsvc.CreateInput{
+			Provider:    "replay",
+			ModelKey:    modelKey,
+			DisplayName: "Stolen",
+			APIKey:      "unused",
+			ExtraConfig: map[string]any{"cassette": cassette},
+		}); err != nil {
+			t.Fatalf("create replay credential for other user: %v", err)
+		}
+		stolen := req
+		stolen.Model = "deepseek-chat"
+		_, err := recorder.InvokeChatCompletion(ctx, other.ID, modelKey, stolen)
+		want := modelsvc.ErrInvalidCassette
+		if cassette == "flow" {
+			want = replay.ErrCassetteMiss
+		}
+		if !errors.Is(err, want) {
+			t.Fatalf("cassette %q: expected %v, got %v", cassette, want, err)
+		}
+	}
+}
@@ -9,6 +9,7 @@ package unit
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	domain "electron-go-app/backend/internal/domain/user"
@@ -103,7 +104,7 @@ func TestUserService_UpdateSettings(t *testing.T) {
 	if err != nil {
 		t.Fatalf("UpdateSettings returned error: %v", err)
 	}
-	if profile.Settings != desired {
+	if !reflect.DeepEqual(profile.Settings, desired) {
 		t.Fatalf("settings not updated: %+v", profile.Settings)
 	}
 
@@ -115,7 +116,7 @@ func TestUserService_UpdateSettings(t *testing.T) {
 	if err != nil {
 		t.Fatalf("parse stored settings: %v", err)
 	}
-	if parsed != desired {
+	if !reflect.DeepEqual(parsed, desired) {
 		t.Fatalf("expected stored settings %+v, got %+v", desired, parsed)
 	}
 }
@@ -361,3 +362,50 @@ func TestUserService_UpdateSettings_AllowsDefaultWithoutCredentials(t *testing.T
 		t.Fatalf("expected preferred model to remain default, got %s", profile.Settings.PreferredModel)
 	}
 }
+
+// TestUserService_UpdateSettings_FallbackChains 校验回退链会被清洗并拒绝未知操作与不可用模型。
+func TestUserService_UpdateSettings_FallbackChains(t *testing.T) {
+	svc, repo, db := setupUserService(t)
+
+	user := &domain.User{Username: "chain", Email: "chain@example.com"}
+	if err := repo.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	for _, cred := range []domain.UserModelCredential{
+		{UserID: user.ID, Provider: "volcengine", ModelKey: "doubao", DisplayName: "Doubao", Status: "enabled"},
+		{UserID: user.ID, Provider: "deepseek", ModelKey: "ds-own", DisplayName: "DeepSeek", Status: "disabled"},
+	} {
+		if err := db.Create(&cred).Error; err != nil {
+			t.Fatalf("create credential: %v", err)
+		}
+	}
+
+	settings := domain.DefaultSettings()
+	settings.FallbackChains = map[string][]string{
+		"Generate": {" doubao ", "doubao", "", domain.DefaultSettings().PreferredModel},
+	}
+	profile, err := svc.UpdateSettings(context.Background(), user.ID, settings)
+	if err != nil {
+		t.Fatalf("UpdateSettings returned error: %v", err)
+	}
+	expected := []string{"doubao", domain.DefaultSettings().PreferredModel}
+	if got := profile.Settings.FallbackChain(domain.FallbackOperationGenerate); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected generate chain: %v", got)
+	}
+	if got := profile.Settings.FallbackChain(domain.FallbackOperationAudit); len(got) != 0 {
+		t.Fatalf("expected empty audit chain, got %v", got)
+	}
+
+	settings.FallbackChains = map[string][]string{"translate": {"doubao"}}
+	if _, err := svc.UpdateSettings(context.Background(), user.ID, settings); !errors.Is(err, usersvc.ErrFallbackChainInvalid) {
+		t.Fatalf("expected ErrFallbackChainInvalid, got %v", err)
+	}
+	settings.FallbackChains = map[string][]string{"default": {"ds-own"}}
+	if _, err := svc.UpdateSettings(context.Background(), user.ID, settings); !errors.Is(err, usersvc.ErrFallbackModelDisabled) {
+		t.Fatalf("expected ErrFallbackModelDisabled, got %v", err)
+	}
+	settings.FallbackChains = map[string][]string{"default": {"missing"}}
+	if _, err := svc.UpdateSettings(context.Background(), user.ID, settings); !errors.Is(err, usersvc.ErrFallbackModelNotFound) {
+		t.Fatalf("expected ErrFallbackModelNotFound, got %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"testing"
 
+	"electron-go-app/backend/internal/domain/llm"
 	volc "electron-go-app/backend/internal/infra/model/volcengine"
 )
 
@@ -64,3 +65,107 @@ func TestVolcengineClientChatCompletion(t *testing.T) {
 		t.Fatalf("unexpected response: %+v", response.Choices)
 	}
 }
+
+// TestVolcengineClientToolCalls 验证工具定义、assistant 工具调用与 tool 结果消息会透传给方舟，响应中的 tool_calls 会被解析。
+func TestVolcengineClientToolCalls(t *testing.T) {
+	server := newIPv4TestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Messages []struct {
+				Role       string `json:"role"`
+				ToolCallID string `json:"tool_call_id"`
+				ToolCalls  []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"messages"`
+			Tools []struct {
+				Type     string `json:"type"`
+				Function struct {
+					Name       string         `json:"name"`
+					Parameters map[string]any `json:"parameters"`
+				} `json:"function"`
+			} `json:"tools"`
+			ToolChoice map[string]any `json:"tool_choice"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if len(payload.Tools) != 1 || payload.Tools[0].Type != "function" || payload.Tools[0].Function.Name != "get_weather" {
+			t.Fatalf("unexpected tools: %+v", payload.Tools)
+		}
+		if payload.Tools[0].Function.Parameters["type"] != "object" {
+			t.Fatalf("expected tool parameters forwarded, got %+v", payload.Tools[0].Function.Parameters)
+		}
+		function, _ := payload.ToolChoice["function"].(map[string]any)
+		if payload.ToolChoice["type"] != "function" || function["name"] != "get_weather" {
+			t.Fatalf("unexpected tool_choice: %+v", payload.ToolChoice)
+		}
+		if len(payload.Messages) != 3 {
+			t.Fatalf("expected 3 messages, got %d", len(payload.Messages))
+		}
+		assistant := payload.Messages[1]
+		if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].ID != "call_1" || assistant.ToolCalls[0].Function.Arguments != `{"city":"北京"}` {
+			t.Fatalf("unexpected assistant tool calls: %+v", assistant.ToolCalls)
+		}
+		if payload.Messages[2].Role != "tool" || payload.Messages[2].ToolCallID != "call_1" {
+			t.Fatalf("unexpected tool message: %+v", payload.Messages[2])
+		}
+		resp := map[string]any{
+			"id":      "volc-tool",
+			"object":  "chat.completion",
+			"created": 100,
+			"model":   "doubao",
+			"choices": []any{
+				map[string]any{
+					"index": 0,
+					"message": map[string]any{
+						"role":    "assistant",
+						"content": "",
+						"tool_calls": []any{
+							map[string]any{
+								"id":       "call_2",
+								"type":     "function",
+								"function": map[string]any{"name": "get_weather", "arguments": `{"city":"上海"}`},
+							},
+						},
+					},
+					"finish_reason": "tool_calls",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := volc.NewClient("ark-test", volc.WithBaseURL(server.URL))
+	resp, err := client.Complete(context.Background(), llm.Request{
+		Model: "doubao",
+		Messages: []llm.Message{
+			{Role: "user", Content: "北京和上海天气如何？"},
+			{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "call_1", Type: "function", Function: llm.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"北京"}`}}}},
+			{Role: "tool", ToolCallID: "call_1", Content: `{"weather":"晴"}`},
+		},
+		Tools: []llm.Tool{{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:       "get_weather",
+				Parameters: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+			},
+		}},
+		ToolChoice: map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}},
+	})
+	if err != nil {
+		t.Fatalf("complete with tools failed: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].FinishReason != "tool_calls" {
+		t.Fatalf("unexpected choices: %+v", resp.Choices)
+	}
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) != 1 || calls[0].ID != "call_2" || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"上海"}` {
+		t.Fatalf("unexpected tool calls: %+v", calls)
+	}
+}
@@ -304,6 +304,7 @@ export interface PublicPromptDetail extends PublicPromptListItem {
   positive_keywords: PublicPromptKeywordItem[];
   negative_keywords: PublicPromptKeywordItem[];
   source_prompt_id?: number | null;
+  variables?: string;
 }
 
 export interface CreatorStats {
@@ -393,6 +394,7 @@ export interface PublicPromptSubmitPayload {
   positiveKeywords: string;
   negativeKeywords: string;
   tags: string;
+  variables?: string;
   model: string;
   language?: string;
 }
@@ -443,6 +445,68 @@ export interface IngestPromptPayload {
   body: string;
   model_key?: string;
   language?: string;
+  bypass_cache?: boolean;
+}
+
+export type PromptVariableType = "string" | "number" | "boolean" | "enum";
+
+/** Prompt 正文中 {{name}} 占位符对应的变量定义。 */
+export interface PromptVariable {
+  name: string;
+  type: PromptVariableType;
+  default?: string;
+  required?: boolean;
+  enum?: string[];
+  description?: string;
+}
+
+export interface RenderPromptPayload {
+  values: Record<string, string | number | boolean>;
+  version?: number;
+}
+
+export interface RenderPromptResponse {
+  body: string;
+  variables: PromptVariable[];
+  missing: string[];
+  unknown: string[];
+}
+
+export interface RunPromptPayload {
+  values?: Record<string, string | number | boolean>;
+  version?: number;
+  model_key?: string;
+  generation_profile?: PromptGenerationProfile;
+}
+
+export type PromptRunStatus = "succeeded" | "failed";
+
+/** 一次 Prompt 试运行的记录，包含渲染输入、模型输出与用量。 */
+export interface PromptRun {
+  id: number;
+  prompt_id: number;
+  version: number;
+  model_key: string;
+  model?: string;
+  values: Record<string, unknown>;
+  generation_profile: PromptGenerationProfile;
+  input: string;
+  output: string;
+  usage: {
+    prompt_tokens: number;
+    completion_tokens: number;
+    total_tokens: number;
+  };
+  cost?: { amount: number; currency: string };
+  latency_ms: number;
+  status: PromptRunStatus;
+  error?: string;
+  created_at: string;
+}
+
+export interface PromptRunListResponse {
+  items: PromptRun[];
+  meta: PromptListMeta;
 }
 
 export interface PromptVersionSummary {
@@ -461,6 +525,7 @@ export interface PromptVersionDetail {
   negative_keywords: PromptListKeyword[];
   created_at: string;
   generation_profile?: PromptGenerationProfile;
+  variables?: PromptVariable[];
 }
 
 export interface PromptDetailResponse {
@@ -474,6 +539,7 @@ export interface PromptDetailResponse {
   positive_keywords: PromptListKeyword[];
   negative_keywords: PromptListKeyword[];
   workspace_token?: string;
+  workspace_version?: number;
   created_at: string;
   updated_at: string;
   published_at?: string | null;
@@ -481,6 +547,7 @@ export interface PromptDetailResponse {
   is_liked?: boolean;
   like_count?: number;
   generation_profile?: PromptGenerationProfile;
+  variables?: PromptVariable[];
 }
 
 export interface AuthTokens {
@@ -506,9 +573,17 @@ export interface AuthUser {
   updated_at?: string;
 }
 
+export type ModelFallbackOperation =
+  | "default"
+  | "interpret"
+  | "augment"
+  | "generate"
+  | "audit";
+
 export interface UserSettings {
   preferred_model: string;
   enable_animations: boolean;
+  fallback_chains?: Partial<Record<ModelFallbackOperation, string[]>>;
 }
 
 export interface AuthProfile {
@@ -519,6 +594,42 @@ export interface AuthProfile {
 // 模型凭据状态（默认仅启用/禁用，也保留字符串向后兼容）
 export type ModelStatus = "enabled" | "disabled" | string;
 
+// 模型凭据的熔断器状态：open / half_open 表示厂商降级
+export type ModelCircuitState = "closed" | "open" | "half_open";
+
+export interface ModelCircuitStatus {
+  state: ModelCircuitState;
+  consecutive_failures: number;
+  last_failure_at?: string | null;
+  last_error_kind?: string;
+  opened_at?: string | null;
+  retry_at?: string | null;
+}
+
+// 价格表中的单价（每百万 token）
+export interface ModelPrice {
+  provider?: string;
+  model?: string;
+  input: number;
+  output: number;
+  cached_input?: number;
+}
+
+// 按价格表估算的调用费用
+export interface ModelCost {
+  amount: number;
+  currency: string;
+}
+
+// 后台健康检查结果，revoked 表示 API Key 可能已被吊销
+export interface ModelCredentialHealth {
+  status: "healthy" | "unhealthy" | "revoked";
+  checked_at?: string | null;
+  latency_ms: number;
+  last_error?: string;
+  consecutive_failures?: number;
+}
+
 // 用户保存的模型凭据结构体，后端会脱敏返回
 export interface UserModelCredential {
   id: number;
@@ -530,6 +641,12 @@ export interface UserModelCredential {
   extra_config: Record<string, unknown>;
   status: ModelStatus;
   last_verified_at?: string | null;
+  circuit?: ModelCircuitStatus | null;
+  health?: ModelCredentialHealth | null;
+  monthly_budget?: number | null;
+  month_to_date_cost?: number | null;
+  currency?: string;
+  price?: ModelPrice | null;
   created_at: string;
   updated_at: string;
   is_builtin?: boolean;
@@ -538,32 +655,93 @@ export interface UserModelCredential {
   reset_after_seconds?: number | null;
 }
 
+// 模型调用用量汇总，对应 GET /api/models/:id/usage
+export interface ModelUsageTotals {
+  requests: number;
+  failures: number;
+  prompt_tokens: number;
+  completion_tokens: number;
+  reasoning_tokens: number;
+  cached_tokens: number;
+  total_tokens: number;
+  average_latency_ms: number;
+  estimated_cost: number;
+}
+
+export interface ModelUsageDaily extends ModelUsageTotals {
+  date: string;
+  operations: Record<string, ModelUsageTotals>;
+}
+
+export interface ModelUsageSummary {
+  credential_id: number;
+  provider: string;
+  model_key: string;
+  currency: string;
+  from: string;
+  to: string;
+  totals: ModelUsageTotals;
+  daily: ModelUsageDaily[];
+}
+
+export type ModelCapability =
+  | "chat"
+  | "reasoning"
+  | "vision"
+  | "tools"
+  | "json_output";
+
+export interface ModelCatalogEntry {
+  id: string;
+  display_name?: string;
+  owned_by?: string;
+  context_window?: number;
+  capabilities?: ModelCapability[];
+}
+
+export interface ModelCatalog {
+  credential_id: number;
+  provider: string;
+  models: ModelCatalogEntry[];
+  fetched_at: string;
+  cached: boolean;
+}
+
 export interface ChatCompletionMessage {
   role: string;
   content: string;
 }
 
+export interface ChatCompletionReasoning {
+  content: string;
+  signature?: string;
+}
+
 export interface ChatCompletionChoice {
   index: number;
   message: ChatCompletionMessage;
+  reasoning?: ChatCompletionReasoning;
   finish_reason?: string;
-  logprobs?: unknown;
 }
 
 export interface ChatCompletionUsage {
   prompt_tokens?: number;
   completion_tokens?: number;
   total_tokens?: number;
-  [key: string]: unknown;
+  cached_tokens?: number;
+  cache_creation_tokens?: number;
+  reasoning_tokens?: number;
+  extra?: Record<string, number>;
 }
 
 export interface ChatCompletionResponse {
   id: string;
-  object: string;
   created: number;
   model: string;
   choices: ChatCompletionChoice[];
   usage?: ChatCompletionUsage;
+  service_tier?: string;
+  system_fingerprint?: string;
 }
 
 // 新增模型凭据时的入参
@@ -574,6 +752,9 @@ export interface CreateUserModelRequest {
   base_url?: string;
   api_key: string;
   extra_config?: Record<string, unknown>;
+  monthly_budget?: number;
+  // 为 true 时先确认 model_key 出现在提供方的模型列表中
+  validate_model?: boolean;
 }
 
 // 更新模型凭据支持的字段（包括启用/禁用）
@@ -583,6 +764,9 @@ export interface UpdateUserModelRequest {
   api_key?: string;
   extra_config?: Record<string, unknown>;
   status?: ModelStatus;
+  // 传 0 表示取消预算
+  monthly_budget?: number;
+  validate_model?: boolean;
 }
 
 export interface TestUserModelRequest {
@@ -610,6 +794,8 @@ export interface PromptKeywordResult {
   polarity: KeywordPolarity;
   source?: string;
   weight?: number;
+  // 手动录入写入工作区后的最新版本号
+  workspace_version?: number;
 }
 
 export interface InterpretPromptResponse {
@@ -618,8 +804,13 @@ export interface InterpretPromptResponse {
   positive_keywords: PromptKeywordResult[];
   negative_keywords: PromptKeywordResult[];
   workspace_token?: string;
+  workspace_version?: number;
   instructions?: string;
   tags?: string[];
+  served_model_key?: string;
+  fallback_used?: boolean;
+  // 模型响应是否来自缓存（未扣减免费额度）
+  cached?: boolean;
 }
 
 export interface AugmentPromptKeywordsRequest {
@@ -631,11 +822,16 @@ export interface AugmentPromptKeywordsRequest {
   existing_positive: PromptKeywordInput[];
   existing_negative: PromptKeywordInput[];
   workspace_token?: string;
+  bypass_cache?: boolean;
 }
 
 export interface AugmentPromptKeywordsResponse {
   positive: PromptKeywordResult[];
   negative: PromptKeywordResult[];
+  workspace_version?: number;
+  served_model_key?: string;
+  fallback_used?: boolean;
+  cached?: boolean;
 }
 
 export interface ManualPromptKeywordRequest {
@@ -643,6 +839,7 @@ export interface ManualPromptKeywordRequest {
   word: string;
   polarity: KeywordPolarity;
   workspace_token?: string;
+  workspace_version?: number;
   prompt_id?: number;
   language?: string;
   weight?: number;
@@ -652,6 +849,12 @@ export interface RemovePromptKeywordRequest {
   word: string;
   polarity: KeywordPolarity;
   workspace_token?: string | null;
+  workspace_version?: number;
+}
+
+/** 工作区写操作返回的最新版本号；版本不一致时接口返回 409 并在 data.workspace 中附带最新快照。 */
+export interface PromptWorkspaceVersionResponse {
+  workspace_version?: number;
 }
 
 export interface GeneratePromptRequest {
@@ -672,6 +875,22 @@ export interface GeneratePromptRequest {
   include_keyword_reference?: boolean;
   workspace_token?: string;
   generation_profile?: PromptGenerationProfile;
+  candidates?: number;
+  rank_by?: "heuristic" | "judge";
+}
+
+export interface GeneratePromptCandidate {
+  index: number;
+  prompt: string;
+  model: string;
+  served_model_key?: string;
+  fallback_used?: boolean;
+  temperature: number;
+  duration_ms?: number;
+  usage?: ChatCompletionUsage;
+  cost?: ModelCost;
+  score?: number;
+  score_reason?: string;
 }
 
 export interface GeneratePromptResponse {
@@ -683,6 +902,13 @@ export interface GeneratePromptResponse {
   positive_keywords?: PromptKeywordResult[];
   negative_keywords?: PromptKeywordResult[];
   workspace_token?: string;
+  workspace_version?: number;
+  served_model_key?: string;
+  fallback_used?: boolean;
+  cost?: ModelCost;
+  candidates?: GeneratePromptCandidate[];
+  selected_index?: number;
+  ranked_by?: string;
 }
 
 export interface SavePromptRequest {
@@ -698,6 +924,8 @@ export interface SavePromptRequest {
   negative_keywords: PromptKeywordInput[];
   workspace_token?: string;
   generation_profile?: PromptGenerationProfile;
+  variables?: PromptVariable[];
+  async?: boolean;
 }
 
 export interface SavePromptResponse {
@@ -706,6 +934,23 @@ export interface SavePromptResponse {
   version: number;
   task_id?: string;
   workspace_token?: string;
+  workspace_version?: number;
+}
+
+export type PromptSaveTaskState = "pending" | "running" | "succeeded" | "failed";
+
+export interface PromptSaveTaskStatus {
+  task_id: string;
+  user_id: number;
+  state: PromptSaveTaskState;
+  attempts: number;
+  error?: string;
+  prompt_id?: number;
+  version?: number;
+  status?: string;
+  workspace_token?: string;
+  created_at: string;
+  updated_at: string;
 }
 
 export interface LoginRequest {
@@ -728,6 +973,7 @@ export interface UpdateCurrentUserRequest {
   avatar_url?: string | null;
   preferred_model?: string;
   enable_animations?: boolean;
+  fallback_chains?: Partial<Record<ModelFallbackOperation, string[]>>;
   profile_headline?: string | null;
   profile_bio?: string | null;
   profile_location?: string | null;
@@ -889,6 +1135,7 @@ export async function interpretPromptDescription(payload: {
   model_key: string;
   language?: string;
   workspace_token?: string | null;
+  bypass_cache?: boolean;
 }): Promise<InterpretPromptResponse> {
   try {
     const requestBody = {
@@ -896,6 +1143,7 @@ export async function interpretPromptDescription(payload: {
       model_key: payload.model_key,
       language: payload.language,
       workspace_token: payload.workspace_token ?? undefined,
+      bypass_cache: payload.bypass_cache || undefined,
     };
     const response: AxiosResponse<InterpretPromptResponse> = await http.post(
       "/prompts/interpret",
@@ -955,13 +1203,16 @@ export async function createManualPromptKeyword(
 
 export async function removePromptKeyword(
   payload: RemovePromptKeywordRequest,
-): Promise<void> {
+): Promise<PromptWorkspaceVersionResponse> {
   try {
-    await http.post("/prompts/keywords/remove", {
-      word: payload.word,
-      polarity: payload.polarity,
-      workspace_token: payload.workspace_token ?? undefined,
-    });
+    const response: AxiosResponse<PromptWorkspaceVersionResponse> =
+      await http.post("/prompts/keywords/remove", {
+        word: payload.word,
+        polarity: payload.polarity,
+        workspace_token: payload.workspace_token ?? undefined,
+        workspace_version: payload.workspace_version ?? undefined,
+      });
+    return response.data ?? {};
   } catch (error) {
     throw normaliseError(error);
   }
@@ -969,15 +1220,19 @@ export async function removePromptKeyword(
 
 export async function syncPromptWorkspaceKeywords(payload: {
   workspace_token: string;
+  workspace_version?: number;
   positive_keywords: PromptKeywordInput[];
   negative_keywords: PromptKeywordInput[];
-}): Promise<void> {
+}): Promise<PromptWorkspaceVersionResponse> {
   try {
-    await http.post("/prompts/keywords/sync", {
-      workspace_token: payload.workspace_token,
-      positive_keywords: payload.positive_keywords.map(normalisePromptKeyword),
-      negative_keywords: payload.negative_keywords.map(normalisePromptKeyword),
-    });
+    const response: AxiosResponse<PromptWorkspaceVersionResponse> =
+      await http.post("/prompts/keywords/sync", {
+        workspace_token: payload.workspace_token,
+        workspace_version: payload.workspace_version ?? undefined,
+        positive_keywords: payload.positive_keywords.map(normalisePromptKeyword),
+        negative_keywords: payload.negative_keywords.map(normalisePromptKeyword),
+      });
+    return response.data ?? {};
   } catch (error) {
     throw normaliseError(error);
   }
@@ -1029,6 +1284,8 @@ export async function generatePromptPreview(
           normalisePromptKeyword,
         ),
         generation_profile: generationProfile,
+        candidates: payload.candidates,
+        rank_by: payload.rank_by,
       },
     );
     return response.data;
@@ -1037,6 +1294,112 @@ export async function generatePromptPreview(
   }
 }
 
+export async function selectGeneratedCandidate(payload: {
+  workspace_token: string;
+  workspace_version?: number;
+  prompt: string;
+}): Promise<PromptWorkspaceVersionResponse> {
+  try {
+    const response: AxiosResponse<PromptWorkspaceVersionResponse> =
+      await http.post("/prompts/generate/select", payload);
+    return response.data ?? {};
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
+export interface PromptWorkspaceSummary {
+  workspace_token: string;
+  topic: string;
+  prompt_id?: number;
+  status?: string;
+  draft_preview?: string;
+  version: number;
+  updated_at: string;
+  expires_at: string;
+}
+
+/** 列出当前用户仍在有效期内的工作区，按更新时间倒序。 */
+export async function fetchPromptWorkspaces(): Promise<PromptWorkspaceSummary[]> {
+  try {
+    const response: AxiosResponse<{ items: PromptWorkspaceSummary[] }> =
+      await http.get("/prompts/workspaces");
+    return response.data?.items ?? [];
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
+/** 丢弃指定工作区。 */
+export async function discardPromptWorkspace(token: string): Promise<void> {
+  try {
+    await http.delete(`/prompts/workspaces/${encodeURIComponent(token)}`);
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
+/** 将工作区保存为草稿 Prompt，已关联 Prompt 时更新该 Prompt。 */
+export async function promotePromptWorkspace(
+  token: string,
+): Promise<SavePromptResponse> {
+  try {
+    const response: AxiosResponse<SavePromptResponse> = await http.post(
+      `/prompts/workspaces/${encodeURIComponent(token)}/promote`,
+    );
+    return response.data;
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
+export interface PromptWorkspaceHistoryResponse {
+  workspace: {
+    workspace_token: string;
+    topic: string;
+    draft_body?: string;
+    positive: PromptKeywordResult[];
+    negative: PromptKeywordResult[];
+    attributes?: Record<string, string>;
+    version: number;
+  };
+  workspace_version: number;
+}
+
+/** 撤销工作区最近一次修改；栈为空时接口返回 400。 */
+export async function undoPromptWorkspace(payload: {
+  workspace_token: string;
+  workspace_version?: number;
+}): Promise<PromptWorkspaceHistoryResponse> {
+  try {
+    const response: AxiosResponse<PromptWorkspaceHistoryResponse> =
+      await http.post(
+        `/prompts/workspace/${encodeURIComponent(payload.workspace_token)}/undo`,
+        { workspace_version: payload.workspace_version ?? undefined },
+      );
+    return response.data;
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
+/** 重做最近一次被撤销的修改。 */
+export async function redoPromptWorkspace(payload: {
+  workspace_token: string;
+  workspace_version?: number;
+}): Promise<PromptWorkspaceHistoryResponse> {
+  try {
+    const response: AxiosResponse<PromptWorkspaceHistoryResponse> =
+      await http.post(
+        `/prompts/workspace/${encodeURIComponent(payload.workspace_token)}/redo`,
+        { workspace_version: payload.workspace_version ?? undefined },
+      );
+    return response.data;
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
 export async function savePrompt(
   payload: SavePromptRequest,
 ): Promise<SavePromptResponse> {
@@ -1063,6 +1426,8 @@ export async function savePrompt(
           normalisePromptKeyword,
         ),
         generation_profile: generationProfile,
+        variables: payload.variables ?? undefined,
+        async: payload.async ?? undefined,
       },
     );
     return response.data;
@@ -1071,6 +1436,20 @@ export async function savePrompt(
   }
 }
 
+/** 查询异步保存任务的处理状态。 */
+export async function fetchPromptSaveTask(
+  taskId: string,
+): Promise<PromptSaveTaskStatus> {
+  try {
+    const response: AxiosResponse<PromptSaveTaskStatus> = await http.get(
+      `/prompts/tasks/${encodeURIComponent(taskId)}`,
+    );
+    return response.data;
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
 /** 列出当前用户配置的所有模型凭据。 */
 export async function fetchUserModels(): Promise<UserModelCredential[]> {
   try {
@@ -1129,6 +1508,38 @@ export async function testUserModel(
   }
 }
 
+/** 获取模型凭据最近 days 天的按日 token 用量。 */
+export async function fetchUserModelUsage(
+  id: number,
+  days?: number,
+): Promise<ModelUsageSummary> {
+  try {
+    const response: AxiosResponse<ModelUsageSummary> = await http.get(
+      `/models/${id}/usage`,
+      { params: days ? { days } : undefined },
+    );
+    return response.data;
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
+/** 获取凭据在提供方可用的模型列表，refresh 为 true 时跳过服务端缓存。 */
+export async function fetchUserModelCatalog(
+  id: number,
+  refresh?: boolean,
+): Promise<ModelCatalog> {
+  try {
+    const response: AxiosResponse<ModelCatalog> = await http.get(
+      `/models/${id}/catalog`,
+      { params: refresh ? { refresh: true } : undefined },
+    );
+    return response.data;
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
 /** 删除模型凭据。 */
 export async function deleteUserModel(id: number): Promise<void> {
   try {
@@ -1439,6 +1850,7 @@ export async function submitPublicPrompt(
       positive_keywords: payload.positiveKeywords,
       negative_keywords: payload.negativeKeywords,
       tags: payload.tags,
+      variables: payload.variables,
       model: payload.model,
       language: payload.language ?? "zh-CN",
     });
@@ -1710,13 +2122,16 @@ export async function ingestPrompt(params: IngestPromptPayload): Promise<PromptD
   if (!body) {
     throw new ApiError({ message: "Prompt body is required" });
   }
-  const payload: Record<string, string> = { body };
+  const payload: Record<string, string | boolean> = { body };
   if (params.model_key && params.model_key.trim()) {
     payload.model_key = params.model_key.trim();
   }
   if (params.language && params.language.trim()) {
     payload.language = params.language.trim();
   }
+  if (params.bypass_cache) {
+    payload.bypass_cache = true;
+  }
   try {
     const response: AxiosResponse<PromptDetailResponse> = await http.post(
       "/prompts/ingest",
@@ -1826,6 +2241,7 @@ export async function fetchPromptVersion(
       positive_keywords: PromptListKeyword[];
       negative_keywords: PromptListKeyword[];
       created_at: string;
+      variables?: PromptVariable[];
     }> = await http.get(`/prompts/${promptId}/versions/${versionNo}`);
     const data = response.data;
     return {
@@ -1836,7 +2252,87 @@ export async function fetchPromptVersion(
       positive_keywords: data.positive_keywords ?? [],
       negative_keywords: data.negative_keywords ?? [],
       created_at: data.created_at,
+      variables: data.variables ?? [],
+    };
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
+/** 代入变量值渲染 Prompt 正文，返回缺失与未定义的变量。 */
+export async function renderPrompt(
+  promptId: number,
+  payload: RenderPromptPayload,
+): Promise<RenderPromptResponse> {
+  if (!promptId) {
+    throw new ApiError({ message: "Prompt id is required" });
+  }
+  try {
+    const response: AxiosResponse<RenderPromptResponse> = await http.post(
+      `/prompts/${promptId}/render`,
+      {
+        values: payload.values,
+        version: payload.version ?? undefined,
+      },
+    );
+    return response.data;
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
+/** 渲染 Prompt 后调用模型试运行，返回本次运行记录。 */
+export async function runPrompt(
+  promptId: number,
+  payload: RunPromptPayload,
+): Promise<PromptRun> {
+  if (!promptId) {
+    throw new ApiError({ message: "Prompt id is required" });
+  }
+  try {
+    const response: AxiosResponse<PromptRun> = await http.post(
+      `/prompts/${promptId}/run`,
+      {
+        values: payload.values ?? {},
+        version: payload.version ?? undefined,
+        model_key: payload.model_key?.trim() || undefined,
+        generation_profile: normalizeGenerationProfilePayload(payload.generation_profile),
+      },
+    );
+    return response.data;
+  } catch (error) {
+    throw normaliseError(error);
+  }
+}
+
+/** 分页获取 Prompt 的试运行记录，可按版本筛选。 */
+export async function fetchPromptRuns(
+  promptId: number,
+  params: { version?: number; page?: number; pageSize?: number } = {},
+): Promise<PromptRunListResponse> {
+  if (!promptId) {
+    throw new ApiError({ message: "Prompt id is required" });
+  }
+  try {
+    const response: AxiosResponse<{ items: PromptRun[] }> & { meta?: PromptListMeta } = await http.get(
+      `/prompts/${promptId}/runs`,
+      {
+        params: {
+          version: params.version,
+          page: params.page,
+          page_size: params.pageSize,
+        },
+      },
+    );
+    const items = response.data?.items ?? [];
+    const fallbackMeta: PromptListMeta = {
+      page: params.page ?? 1,
+      page_size: params.pageSize ?? items.length,
+      total_items: items.length,
+      total_pages: 1,
+      current_count: items.length,
     };
+    return { items, meta: response.meta ?? fallbackMeta };
   } catch (error) {
     throw normaliseError(error);
   }
@@ -2024,6 +2520,7 @@ export interface AdminMetricsDailyMetric {
   generate_success_rate: number;
   average_latency_ms: number;
   save_requests: number;
+  generate_cost: number;
 }
 
 export interface AdminMetricsTotals {
@@ -2033,6 +2530,7 @@ export interface AdminMetricsTotals {
   generate_success_rate: number;
   average_latency_ms: number;
   save_requests: number;
+  generate_cost: number;
 }
 
 export interface AdminMetricsSnapshot {
@@ -2058,6 +2556,7 @@ export async function fetchAdminMetrics(): Promise<AdminMetricsSnapshot> {
         generate_success_rate: 0,
         average_latency_ms: 0,
         save_requests: 0,
+        generate_cost: 0,
       },
       daily: Array.isArray(snapshot?.daily) ? snapshot.daily : [],
     };
@@ -39,6 +39,13 @@ export type ErrorCode =
   | "EMAIL_ALREADY_VERIFIED"
   | "VERIFICATION_TOKEN_INVALID"
   | "INVALID_CREDENTIALS"
+  | "MODEL_RATE_LIMITED"
+  | "MODEL_AUTH_FAILED"
+  | "MODEL_QUOTA_EXHAUSTED"
+  | "MODEL_CONTEXT_TOO_LONG"
+  | "MODEL_CONTENT_FILTERED"
+  | "MODEL_UNAVAILABLE"
+  | "MODEL_BUDGET_EXCEEDED"
   | string;
 
 /** Shape of the error payload sent by the backend. */
@@ -1516,6 +1516,32 @@ export default function SettingsPage() {
                           {formatVerifiedAt(credential.last_verified_at)}
                         </p>
                       ) : null}
+                      {!isBuiltin &&
+                      credential.circuit &&
+                      credential.circuit.state !== "closed" ? (
+                        <p className="text-xs text-amber-600 dark:text-amber-400">
+                          {credential.circuit.state === "open"
+                            ? t("settings.modelCard.circuitOpen", {
+                                count: credential.circuit.consecutive_failures,
+                              })
+                            : t("settings.modelCard.circuitHalfOpen")}
+                        </p>
+                      ) : null}
+                      {!isBuiltin &&
+                      typeof credential.month_to_date_cost === "number" ? (
+                        <p className="text-xs text-slate-400 dark:text-slate-500">
+                          {typeof credential.monthly_budget === "number"
+                            ? t("settings.modelCard.monthCostWithBudget", {
+                                cost: credential.month_to_date_cost.toFixed(4),
+                                budget: credential.monthly_budget,
+                                currency: credential.currency ?? "",
+                              })
+                            : t("settings.modelCard.monthCost", {
+                                cost: credential.month_to_date_cost.toFixed(4),
+                                currency: credential.currency ?? "",
+                              })}
+                        </p>
+                      ) : null}
                     </div>
                     <div className="flex flex-wrap gap-2 sm:flex-none sm:items-center sm:justify-end">
                       {!isBuiltin ? (